@@ -12,6 +12,14 @@ const (
 	AgentDeploymentComponentLabelKey = "agent.datadoghq.com/component"
 	// MD5AgentDeploymentAnnotationKey annotation key used on ExtendedDaemonSet in order to identify which AgentDeployment have been used to generate it.
 	MD5AgentDeploymentAnnotationKey = "agent.datadoghq.com/agentspechash"
+	// ChecksHashAnnotationKey annotation key used on the pod templates in order to roll the pods when their checks change.
+	ChecksHashAnnotationKey = "agent.datadoghq.com/checkshash"
+	// ReferencesHashAnnotationKey annotation key used on the pod templates in order to roll the pods when the ConfigMaps and Secrets they use change.
+	ReferencesHashAnnotationKey = "agent.datadoghq.com/referenceshash"
+	// RolloutOnChangeAnnotationKey annotation key used on a ConfigMap or a Secret, set to "false" to not roll the pods using it when it changes.
+	RolloutOnChangeAnnotationKey = "agent.datadoghq.com/rollout-on-change"
+	// ContainerRuntimeLabelKey label key set on the nodes to the container runtime they run, when the nodes run several runtimes.
+	ContainerRuntimeLabelKey = "agent.datadoghq.com/container-runtime"
 
 	// DefaultAgentResourceSuffix use as suffix for agent resource naming
 	DefaultAgentResourceSuffix = "agent"
@@ -39,6 +47,14 @@ const (
 	DefaultAdmissionControllerTargetPort = 8000
 	// DefaultDogstatsdPort default dogstatsd port
 	DefaultDogstatsdPort = 8125
+	// DefaultDogstatsdSocketPath default dogstatsd socket path on the host
+	DefaultDogstatsdSocketPath = "/var/run/datadog/dsd.socket"
+	// DefaultAPMSocketPath default trace intake socket path on the host
+	DefaultAPMSocketPath = "/var/run/datadog/apm.socket"
+	// DefaultProxyUsernameKey default key of the proxy username in the credentials Secret
+	DefaultProxyUsernameKey = "username"
+	// DefaultProxyPasswordKey default key of the proxy password in the credentials Secret
+	DefaultProxyPasswordKey = "password"
 )
 
 // Datadog env var names
@@ -48,6 +64,17 @@ const (
 	DDClusterName                                = "DD_CLUSTER_NAME"
 	DDSite                                       = "DD_SITE"
 	DDddURL                                      = "DD_DD_URL"
+	DDProxyHTTP                                  = "DD_PROXY_HTTP"
+	DDProxyHTTPS                                 = "DD_PROXY_HTTPS"
+	DDProxyNoProxy                               = "DD_PROXY_NO_PROXY"
+	ProxyUsername                                = "PROXY_USERNAME"
+	ProxyPassword                                = "PROXY_PASSWORD"
+	DDAdditionalEndpoints                        = "DD_ADDITIONAL_ENDPOINTS"
+	DDLogsConfigAdditionalEndpoints              = "DD_LOGS_CONFIG_ADDITIONAL_ENDPOINTS"
+	DDLogsConfigUseHTTP                          = "DD_LOGS_CONFIG_USE_HTTP"
+	DDAPMAdditionalEndpoints                     = "DD_APM_ADDITIONAL_ENDPOINTS"
+	DDProcessAdditionalEndpoints                 = "DD_PROCESS_ADDITIONAL_ENDPOINTS"
+	AdditionalEndpointAPIKeyPrefix               = "ADDITIONAL_ENDPOINT_API_KEY_"
 	DDHealthPort                                 = "DD_HEALTH_PORT"
 	DDLogLevel                                   = "DD_LOG_LEVEL"
 	DDPodLabelsAsTags                            = "DD_KUBERNETES_POD_LABELS_AS_TAGS"
@@ -59,8 +86,18 @@ const (
 	DDLogsConfigContainerCollectAll              = "DD_LOGS_CONFIG_CONTAINER_COLLECT_ALL"
 	DDLogsContainerCollectUsingFiles             = "DD_LOGS_CONFIG_K8S_CONTAINER_USE_FILE"
 	DDLogsConfigOpenFilesLimit                   = "DD_LOGS_CONFIG_OPEN_FILES_LIMIT"
+	DDLogsConfigProcessingRules                  = "DD_LOGS_CONFIG_PROCESSING_RULES"
+	DDContainerIncludeLogs                       = "DD_CONTAINER_INCLUDE_LOGS"
+	DDContainerExcludeLogs                       = "DD_CONTAINER_EXCLUDE_LOGS"
+	DDLogsConfigUseTCP                           = "DD_LOGS_CONFIG_USE_TCP"
+	DDLogsConfigUseCompression                   = "DD_LOGS_CONFIG_USE_COMPRESSION"
+	DDLogsConfigCompressionLevel                 = "DD_LOGS_CONFIG_COMPRESSION_LEVEL"
+	DDLogsConfigBatchWait                        = "DD_LOGS_CONFIG_BATCH_WAIT"
+	DDLogsConfigLogsDDURL                        = "DD_LOGS_CONFIG_LOGS_DD_URL"
 	DDDogstatsdOriginDetection                   = "DD_DOGSTATSD_ORIGIN_DETECTION"
 	DDDogstatsdPort                              = "DD_DOGSTATSD_PORT"
+	DDDogstatsdSocket                            = "DD_DOGSTATSD_SOCKET"
+	DDAPMReceiverSocket                          = "DD_APM_RECEIVER_SOCKET"
 	DDClusterAgentEnabled                        = "DD_CLUSTER_AGENT_ENABLED"
 	DDClusterAgentKubeServiceName                = "DD_CLUSTER_AGENT_KUBERNETES_SERVICE_NAME"
 	DDClusterAgentAuthToken                      = "DD_CLUSTER_AGENT_AUTH_TOKEN"
@@ -80,13 +117,25 @@ const (
 	DDSystemProbeAgentEnabled                    = "DD_SYSTEM_PROBE_ENABLED"
 	DDEnableMetadataCollection                   = "DD_ENABLE_METADATA_COLLECTION"
 	DDKubeletHost                                = "DD_KUBERNETES_KUBELET_HOST"
+	DDKubeletTLSVerify                           = "DD_KUBELET_TLS_VERIFY"
+	DDKubeletClientCA                            = "DD_KUBELET_CLIENT_CA"
+	DDEC2PreferIMDSv2                            = "DD_EC2_PREFER_IMDSV2"
 	DDCriSocketPath                              = "DD_CRI_SOCKET_PATH"
 	DockerHost                                   = "DOCKER_HOST"
+	DDLogsConfigRunPath                          = "DD_LOGS_CONFIG_RUN_PATH"
 	DDAdmissionControllerEnabled                 = "DD_ADMISSION_CONTROLLER_ENABLED"
 	DDAdmissionControllerMutateUnlabelled        = "DD_ADMISSION_CONTROLLER_MUTATE_UNLABELLED"
 	DDAdmissionControllerInjectConfig            = "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_ENABLED"
 	DDAdmissionControllerInjectTags              = "DD_ADMISSION_CONTROLLER_INJECT_TAGS_ENABLED"
 	DDAdmissionControllerServiceName             = "DD_ADMISSION_CONTROLLER_SERVICE_NAME"
+	DDAdmissionControllerCertificateSecretName   = "DD_ADMISSION_CONTROLLER_CERTIFICATE_SECRET_NAME"
+	DDAdmissionControllerInjectEntityID          = "DD_ADMISSION_CONTROLLER_INJECT_ENTITY_ID_ENABLED"
+	DDAdmissionControllerAutoInstrumentation     = "DD_ADMISSION_CONTROLLER_AUTO_INSTRUMENTATION_ENABLED"
+	DDAdmissionControllerInjectConfigMode        = "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_MODE"
+	DDAdmissionControllerFailurePolicy           = "DD_ADMISSION_CONTROLLER_FAILURE_POLICY"
+	DDAdmissionControllerWebhookName             = "DD_ADMISSION_CONTROLLER_WEBHOOK_NAME"
+	DDAdmissionControllerDogstatsdSocket         = "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_DOGSTATSD_SOCKET"
+	DDAdmissionControllerTraceAgentSocket        = "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_TRACE_AGENT_SOCKET"
 	DDComplianceConfigEnabled                    = "DD_COMPLIANCE_CONFIG_ENABLED"
 	DDComplianceConfigCheckInterval              = "DD_COMPLIANCE_CONFIG_CHECK_INTERVAL"
 	DDComplianceConfigDir                        = "DD_COMPLIANCE_CONFIG_DIR"
@@ -129,6 +178,7 @@ const (
 	CriSocketVolumeReadOnly            = true
 	DogstatsdSockerVolumeName          = "dsdsocket"
 	DogstatsdSockerVolumePath          = "/var/run/datadog"
+	APMSocketVolumeName                = "apmsocket"
 	PointerVolumeName                  = "pointerdir"
 	PointerVolumePath                  = "/opt/datadog-agent/run"
 	LogPodVolumeName                   = "logpodpath"
@@ -163,6 +213,19 @@ const (
 	ClusterAgentCustomConfigVolumeName    = "custom-datadog-yaml"
 	ClusterAgentCustomConfigVolumePath    = "/etc/datadog-agent/datadog-cluster.yaml"
 	ClusterAgentCustomConfigVolumeSubPath = "datadog-cluster.yaml"
+	ClusterAgentCertificatesVolumeName    = "certificates"
+	ClusterAgentCertificatesVolumePath    = "/etc/datadog-agent/certificates"
+
+	// Windows Agent mount paths and named pipes
+
+	WindowsConfigVolumePath            = "C:/ProgramData/Datadog"
+	WindowsConfdVolumePath             = "C:/conf.d"
+	WindowsChecksdVolumePath           = "C:/checks.d"
+	WindowsAgentCustomConfigVolumePath = "C:/etc/datadog-agent"
+	WindowsPointerVolumePath           = "C:/var/lib/datadog-agent/logs"
+	WindowsLogPodVolumePath            = "C:/var/log/pods"
+	WindowsLogContainerVolumePath      = "C:/ProgramData/docker/containers"
+	DefaultWindowsDockerPipePath       = `\\.\pipe\docker_engine`
 
 	DefaultSystemProbeSecCompRootPath = "/var/lib/kubelet/seccomp"
 	DefaultAppArmorProfileName        = "unconfined"
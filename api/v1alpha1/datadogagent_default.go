@@ -22,6 +22,7 @@ const (
 	defaultCollectEvents                          bool   = false
 	defaultLeaderElection                         bool   = false
 	defaultDockerSocketPath                       string = "/var/run/docker.sock"
+	defaultCriSocketAutoDetect                    bool   = true
 	defaultDogstatsdOriginDetection               bool   = false
 	defaultUseDogStatsDSocketVolume               bool   = false
 	defaultApmEnabled                             bool   = false
@@ -50,6 +51,12 @@ const (
 	defaultRbacCreate                                    = true
 	defaultMutateUnlabelled                              = false
 	DefaultAdmissionServiceName                          = "datadog-admission-controller"
+	defaultAdmissionInjectConfig                         = true
+	defaultAdmissionInjectEntityID                       = true
+	defaultAdmissionInjectTags                           = true
+	defaultAdmissionInjectAPMLibraries                   = false
+	defaultAdmissionInjectionMode                        = AdmissionControllerInjectionModeHostIP
+	defaultAdmissionFailurePolicy                        = AdmissionControllerFailurePolicyIgnore
 )
 
 var defaultImagePullPolicy = corev1.PullIfNotPresent
@@ -339,6 +346,10 @@ func IsDefaultedNetworkPolicy(policy *NetworkPolicySpec) bool {
 		return false
 	}
 
+	if policy.Flavor == "" {
+		return false
+	}
+
 	return true
 }
 
@@ -443,6 +454,7 @@ func DefaultDatadogAgentSpecAgentConfig(config *NodeAgentConfig) *NodeAgentConfi
 	if config.CriSocket == nil {
 		config.CriSocket = &CRISocketConfig{
 			DockerSocketPath: NewStringPointer(defaultDockerSocketPath),
+			AutoDetect:       NewBoolPointer(defaultCriSocketAutoDetect),
 		}
 	}
 
@@ -655,17 +667,44 @@ func DefaultDatadogAgentSpecClusterAgentConfig(config *ClusterAgentConfig) *Clus
 	}
 
 	if config.AdmissionController != nil {
-		if config.AdmissionController.MutateUnlabelled == nil {
-			NewBoolPointer(defaultMutateUnlabelled)
-		}
-		if config.AdmissionController.ServiceName == nil {
-			NewStringPointer(DefaultAdmissionServiceName)
-		}
+		config.AdmissionController = DefaultDatadogAgentSpecClusterAgentAdmissionController(config.AdmissionController)
 	}
 
 	return config
 }
 
+// DefaultDatadogAgentSpecClusterAgentAdmissionController used to default AdmissionControllerConfig
+// return the defaulted AdmissionControllerConfig
+func DefaultDatadogAgentSpecClusterAgentAdmissionController(config *AdmissionControllerConfig) *AdmissionControllerConfig {
+	if config.MutateUnlabelled == nil {
+		config.MutateUnlabelled = NewBoolPointer(defaultMutateUnlabelled)
+	}
+	if config.ServiceName == nil {
+		config.ServiceName = NewStringPointer(DefaultAdmissionServiceName)
+	}
+	if config.InjectConfig == nil {
+		config.InjectConfig = NewBoolPointer(defaultAdmissionInjectConfig)
+	}
+	if config.InjectEntityID == nil {
+		config.InjectEntityID = NewBoolPointer(defaultAdmissionInjectEntityID)
+	}
+	if config.InjectTags == nil {
+		config.InjectTags = NewBoolPointer(defaultAdmissionInjectTags)
+	}
+	if config.InjectAPMLibraries == nil {
+		config.InjectAPMLibraries = NewBoolPointer(defaultAdmissionInjectAPMLibraries)
+	}
+	if config.InjectionMode == nil {
+		mode := defaultAdmissionInjectionMode
+		config.InjectionMode = &mode
+	}
+	if config.FailurePolicy == nil {
+		policy := defaultAdmissionFailurePolicy
+		config.FailurePolicy = &policy
+	}
+	return config
+}
+
 // DefaultDatadogAgentSpecClusterAgentImage used to default ImageConfig for the Datadog Cluster Agent
 // return the defaulted ImageConfig
 func DefaultDatadogAgentSpecClusterAgentImage(image *ImageConfig) *ImageConfig {
@@ -744,5 +783,9 @@ func DefaultNetworkPolicy(policy *NetworkPolicySpec) *NetworkPolicySpec {
 		policy.Create = NewBoolPointer(false)
 	}
 
+	if policy.Flavor == "" {
+		policy.Flavor = NetworkPolicyFlavorKubernetes
+	}
+
 	return policy
 }
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	aksKubeletCAVolumeName = "kubelet-ca"
+	aksKubeletCAPath       = "/etc/kubernetes/certs/kubeletserver.crt"
+	aksKubeletCAVolumePath = "/host/etc/kubernetes/certs/kubeletserver.crt"
+)
+
+// platformProfile is the curated set of defaults and constraints of a platform
+type platformProfile struct {
+	// env is added to the Agent env vars, unless they are set in spec.agent.config.env
+	env []corev1.EnvVar
+	// volumes and volumeMounts are added to the Agent, unless a volume with the same name is set in the spec
+	volumes      []corev1.Volume
+	volumeMounts []corev1.VolumeMount
+	// seLinuxOptions are set on the Agent pods, unless they are set in spec.agent.config.securityContext
+	seLinuxOptions *corev1.SELinuxOptions
+	// forbidden are the features that the platform doesn't allow
+	forbidden []platformConstraint
+}
+
+// platformConstraint is a feature that isn't allowed on a platform
+type platformConstraint struct {
+	// field is the field of the spec enabling the feature
+	field   string
+	enabled func(agent *DatadogAgentSpecAgentSpec) bool
+}
+
+var platformProfiles = map[PlatformName]platformProfile{
+	// Autopilot rejects the pods using the host namespaces, the host ports, or running privileged containers
+	PlatformGKEAutopilot: {
+		forbidden: []platformConstraint{
+			{field: "spec.agent.hostNetwork", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return agent.HostNetwork }},
+			{field: "spec.agent.hostPID", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return agent.HostPID }},
+			{field: "spec.agent.config.hostPort", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return agent.Config.HostPort != nil }},
+			{field: "spec.agent.apm.hostPort", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return agent.Apm.HostPort != nil }},
+			{field: "spec.agent.systemProbe.enabled", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return BoolValue(agent.SystemProbe.Enabled) }},
+			{field: "spec.agent.security.compliance.enabled", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return BoolValue(agent.Security.Compliance.Enabled) }},
+			{field: "spec.agent.security.runtime.enabled", enabled: func(agent *DatadogAgentSpecAgentSpec) bool { return BoolValue(agent.Security.Runtime.Enabled) }},
+		},
+	},
+	// The EKS nodes require IMDSv2 to retrieve the EC2 metadata, e.g. the hostname
+	PlatformEKS: {
+		env: []corev1.EnvVar{{Name: DDEC2PreferIMDSv2, Value: "true"}},
+	},
+	// The AKS kubelet certificate is issued for the node name and isn't signed by the cluster CA
+	PlatformAKS: {
+		env: []corev1.EnvVar{
+			{
+				Name: DDKubeletHost,
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+				},
+			},
+			{Name: DDKubeletClientCA, Value: aksKubeletCAVolumePath},
+		},
+		volumes: []corev1.Volume{
+			{
+				Name: aksKubeletCAVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: aksKubeletCAPath},
+				},
+			},
+		},
+		volumeMounts: []corev1.VolumeMount{
+			{Name: aksKubeletCAVolumeName, MountPath: aksKubeletCAVolumePath, ReadOnly: true},
+		},
+	},
+	// The SCC of the Agent must allow the spc_t SELinux type, to access the container runtime socket and the host files
+	PlatformOpenShift: {
+		seLinuxOptions: &corev1.SELinuxOptions{
+			User:  "system_u",
+			Role:  "system_r",
+			Type:  "spc_t",
+			Level: "s0",
+		},
+	},
+	// The kind kubelets serve a self-signed certificate
+	PlatformKind: {
+		env: []corev1.EnvVar{{Name: DDKubeletTLSVerify, Value: "false"}},
+	},
+}
+
+// ApplyPlatformProfile returns a copy of the DatadogAgent with the defaults of the platform applied,
+// the settings of the spec taking precedence over them
+func ApplyPlatformProfile(dda *DatadogAgent, platform PlatformName) *DatadogAgent {
+	profile, found := platformProfiles[platform]
+	if !found || dda.Spec.Agent == nil {
+		return dda
+	}
+	profiled := dda.DeepCopy()
+	config := &profiled.Spec.Agent.Config
+
+	for _, envVar := range profile.env {
+		if !hasEnvVar(config.Env, envVar.Name) {
+			config.Env = append(config.Env, envVar)
+		}
+	}
+	for _, volume := range profile.volumes {
+		if !hasVolume(config.Volumes, volume.Name) {
+			config.Volumes = append(config.Volumes, volume)
+		}
+	}
+	for _, volumeMount := range profile.volumeMounts {
+		if !hasVolumeMount(config.VolumeMounts, volumeMount.Name) {
+			config.VolumeMounts = append(config.VolumeMounts, volumeMount)
+		}
+	}
+	if profile.seLinuxOptions != nil {
+		if config.SecurityContext == nil {
+			config.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		if config.SecurityContext.SELinuxOptions == nil {
+			config.SecurityContext.SELinuxOptions = profile.seLinuxOptions.DeepCopy()
+		}
+	}
+	return profiled
+}
+
+func hasEnvVar(envVars []corev1.EnvVar, name string) bool {
+	for _, envVar := range envVars {
+		if envVar.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, volume := range volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolumeMount(volumeMounts []corev1.VolumeMount, name string) bool {
+	for _, volumeMount := range volumeMounts {
+		if volumeMount.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -41,6 +41,118 @@ type DatadogAgentSpec struct {
 	// Set to 'datadoghq.eu' to send data to the EU site.
 	// +optional
 	Site string `json:"site,omitempty"`
+
+	// Configure the proxy used by all the components, and by the operator, to reach Datadog
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// Additional Datadog intakes the metrics, logs, traces and processes are dual shipped to
+	// +optional
+	// +listType=atomic
+	AdditionalEndpoints []AdditionalEndpoint `json:"additionalEndpoints,omitempty"`
+
+	// Checks configured on the Agents, or dispatched as cluster checks by the Cluster Agent.
+	// They are rendered in a ConfigMap managed by the operator, added to the Confd directories.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Checks []CheckConfig `json:"checks,omitempty"`
+
+	// Platform applies the defaults and constraints of a managed Kubernetes platform:
+	// "gke-autopilot", "eks", "aks", "openshift" or "kind".
+	// "auto" detects the platform from the APIs of the cluster and the labels of the nodes.
+	// +optional
+	Platform PlatformName `json:"platform,omitempty"`
+}
+
+// CheckConfig defines the configuration of an integration check
+// See https://docs.datadoghq.com/getting_started/integrations/#configuring-agent-checks for more details.
+// +k8s:openapi-gen=true
+type CheckConfig struct {
+	// Name of the integration, e.g. "redisdb". The configuration is rendered in conf.d/<name>.yaml
+	Name string `json:"name"`
+
+	// InitConfig is the YAML of the "init_config" section shared by the instances
+	// +optional
+	InitConfig *string `json:"initConfig,omitempty"`
+
+	// Instances is the YAML list of the instances of the check
+	// +optional
+	Instances *string `json:"instances,omitempty"`
+
+	// Logs is the YAML list of the logs configurations of the integration
+	// +optional
+	Logs *string `json:"logs,omitempty"`
+
+	// ClusterCheck dispatches the instances as cluster checks by the Cluster Agent instead of running them on every Agent
+	// +optional
+	ClusterCheck *bool `json:"clusterCheck,omitempty"`
+}
+
+// PodTemplatePatch defines a patch of the pod template rendered by the operator for a component
+// +k8s:openapi-gen=true
+type PodTemplatePatch struct {
+	// Name of the patch, reported in the errors
+	Name string `json:"name"`
+
+	// Patch is the YAML or JSON strategic merge patch of the pod template, e.g. "spec: {runtimeClassName: gvisor}".
+	// The containers, the volumes and the other named lists are merged by name, and the "$patch: delete"
+	// directive removes an element.
+	Patch string `json:"patch"`
+}
+
+// AdditionalEndpoint defines an additional Datadog intake the data is dual shipped to
+// +k8s:openapi-gen=true
+type AdditionalEndpoint struct {
+	// Site of the Datadog intake, e.g. "datadoghq.eu". The metrics, logs, traces and processes
+	// are sent to the intakes of the site. The logs are sent over HTTPS.
+	// +optional
+	Site string `json:"site,omitempty"`
+
+	// URL of the metrics intake, e.g. "https://app.datadoghq.eu", overrides the metrics intake of the site.
+	// Without site, only the metrics are sent to this endpoint.
+	// +optional
+	URL *string `json:"url,omitempty"`
+
+	// APISecret references the Secret containing the API key of the endpoint, the key defaults to "api_key"
+	APISecret Secret `json:"apiSecret"`
+}
+
+// ProxyConfig contains the configuration of the proxy used to reach Datadog
+// +k8s:openapi-gen=true
+type ProxyConfig struct {
+	// URL of the proxy used for the HTTP requests, e.g. "http://proxy.example.com:3128"
+	// +optional
+	HTTP *string `json:"http,omitempty"`
+
+	// URL of the proxy used for the HTTPS requests, e.g. "http://proxy.example.com:3128"
+	// +optional
+	HTTPS *string `json:"https,omitempty"`
+
+	// Hosts, domains (".example.com") and CIDRs reached without proxy
+	// +optional
+	// +listType=set
+	NoProxy []string `json:"noProxy,omitempty"`
+
+	// Secret containing the username and the password of the proxy, added to the proxy URLs.
+	// The values must be URL-encoded.
+	// +optional
+	CredentialsSecret *ProxyCredentialsSecret `json:"credentialsSecret,omitempty"`
+}
+
+// ProxyCredentialsSecret references the Secret containing the proxy credentials
+// +k8s:openapi-gen=true
+type ProxyCredentialsSecret struct {
+	// SecretName is the name of the secret
+	SecretName string `json:"secretName"`
+
+	// UsernameKey is the key of the username, defaults to "username"
+	// +optional
+	UsernameKey string `json:"usernameKey,omitempty"`
+
+	// PasswordKey is the key of the password, defaults to "password"
+	// +optional
+	PasswordKey string `json:"passwordKey,omitempty"`
 }
 
 // AgentCredentials contains credentials values to configure the Agent
@@ -137,6 +249,18 @@ type DatadogAgentSpecAgentSpec struct {
 	// the pod priority will be default or zero if there is no default.
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
+	// Duration in seconds the Agent pods need to terminate gracefully. Defaults to 30 seconds.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PodTemplatePatches are strategic merge patches applied in order to the pod template of the Agent rendered by
+	// the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the
+	// labels set by the operator can't be changed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PodTemplatePatches []PodTemplatePatch `json:"podTemplatePatches,omitempty"`
+
 	// Set DNS policy for the pod.
 	// Defaults to "ClusterFirst".
 	// Valid values are 'ClusterFirstWithHostNet', 'ClusterFirst', 'Default' or 'None'.
@@ -199,6 +323,92 @@ type DatadogAgentSpecAgentSpec struct {
 	// Provide Agent Network Policy configuration
 	// +optional
 	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Windows configures the Agent DaemonSet deployed on the Windows nodes
+	// +optional
+	Windows *WindowsAgentSpec `json:"windows,omitempty"`
+
+	// ResourceRecommendations configures the recommendation of the resources of the Agent containers from their usage
+	// +optional
+	ResourceRecommendations *ResourceRecommendationsConfig `json:"resourceRecommendations,omitempty"`
+}
+
+// ResourceRecommendationsConfig configures the recommendation of the resources of the Agent containers
+// from the usage reported by the PodMetrics of the metrics.k8s.io API
+// +k8s:openapi-gen=true
+type ResourceRecommendationsConfig struct {
+	// Enabled enables the recommendations. Default: false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Mode defines what is done with the recommendations: "Recommend" only reports them in the status,
+	// "Auto" applies them to the Agent containers, "VPA" creates a VerticalPodAutoscaler for each Agent DaemonSet.
+	// Default: Recommend
+	// +optional
+	Mode ResourceRecommendationsMode `json:"mode,omitempty"`
+
+	// Window is the duration of the usage history the recommendations are computed from. Default: 24h
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// Percentile of the usage recommended as requests, between 50 and 100. Default: 95
+	// +optional
+	// +kubebuilder:validation:Minimum=50
+	// +kubebuilder:validation:Maximum=100
+	Percentile *int32 `json:"percentile,omitempty"`
+
+	// MinAllowed is the lower bound of the recommended requests and limits
+	// +optional
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+
+	// MaxAllowed is the upper bound of the recommended requests and limits
+	// +optional
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// ResourceRecommendationsMode defines what is done with the resource recommendations
+// +kubebuilder:validation:Enum=Recommend;Auto;VPA
+type ResourceRecommendationsMode string
+
+const (
+	// ResourceRecommendationsModeRecommend reports the recommendations in the status
+	ResourceRecommendationsModeRecommend ResourceRecommendationsMode = "Recommend"
+	// ResourceRecommendationsModeAuto applies the recommendations to the Agent containers
+	ResourceRecommendationsModeAuto ResourceRecommendationsMode = "Auto"
+	// ResourceRecommendationsModeVPA creates a VerticalPodAutoscaler for each Agent DaemonSet
+	ResourceRecommendationsModeVPA ResourceRecommendationsMode = "VPA"
+)
+
+// WindowsAgentSpec defines the Agent DaemonSet deployed on the Windows nodes
+// +k8s:openapi-gen=true
+type WindowsAgentSpec struct {
+	// Enabled deploys a second Agent DaemonSet on the nodes labelled `kubernetes.io/os: windows`,
+	// the Agent DaemonSet being restricted to the Linux nodes.
+	// The System Probe and the Security Agent only run on Linux and aren't deployed on the Windows nodes.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// The container image of the Windows Agent, by default the image of the Agent
+	// (the Agent images are published for Linux and Windows)
+	// +optional
+	Image *ImageConfig `json:"image,omitempty"`
+
+	// Name of the Windows Agent DaemonSet, by default `<name>-agent-windows`
+	// +optional
+	DaemonsetName string `json:"daemonsetName,omitempty"`
+
+	// Named pipe of the container runtime of the Windows nodes, by default the Docker named pipe `\\.\pipe\docker_engine`.
+	// Use `criSocketPath: \\.\pipe\containerd-containerd` for containerd. The runtime detection isn't supported on Windows.
+	// +optional
+	CriSocket *CRISocketConfig `json:"criSocket,omitempty"`
+
+	// PodTemplatePatches are strategic merge patches applied in order to the pod template of the Windows Agent.
+	// The patches of the Agent (`spec.agent.podTemplatePatches`) aren't applied to the Windows Agent, they can
+	// target containers or node labels that only exist on Linux.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PodTemplatePatches []PodTemplatePatch `json:"podTemplatePatches,omitempty"`
 }
 
 // RbacConfig contains RBAC configuration
@@ -268,6 +478,11 @@ type APMSpec struct {
 	// +optional
 	HostPort *int32 `json:"hostPort,omitempty"`
 
+	// UnixDomainSocket enables the trace intake over Unix Domain Socket
+	// ref: https://docs.datadoghq.com/agent/kubernetes/apm/?tab=daemonset#setup
+	// +optional
+	UnixDomainSocket *UnixDomainSocketConfig `json:"unixDomainSocket,omitempty"`
+
 	// The Datadog Agent supports many environment variables
 	// Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables
 	//
@@ -280,6 +495,23 @@ type APMSpec struct {
 	// Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class
 	// Ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Override of the liveness probe of the APM Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the APM Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the APM Agent container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the APM Agent container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
 }
 
 // LogSpec contains the Log Agent configuration
@@ -333,6 +565,107 @@ type LogSpec struct {
 	//
 	// +optional
 	OpenFilesLimit *int32 `json:"openFilesLimit,omitempty"`
+
+	// ProcessingRules are the global processing rules applied to all the logs collected by the Agent.
+	// ref: https://docs.datadoghq.com/agent/logs/advanced_log_collection/#global-processing-rules
+	//
+	// +optional
+	// +listType=atomic
+	ProcessingRules []LogProcessingRule `json:"processingRules,omitempty"`
+
+	// ContainerInclude restricts the log collection to the containers matching one of the filters,
+	// in the form `image:<regex>`, `name:<regex>` or `kube_namespace:<regex>`.
+	// ref: https://docs.datadoghq.com/agent/guide/autodiscovery-management/
+	//
+	// +optional
+	// +listType=atomic
+	ContainerInclude []string `json:"containerInclude,omitempty"`
+
+	// ContainerExclude excludes the containers matching one of the filters from the log collection,
+	// in the form `image:<regex>`, `name:<regex>` or `kube_namespace:<regex>`.
+	// ref: https://docs.datadoghq.com/agent/guide/autodiscovery-management/
+	//
+	// +optional
+	// +listType=atomic
+	ContainerExclude []string `json:"containerExclude,omitempty"`
+
+	// Transport configures how the logs are sent to Datadog
+	//
+	// +optional
+	Transport *LogTransportConfig `json:"transport,omitempty"`
+
+	// DDUrl overrides the logs intake endpoint, as `<host>:<port>`
+	//
+	// +optional
+	DDUrl *string `json:"ddUrl,omitempty"`
+}
+
+// LogProcessingRuleType is the type of a log processing rule
+// +kubebuilder:validation:Enum=exclude_at_match;include_at_match;mask_sequences;multi_line
+type LogProcessingRuleType string
+
+const (
+	// LogProcessingRuleExcludeAtMatch drops the logs matching the pattern
+	LogProcessingRuleExcludeAtMatch LogProcessingRuleType = "exclude_at_match"
+	// LogProcessingRuleIncludeAtMatch only keeps the logs matching the pattern
+	LogProcessingRuleIncludeAtMatch LogProcessingRuleType = "include_at_match"
+	// LogProcessingRuleMaskSequences replaces the sequences matching the pattern with the placeholder
+	LogProcessingRuleMaskSequences LogProcessingRuleType = "mask_sequences"
+	// LogProcessingRuleMultiLine aggregates the lines following a line matching the pattern into a single log
+	LogProcessingRuleMultiLine LogProcessingRuleType = "multi_line"
+)
+
+// LogProcessingRule defines a processing rule applied to the logs
+// +k8s:openapi-gen=true
+type LogProcessingRule struct {
+	// Type of the rule: exclude_at_match, include_at_match, mask_sequences or multi_line
+	Type LogProcessingRuleType `json:"type"`
+
+	// Name of the rule
+	Name string `json:"name"`
+
+	// Pattern is the regular expression matched against the logs
+	Pattern string `json:"pattern"`
+
+	// ReplacePlaceholder replaces the sequences matching the pattern, required by the mask_sequences rules
+	// +optional
+	ReplacePlaceholder *string `json:"replacePlaceholder,omitempty"`
+}
+
+// LogTransportProtocol is the protocol used to send the logs
+// +kubebuilder:validation:Enum=HTTP;TCP
+type LogTransportProtocol string
+
+const (
+	// LogTransportProtocolHTTP sends the logs in batches over HTTPS
+	LogTransportProtocolHTTP LogTransportProtocol = "HTTP"
+	// LogTransportProtocolTCP sends the logs over TCP
+	LogTransportProtocolTCP LogTransportProtocol = "TCP"
+)
+
+// LogTransportConfig configures how the logs are sent to Datadog
+// +k8s:openapi-gen=true
+type LogTransportConfig struct {
+	// Protocol used to send the logs: HTTP or TCP. By default the Agent uses HTTP when the intake is reachable,
+	// and HTTP is always used when logs are sent to additional endpoints.
+	// +optional
+	Protocol LogTransportProtocol `json:"protocol,omitempty"`
+
+	// UseCompression compresses the logs sent over HTTP
+	// +optional
+	UseCompression *bool `json:"useCompression,omitempty"`
+
+	// CompressionLevel of the logs sent over HTTP, from 0 (no compression) to 9 (best compression)
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=9
+	CompressionLevel *int32 `json:"compressionLevel,omitempty"`
+
+	// BatchWait is the maximum time in seconds the Agent waits to fill a batch of logs sent over HTTP, from 1 to 10
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	BatchWait *int32 `json:"batchWait,omitempty"`
 }
 
 // ProcessSpec contains the Process Agent configuration
@@ -356,6 +689,23 @@ type ProcessSpec struct {
 	// Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class
 	// Ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Override of the liveness probe of the Process Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the Process Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the Process Agent container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the Process Agent container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
 }
 
 // SystemProbeSpec contains the SystemProbe Agent configuration
@@ -415,6 +765,23 @@ type SystemProbeSpec struct {
 	// Ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Override of the liveness probe of the System Probe container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the System Probe container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the System Probe container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the System Probe container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+
 	// You can modify the security context used to run the containers by
 	// modifying the label type
 	// +optional
@@ -444,6 +811,23 @@ type SecuritySpec struct {
 	// Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class
 	// Ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Override of the liveness probe of the Security Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the Security Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the Security Agent container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the Security Agent container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
 }
 
 // ComplianceSpec contains configuration for continuous compliance
@@ -591,6 +975,23 @@ type NodeAgentConfig struct {
 	// Ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Override of the liveness probe of the Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the Agent container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the Agent container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+
 	// Configure the CRI Socket
 	CriSocket *CRISocketConfig `json:"criSocket,omitempty"`
 
@@ -602,6 +1003,12 @@ type NodeAgentConfig struct {
 	// +listType=atomic
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// AutoTolerations makes the Agent pods tolerate the taints of the nodes matching one of the patterns,
+	// so that tainting a node doesn't prevent the Agent from running on it
+	// +optional
+	// +listType=atomic
+	AutoTolerations []TaintPattern `json:"autoTolerations,omitempty"`
+
 	// Number of port to expose on the host.
 	// If specified, this must be a valid port number, 0 < x < 65536.
 	// If HostNetwork is specified, this must match ContainerPort.
@@ -611,6 +1018,21 @@ type NodeAgentConfig struct {
 	HostPort *int32 `json:"hostPort,omitempty"`
 }
 
+// TaintPattern matches the taints of the nodes
+// +k8s:openapi-gen=true
+type TaintPattern struct {
+	// Key is a shell pattern matching the key of the taint, e.g. "dedicated.example.com/*"
+	Key string `json:"key"`
+
+	// Value is a shell pattern matching the value of the taint, any value matches if it isn't set
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Effect of the taint, any effect matches if it isn't set
+	// +optional
+	Effect corev1.TaintEffect `json:"effect,omitempty"`
+}
+
 // CRISocketConfig contains the CRI socket configuration parameters
 // +k8s:openapi-gen=true
 type CRISocketConfig struct {
@@ -622,6 +1044,14 @@ type CRISocketConfig struct {
 	// This is supported starting from agent 6.6.0
 	// +optional
 	CriSocketPath *string `json:"criSocketPath,omitempty"`
+
+	// AutoDetect enables the detection of the container runtime of the nodes (docker, containerd, cri-o or k3s) from their status,
+	// in order to use the socket of the runtime instead of the paths above. The paths above are used when no runtime is detected.
+	// When the nodes run several runtimes, the nodes are labelled with their runtime and the Agents of the nodes that don't run
+	// the most common runtime are deployed by an additional DaemonSet per runtime.
+	// Enabled by default when criSocket isn't set.
+	// +optional
+	AutoDetect *bool `json:"autoDetect,omitempty"`
 }
 
 // DogstatsdConfig contains the Dogstatsd configuration parameters
@@ -634,8 +1064,27 @@ type DogstatsdConfig struct {
 
 	// Enable dogstatsd over Unix Domain Socket
 	// ref: https://docs.datadoghq.com/developers/dogstatsd/unix_socket/
+	// Deprecated: use UnixDomainSocket instead
 	// +optional
 	UseDogStatsDSocketVolume *bool `json:"useDogStatsDSocketVolume,omitempty"`
+
+	// UnixDomainSocket enables the DogStatsD intake over Unix Domain Socket
+	// ref: https://docs.datadoghq.com/developers/dogstatsd/unix_socket/
+	// +optional
+	UnixDomainSocket *UnixDomainSocketConfig `json:"unixDomainSocket,omitempty"`
+}
+
+// UnixDomainSocketConfig contains the configuration of an Agent intake over Unix Domain Socket
+// +k8s:openapi-gen=true
+type UnixDomainSocketConfig struct {
+	// Enable the intake over Unix Domain Socket, the hostPort isn't exposed anymore
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Path of the socket on the host. Its directory is mounted in the Agent pods,
+	// it must be mounted at the same path in the application pods.
+	// +optional
+	HostFilepath *string `json:"hostFilepath,omitempty"`
 }
 
 // DatadogAgentSpecClusterAgentSpec defines the desired state of the cluster Agent
@@ -673,6 +1122,18 @@ type DatadogAgentSpecClusterAgentSpec struct {
 	// the pod priority will be default or zero if there is no default.
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
+	// Duration in seconds the Cluster Agent pods need to terminate gracefully. Defaults to 30 seconds.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PodTemplatePatches are strategic merge patches applied in order to the pod template of the Cluster Agent rendered by
+	// the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the
+	// labels set by the operator can't be changed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PodTemplatePatches []PodTemplatePatch `json:"podTemplatePatches,omitempty"`
+
 	// If specified, the pod's scheduling constraints
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
@@ -691,6 +1152,21 @@ type DatadogAgentSpecClusterAgentSpec struct {
 	// Provide Cluster Agent Network Policy configuration
 	// +optional
 	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// AntiAffinityPreset adds a pod anti-affinity between the Cluster Agent replicas.
+	// Its term is added to the pod anti-affinity of Affinity.
+	// +optional
+	AntiAffinityPreset *PodAntiAffinityPreset `json:"antiAffinityPreset,omitempty"`
+
+	// TopologySpreadConstraints describes how the Cluster Agent pods ought to spread across topology domains
+	// +optional
+	// +listType=atomic
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configures the PodDisruptionBudget of the Cluster Agent pods,
+	// it defaults to minAvailable: 1
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
 }
 
 // ClusterAgentConfig contains the configuration of the Cluster Agent
@@ -701,6 +1177,10 @@ type ClusterAgentConfig struct {
 	// Configure the Admission Controller
 	AdmissionController *AdmissionControllerConfig `json:"admissionController,omitempty"`
 
+	// Configure the certificates used by the external metrics server and the Admission Controller
+	// +optional
+	Certificates *ClusterAgentCertificatesConfig `json:"certificates,omitempty"`
+
 	// Enable the Cluster Checks and Endpoint Checks feature on both the cluster-agents and the daemonset
 	// ref:
 	// https://docs.datadoghq.com/agent/cluster_agent/clusterchecks/
@@ -720,6 +1200,23 @@ type ClusterAgentConfig struct {
 	// Datadog cluster-agent resource requests and limits
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Override of the liveness probe of the Cluster Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the Cluster Agent container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the Cluster Agent container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the Cluster Agent container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+
 	// Confd Provide additional cluster check configurations. Each key will become a file in /conf.d
 	// see https://docs.datadoghq.com/agent/autodiscovery/ for more details.
 	// +optional
@@ -790,6 +1287,100 @@ type AdmissionControllerConfig struct {
 	// ServiceName corresponds to the webhook service name
 	// +optional
 	ServiceName *string `json:"serviceName,omitempty"`
+
+	// InjectConfig enables injecting the environment variables required to reach the Agent (DD_AGENT_HOST, DD_DOGSTATSD_URL, DD_TRACE_AGENT_URL)
+	// Default: true
+	// +optional
+	InjectConfig *bool `json:"injectConfig,omitempty"`
+
+	// InjectEntityID enables injecting the DD_ENTITY_ID environment variable used for origin detection
+	// Default: true
+	// +optional
+	InjectEntityID *bool `json:"injectEntityID,omitempty"`
+
+	// InjectTags enables injecting the standard tags (env, service, version) from the pod and owner labels
+	// Default: true
+	// +optional
+	InjectTags *bool `json:"injectTags,omitempty"`
+
+	// InjectAPMLibraries enables injecting the APM tracing libraries requested by the pod annotations
+	// Default: false
+	// +optional
+	InjectAPMLibraries *bool `json:"injectAPMLibraries,omitempty"`
+
+	// InjectionMode defines how the injected configuration reaches the Agent: "hostip", "service" or "socket"
+	// Default: "hostip"
+	// +optional
+	InjectionMode *AdmissionControllerInjectionMode `json:"injectionMode,omitempty"`
+
+	// NamespaceSelector restricts the namespaces whose pods are mutated
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ObjectSelector restricts the pods that are mutated.
+	// Defaults to the pods labelled with 'admission.datadoghq.com/enabled="true"', or all the pods
+	// not labelled with 'admission.datadoghq.com/enabled="false"' if MutateUnlabelled is true.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// FailurePolicy defines how errors of the admission controller are handled by the API server: "Ignore" or "Fail"
+	// Default: "Ignore"
+	// +optional
+	FailurePolicy *AdmissionControllerFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// AdmissionControllerInjectionMode defines how the injected configuration reaches the Agent
+// +kubebuilder:validation:Enum=hostip;service;socket
+type AdmissionControllerInjectionMode string
+
+const (
+	// AdmissionControllerInjectionModeHostIP uses the IP of the host running the pod
+	AdmissionControllerInjectionModeHostIP AdmissionControllerInjectionMode = "hostip"
+	// AdmissionControllerInjectionModeService uses the local Agent service
+	AdmissionControllerInjectionModeService AdmissionControllerInjectionMode = "service"
+	// AdmissionControllerInjectionModeSocket uses the Unix Domain Sockets mounted from the host
+	AdmissionControllerInjectionModeSocket AdmissionControllerInjectionMode = "socket"
+)
+
+// AdmissionControllerFailurePolicy defines how errors of the admission controller are handled
+// +kubebuilder:validation:Enum=Ignore;Fail
+type AdmissionControllerFailurePolicy string
+
+const (
+	// AdmissionControllerFailurePolicyIgnore admits the pods if the admission controller fails
+	AdmissionControllerFailurePolicyIgnore AdmissionControllerFailurePolicy = "Ignore"
+	// AdmissionControllerFailurePolicyFail rejects the pods if the admission controller fails
+	AdmissionControllerFailurePolicyFail AdmissionControllerFailurePolicy = "Fail"
+)
+
+// CertificatesProvider defines how the Cluster Agent certificates are managed
+type CertificatesProvider string
+
+const (
+	// CertificatesProviderAuto uses cert-manager if it is installed, the operator otherwise
+	CertificatesProviderAuto CertificatesProvider = "auto"
+	// CertificatesProviderOperator the operator generates a self-signed CA and the serving certificate
+	CertificatesProviderOperator CertificatesProvider = "operator"
+	// CertificatesProviderCertManager cert-manager issues the CA and the serving certificate
+	CertificatesProviderCertManager CertificatesProvider = "cert-manager"
+)
+
+// ClusterAgentCertificatesConfig contains the configuration of the certificates served by the Cluster Agent
+// +k8s:openapi-gen=true
+type ClusterAgentCertificatesConfig struct {
+	// Provider of the certificates: auto, operator or cert-manager.
+	// Defaults to auto: cert-manager is used if it is installed in the cluster.
+	// +optional
+	// +kubebuilder:validation:Enum=auto;operator;cert-manager
+	Provider *CertificatesProvider `json:"provider,omitempty"`
+
+	// Validity of the serving certificate. Defaults to 8760h (1 year).
+	// +optional
+	Validity *metav1.Duration `json:"validity,omitempty"`
+
+	// RenewBefore is how long before its expiry the serving certificate is renewed. Defaults to 720h (30 days).
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
 }
 
 // ClusterChecksRunnerConfig contains the configuration of the Cluster Checks Runner
@@ -798,6 +1389,23 @@ type ClusterChecksRunnerConfig struct {
 	// Datadog Cluster Checks Runner resource requests and limits
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Override of the liveness probe of the Cluster Checks Runner container: the fields that are set replace the ones of the default probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Override of the readiness probe of the Cluster Checks Runner container: the fields that are set replace the ones of the default probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the Cluster Checks Runner container, which delays the liveness and readiness probes until it succeeds.
+	// The fields that aren't set are taken from the liveness probe
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// Lifecycle hooks of the Cluster Checks Runner container
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+
 	// Set logging verbosity, valid log levels are:
 	// trace, debug, info, warn, error, critical, and off
 	LogLevel *string `json:"logLevel,omitempty"`
@@ -860,6 +1468,18 @@ type DatadogAgentSpecClusterChecksRunnerSpec struct {
 	// the pod priority will be default or zero if there is no default.
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
+	// Duration in seconds the Cluster Checks Runner pods need to terminate gracefully. Defaults to 30 seconds.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PodTemplatePatches are strategic merge patches applied in order to the pod template of the Cluster Checks Runner rendered by
+	// the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the
+	// labels set by the operator can't be changed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PodTemplatePatches []PodTemplatePatch `json:"podTemplatePatches,omitempty"`
+
 	// If specified, the pod's scheduling constraints
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
@@ -878,6 +1498,70 @@ type DatadogAgentSpecClusterChecksRunnerSpec struct {
 	// Provide Cluster Checks Runner Network Policy configuration
 	// +optional
 	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// AntiAffinityPreset adds a pod anti-affinity between the Cluster Checks Runner replicas.
+	// Its term is added to the pod anti-affinity of Affinity.
+	// +optional
+	AntiAffinityPreset *PodAntiAffinityPreset `json:"antiAffinityPreset,omitempty"`
+
+	// TopologySpreadConstraints describes how the Cluster Checks Runner pods ought to spread across topology domains
+	// +optional
+	// +listType=atomic
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configures the PodDisruptionBudget of the Cluster Checks Runner pods,
+	// it defaults to minAvailable: 1
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+}
+
+// PodAntiAffinityPreset contains a pod anti-affinity preset
+// +k8s:openapi-gen=true
+type PodAntiAffinityPreset struct {
+	// Type of the anti-affinity: "soft" prefers scheduling the replicas in distinct topology domains,
+	// "hard" requires it and leaves the extra replicas pending
+	Type PodAntiAffinityType `json:"type,omitempty"`
+
+	// Topology domain of the anti-affinity: "host" or "zone"
+	Topology PodAntiAffinityTopology `json:"topology,omitempty"`
+}
+
+// PodAntiAffinityType defines how strictly the pod anti-affinity is enforced
+// +kubebuilder:validation:Enum=soft;hard
+type PodAntiAffinityType string
+
+const (
+	// PodAntiAffinityTypeSoft uses a preferred pod anti-affinity
+	PodAntiAffinityTypeSoft PodAntiAffinityType = "soft"
+	// PodAntiAffinityTypeHard uses a required pod anti-affinity
+	PodAntiAffinityTypeHard PodAntiAffinityType = "hard"
+)
+
+// PodAntiAffinityTopology defines the topology domain of the pod anti-affinity
+// +kubebuilder:validation:Enum=host;zone
+type PodAntiAffinityTopology string
+
+const (
+	// PodAntiAffinityTopologyHost spreads the replicas across nodes
+	PodAntiAffinityTopologyHost PodAntiAffinityTopology = "host"
+	// PodAntiAffinityTopologyZone spreads the replicas across availability zones
+	PodAntiAffinityTopologyZone PodAntiAffinityTopology = "zone"
+)
+
+// PodDisruptionBudgetConfig contains the configuration of a PodDisruptionBudget
+// +k8s:openapi-gen=true
+type PodDisruptionBudgetConfig struct {
+	// Enable the PodDisruptionBudget creation, enabled by default
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Minimum number or percentage of available pods, exclusive with MaxUnavailable
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// Maximum number or percentage of unavailable pods, exclusive with MinAvailable
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 // ImageConfig Datadog agent container image config
@@ -905,8 +1589,52 @@ type NetworkPolicySpec struct {
 	// If true, create a NetworkPolicy for the current agent
 	// +optional
 	Create *bool `json:"create,omitempty"`
+
+	// Flavor of the network policy to create: "kubernetes" (default) creates a NetworkPolicy,
+	// "cilium" creates a CiliumNetworkPolicy with FQDN-based egress to the Datadog intake
+	// +optional
+	Flavor NetworkPolicyFlavor `json:"flavor,omitempty"`
+
+	// Allow egress to any pod and host, required to run the checks configured through Autodiscovery
+	// +optional
+	AllowAutodiscovery *bool `json:"allowAutodiscovery,omitempty"`
+
+	// Cilium selector of the DNS server entity, defaults to the kube-dns pods of the kube-system namespace
+	// +optional
+	// +listType=atomic
+	DNSSelectorEndpoints []metav1.LabelSelector `json:"dnsSelectorEndpoints,omitempty"`
 }
 
+// NetworkPolicyFlavor specifies which flavor of network policy to use
+// +kubebuilder:validation:Enum=kubernetes;cilium
+type NetworkPolicyFlavor string
+
+const (
+	// NetworkPolicyFlavorKubernetes refers to `networking.k8s.io/v1/NetworkPolicy`
+	NetworkPolicyFlavorKubernetes NetworkPolicyFlavor = "kubernetes"
+	// NetworkPolicyFlavorCilium refers to `cilium.io/v2/CiliumNetworkPolicy`
+	NetworkPolicyFlavorCilium NetworkPolicyFlavor = "cilium"
+)
+
+// PlatformName is the name of a managed Kubernetes platform
+// +kubebuilder:validation:Enum=auto;gke-autopilot;eks;aks;openshift;kind
+type PlatformName string
+
+const (
+	// PlatformAuto detects the platform of the cluster
+	PlatformAuto PlatformName = "auto"
+	// PlatformGKEAutopilot refers to Google Kubernetes Engine in Autopilot mode
+	PlatformGKEAutopilot PlatformName = "gke-autopilot"
+	// PlatformEKS refers to Amazon Elastic Kubernetes Service
+	PlatformEKS PlatformName = "eks"
+	// PlatformAKS refers to Azure Kubernetes Service
+	PlatformAKS PlatformName = "aks"
+	// PlatformOpenShift refers to Red Hat OpenShift
+	PlatformOpenShift PlatformName = "openshift"
+	// PlatformKind refers to kind, Kubernetes in Docker
+	PlatformKind PlatformName = "kind"
+)
+
 // DatadogAgentState type representing the deployment state of the different Agent components
 type DatadogAgentState string
 
@@ -938,12 +1666,165 @@ type DatadogAgentStatus struct {
 	// +optional
 	ClusterChecksRunner *DeploymentStatus `json:"clusterChecksRunner,omitempty"`
 
+	// The actual state of the certificates served by the Cluster Agent
+	// +optional
+	ClusterAgentCertificates *CertificatesStatus `json:"clusterAgentCertificates,omitempty"`
+
 	// Conditions Represents the latest available observations of a DatadogAgent's current state.
 	// +listType=map
 	// +listMapKey=type
 	Conditions []DatadogAgentCondition `json:"conditions,omitempty"`
+
+	// The container runtimes detected on the nodes, when the detection is enabled
+	// +optional
+	// +listType=map
+	// +listMapKey=runtime
+	ContainerRuntimes []ContainerRuntimeStatus `json:"containerRuntimes,omitempty"`
+
+	// The actual state of the Windows Agent as a daemonset
+	// +optional
+	WindowsAgent *DaemonSetStatus `json:"windowsAgent,omitempty"`
+
+	// The platform whose profile is applied, detected when spec.platform is "auto"
+	// +optional
+	Platform PlatformName `json:"platform,omitempty"`
+
+	// The health of the Agent on the nodes, with the unhealthy nodes whose Agent restarted the most
+	// +optional
+	AgentHealth *AgentHealthStatus `json:"agentHealth,omitempty"`
+
+	// The number of nodes that can and can't run an Agent pod, according to the taints and the labels of the nodes
+	// +optional
+	AgentCoverage *AgentCoverageStatus `json:"agentCoverage,omitempty"`
+
+	// The resources recommended for the Agent containers from their usage
+	// +optional
+	// +listType=map
+	// +listMapKey=container
+	ResourceRecommendations []ContainerResourceRecommendation `json:"resourceRecommendations,omitempty"`
+}
+
+// ContainerRuntimeStatus defines the observed state of the nodes running a container runtime
+// +k8s:openapi-gen=true
+type ContainerRuntimeStatus struct {
+	// Runtime is the container runtime reported by the nodes: docker, containerd, cri-o, k3s or the name of another runtime
+	Runtime string `json:"runtime"`
+
+	// Nodes is the number of nodes running the runtime
+	Nodes int32 `json:"nodes"`
+
+	// SocketPath is the path of the runtime socket used by the Agents of these nodes
+	SocketPath string `json:"socketPath,omitempty"`
+
+	// DaemonsetName is the name of the DaemonSet deploying the Agents of these nodes
+	DaemonsetName string `json:"daemonsetName,omitempty"`
 }
 
+// AgentCoverageStatus defines the number of nodes that can run an Agent pod
+// +k8s:openapi-gen=true
+type AgentCoverageStatus struct {
+	// CoveredNodes is the number of nodes that can run an Agent pod
+	CoveredNodes int32 `json:"coveredNodes"`
+
+	// UncoveredNodes is the number of nodes that can't run an Agent pod, because of their taints or their labels
+	UncoveredNodes int32 `json:"uncoveredNodes"`
+}
+
+// ContainerResourceRecommendation defines the resources recommended for an Agent container
+// +k8s:openapi-gen=true
+type ContainerResourceRecommendation struct {
+	// Container is the name of the container
+	Container string `json:"container"`
+
+	// Requests are the recommended requests: the configured percentile of the usage, with a margin
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// Limits are the recommended limits: the highest memory usage, with a margin
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+
+	// LastUpdateTime is the last time the recommendation changed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// AgentHealthStatus summarizes the health of the Agent pods on the nodes
+// +k8s:openapi-gen=true
+type AgentHealthStatus struct {
+	// HealthyNodes is the number of nodes running a ready Agent
+	HealthyNodes int32 `json:"healthyNodes"`
+
+	// UnhealthyNodes is the number of nodes without a ready Agent
+	UnhealthyNodes int32 `json:"unhealthyNodes"`
+
+	// Reasons is the number of unhealthy nodes per reason
+	// +optional
+	// +listType=map
+	// +listMapKey=reason
+	Reasons []AgentHealthReasonCount `json:"reasons,omitempty"`
+
+	// Nodes are the unhealthy nodes whose Agent restarted the most, limited to 10 nodes.
+	// Use `kubectl datadog agent health` to list all the nodes.
+	// +optional
+	// +listType=atomic
+	Nodes []NodeAgentHealth `json:"nodes,omitempty"`
+}
+
+// AgentHealthReasonCount defines the number of nodes whose Agent is unhealthy for a reason
+// +k8s:openapi-gen=true
+type AgentHealthReasonCount struct {
+	// Reason why the Agent is unhealthy
+	Reason AgentHealthReason `json:"reason"`
+
+	// Nodes is the number of nodes whose Agent is unhealthy for this reason
+	Nodes int32 `json:"nodes"`
+}
+
+// NodeAgentHealth defines the health of the Agent on a node
+// +k8s:openapi-gen=true
+type NodeAgentHealth struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+
+	// Pod is the name of the Agent pod of the node, empty if there is none
+	// +optional
+	Pod string `json:"pod,omitempty"`
+
+	// Reason is the health of the Agent: Healthy, or why it isn't healthy
+	Reason AgentHealthReason `json:"reason"`
+
+	// Message details the reason, e.g. the last termination reason of a crashing container
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Restarts is the number of restarts of the containers of the Agent pod
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+}
+
+// AgentHealthReason is the health of the Agent on a node
+type AgentHealthReason string
+
+const (
+	// AgentHealthReasonHealthy is used when the Agent pod is ready
+	AgentHealthReasonHealthy AgentHealthReason = "Healthy"
+	// AgentHealthReasonNoAgent is used when the node doesn't run an Agent pod
+	AgentHealthReasonNoAgent AgentHealthReason = "NoAgent"
+	// AgentHealthReasonCrashLoopBackOff is used when a container of the Agent pod keeps crashing
+	AgentHealthReasonCrashLoopBackOff AgentHealthReason = "CrashLoopBackOff"
+	// AgentHealthReasonImagePullFailure is used when an image of the Agent pod can't be pulled
+	AgentHealthReasonImagePullFailure AgentHealthReason = "ImagePullFailure"
+	// AgentHealthReasonPendingResources is used when the Agent pod can't be scheduled because the node lacks resources
+	AgentHealthReasonPendingResources AgentHealthReason = "PendingResources"
+	// AgentHealthReasonPendingTaints is used when the Agent pod can't be scheduled because of the taints of the node
+	AgentHealthReasonPendingTaints AgentHealthReason = "PendingTaints"
+	// AgentHealthReasonPending is used when the Agent pod is pending for another reason
+	AgentHealthReasonPending AgentHealthReason = "Pending"
+	// AgentHealthReasonNotReady is used when the Agent pod is running but not ready
+	AgentHealthReasonNotReady AgentHealthReason = "NotReady"
+)
+
 // DaemonSetStatus defines the observed state of Agent running as DaemonSet
 // +k8s:openapi-gen=true
 type DaemonSetStatus struct {
@@ -962,6 +1843,25 @@ type DaemonSetStatus struct {
 	DaemonsetName string `json:"daemonsetName,omitempty"`
 }
 
+// CertificatesStatus defines the observed state of the certificates served by the Cluster Agent
+// +k8s:openapi-gen=true
+type CertificatesStatus struct {
+	// Provider managing the certificates
+	Provider string `json:"provider,omitempty"`
+
+	// SecretName is the name of the Secret containing the serving certificate
+	SecretName string `json:"secretName,omitempty"`
+
+	// NotAfter is the expiration time of the serving certificate
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// CANotAfter is the expiration time of the CA certificate
+	CANotAfter *metav1.Time `json:"caNotAfter,omitempty"`
+
+	// LastRotation is the time at which the serving certificate was last issued
+	LastRotation *metav1.Time `json:"lastRotation,omitempty"`
+}
+
 // DeploymentStatus type representing the Cluster Agent Deployment status
 // +k8s:openapi-gen=true
 type DeploymentStatus struct {
@@ -1040,6 +1940,15 @@ const (
 	ConditionTypeActiveDatadogMetrics DatadogAgentConditionType = "ActiveDatadogMetrics"
 	// ConditionTypeDatadogMetricsError cannot forward deployment metrics and events to Datadog
 	ConditionTypeDatadogMetricsError DatadogAgentConditionType = "DatadogMetricsError"
+
+	// ConditionTypeConflict a resource shared at the cluster level is managed by another DatadogAgent
+	ConditionTypeConflict DatadogAgentConditionType = "Conflict"
+
+	// ConditionTypeSchedulingWarning the replica count can't satisfy the spread or the PodDisruptionBudget
+	ConditionTypeSchedulingWarning DatadogAgentConditionType = "SchedulingWarning"
+
+	// ConditionTypeUncoveredNodes some nodes can't run an Agent pod because of their taints or their labels
+	ConditionTypeUncoveredNodes DatadogAgentConditionType = "UncoveredNodes"
 )
 
 // DatadogAgent Deployment with Datadog Operator
@@ -7,8 +7,16 @@ package v1alpha1
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
 )
 
 // IsValidDatadogAgent use to check if a DatadogAgentSpec is valid
@@ -21,6 +29,26 @@ func IsValidDatadogAgent(spec *DatadogAgentSpec) error {
 				errs = append(errs, fmt.Errorf("invalid spec.agent.customConfig, err: %v", err))
 			}
 		}
+		if err = IsValidPodTemplatePatches(spec.Agent.PodTemplatePatches); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.podTemplatePatches, err: %v", err))
+		}
+		if err = IsValidTaintPatterns(spec.Agent.Config.AutoTolerations); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.config.autoTolerations, err: %v", err))
+		}
+		if err = IsValidResourceRecommendations(spec.Agent.ResourceRecommendations); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.resourceRecommendations, err: %v", err))
+		}
+		if err = IsValidLogSpec(&spec.Agent.Log); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.log, err: %v", err))
+		}
+		if spec.Agent.Windows != nil {
+			if err = IsValidPodTemplatePatches(spec.Agent.Windows.PodTemplatePatches); err != nil {
+				errs = append(errs, fmt.Errorf("invalid spec.agent.windows.podTemplatePatches, err: %v", err))
+			}
+		}
+		if spec.Agent.Windows != nil && spec.Agent.Windows.DaemonsetName != "" && spec.Agent.Windows.DaemonsetName == spec.Agent.DaemonsetName {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.windows.daemonsetName, err: must be different from 'spec.agent.daemonsetName'"))
+		}
 	}
 
 	if spec.ClusterAgent != nil {
@@ -29,6 +57,15 @@ func IsValidDatadogAgent(spec *DatadogAgentSpec) error {
 				errs = append(errs, fmt.Errorf("invalid spec.clusterAgent.customConfig, err: %v", err))
 			}
 		}
+		if err = IsValidPodDisruptionBudgetConfig(spec.ClusterAgent.PodDisruptionBudget); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.clusterAgent.podDisruptionBudget, err: %v", err))
+		}
+		if err = IsValidPodTemplatePatches(spec.ClusterAgent.PodTemplatePatches); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.clusterAgent.podTemplatePatches, err: %v", err))
+		}
+		if err = isValidAdmissionControllerInjectionMode(spec); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.clusterAgent.config.admissionController, err: %v", err))
+		}
 	}
 
 	if spec.ClusterChecksRunner != nil {
@@ -37,8 +74,67 @@ func IsValidDatadogAgent(spec *DatadogAgentSpec) error {
 				errs = append(errs, fmt.Errorf("invalid spec.clusterChecksRunner.customConfig, err: %v", err))
 			}
 		}
+		if err = IsValidPodDisruptionBudgetConfig(spec.ClusterChecksRunner.PodDisruptionBudget); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.clusterChecksRunner.podDisruptionBudget, err: %v", err))
+		}
+		if err = IsValidPodTemplatePatches(spec.ClusterChecksRunner.PodTemplatePatches); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.clusterChecksRunner.podTemplatePatches, err: %v", err))
+		}
 	}
 
+	for _, overrides := range getContainerOverrides(spec) {
+		if err = isValidContainerOverrides(&overrides); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s, err: %v", overrides.path, err))
+		}
+	}
+
+	if spec.Proxy != nil {
+		if err = IsValidProxyConfig(spec.Proxy); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.proxy, err: %v", err))
+		}
+	}
+
+	for i := range spec.AdditionalEndpoints {
+		if err = IsValidAdditionalEndpoint(&spec.AdditionalEndpoints[i]); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.additionalEndpoints[%d], err: %v", i, err))
+		}
+		// The logs are dual shipped over HTTP only
+		if spec.AdditionalEndpoints[i].Site != "" && spec.Agent != nil && spec.Agent.Log.Transport != nil && spec.Agent.Log.Transport.Protocol == LogTransportProtocolTCP {
+			errs = append(errs, fmt.Errorf("invalid spec.agent.log.transport.protocol, err: the logs are sent to spec.additionalEndpoints[%d] over HTTP", i))
+		}
+	}
+
+	names := map[string]bool{}
+	for i := range spec.Checks {
+		check := &spec.Checks[i]
+		if names[check.Name] {
+			errs = append(errs, fmt.Errorf("invalid spec.checks[%d], err: duplicated check %q", i, check.Name))
+			continue
+		}
+		names[check.Name] = true
+		if err = IsValidCheckConfig(check); err != nil {
+			errs = append(errs, fmt.Errorf("invalid spec.checks[%d], err: %v", i, err))
+			continue
+		}
+		if BoolValue(check.ClusterCheck) && (spec.ClusterAgent == nil || !BoolValue(spec.ClusterAgent.Config.ClusterChecksEnabled)) {
+			errs = append(errs, fmt.Errorf("invalid spec.checks[%d], err: the cluster check %q requires 'clusterAgent.config.clusterChecksEnabled'", i, check.Name))
+		}
+	}
+
+	return utilserrors.NewAggregate(errs)
+}
+
+// IsValidPlatformProfile used to check that a DatadogAgentSpec doesn't enable a feature that the platform doesn't allow
+func IsValidPlatformProfile(spec *DatadogAgentSpec, platform PlatformName) error {
+	if spec.Agent == nil {
+		return nil
+	}
+	var errs []error
+	for _, constraint := range platformProfiles[platform].forbidden {
+		if constraint.enabled(spec.Agent) {
+			errs = append(errs, fmt.Errorf("invalid %s, err: not allowed on the %q platform", constraint.field, platform))
+		}
+	}
 	return utilserrors.NewAggregate(errs)
 }
 
@@ -49,3 +145,460 @@ func IsValidCustomConfigSpec(ccs *CustomConfigSpec) error {
 	}
 	return nil
 }
+
+// IsValidProxyConfig used to check if a ProxyConfig is properly set
+func IsValidProxyConfig(config *ProxyConfig) error {
+	for _, proxyURL := range []*string{config.HTTP, config.HTTPS} {
+		if proxyURL == nil {
+			continue
+		}
+		u, err := url.Parse(*proxyURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("proxy %q must be an absolute URL", *proxyURL)
+		}
+		if u.User != nil && config.CredentialsSecret != nil {
+			return fmt.Errorf("proxy %q can't contain credentials when 'credentialsSecret' is set", *proxyURL)
+		}
+	}
+	if config.CredentialsSecret != nil && config.CredentialsSecret.SecretName == "" {
+		return fmt.Errorf("'credentialsSecret.secretName' must be set")
+	}
+	return nil
+}
+
+// IsValidAdditionalEndpoint used to check if an AdditionalEndpoint is properly set
+func IsValidAdditionalEndpoint(endpoint *AdditionalEndpoint) error {
+	if endpoint.Site == "" && endpoint.URL == nil {
+		return fmt.Errorf("'site' or 'url' must be set")
+	}
+	if endpoint.URL != nil {
+		u, err := url.Parse(*endpoint.URL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("url %q must be an absolute URL", *endpoint.URL)
+		}
+	}
+	if endpoint.APISecret.SecretName == "" {
+		return fmt.Errorf("'apiSecret.secretName' must be set")
+	}
+	return nil
+}
+
+var checkNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// IsValidCheckConfig used to check if a CheckConfig is properly set
+func IsValidCheckConfig(check *CheckConfig) error {
+	if !checkNameRegexp.MatchString(check.Name) {
+		return fmt.Errorf("'name' %q must only contain alphanumeric characters, '_', '.' and '-'", check.Name)
+	}
+	if check.InitConfig != nil {
+		initConfig := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(*check.InitConfig), &initConfig); err != nil {
+			return fmt.Errorf("'initConfig' must be a YAML map: %v", err)
+		}
+	}
+	if check.Instances == nil && check.Logs == nil {
+		return fmt.Errorf("'instances' or 'logs' must be set")
+	}
+	if check.Instances != nil {
+		if _, err := parseYAMLListOfMaps(*check.Instances); err != nil {
+			return fmt.Errorf("'instances' %v", err)
+		}
+	}
+	if check.Logs != nil {
+		if BoolValue(check.ClusterCheck) {
+			return fmt.Errorf("the logs can't be collected by a cluster check")
+		}
+		logs, err := parseYAMLListOfMaps(*check.Logs)
+		if err != nil {
+			return fmt.Errorf("'logs' %v", err)
+		}
+		for i, logConfig := range logs {
+			if _, found := logConfig["type"]; !found {
+				return fmt.Errorf("'logs[%d].type' must be set", i)
+			}
+		}
+	}
+	return nil
+}
+
+func parseYAMLListOfMaps(data string) ([]map[string]interface{}, error) {
+	var list []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &list); err != nil {
+		return nil, fmt.Errorf("must be a YAML list of maps: %v", err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("must not be empty")
+	}
+	return list, nil
+}
+
+// IsValidPodDisruptionBudgetConfig used to check if a PodDisruptionBudgetConfig is properly set
+func IsValidPodDisruptionBudgetConfig(config *PodDisruptionBudgetConfig) error {
+	if config != nil && config.MinAvailable != nil && config.MaxUnavailable != nil {
+		return fmt.Errorf("'minAvailable' and 'maxUnavailable' should not be set at the same time")
+	}
+	return nil
+}
+
+// IsValidPodTemplatePatches used to check if the PodTemplatePatches are properly set
+func IsValidPodTemplatePatches(patches []PodTemplatePatch) error {
+	names := map[string]bool{}
+	for i, patch := range patches {
+		if patch.Name == "" {
+			return fmt.Errorf("'[%d].name' must be set", i)
+		}
+		if names[patch.Name] {
+			return fmt.Errorf("duplicated patch %q", patch.Name)
+		}
+		names[patch.Name] = true
+		patchMap := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(patch.Patch), &patchMap); err != nil {
+			return fmt.Errorf("the patch %q must be a YAML map: %v", patch.Name, err)
+		}
+		if len(patchMap) == 0 {
+			return fmt.Errorf("the patch %q must not be empty", patch.Name)
+		}
+	}
+	return nil
+}
+
+// IsValidTaintPatterns used to check if the TaintPatterns are valid shell patterns
+func IsValidTaintPatterns(patterns []TaintPattern) error {
+	for i, pattern := range patterns {
+		if pattern.Key == "" {
+			return fmt.Errorf("'[%d].key' must be set", i)
+		}
+		if _, err := path.Match(pattern.Key, ""); err != nil {
+			return fmt.Errorf("'[%d].key' %q is an invalid pattern: %v", i, pattern.Key, err)
+		}
+		if _, err := path.Match(pattern.Value, ""); err != nil {
+			return fmt.Errorf("'[%d].value' %q is an invalid pattern: %v", i, pattern.Value, err)
+		}
+	}
+	return nil
+}
+
+// logsContainerFilterPrefixes are the attributes the containers are filtered on
+var logsContainerFilterPrefixes = []string{"image:", "name:", "kube_namespace:"}
+
+// IsValidLogSpec used to check that the processing rules, the container filters and the transport of the logs are valid
+func IsValidLogSpec(log *LogSpec) error {
+	for i, rule := range log.ProcessingRules {
+		if rule.Name == "" {
+			return fmt.Errorf("'processingRules[%d].name' must be set", i)
+		}
+		switch rule.Type {
+		case LogProcessingRuleExcludeAtMatch, LogProcessingRuleIncludeAtMatch, LogProcessingRuleMultiLine:
+			if rule.ReplacePlaceholder != nil {
+				return fmt.Errorf("'processingRules[%d].replacePlaceholder' is only used by the %s rules", i, LogProcessingRuleMaskSequences)
+			}
+		case LogProcessingRuleMaskSequences:
+			if rule.ReplacePlaceholder == nil {
+				return fmt.Errorf("'processingRules[%d].replacePlaceholder' must be set", i)
+			}
+		default:
+			return fmt.Errorf("'processingRules[%d].type' %q is not a valid rule type", i, rule.Type)
+		}
+		if rule.Pattern == "" {
+			return fmt.Errorf("'processingRules[%d].pattern' must be set", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("'processingRules[%d].pattern' %q is an invalid regular expression: %v", i, rule.Pattern, err)
+		}
+	}
+
+	for i, filter := range log.ContainerInclude {
+		if err := isValidLogsContainerFilter(filter); err != nil {
+			return fmt.Errorf("'containerInclude[%d]' %v", i, err)
+		}
+	}
+	for i, filter := range log.ContainerExclude {
+		if err := isValidLogsContainerFilter(filter); err != nil {
+			return fmt.Errorf("'containerExclude[%d]' %v", i, err)
+		}
+	}
+
+	if transport := log.Transport; transport != nil && transport.Protocol == LogTransportProtocolTCP {
+		if transport.UseCompression != nil || transport.CompressionLevel != nil || transport.BatchWait != nil {
+			return fmt.Errorf("'transport.useCompression', 'transport.compressionLevel' and 'transport.batchWait' are only used by the %s protocol", LogTransportProtocolHTTP)
+		}
+	}
+
+	if log.DDUrl != nil {
+		if _, port, err := net.SplitHostPort(*log.DDUrl); err != nil || port == "" {
+			return fmt.Errorf("'ddUrl' %q must be in the form <host>:<port>", *log.DDUrl)
+		}
+	}
+	return nil
+}
+
+// isValidLogsContainerFilter checks that a container filter is a regular expression prefixed by the filtered attribute
+func isValidLogsContainerFilter(filter string) error {
+	for _, prefix := range logsContainerFilterPrefixes {
+		if strings.HasPrefix(filter, prefix) {
+			if _, err := regexp.Compile(strings.TrimPrefix(filter, prefix)); err != nil {
+				return fmt.Errorf("%q is an invalid regular expression: %v", filter, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%q must start with one of %s", filter, strings.Join(logsContainerFilterPrefixes, ", "))
+}
+
+// IsValidResourceRecommendations used to check that the bounds of the resource recommendations are consistent
+func IsValidResourceRecommendations(config *ResourceRecommendationsConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Window != nil && config.Window.Duration <= 0 {
+		return fmt.Errorf("'window' %s must be positive", config.Window.Duration)
+	}
+	for name, min := range config.MinAllowed {
+		if max, found := config.MaxAllowed[name]; found && min.Cmp(max) > 0 {
+			return fmt.Errorf("'minAllowed.%s' %s must be lower than 'maxAllowed.%s' %s", name, min.String(), name, max.String())
+		}
+	}
+	return nil
+}
+
+// isValidAdmissionControllerInjectionMode checks that the sockets are exposed by the Agent when the admission controller injects them
+func isValidAdmissionControllerInjectionMode(spec *DatadogAgentSpec) error {
+	config := spec.ClusterAgent.Config
+	if config.AdmissionController == nil || !config.AdmissionController.Enabled {
+		return nil
+	}
+	if config.AdmissionController.InjectionMode == nil || *config.AdmissionController.InjectionMode != AdmissionControllerInjectionModeSocket {
+		return nil
+	}
+	if spec.Agent != nil {
+		if dsd := spec.Agent.Config.Dogstatsd; dsd != nil {
+			if BoolValue(dsd.UseDogStatsDSocketVolume) || (dsd.UnixDomainSocket != nil && BoolValue(dsd.UnixDomainSocket.Enabled)) {
+				return nil
+			}
+		}
+		if apm := spec.Agent.Apm; BoolValue(apm.Enabled) && apm.UnixDomainSocket != nil && BoolValue(apm.UnixDomainSocket.Enabled) {
+			return nil
+		}
+	}
+	return fmt.Errorf("'injectionMode: socket' requires the Agent to expose DogStatsD or APM over Unix Domain Socket")
+}
+
+// containerOverrides contains the probes and the lifecycle hooks set in the spec for a container,
+// with the ports served by the container and its default probes
+type containerOverrides struct {
+	path             string
+	container        string
+	ports            []corev1.ContainerPort
+	defaultLiveness  bool
+	defaultReadiness bool
+	livenessProbe    *corev1.Probe
+	readinessProbe   *corev1.Probe
+	startupProbe     *corev1.Probe
+	lifecycle        *corev1.Lifecycle
+}
+
+// getContainerOverrides returns the overrides of the containers of the spec.
+// The ports and the default probes must be kept in sync with the containers built by the controller.
+func getContainerOverrides(spec *DatadogAgentSpec) []containerOverrides {
+	var overrides []containerOverrides
+	healthPort := corev1.ContainerPort{ContainerPort: DefaultAgentHealthPort}
+
+	if agent := spec.Agent; agent != nil {
+		dogstatsdPort := corev1.ContainerPort{Name: "dogstatsdport", ContainerPort: DefaultDogstatsdPort}
+		dsd := agent.Config.Dogstatsd
+		udsOnly := dsd != nil && dsd.UnixDomainSocket != nil && BoolValue(dsd.UnixDomainSocket.Enabled)
+		if agent.HostNetwork && agent.Config.HostPort != nil && !udsOnly {
+			dogstatsdPort.ContainerPort = *agent.Config.HostPort
+		}
+		overrides = append(overrides,
+			containerOverrides{
+				path:             "spec.agent.config",
+				container:        "agent",
+				ports:            []corev1.ContainerPort{dogstatsdPort, healthPort},
+				defaultLiveness:  true,
+				defaultReadiness: true,
+				livenessProbe:    agent.Config.LivenessProbe,
+				readinessProbe:   agent.Config.ReadinessProbe,
+				startupProbe:     agent.Config.StartupProbe,
+				lifecycle:        agent.Config.Lifecycle,
+			},
+			containerOverrides{
+				path:            "spec.agent.apm",
+				container:       "trace-agent",
+				ports:           []corev1.ContainerPort{{Name: "traceport", ContainerPort: DefaultAPMAgentTCPPort}},
+				defaultLiveness: true,
+				livenessProbe:   agent.Apm.LivenessProbe,
+				readinessProbe:  agent.Apm.ReadinessProbe,
+				startupProbe:    agent.Apm.StartupProbe,
+				lifecycle:       agent.Apm.Lifecycle,
+			},
+			containerOverrides{
+				path:           "spec.agent.process",
+				container:      "process-agent",
+				livenessProbe:  agent.Process.LivenessProbe,
+				readinessProbe: agent.Process.ReadinessProbe,
+				startupProbe:   agent.Process.StartupProbe,
+				lifecycle:      agent.Process.Lifecycle,
+			},
+			containerOverrides{
+				path:           "spec.agent.systemProbe",
+				container:      "system-probe",
+				livenessProbe:  agent.SystemProbe.LivenessProbe,
+				readinessProbe: agent.SystemProbe.ReadinessProbe,
+				startupProbe:   agent.SystemProbe.StartupProbe,
+				lifecycle:      agent.SystemProbe.Lifecycle,
+			},
+			containerOverrides{
+				path:           "spec.agent.security",
+				container:      "security-agent",
+				livenessProbe:  agent.Security.LivenessProbe,
+				readinessProbe: agent.Security.ReadinessProbe,
+				startupProbe:   agent.Security.StartupProbe,
+				lifecycle:      agent.Security.Lifecycle,
+			},
+		)
+	}
+
+	if clusterAgent := spec.ClusterAgent; clusterAgent != nil {
+		config := clusterAgent.Config
+		dca := containerOverrides{
+			path:           "spec.clusterAgent.config",
+			container:      "cluster-agent",
+			ports:          []corev1.ContainerPort{{Name: "agentport", ContainerPort: DefaultClusterAgentServicePort}},
+			livenessProbe:  config.LivenessProbe,
+			readinessProbe: config.ReadinessProbe,
+			startupProbe:   config.StartupProbe,
+			lifecycle:      config.Lifecycle,
+		}
+		// The default probes of the Cluster Agent check the external metrics provider
+		if config.ExternalMetrics != nil && config.ExternalMetrics.Enabled {
+			port := int32(DefaultMetricsServerTargetPort)
+			if config.ExternalMetrics.Port != nil {
+				port = *config.ExternalMetrics.Port
+			}
+			dca.ports = append(dca.ports, corev1.ContainerPort{Name: "metricsapi", ContainerPort: port})
+			dca.defaultLiveness = true
+			dca.defaultReadiness = true
+		}
+		overrides = append(overrides, dca)
+	}
+
+	if runner := spec.ClusterChecksRunner; runner != nil {
+		overrides = append(overrides, containerOverrides{
+			path:             "spec.clusterChecksRunner.config",
+			container:        "cluster-checks-runner",
+			ports:            []corev1.ContainerPort{healthPort},
+			defaultLiveness:  true,
+			defaultReadiness: true,
+			livenessProbe:    runner.Config.LivenessProbe,
+			readinessProbe:   runner.Config.ReadinessProbe,
+			startupProbe:     runner.Config.StartupProbe,
+			lifecycle:        runner.Config.Lifecycle,
+		})
+	}
+	return overrides
+}
+
+// isValidContainerOverrides checks that the probes and the lifecycle hooks of a container have a handler,
+// taken from the default probe when it isn't set, and that the ports they use are served by the container
+func isValidContainerOverrides(overrides *containerOverrides) error {
+	livenessHandler := overrides.defaultLiveness || (overrides.livenessProbe != nil && countHandlers(&overrides.livenessProbe.Handler) > 0)
+	probes := []struct {
+		name       string
+		probe      *corev1.Probe
+		hasDefault bool
+	}{
+		{name: "livenessProbe", probe: overrides.livenessProbe, hasDefault: overrides.defaultLiveness},
+		{name: "readinessProbe", probe: overrides.readinessProbe, hasDefault: overrides.defaultReadiness},
+		// The startup probe checks the container like the liveness probe by default
+		{name: "startupProbe", probe: overrides.startupProbe, hasDefault: livenessHandler},
+	}
+	for _, p := range probes {
+		if p.probe == nil {
+			continue
+		}
+		switch countHandlers(&p.probe.Handler) {
+		case 0:
+			if !p.hasDefault {
+				return fmt.Errorf("'%s' must set a handler: the container %s has no default one", p.name, overrides.container)
+			}
+		case 1:
+			if err := isValidHandlerPorts(overrides, p.name, &p.probe.Handler); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("'%s' must set a single handler", p.name)
+		}
+	}
+
+	if lifecycle := overrides.lifecycle; lifecycle != nil {
+		hooks := []struct {
+			name    string
+			handler *corev1.Handler
+		}{
+			{name: "lifecycle.postStart", handler: lifecycle.PostStart},
+			{name: "lifecycle.preStop", handler: lifecycle.PreStop},
+		}
+		for _, h := range hooks {
+			if h.handler == nil {
+				continue
+			}
+			if countHandlers(h.handler) != 1 {
+				return fmt.Errorf("'%s' must set a single handler", h.name)
+			}
+			if err := isValidHandlerPorts(overrides, h.name, h.handler); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func countHandlers(handler *corev1.Handler) int {
+	count := 0
+	if handler.Exec != nil {
+		count++
+	}
+	if handler.HTTPGet != nil {
+		count++
+	}
+	if handler.TCPSocket != nil {
+		count++
+	}
+	return count
+}
+
+// isValidHandlerPorts checks that the port of an httpGet or tcpSocket handler is served by the container, by name or number
+func isValidHandlerPorts(overrides *containerOverrides, name string, handler *corev1.Handler) error {
+	var ports []intstr.IntOrString
+	if handler.HTTPGet != nil {
+		ports = append(ports, handler.HTTPGet.Port)
+	}
+	if handler.TCPSocket != nil {
+		ports = append(ports, handler.TCPSocket.Port)
+	}
+	for _, port := range ports {
+		if !hasContainerPort(overrides.ports, port) {
+			return fmt.Errorf("the port %s of the %s of the container %s doesn't exist on the container", port.String(), name, overrides.container)
+		}
+	}
+	return nil
+}
+
+func hasContainerPort(containerPorts []corev1.ContainerPort, port intstr.IntOrString) bool {
+	for _, containerPort := range containerPorts {
+		if port.Type == intstr.String && containerPort.Name != "" && port.StrVal == containerPort.Name {
+			return true
+		}
+		if port.Type == intstr.Int && port.IntVal == containerPort.ContainerPort {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestIsValidLogSpec(t *testing.T) {
+	tcp := &LogTransportConfig{Protocol: LogTransportProtocolTCP}
+	tests := []struct {
+		name                string
+		log                 LogSpec
+		additionalEndpoints []AdditionalEndpoint
+		wantErr             string
+	}{
+		{
+			name: "valid",
+			log: LogSpec{
+				ProcessingRules: []LogProcessingRule{
+					{Type: LogProcessingRuleExcludeAtMatch, Name: "exclude_healthchecks", Pattern: "GET /healthz"},
+					{Type: LogProcessingRuleMaskSequences, Name: "mask_tokens", Pattern: `token=\w+`, ReplacePlaceholder: NewStringPointer("token=[masked]")},
+				},
+				ContainerExclude: []string{"name:datadog-agent", "kube_namespace:kube-system"},
+				Transport: &LogTransportConfig{
+					Protocol:         LogTransportProtocolHTTP,
+					UseCompression:   NewBoolPointer(true),
+					CompressionLevel: NewInt32Pointer(6),
+					BatchWait:        NewInt32Pointer(5),
+				},
+				DDUrl: NewStringPointer("logs-proxy.example.com:10516"),
+			},
+		},
+		{
+			name: "invalid regular expression",
+			log: LogSpec{ProcessingRules: []LogProcessingRule{
+				{Type: LogProcessingRuleExcludeAtMatch, Name: "exclude_healthchecks", Pattern: "GET /(healthz"},
+			}},
+			wantErr: `'processingRules[0].pattern' "GET /(healthz" is an invalid regular expression`,
+		},
+		{
+			name: "mask_sequences without placeholder",
+			log: LogSpec{ProcessingRules: []LogProcessingRule{
+				{Type: LogProcessingRuleMaskSequences, Name: "mask_tokens", Pattern: `token=\w+`},
+			}},
+			wantErr: "'processingRules[0].replacePlaceholder' must be set",
+		},
+		{
+			name: "HTTP options with the TCP transport",
+			log: LogSpec{Transport: &LogTransportConfig{
+				Protocol:       LogTransportProtocolTCP,
+				UseCompression: NewBoolPointer(true),
+			}},
+			wantErr: "only used by the HTTP protocol",
+		},
+		{
+			name:    "invalid container filter prefix",
+			log:     LogSpec{ContainerExclude: []string{"pod:datadog-agent"}},
+			wantErr: `'containerExclude[0]' "pod:datadog-agent" must start with one of image:, name:, kube_namespace:`,
+		},
+		{
+			name:    "ddUrl without port",
+			log:     LogSpec{DDUrl: NewStringPointer("logs-proxy.example.com")},
+			wantErr: `'ddUrl' "logs-proxy.example.com" must be in the form <host>:<port>`,
+		},
+		{
+			name: "TCP transport with additional endpoints",
+			log:  LogSpec{Transport: tcp},
+			additionalEndpoints: []AdditionalEndpoint{
+				{Site: "datadoghq.eu", APISecret: Secret{SecretName: "eu"}},
+			},
+			wantErr: "the logs are sent to spec.additionalEndpoints[0] over HTTP",
+		},
+		{
+			name: "TCP transport with additional URL endpoints",
+			log:  LogSpec{Transport: tcp},
+			additionalEndpoints: []AdditionalEndpoint{
+				{URL: NewStringPointer("https://metrics.example.com"), APISecret: Secret{SecretName: "custom"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &DatadogAgentSpec{
+				Agent:               &DatadogAgentSpecAgentSpec{Log: tt.log},
+				AdditionalEndpoints: tt.additionalEndpoints,
+			}
+			err := IsValidDatadogAgent(spec)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsValidContainerOverrides(t *testing.T) {
+	httpProbe := func(port intstr.IntOrString) *corev1.Probe {
+		return &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: port}}}
+	}
+	tests := []struct {
+		name    string
+		update  func(spec *DatadogAgentSpec)
+		wantErr string
+	}{
+		{
+			name: "thresholds over the default probes",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Config.LivenessProbe = &corev1.Probe{FailureThreshold: 12}
+				spec.Agent.Config.StartupProbe = &corev1.Probe{FailureThreshold: 60}
+				spec.Agent.Apm.StartupProbe = &corev1.Probe{FailureThreshold: 60}
+			},
+		},
+		{
+			name: "declared ports and ports of the default probes",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Config.LivenessProbe = httpProbe(intstr.FromString("dogstatsdport"))
+				spec.Agent.Config.ReadinessProbe = httpProbe(intstr.FromInt(int(DefaultAgentHealthPort)))
+				spec.ClusterAgent.Config.ReadinessProbe = &corev1.Probe{Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("agentport")}}}
+			},
+		},
+		{
+			name: "unknown port",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Config.StartupProbe = httpProbe(intstr.FromString("healthport"))
+			},
+			wantErr: "invalid spec.agent.config, err: the port healthport of the startupProbe of the container agent doesn't exist on the container",
+		},
+		{
+			name: "unknown lifecycle hook port",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Config.Lifecycle = &corev1.Lifecycle{PostStart: &corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(9999)}}}
+			},
+			wantErr: "invalid spec.agent.config, err: the port 9999 of the lifecycle.postStart of the container agent doesn't exist on the container",
+		},
+		{
+			name: "port of a disabled feature",
+			update: func(spec *DatadogAgentSpec) {
+				spec.ClusterAgent.Config.ReadinessProbe = &corev1.Probe{Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("metricsapi")}}}
+			},
+			wantErr: "the port metricsapi of the readinessProbe of the container cluster-agent doesn't exist on the container",
+		},
+		{
+			name: "startup probe without handler nor default",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Process.StartupProbe = &corev1.Probe{FailureThreshold: 60}
+			},
+			wantErr: "invalid spec.agent.process, err: 'startupProbe' must set a handler: the container process-agent has no default one",
+		},
+		{
+			name: "liveness probe without handler nor default",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.SystemProbe.LivenessProbe = &corev1.Probe{FailureThreshold: 3}
+			},
+			wantErr: "invalid spec.agent.systemProbe, err: 'livenessProbe' must set a handler: the container system-probe has no default one",
+		},
+		{
+			name: "startup probe taking the handler of the liveness probe override",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Security.LivenessProbe = &corev1.Probe{Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"true"}}}}
+				spec.Agent.Security.StartupProbe = &corev1.Probe{FailureThreshold: 60}
+			},
+		},
+		{
+			name: "several handlers",
+			update: func(spec *DatadogAgentSpec) {
+				probe := httpProbe(intstr.FromInt(int(DefaultAgentHealthPort)))
+				probe.Exec = &corev1.ExecAction{Command: []string{"true"}}
+				spec.ClusterChecksRunner.Config.LivenessProbe = probe
+			},
+			wantErr: "invalid spec.clusterChecksRunner.config, err: 'livenessProbe' must set a single handler",
+		},
+		{
+			name: "lifecycle hook without handler",
+			update: func(spec *DatadogAgentSpec) {
+				spec.Agent.Apm.Lifecycle = &corev1.Lifecycle{PreStop: &corev1.Handler{}}
+			},
+			wantErr: "invalid spec.agent.apm, err: 'lifecycle.preStop' must set a single handler",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &DatadogAgentSpec{
+				Agent:               &DatadogAgentSpecAgentSpec{},
+				ClusterAgent:        &DatadogAgentSpecClusterAgentSpec{},
+				ClusterChecksRunner: &DatadogAgentSpecClusterChecksRunnerSpec{},
+			}
+			tt.update(spec)
+			err := IsValidDatadogAgent(spec)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -31,6 +31,11 @@ func (in *APMSpec) DeepCopyInto(out *APMSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.UnixDomainSocket != nil {
+		in, out := &in.UnixDomainSocket, &out.UnixDomainSocket
+		*out = new(UnixDomainSocketConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
 		*out = make([]v1.EnvVar, len(*in))
@@ -43,6 +48,26 @@ func (in *APMSpec) DeepCopyInto(out *APMSpec) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APMSpec.
@@ -55,6 +80,27 @@ func (in *APMSpec) DeepCopy() *APMSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalEndpoint) DeepCopyInto(out *AdditionalEndpoint) {
+	*out = *in
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(string)
+		**out = **in
+	}
+	out.APISecret = in.APISecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalEndpoint.
+func (in *AdditionalEndpoint) DeepCopy() *AdditionalEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdmissionControllerConfig) DeepCopyInto(out *AdmissionControllerConfig) {
 	*out = *in
@@ -68,6 +114,46 @@ func (in *AdmissionControllerConfig) DeepCopyInto(out *AdmissionControllerConfig
 		*out = new(string)
 		**out = **in
 	}
+	if in.InjectConfig != nil {
+		in, out := &in.InjectConfig, &out.InjectConfig
+		*out = new(bool)
+		**out = **in
+	}
+	if in.InjectEntityID != nil {
+		in, out := &in.InjectEntityID, &out.InjectEntityID
+		*out = new(bool)
+		**out = **in
+	}
+	if in.InjectTags != nil {
+		in, out := &in.InjectTags, &out.InjectTags
+		*out = new(bool)
+		**out = **in
+	}
+	if in.InjectAPMLibraries != nil {
+		in, out := &in.InjectAPMLibraries, &out.InjectAPMLibraries
+		*out = new(bool)
+		**out = **in
+	}
+	if in.InjectionMode != nil {
+		in, out := &in.InjectionMode, &out.InjectionMode
+		*out = new(AdmissionControllerInjectionMode)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(AdmissionControllerFailurePolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionControllerConfig.
@@ -80,6 +166,21 @@ func (in *AdmissionControllerConfig) DeepCopy() *AdmissionControllerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentCoverageStatus) DeepCopyInto(out *AgentCoverageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentCoverageStatus.
+func (in *AgentCoverageStatus) DeepCopy() *AgentCoverageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentCoverageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AgentCredentials) DeepCopyInto(out *AgentCredentials) {
 	*out = *in
@@ -110,6 +211,46 @@ func (in *AgentCredentials) DeepCopy() *AgentCredentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentHealthReasonCount) DeepCopyInto(out *AgentHealthReasonCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentHealthReasonCount.
+func (in *AgentHealthReasonCount) DeepCopy() *AgentHealthReasonCount {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentHealthReasonCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentHealthStatus) DeepCopyInto(out *AgentHealthStatus) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]AgentHealthReasonCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeAgentHealth, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentHealthStatus.
+func (in *AgentHealthStatus) DeepCopy() *AgentHealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentHealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CRISocketConfig) DeepCopyInto(out *CRISocketConfig) {
 	*out = *in
@@ -123,6 +264,11 @@ func (in *CRISocketConfig) DeepCopyInto(out *CRISocketConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AutoDetect != nil {
+		in, out := &in.AutoDetect, &out.AutoDetect
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRISocketConfig.
@@ -135,6 +281,98 @@ func (in *CRISocketConfig) DeepCopy() *CRISocketConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatesStatus) DeepCopyInto(out *CertificatesStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.CANotAfter != nil {
+		in, out := &in.CANotAfter, &out.CANotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotation != nil {
+		in, out := &in.LastRotation, &out.LastRotation
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatesStatus.
+func (in *CertificatesStatus) DeepCopy() *CertificatesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckConfig) DeepCopyInto(out *CheckConfig) {
+	*out = *in
+	if in.InitConfig != nil {
+		in, out := &in.InitConfig, &out.InitConfig
+		*out = new(string)
+		**out = **in
+	}
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = new(string)
+		**out = **in
+	}
+	if in.Logs != nil {
+		in, out := &in.Logs, &out.Logs
+		*out = new(string)
+		**out = **in
+	}
+	if in.ClusterCheck != nil {
+		in, out := &in.ClusterCheck, &out.ClusterCheck
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckConfig.
+func (in *CheckConfig) DeepCopy() *CheckConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAgentCertificatesConfig) DeepCopyInto(out *ClusterAgentCertificatesConfig) {
+	*out = *in
+	if in.Provider != nil {
+		in, out := &in.Provider, &out.Provider
+		*out = new(CertificatesProvider)
+		**out = **in
+	}
+	if in.Validity != nil {
+		in, out := &in.Validity, &out.Validity
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAgentCertificatesConfig.
+func (in *ClusterAgentCertificatesConfig) DeepCopy() *ClusterAgentCertificatesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAgentCertificatesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterAgentConfig) DeepCopyInto(out *ClusterAgentConfig) {
 	*out = *in
@@ -148,6 +386,11 @@ func (in *ClusterAgentConfig) DeepCopyInto(out *ClusterAgentConfig) {
 		*out = new(AdmissionControllerConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = new(ClusterAgentCertificatesConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ClusterChecksEnabled != nil {
 		in, out := &in.ClusterChecksEnabled, &out.ClusterChecksEnabled
 		*out = new(bool)
@@ -168,6 +411,26 @@ func (in *ClusterAgentConfig) DeepCopyInto(out *ClusterAgentConfig) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Confd != nil {
 		in, out := &in.Confd, &out.Confd
 		*out = new(ConfigDirSpec)
@@ -214,6 +477,26 @@ func (in *ClusterChecksRunnerConfig) DeepCopyInto(out *ClusterChecksRunnerConfig
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LogLevel != nil {
 		in, out := &in.LogLevel, &out.LogLevel
 		*out = new(string)
@@ -312,6 +595,51 @@ func (in *ConfigFileConfigMapSpec) DeepCopy() *ConfigFileConfigMapSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerResourceRecommendation) DeepCopyInto(out *ContainerResourceRecommendation) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourceRecommendation.
+func (in *ContainerResourceRecommendation) DeepCopy() *ContainerResourceRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRuntimeStatus) DeepCopyInto(out *ContainerRuntimeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRuntimeStatus.
+func (in *ContainerRuntimeStatus) DeepCopy() *ContainerRuntimeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRuntimeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomConfigSpec) DeepCopyInto(out *CustomConfigSpec) {
 	*out = *in
@@ -522,6 +850,25 @@ func (in *DatadogAgentSpec) DeepCopyInto(out *DatadogAgentSpec) {
 		*out = new(DatadogAgentSpecClusterChecksRunnerSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalEndpoints != nil {
+		in, out := &in.AdditionalEndpoints, &out.AdditionalEndpoints
+		*out = make([]AdditionalEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]CheckConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogAgentSpec.
@@ -564,6 +911,16 @@ func (in *DatadogAgentSpecAgentSpec) DeepCopyInto(out *DatadogAgentSpecAgentSpec
 			(*out)[key] = val
 		}
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PodTemplatePatches != nil {
+		in, out := &in.PodTemplatePatches, &out.PodTemplatePatches
+		*out = make([]PodTemplatePatch, len(*in))
+		copy(*out, *in)
+	}
 	if in.DNSConfig != nil {
 		in, out := &in.DNSConfig, &out.DNSConfig
 		*out = new(v1.PodDNSConfig)
@@ -587,6 +944,16 @@ func (in *DatadogAgentSpecAgentSpec) DeepCopyInto(out *DatadogAgentSpecAgentSpec
 		(*in).DeepCopyInto(*out)
 	}
 	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = new(WindowsAgentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = new(ResourceRecommendationsConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogAgentSpecAgentSpec.
@@ -629,6 +996,16 @@ func (in *DatadogAgentSpecClusterAgentSpec) DeepCopyInto(out *DatadogAgentSpecCl
 			(*out)[key] = val
 		}
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PodTemplatePatches != nil {
+		in, out := &in.PodTemplatePatches, &out.PodTemplatePatches
+		*out = make([]PodTemplatePatch, len(*in))
+		copy(*out, *in)
+	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
 		*out = new(v1.Affinity)
@@ -649,6 +1026,23 @@ func (in *DatadogAgentSpecClusterAgentSpec) DeepCopyInto(out *DatadogAgentSpecCl
 		}
 	}
 	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	if in.AntiAffinityPreset != nil {
+		in, out := &in.AntiAffinityPreset, &out.AntiAffinityPreset
+		*out = new(PodAntiAffinityPreset)
+		**out = **in
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogAgentSpecClusterAgentSpec.
@@ -691,6 +1085,16 @@ func (in *DatadogAgentSpecClusterChecksRunnerSpec) DeepCopyInto(out *DatadogAgen
 			(*out)[key] = val
 		}
 	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PodTemplatePatches != nil {
+		in, out := &in.PodTemplatePatches, &out.PodTemplatePatches
+		*out = make([]PodTemplatePatch, len(*in))
+		copy(*out, *in)
+	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
 		*out = new(v1.Affinity)
@@ -711,6 +1115,23 @@ func (in *DatadogAgentSpecClusterChecksRunnerSpec) DeepCopyInto(out *DatadogAgen
 		}
 	}
 	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	if in.AntiAffinityPreset != nil {
+		in, out := &in.AntiAffinityPreset, &out.AntiAffinityPreset
+		*out = new(PodAntiAffinityPreset)
+		**out = **in
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogAgentSpecClusterChecksRunnerSpec.
@@ -741,6 +1162,11 @@ func (in *DatadogAgentStatus) DeepCopyInto(out *DatadogAgentStatus) {
 		*out = new(DeploymentStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterAgentCertificates != nil {
+		in, out := &in.ClusterAgentCertificates, &out.ClusterAgentCertificates
+		*out = new(CertificatesStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]DatadogAgentCondition, len(*in))
@@ -748,6 +1174,33 @@ func (in *DatadogAgentStatus) DeepCopyInto(out *DatadogAgentStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ContainerRuntimes != nil {
+		in, out := &in.ContainerRuntimes, &out.ContainerRuntimes
+		*out = make([]ContainerRuntimeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.WindowsAgent != nil {
+		in, out := &in.WindowsAgent, &out.WindowsAgent
+		*out = new(DaemonSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgentHealth != nil {
+		in, out := &in.AgentHealth, &out.AgentHealth
+		*out = new(AgentHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgentCoverage != nil {
+		in, out := &in.AgentCoverage, &out.AgentCoverage
+		*out = new(AgentCoverageStatus)
+		**out = **in
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = make([]ContainerResourceRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogAgentStatus.
@@ -905,6 +1358,11 @@ func (in *DogstatsdConfig) DeepCopyInto(out *DogstatsdConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.UnixDomainSocket != nil {
+		in, out := &in.UnixDomainSocket, &out.UnixDomainSocket
+		*out = new(UnixDomainSocketConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DogstatsdConfig.
@@ -972,15 +1430,35 @@ func (in *ImageConfig) DeepCopy() *ImageConfig {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LogSpec) DeepCopyInto(out *LogSpec) {
+func (in *LogProcessingRule) DeepCopyInto(out *LogProcessingRule) {
 	*out = *in
-	if in.Enabled != nil {
-		in, out := &in.Enabled, &out.Enabled
-		*out = new(bool)
+	if in.ReplacePlaceholder != nil {
+		in, out := &in.ReplacePlaceholder, &out.ReplacePlaceholder
+		*out = new(string)
 		**out = **in
 	}
-	if in.LogsConfigContainerCollectAll != nil {
-		in, out := &in.LogsConfigContainerCollectAll, &out.LogsConfigContainerCollectAll
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogProcessingRule.
+func (in *LogProcessingRule) DeepCopy() *LogProcessingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(LogProcessingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSpec) DeepCopyInto(out *LogSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LogsConfigContainerCollectAll != nil {
+		in, out := &in.LogsConfigContainerCollectAll, &out.LogsConfigContainerCollectAll
 		*out = new(bool)
 		**out = **in
 	}
@@ -1009,6 +1487,33 @@ func (in *LogSpec) DeepCopyInto(out *LogSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ProcessingRules != nil {
+		in, out := &in.ProcessingRules, &out.ProcessingRules
+		*out = make([]LogProcessingRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ContainerInclude != nil {
+		in, out := &in.ContainerInclude, &out.ContainerInclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerExclude != nil {
+		in, out := &in.ContainerExclude, &out.ContainerExclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transport != nil {
+		in, out := &in.Transport, &out.Transport
+		*out = new(LogTransportConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DDUrl != nil {
+		in, out := &in.DDUrl, &out.DDUrl
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSpec.
@@ -1021,6 +1526,36 @@ func (in *LogSpec) DeepCopy() *LogSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogTransportConfig) DeepCopyInto(out *LogTransportConfig) {
+	*out = *in
+	if in.UseCompression != nil {
+		in, out := &in.UseCompression, &out.UseCompression
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CompressionLevel != nil {
+		in, out := &in.CompressionLevel, &out.CompressionLevel
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BatchWait != nil {
+		in, out := &in.BatchWait, &out.BatchWait
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogTransportConfig.
+func (in *LogTransportConfig) DeepCopy() *LogTransportConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LogTransportConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
 	*out = *in
@@ -1029,6 +1564,18 @@ func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AllowAutodiscovery != nil {
+		in, out := &in.AllowAutodiscovery, &out.AllowAutodiscovery
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DNSSelectorEndpoints != nil {
+		in, out := &in.DNSSelectorEndpoints, &out.DNSSelectorEndpoints
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
@@ -1124,6 +1671,26 @@ func (in *NodeAgentConfig) DeepCopyInto(out *NodeAgentConfig) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CriSocket != nil {
 		in, out := &in.CriSocket, &out.CriSocket
 		*out = new(CRISocketConfig)
@@ -1141,6 +1708,11 @@ func (in *NodeAgentConfig) DeepCopyInto(out *NodeAgentConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AutoTolerations != nil {
+		in, out := &in.AutoTolerations, &out.AutoTolerations
+		*out = make([]TaintPattern, len(*in))
+		copy(*out, *in)
+	}
 	if in.HostPort != nil {
 		in, out := &in.HostPort, &out.HostPort
 		*out = new(int32)
@@ -1158,6 +1730,81 @@ func (in *NodeAgentConfig) DeepCopy() *NodeAgentConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentHealth) DeepCopyInto(out *NodeAgentHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentHealth.
+func (in *NodeAgentHealth) DeepCopy() *NodeAgentHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodAntiAffinityPreset) DeepCopyInto(out *PodAntiAffinityPreset) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAntiAffinityPreset.
+func (in *PodAntiAffinityPreset) DeepCopy() *PodAntiAffinityPreset {
+	if in == nil {
+		return nil
+	}
+	out := new(PodAntiAffinityPreset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetConfig.
+func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplatePatch) DeepCopyInto(out *PodTemplatePatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplatePatch.
+func (in *PodTemplatePatch) DeepCopy() *PodTemplatePatch {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplatePatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProcessSpec) DeepCopyInto(out *ProcessSpec) {
 	*out = *in
@@ -1178,6 +1825,26 @@ func (in *ProcessSpec) DeepCopyInto(out *ProcessSpec) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessSpec.
@@ -1190,6 +1857,56 @@ func (in *ProcessSpec) DeepCopy() *ProcessSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(string)
+		**out = **in
+	}
+	if in.HTTPS != nil {
+		in, out := &in.HTTPS, &out.HTTPS
+		*out = new(string)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(ProxyCredentialsSecret)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfig.
+func (in *ProxyConfig) DeepCopy() *ProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyCredentialsSecret) DeepCopyInto(out *ProxyCredentialsSecret) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyCredentialsSecret.
+func (in *ProxyCredentialsSecret) DeepCopy() *ProxyCredentialsSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyCredentialsSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RbacConfig) DeepCopyInto(out *RbacConfig) {
 	*out = *in
@@ -1215,6 +1932,50 @@ func (in *RbacConfig) DeepCopy() *RbacConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRecommendationsConfig) DeepCopyInto(out *ResourceRecommendationsConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Percentile != nil {
+		in, out := &in.Percentile, &out.Percentile
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllowed != nil {
+		in, out := &in.MinAllowed, &out.MinAllowed
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxAllowed != nil {
+		in, out := &in.MaxAllowed, &out.MaxAllowed
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRecommendationsConfig.
+func (in *ResourceRecommendationsConfig) DeepCopy() *ResourceRecommendationsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRecommendationsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuntimeSecuritySpec) DeepCopyInto(out *RuntimeSecuritySpec) {
 	*out = *in
@@ -1277,6 +2038,26 @@ func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuritySpec.
@@ -1354,6 +2135,26 @@ func (in *SystemProbeSpec) DeepCopyInto(out *SystemProbeSpec) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SecurityContext != nil {
 		in, out := &in.SecurityContext, &out.SecurityContext
 		*out = new(v1.SecurityContext)
@@ -1370,3 +2171,78 @@ func (in *SystemProbeSpec) DeepCopy() *SystemProbeSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaintPattern) DeepCopyInto(out *TaintPattern) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaintPattern.
+func (in *TaintPattern) DeepCopy() *TaintPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(TaintPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnixDomainSocketConfig) DeepCopyInto(out *UnixDomainSocketConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HostFilepath != nil {
+		in, out := &in.HostFilepath, &out.HostFilepath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnixDomainSocketConfig.
+func (in *UnixDomainSocketConfig) DeepCopy() *UnixDomainSocketConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UnixDomainSocketConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowsAgentSpec) DeepCopyInto(out *WindowsAgentSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(ImageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CriSocket != nil {
+		in, out := &in.CriSocket, &out.CriSocket
+		*out = new(CRISocketConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplatePatches != nil {
+		in, out := &in.PodTemplatePatches, &out.PodTemplatePatches
+		*out = make([]PodTemplatePatch, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowsAgentSpec.
+func (in *WindowsAgentSpec) DeepCopy() *WindowsAgentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowsAgentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,14 +19,23 @@ import (
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
 		"./api/v1alpha1.APMSpec":                                 schema__api_v1alpha1_APMSpec(ref),
+		"./api/v1alpha1.AdditionalEndpoint":                      schema__api_v1alpha1_AdditionalEndpoint(ref),
 		"./api/v1alpha1.AdmissionControllerConfig":               schema__api_v1alpha1_AdmissionControllerConfig(ref),
+		"./api/v1alpha1.AgentCoverageStatus":                     schema__api_v1alpha1_AgentCoverageStatus(ref),
 		"./api/v1alpha1.AgentCredentials":                        schema__api_v1alpha1_AgentCredentials(ref),
+		"./api/v1alpha1.AgentHealthReasonCount":                  schema__api_v1alpha1_AgentHealthReasonCount(ref),
+		"./api/v1alpha1.AgentHealthStatus":                       schema__api_v1alpha1_AgentHealthStatus(ref),
 		"./api/v1alpha1.CRISocketConfig":                         schema__api_v1alpha1_CRISocketConfig(ref),
+		"./api/v1alpha1.CertificatesStatus":                      schema__api_v1alpha1_CertificatesStatus(ref),
+		"./api/v1alpha1.CheckConfig":                             schema__api_v1alpha1_CheckConfig(ref),
+		"./api/v1alpha1.ClusterAgentCertificatesConfig":          schema__api_v1alpha1_ClusterAgentCertificatesConfig(ref),
 		"./api/v1alpha1.ClusterAgentConfig":                      schema__api_v1alpha1_ClusterAgentConfig(ref),
 		"./api/v1alpha1.ClusterChecksRunnerConfig":               schema__api_v1alpha1_ClusterChecksRunnerConfig(ref),
 		"./api/v1alpha1.ComplianceSpec":                          schema__api_v1alpha1_ComplianceSpec(ref),
 		"./api/v1alpha1.ConfigDirSpec":                           schema__api_v1alpha1_ConfigDirSpec(ref),
 		"./api/v1alpha1.ConfigFileConfigMapSpec":                 schema__api_v1alpha1_ConfigFileConfigMapSpec(ref),
+		"./api/v1alpha1.ContainerResourceRecommendation":         schema__api_v1alpha1_ContainerResourceRecommendation(ref),
+		"./api/v1alpha1.ContainerRuntimeStatus":                  schema__api_v1alpha1_ContainerRuntimeStatus(ref),
 		"./api/v1alpha1.CustomConfigSpec":                        schema__api_v1alpha1_CustomConfigSpec(ref),
 		"./api/v1alpha1.DaemonSetDeploymentStrategy":             schema__api_v1alpha1_DaemonSetDeploymentStrategy(ref),
 		"./api/v1alpha1.DaemonSetRollingUpdateSpec":              schema__api_v1alpha1_DaemonSetRollingUpdateSpec(ref),
@@ -44,16 +53,28 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"./api/v1alpha1.DogstatsdConfig":                         schema__api_v1alpha1_DogstatsdConfig(ref),
 		"./api/v1alpha1.ExternalMetricsConfig":                   schema__api_v1alpha1_ExternalMetricsConfig(ref),
 		"./api/v1alpha1.ImageConfig":                             schema__api_v1alpha1_ImageConfig(ref),
+		"./api/v1alpha1.LogProcessingRule":                       schema__api_v1alpha1_LogProcessingRule(ref),
 		"./api/v1alpha1.LogSpec":                                 schema__api_v1alpha1_LogSpec(ref),
+		"./api/v1alpha1.LogTransportConfig":                      schema__api_v1alpha1_LogTransportConfig(ref),
 		"./api/v1alpha1.NetworkPolicySpec":                       schema__api_v1alpha1_NetworkPolicySpec(ref),
 		"./api/v1alpha1.NodeAgentConfig":                         schema__api_v1alpha1_NodeAgentConfig(ref),
+		"./api/v1alpha1.NodeAgentHealth":                         schema__api_v1alpha1_NodeAgentHealth(ref),
+		"./api/v1alpha1.PodAntiAffinityPreset":                   schema__api_v1alpha1_PodAntiAffinityPreset(ref),
+		"./api/v1alpha1.PodDisruptionBudgetConfig":               schema__api_v1alpha1_PodDisruptionBudgetConfig(ref),
+		"./api/v1alpha1.PodTemplatePatch":                        schema__api_v1alpha1_PodTemplatePatch(ref),
 		"./api/v1alpha1.ProcessSpec":                             schema__api_v1alpha1_ProcessSpec(ref),
+		"./api/v1alpha1.ProxyConfig":                             schema__api_v1alpha1_ProxyConfig(ref),
+		"./api/v1alpha1.ProxyCredentialsSecret":                  schema__api_v1alpha1_ProxyCredentialsSecret(ref),
 		"./api/v1alpha1.RbacConfig":                              schema__api_v1alpha1_RbacConfig(ref),
+		"./api/v1alpha1.ResourceRecommendationsConfig":           schema__api_v1alpha1_ResourceRecommendationsConfig(ref),
 		"./api/v1alpha1.RuntimeSecuritySpec":                     schema__api_v1alpha1_RuntimeSecuritySpec(ref),
 		"./api/v1alpha1.Secret":                                  schema__api_v1alpha1_Secret(ref),
 		"./api/v1alpha1.SecuritySpec":                            schema__api_v1alpha1_SecuritySpec(ref),
 		"./api/v1alpha1.SyscallMonitorSpec":                      schema__api_v1alpha1_SyscallMonitorSpec(ref),
 		"./api/v1alpha1.SystemProbeSpec":                         schema__api_v1alpha1_SystemProbeSpec(ref),
+		"./api/v1alpha1.TaintPattern":                            schema__api_v1alpha1_TaintPattern(ref),
+		"./api/v1alpha1.UnixDomainSocketConfig":                  schema__api_v1alpha1_UnixDomainSocketConfig(ref),
+		"./api/v1alpha1.WindowsAgentSpec":                        schema__api_v1alpha1_WindowsAgentSpec(ref),
 	}
 }
 
@@ -78,6 +99,12 @@ func schema__api_v1alpha1_APMSpec(ref common.ReferenceCallback) common.OpenAPIDe
 							Format:      "int32",
 						},
 					},
+					"unixDomainSocket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UnixDomainSocket enables the trace intake over Unix Domain Socket ref: https://docs.datadoghq.com/agent/kubernetes/apm/?tab=daemonset#setup",
+							Ref:         ref("./api/v1alpha1.UnixDomainSocketConfig"),
+						},
+					},
 					"env": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
@@ -105,11 +132,71 @@ func schema__api_v1alpha1_APMSpec(ref common.ReferenceCallback) common.OpenAPIDe
 							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
 						},
 					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the APM Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the APM Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the APM Agent container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the APM Agent container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.UnixDomainSocketConfig", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements"},
+	}
+}
+
+func schema__api_v1alpha1_AdditionalEndpoint(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AdditionalEndpoint defines an additional Datadog intake the data is dual shipped to",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"site": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Site of the Datadog intake, e.g. \"datadoghq.eu\". The metrics, logs, traces and processes are sent to the intakes of the site. The logs are sent over HTTPS.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of the metrics intake, e.g. \"https://app.datadoghq.eu\", overrides the metrics intake of the site. Without site, only the metrics are sent to this endpoint.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiSecret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APISecret references the Secret containing the API key of the endpoint, the key defaults to \"api_key\"",
+							Ref:         ref("./api/v1alpha1.Secret"),
+						},
+					},
 				},
+				Required: []string{"apiSecret"},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements"},
+			"./api/v1alpha1.Secret"},
 	}
 }
 
@@ -141,7 +228,91 @@ func schema__api_v1alpha1_AdmissionControllerConfig(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
+					"injectConfig": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InjectConfig enables injecting the environment variables required to reach the Agent (DD_AGENT_HOST, DD_DOGSTATSD_URL, DD_TRACE_AGENT_URL) Default: true",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"injectEntityID": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InjectEntityID enables injecting the DD_ENTITY_ID environment variable used for origin detection Default: true",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"injectTags": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InjectTags enables injecting the standard tags (env, service, version) from the pod and owner labels Default: true",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"injectAPMLibraries": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InjectAPMLibraries enables injecting the APM tracing libraries requested by the pod annotations Default: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"injectionMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InjectionMode defines how the injected configuration reaches the Agent: \"hostip\", \"service\" or \"socket\" Default: \"hostip\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespaceSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NamespaceSelector restricts the namespaces whose pods are mutated",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"objectSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObjectSelector restricts the pods that are mutated. Defaults to the pods labelled with 'admission.datadoghq.com/enabled=\"true\"', or all the pods not labelled with 'admission.datadoghq.com/enabled=\"false\"' if MutateUnlabelled is true.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"failurePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailurePolicy defines how errors of the admission controller are handled by the API server: \"Ignore\" or \"Fail\" Default: \"Ignore\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema__api_v1alpha1_AgentCoverageStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AgentCoverageStatus defines the number of nodes that can run an Agent pod",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"coveredNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CoveredNodes is the number of nodes that can run an Agent pod",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"uncoveredNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UncoveredNodes is the number of nodes that can't run an Agent pod, because of their taints or their labels",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
+				Required: []string{"coveredNodes", "uncoveredNodes"},
 			},
 		},
 	}
@@ -216,207 +387,495 @@ func schema__api_v1alpha1_AgentCredentials(ref common.ReferenceCallback) common.
 	}
 }
 
-func schema__api_v1alpha1_CRISocketConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema__api_v1alpha1_AgentHealthReasonCount(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "CRISocketConfig contains the CRI socket configuration parameters",
+				Description: "AgentHealthReasonCount defines the number of nodes whose Agent is unhealthy for a reason",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"dockerSocketPath": {
+					"reason": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Path to the docker runtime socket",
+							Description: "Reason why the Agent is unhealthy",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"criSocketPath": {
+					"nodes": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Path to the container runtime socket (if different from Docker) This is supported starting from agent 6.6.0",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Nodes is the number of nodes whose Agent is unhealthy for this reason",
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
 				},
+				Required: []string{"reason", "nodes"},
 			},
 		},
 	}
 }
 
-func schema__api_v1alpha1_ClusterAgentConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema__api_v1alpha1_AgentHealthStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "ClusterAgentConfig contains the configuration of the Cluster Agent",
+				Description: "AgentHealthStatus summarizes the health of the Agent pods on the nodes",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"externalMetrics": {
-						SchemaProps: spec.SchemaProps{
-							Ref: ref("./api/v1alpha1.ExternalMetricsConfig"),
-						},
-					},
-					"admissionController": {
-						SchemaProps: spec.SchemaProps{
-							Description: "Configure the Admission Controller",
-							Ref:         ref("./api/v1alpha1.AdmissionControllerConfig"),
-						},
-					},
-					"clusterChecksEnabled": {
-						SchemaProps: spec.SchemaProps{
-							Description: "Enable the Cluster Checks and Endpoint Checks feature on both the cluster-agents and the daemonset ref: https://docs.datadoghq.com/agent/cluster_agent/clusterchecks/ https://docs.datadoghq.com/agent/cluster_agent/endpointschecks/ Autodiscovery via Kube Service annotations is automatically enabled",
-							Type:        []string{"boolean"},
-							Format:      "",
-						},
-					},
-					"collectEvents": {
-						SchemaProps: spec.SchemaProps{
-							Description: "Enables this to start event collection from the kubernetes API ref: https://docs.datadoghq.com/agent/cluster_agent/event_collection/",
-							Type:        []string{"boolean"},
-							Format:      "",
-						},
-					},
-					"logLevel": {
-						SchemaProps: spec.SchemaProps{
-							Description: "Set logging verbosity, valid log levels are: trace, debug, info, warn, error, critical, and off",
-							Type:        []string{"string"},
-							Format:      "",
-						},
-					},
-					"resources": {
+					"healthyNodes": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Datadog cluster-agent resource requests and limits",
-							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+							Description: "HealthyNodes is the number of nodes running a ready Agent",
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"confd": {
+					"unhealthyNodes": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Confd Provide additional cluster check configurations. Each key will become a file in /conf.d see https://docs.datadoghq.com/agent/autodiscovery/ for more details.",
-							Ref:         ref("./api/v1alpha1.ConfigDirSpec"),
+							Description: "UnhealthyNodes is the number of nodes without a ready Agent",
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"env": {
+					"reasons": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
 								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
+									"reason",
 								},
 								"x-kubernetes-list-type": "map",
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
+							Description: "Reasons is the number of unhealthy nodes per reason",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+										Ref: ref("./api/v1alpha1.AgentHealthReasonCount"),
 									},
 								},
 							},
 						},
 					},
-					"volumeMounts": {
+					"nodes": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-									"mountPath",
-								},
-								"x-kubernetes-list-type": "map",
+								"x-kubernetes-list-type": "atomic",
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "Specify additional volume mounts in the Datadog Cluster Agent container",
+							Description: "Nodes are the unhealthy nodes whose Agent restarted the most, limited to 10 nodes. Use `kubectl datadog agent health` to list all the nodes.",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.VolumeMount"),
+										Ref: ref("./api/v1alpha1.NodeAgentHealth"),
 									},
 								},
 							},
 						},
 					},
-					"volumes": {
-						VendorExtensible: spec.VendorExtensible{
-							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-								},
-								"x-kubernetes-list-type": "map",
-							},
+				},
+				Required: []string{"healthyNodes", "unhealthyNodes"},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.AgentHealthReasonCount", "./api/v1alpha1.NodeAgentHealth"},
+	}
+}
+
+func schema__api_v1alpha1_CRISocketConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CRISocketConfig contains the CRI socket configuration parameters",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"dockerSocketPath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path to the docker runtime socket",
+							Type:        []string{"string"},
+							Format:      "",
 						},
+					},
+					"criSocketPath": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Specify additional volumes in the Datadog Cluster Agent container",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.Volume"),
-									},
-								},
-							},
+							Description: "Path to the container runtime socket (if different from Docker) This is supported starting from agent 6.6.0",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"autoDetect": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoDetect enables the detection of the container runtime of the nodes (docker, containerd, cri-o or k3s) from their status, in order to use the socket of the runtime instead of the paths above. The paths above are used when no runtime is detected. When the nodes run several runtimes, the nodes are labelled with their runtime and the Agents of the nodes that don't run the most common runtime are deployed by an additional DaemonSet per runtime. Enabled by default when criSocket isn't set.",
+							Type:        []string{"boolean"},
+							Format:      "",
 						},
 					},
 				},
 			},
 		},
-		Dependencies: []string{
-			"./api/v1alpha1.AdmissionControllerConfig", "./api/v1alpha1.ConfigDirSpec", "./api/v1alpha1.ExternalMetricsConfig", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
 	}
 }
 
-func schema__api_v1alpha1_ClusterChecksRunnerConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema__api_v1alpha1_CertificatesStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "ClusterChecksRunnerConfig contains the configuration of the Cluster Checks Runner",
+				Description: "CertificatesStatus defines the observed state of the certificates served by the Cluster Agent",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"resources": {
+					"provider": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Datadog Cluster Checks Runner resource requests and limits",
-							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+							Description: "Provider managing the certificates",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"logLevel": {
+					"secretName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Set logging verbosity, valid log levels are: trace, debug, info, warn, error, critical, and off",
+							Description: "SecretName is the name of the Secret containing the serving certificate",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"env": {
-						VendorExtensible: spec.VendorExtensible{
-							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-								},
-								"x-kubernetes-list-type": "map",
-							},
-						},
+					"notAfter": {
 						SchemaProps: spec.SchemaProps{
-							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.EnvVar"),
-									},
-								},
-							},
+							Description: "NotAfter is the expiration time of the serving certificate",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"volumeMounts": {
-						VendorExtensible: spec.VendorExtensible{
-							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-									"mountPath",
-								},
-								"x-kubernetes-list-type": "map",
-							},
-						},
+					"caNotAfter": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CANotAfter is the expiration time of the CA certificate",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"lastRotation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastRotation is the time at which the serving certificate was last issued",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema__api_v1alpha1_CheckConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CheckConfig defines the configuration of an integration check See https://docs.datadoghq.com/getting_started/integrations/#configuring-agent-checks for more details.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the integration, e.g. \"redisdb\". The configuration is rendered in conf.d/<name>.yaml",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"initConfig": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InitConfig is the YAML of the \"init_config\" section shared by the instances",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"instances": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Instances is the YAML list of the instances of the check",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"logs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Logs is the YAML list of the logs configurations of the integration",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"clusterCheck": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterCheck dispatches the instances as cluster checks by the Cluster Agent instead of running them on every Agent",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_ClusterAgentCertificatesConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClusterAgentCertificatesConfig contains the configuration of the certificates served by the Cluster Agent",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"provider": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Provider of the certificates: auto, operator or cert-manager. Defaults to auto: cert-manager is used if it is installed in the cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"validity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Validity of the serving certificate. Defaults to 8760h (1 year).",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"renewBefore": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RenewBefore is how long before its expiry the serving certificate is renewed. Defaults to 720h (30 days).",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema__api_v1alpha1_ClusterAgentConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClusterAgentConfig contains the configuration of the Cluster Agent",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"externalMetrics": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./api/v1alpha1.ExternalMetricsConfig"),
+						},
+					},
+					"admissionController": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configure the Admission Controller",
+							Ref:         ref("./api/v1alpha1.AdmissionControllerConfig"),
+						},
+					},
+					"certificates": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configure the certificates used by the external metrics server and the Admission Controller",
+							Ref:         ref("./api/v1alpha1.ClusterAgentCertificatesConfig"),
+						},
+					},
+					"clusterChecksEnabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enable the Cluster Checks and Endpoint Checks feature on both the cluster-agents and the daemonset ref: https://docs.datadoghq.com/agent/cluster_agent/clusterchecks/ https://docs.datadoghq.com/agent/cluster_agent/endpointschecks/ Autodiscovery via Kube Service annotations is automatically enabled",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"collectEvents": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enables this to start event collection from the kubernetes API ref: https://docs.datadoghq.com/agent/cluster_agent/event_collection/",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"logLevel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Set logging verbosity, valid log levels are: trace, debug, info, warn, error, critical, and off",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Datadog cluster-agent resource requests and limits",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the Cluster Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the Cluster Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the Cluster Agent container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the Cluster Agent container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
+					"confd": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Confd Provide additional cluster check configurations. Each key will become a file in /conf.d see https://docs.datadoghq.com/agent/autodiscovery/ for more details.",
+							Ref:         ref("./api/v1alpha1.ConfigDirSpec"),
+						},
+					},
+					"env": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"volumeMounts": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+									"mountPath",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Specify additional volume mounts in the Datadog Cluster Agent container",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.VolumeMount"),
+									},
+								},
+							},
+						},
+					},
+					"volumes": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Specify additional volumes in the Datadog Cluster Agent container",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Volume"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.AdmissionControllerConfig", "./api/v1alpha1.ClusterAgentCertificatesConfig", "./api/v1alpha1.ConfigDirSpec", "./api/v1alpha1.ExternalMetricsConfig", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+	}
+}
+
+func schema__api_v1alpha1_ClusterChecksRunnerConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClusterChecksRunnerConfig contains the configuration of the Cluster Checks Runner",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Datadog Cluster Checks Runner resource requests and limits",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the Cluster Checks Runner container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the Cluster Checks Runner container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the Cluster Checks Runner container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the Cluster Checks Runner container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
+					"logLevel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Set logging verbosity, valid log levels are: trace, debug, info, warn, error, critical, and off",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"env": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"volumeMounts": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+									"mountPath",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
 						SchemaProps: spec.SchemaProps{
 							Description: "Specify additional volume mounts in the Datadog Cluster Check Runner container",
 							Type:        []string{"array"},
@@ -454,7 +913,7 @@ func schema__api_v1alpha1_ClusterChecksRunnerConfig(ref common.ReferenceCallback
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
 	}
 }
 
@@ -539,6 +998,105 @@ func schema__api_v1alpha1_ConfigFileConfigMapSpec(ref common.ReferenceCallback)
 	}
 }
 
+func schema__api_v1alpha1_ContainerResourceRecommendation(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ContainerResourceRecommendation defines the resources recommended for an Agent container",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"container": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Container is the name of the container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"requests": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Requests are the recommended requests: the configured percentile of the usage, with a margin",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+									},
+								},
+							},
+						},
+					},
+					"limits": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limits are the recommended limits: the highest memory usage, with a margin",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+									},
+								},
+							},
+						},
+					},
+					"lastUpdateTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastUpdateTime is the last time the recommendation changed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"container"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema__api_v1alpha1_ContainerRuntimeStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ContainerRuntimeStatus defines the observed state of the nodes running a container runtime",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"runtime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Runtime is the container runtime reported by the nodes: docker, containerd, cri-o, k3s or the name of another runtime",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"nodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Nodes is the number of nodes running the runtime",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"socketPath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SocketPath is the path of the runtime socket used by the Agents of these nodes",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"daemonsetName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DaemonsetName is the name of the DaemonSet deploying the Agents of these nodes",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"runtime", "nodes"},
+			},
+		},
+	}
+}
+
 func schema__api_v1alpha1_CustomConfigSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -874,12 +1432,64 @@ func schema__api_v1alpha1_DatadogAgentSpec(ref common.ReferenceCallback) common.
 							Format:      "",
 						},
 					},
+					"proxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configure the proxy used by all the components, and by the operator, to reach Datadog",
+							Ref:         ref("./api/v1alpha1.ProxyConfig"),
+						},
+					},
+					"additionalEndpoints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Additional Datadog intakes the metrics, logs, traces and processes are dual shipped to",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.AdditionalEndpoint"),
+									},
+								},
+							},
+						},
+					},
+					"checks": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Checks configured on the Agents, or dispatched as cluster checks by the Cluster Agent. They are rendered in a ConfigMap managed by the operator, added to the Confd directories.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.CheckConfig"),
+									},
+								},
+							},
+						},
+					},
+					"platform": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Platform applies the defaults and constraints of a managed Kubernetes platform: \"gke-autopilot\", \"eks\", \"aks\", \"openshift\" or \"kind\". \"auto\" detects the platform from the APIs of the cluster and the labels of the nodes.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"credentials"},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.AgentCredentials", "./api/v1alpha1.DatadogAgentSpecAgentSpec", "./api/v1alpha1.DatadogAgentSpecClusterAgentSpec", "./api/v1alpha1.DatadogAgentSpecClusterChecksRunnerSpec"},
+			"./api/v1alpha1.AdditionalEndpoint", "./api/v1alpha1.AgentCredentials", "./api/v1alpha1.CheckConfig", "./api/v1alpha1.DatadogAgentSpecAgentSpec", "./api/v1alpha1.DatadogAgentSpecClusterAgentSpec", "./api/v1alpha1.DatadogAgentSpecClusterChecksRunnerSpec", "./api/v1alpha1.ProxyConfig"},
 	}
 }
 
@@ -965,6 +1575,34 @@ func schema__api_v1alpha1_DatadogAgentSpecAgentSpec(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
+					"terminationGracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration in seconds the Agent pods need to terminate gracefully. Defaults to 30 seconds.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"podTemplatePatches": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "PodTemplatePatches are strategic merge patches applied in order to the pod template of the Agent rendered by the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the labels set by the operator can't be changed.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.PodTemplatePatch"),
+									},
+								},
+							},
+						},
+					},
 					"dnsPolicy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Set DNS policy for the pod. Defaults to \"ClusterFirst\". Valid values are 'ClusterFirstWithHostNet', 'ClusterFirst', 'Default' or 'None'. DNS parameters given in DNSConfig will be merged with the policy selected with DNSPolicy. To have DNS options set along with hostNetwork, you have to specify DNS policy explicitly to 'ClusterFirstWithHostNet'.",
@@ -1055,12 +1693,24 @@ func schema__api_v1alpha1_DatadogAgentSpecAgentSpec(ref common.ReferenceCallback
 							Ref:         ref("./api/v1alpha1.NetworkPolicySpec"),
 						},
 					},
+					"windows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Windows configures the Agent DaemonSet deployed on the Windows nodes",
+							Ref:         ref("./api/v1alpha1.WindowsAgentSpec"),
+						},
+					},
+					"resourceRecommendations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceRecommendations configures the recommendation of the resources of the Agent containers from their usage",
+							Ref:         ref("./api/v1alpha1.ResourceRecommendationsConfig"),
+						},
+					},
 				},
 				Required: []string{"image"},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.APMSpec", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.DaemonSetDeploymentStrategy", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.LogSpec", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.NodeAgentConfig", "./api/v1alpha1.ProcessSpec", "./api/v1alpha1.RbacConfig", "./api/v1alpha1.SecuritySpec", "./api/v1alpha1.SystemProbeSpec", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.PodDNSConfig"},
+			"./api/v1alpha1.APMSpec", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.DaemonSetDeploymentStrategy", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.LogSpec", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.NodeAgentConfig", "./api/v1alpha1.PodTemplatePatch", "./api/v1alpha1.ProcessSpec", "./api/v1alpha1.RbacConfig", "./api/v1alpha1.ResourceRecommendationsConfig", "./api/v1alpha1.SecuritySpec", "./api/v1alpha1.SystemProbeSpec", "./api/v1alpha1.WindowsAgentSpec", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.PodDNSConfig"},
 	}
 }
 
@@ -1132,20 +1782,48 @@ func schema__api_v1alpha1_DatadogAgentSpecClusterAgentSpec(ref common.ReferenceC
 								Allows: true,
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Type:   []string{"string"},
-										Format: "",
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"priorityClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If specified, indicates the pod's priority. \"system-node-critical\" and \"system-cluster-critical\" are two special keywords which indicate the highest priorities with the former being the highest priority. Any other name must be defined by creating a PriorityClass object with that name. If not specified, the pod priority will be default or zero if there is no default.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"terminationGracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration in seconds the Cluster Agent pods need to terminate gracefully. Defaults to 30 seconds.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"podTemplatePatches": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "PodTemplatePatches are strategic merge patches applied in order to the pod template of the Cluster Agent rendered by the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the labels set by the operator can't be changed.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.PodTemplatePatch"),
 									},
 								},
 							},
 						},
 					},
-					"priorityClassName": {
-						SchemaProps: spec.SchemaProps{
-							Description: "If specified, indicates the pod's priority. \"system-node-critical\" and \"system-cluster-critical\" are two special keywords which indicate the highest priorities with the former being the highest priority. Any other name must be defined by creating a PriorityClass object with that name. If not specified, the pod priority will be default or zero if there is no default.",
-							Type:        []string{"string"},
-							Format:      "",
-						},
-					},
 					"affinity": {
 						SchemaProps: spec.SchemaProps{
 							Description: "If specified, the pod's scheduling constraints",
@@ -1191,12 +1869,42 @@ func schema__api_v1alpha1_DatadogAgentSpecClusterAgentSpec(ref common.ReferenceC
 							Ref:         ref("./api/v1alpha1.NetworkPolicySpec"),
 						},
 					},
+					"antiAffinityPreset": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AntiAffinityPreset adds a pod anti-affinity between the Cluster Agent replicas. Its term is added to the pod anti-affinity of Affinity.",
+							Ref:         ref("./api/v1alpha1.PodAntiAffinityPreset"),
+						},
+					},
+					"topologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "TopologySpreadConstraints describes how the Cluster Agent pods ought to spread across topology domains",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"podDisruptionBudget": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodDisruptionBudget configures the PodDisruptionBudget of the Cluster Agent pods, it defaults to minAvailable: 1",
+							Ref:         ref("./api/v1alpha1.PodDisruptionBudgetConfig"),
+						},
+					},
 				},
 				Required: []string{"image"},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.ClusterAgentConfig", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.RbacConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration"},
+			"./api/v1alpha1.ClusterAgentConfig", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.PodAntiAffinityPreset", "./api/v1alpha1.PodDisruptionBudgetConfig", "./api/v1alpha1.PodTemplatePatch", "./api/v1alpha1.RbacConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.TopologySpreadConstraint"},
 	}
 }
 
@@ -1282,6 +1990,34 @@ func schema__api_v1alpha1_DatadogAgentSpecClusterChecksRunnerSpec(ref common.Ref
 							Format:      "",
 						},
 					},
+					"terminationGracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration in seconds the Cluster Checks Runner pods need to terminate gracefully. Defaults to 30 seconds.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"podTemplatePatches": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "PodTemplatePatches are strategic merge patches applied in order to the pod template of the Cluster Checks Runner rendered by the operator, to set the fields that have no dedicated field in the spec. They are applied last, and the labels set by the operator can't be changed.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.PodTemplatePatch"),
+									},
+								},
+							},
+						},
+					},
 					"affinity": {
 						SchemaProps: spec.SchemaProps{
 							Description: "If specified, the pod's scheduling constraints",
@@ -1327,12 +2063,42 @@ func schema__api_v1alpha1_DatadogAgentSpecClusterChecksRunnerSpec(ref common.Ref
 							Ref:         ref("./api/v1alpha1.NetworkPolicySpec"),
 						},
 					},
+					"antiAffinityPreset": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AntiAffinityPreset adds a pod anti-affinity between the Cluster Checks Runner replicas. Its term is added to the pod anti-affinity of Affinity.",
+							Ref:         ref("./api/v1alpha1.PodAntiAffinityPreset"),
+						},
+					},
+					"topologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "TopologySpreadConstraints describes how the Cluster Checks Runner pods ought to spread across topology domains",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"podDisruptionBudget": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodDisruptionBudget configures the PodDisruptionBudget of the Cluster Checks Runner pods, it defaults to minAvailable: 1",
+							Ref:         ref("./api/v1alpha1.PodDisruptionBudgetConfig"),
+						},
+					},
 				},
 				Required: []string{"image"},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.ClusterChecksRunnerConfig", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.RbacConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration"},
+			"./api/v1alpha1.ClusterChecksRunnerConfig", "./api/v1alpha1.CustomConfigSpec", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.NetworkPolicySpec", "./api/v1alpha1.PodAntiAffinityPreset", "./api/v1alpha1.PodDisruptionBudgetConfig", "./api/v1alpha1.PodTemplatePatch", "./api/v1alpha1.RbacConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.TopologySpreadConstraint"},
 	}
 }
 
@@ -1361,6 +2127,12 @@ func schema__api_v1alpha1_DatadogAgentStatus(ref common.ReferenceCallback) commo
 							Ref:         ref("./api/v1alpha1.DeploymentStatus"),
 						},
 					},
+					"clusterAgentCertificates": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The actual state of the certificates served by the Cluster Agent",
+							Ref:         ref("./api/v1alpha1.CertificatesStatus"),
+						},
+					},
 					"conditions": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
@@ -1382,11 +2154,78 @@ func schema__api_v1alpha1_DatadogAgentStatus(ref common.ReferenceCallback) commo
 							},
 						},
 					},
+					"containerRuntimes": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"runtime",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "The container runtimes detected on the nodes, when the detection is enabled",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.ContainerRuntimeStatus"),
+									},
+								},
+							},
+						},
+					},
+					"windowsAgent": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The actual state of the Windows Agent as a daemonset",
+							Ref:         ref("./api/v1alpha1.DaemonSetStatus"),
+						},
+					},
+					"platform": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The platform whose profile is applied, detected when spec.platform is \"auto\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"agentHealth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The health of the Agent on the nodes, with the unhealthy nodes whose Agent restarted the most",
+							Ref:         ref("./api/v1alpha1.AgentHealthStatus"),
+						},
+					},
+					"agentCoverage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The number of nodes that can and can't run an Agent pod, according to the taints and the labels of the nodes",
+							Ref:         ref("./api/v1alpha1.AgentCoverageStatus"),
+						},
+					},
+					"resourceRecommendations": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"container",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "The resources recommended for the Agent containers from their usage",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.ContainerResourceRecommendation"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.DaemonSetStatus", "./api/v1alpha1.DatadogAgentCondition", "./api/v1alpha1.DeploymentStatus"},
+			"./api/v1alpha1.AgentCoverageStatus", "./api/v1alpha1.AgentHealthStatus", "./api/v1alpha1.CertificatesStatus", "./api/v1alpha1.ContainerResourceRecommendation", "./api/v1alpha1.ContainerRuntimeStatus", "./api/v1alpha1.DaemonSetStatus", "./api/v1alpha1.DatadogAgentCondition", "./api/v1alpha1.DeploymentStatus"},
 	}
 }
 
@@ -1595,14 +2434,22 @@ func schema__api_v1alpha1_DogstatsdConfig(ref common.ReferenceCallback) common.O
 					},
 					"useDogStatsDSocketVolume": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Enable dogstatsd over Unix Domain Socket ref: https://docs.datadoghq.com/developers/dogstatsd/unix_socket/",
+							Description: "Enable dogstatsd over Unix Domain Socket ref: https://docs.datadoghq.com/developers/dogstatsd/unix_socket/ Deprecated: use UnixDomainSocket instead",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
 					},
+					"unixDomainSocket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UnixDomainSocket enables the DogStatsD intake over Unix Domain Socket ref: https://docs.datadoghq.com/developers/dogstatsd/unix_socket/",
+							Ref:         ref("./api/v1alpha1.UnixDomainSocketConfig"),
+						},
+					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"./api/v1alpha1.UnixDomainSocketConfig"},
 	}
 }
 
@@ -1697,6 +2544,48 @@ func schema__api_v1alpha1_ImageConfig(ref common.ReferenceCallback) common.OpenA
 	}
 }
 
+func schema__api_v1alpha1_LogProcessingRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LogProcessingRule defines a processing rule applied to the logs",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the rule: exclude_at_match, include_at_match, mask_sequences or multi_line",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the rule",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"pattern": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Pattern is the regular expression matched against the logs",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replacePlaceholder": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplacePlaceholder replaces the sequences matching the pattern, required by the mask_sequences rules",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "name", "pattern"},
+			},
+		},
+	}
+}
+
 func schema__api_v1alpha1_LogSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1748,7 +2637,119 @@ func schema__api_v1alpha1_LogSpec(ref common.ReferenceCallback) common.OpenAPIDe
 					},
 					"openFilesLimit": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Set the maximum number of logs files that the Datadog Agent will tail up to. Increasing this limit can increase resource consumption of the Agent. ref: https://docs.datadoghq.com/agent/basic_agent_usage/kubernetes/#log-collection-setup Default to 100",
+							Description: "Set the maximum number of logs files that the Datadog Agent will tail up to. Increasing this limit can increase resource consumption of the Agent. ref: https://docs.datadoghq.com/agent/basic_agent_usage/kubernetes/#log-collection-setup Default to 100",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"processingRules": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "ProcessingRules are the global processing rules applied to all the logs collected by the Agent. ref: https://docs.datadoghq.com/agent/logs/advanced_log_collection/#global-processing-rules",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.LogProcessingRule"),
+									},
+								},
+							},
+						},
+					},
+					"containerInclude": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainerInclude restricts the log collection to the containers matching one of the filters, in the form `image:<regex>`, `name:<regex>` or `kube_namespace:<regex>`. ref: https://docs.datadoghq.com/agent/guide/autodiscovery-management/",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"containerExclude": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainerExclude excludes the containers matching one of the filters from the log collection, in the form `image:<regex>`, `name:<regex>` or `kube_namespace:<regex>`. ref: https://docs.datadoghq.com/agent/guide/autodiscovery-management/",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"transport": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Transport configures how the logs are sent to Datadog",
+							Ref:         ref("./api/v1alpha1.LogTransportConfig"),
+						},
+					},
+					"ddUrl": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DDUrl overrides the logs intake endpoint, as `<host>:<port>`",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.LogProcessingRule", "./api/v1alpha1.LogTransportConfig"},
+	}
+}
+
+func schema__api_v1alpha1_LogTransportConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "LogTransportConfig configures how the logs are sent to Datadog",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"protocol": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Protocol used to send the logs: HTTP or TCP. By default the Agent uses HTTP when the intake is reachable, and HTTP is always used when logs are sent to additional endpoints.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"useCompression": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UseCompression compresses the logs sent over HTTP",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"compressionLevel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompressionLevel of the logs sent over HTTP, from 0 (no compression) to 9 (best compression)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"batchWait": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BatchWait is the maximum time in seconds the Agent waits to fill a batch of logs sent over HTTP, from 1 to 10",
 							Type:        []string{"integer"},
 							Format:      "int32",
 						},
@@ -1773,9 +2774,43 @@ func schema__api_v1alpha1_NetworkPolicySpec(ref common.ReferenceCallback) common
 							Format:      "",
 						},
 					},
+					"flavor": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Flavor of the network policy to create: \"kubernetes\" (default) creates a NetworkPolicy, \"cilium\" creates a CiliumNetworkPolicy with FQDN-based egress to the Datadog intake",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"allowAutodiscovery": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Allow egress to any pod and host, required to run the checks configured through Autodiscovery",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"dnsSelectorEndpoints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Cilium selector of the DNS server entity, defaults to the kube-dns pods of the kube-system namespace",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -1945,103 +2980,396 @@ func schema__api_v1alpha1_NodeAgentConfig(ref common.ReferenceCallback) common.O
 							},
 						},
 					},
-					"resources": {
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Datadog Agent resource requests and limits Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class Ref: http://kubernetes.io/docs/user-guide/compute-resources/",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the Agent container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the Agent container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
+					"criSocket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configure the CRI Socket",
+							Ref:         ref("./api/v1alpha1.CRISocketConfig"),
+						},
+					},
+					"dogstatsd": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configure Dogstatsd",
+							Ref:         ref("./api/v1alpha1.DogstatsdConfig"),
+						},
+					},
+					"tolerations": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "If specified, the Agent pod's tolerations.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Toleration"),
+									},
+								},
+							},
+						},
+					},
+					"autoTolerations": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "atomic",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoTolerations makes the Agent pods tolerate the taints of the nodes matching one of the patterns, so that tainting a node doesn't prevent the Agent from running on it",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.TaintPattern"),
+									},
+								},
+							},
+						},
+					},
+					"hostPort": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of port to expose on the host. If specified, this must be a valid port number, 0 < x < 65536. If HostNetwork is specified, this must match ContainerPort. Most containers do not need this.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.CRISocketConfig", "./api/v1alpha1.ConfigDirSpec", "./api/v1alpha1.DogstatsdConfig", "./api/v1alpha1.TaintPattern", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+	}
+}
+
+func schema__api_v1alpha1_NodeAgentHealth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NodeAgentHealth defines the health of the Agent on a node",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"node": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Node is the name of the node",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"pod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Pod is the name of the Agent pod of the node, empty if there is none",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is the health of the Agent: Healthy, or why it isn't healthy",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message details the reason, e.g. the last termination reason of a crashing container",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"restarts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Restarts is the number of restarts of the containers of the Agent pod",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"node", "reason"},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_PodAntiAffinityPreset(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PodAntiAffinityPreset contains a pod anti-affinity preset",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the anti-affinity: \"soft\" prefers scheduling the replicas in distinct topology domains, \"hard\" requires it and leaves the extra replicas pending",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"topology": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Topology domain of the anti-affinity: \"host\" or \"zone\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_PodDisruptionBudgetConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PodDisruptionBudgetConfig contains the configuration of a PodDisruptionBudget",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enable the PodDisruptionBudget creation, enabled by default",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"minAvailable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Minimum number or percentage of available pods, exclusive with MaxUnavailable",
+							Ref:         ref("k8s.io/apimachinery/pkg/util/intstr.IntOrString"),
+						},
+					},
+					"maxUnavailable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Maximum number or percentage of unavailable pods, exclusive with MinAvailable",
+							Ref:         ref("k8s.io/apimachinery/pkg/util/intstr.IntOrString"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/util/intstr.IntOrString"},
+	}
+}
+
+func schema__api_v1alpha1_PodTemplatePatch(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PodTemplatePatch defines a patch of the pod template rendered by the operator for a component",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the patch, reported in the errors",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"patch": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Patch is the YAML or JSON strategic merge patch of the pod template, e.g. \"spec: {runtimeClassName: gvisor}\". The containers, the volumes and the other named lists are merged by name, and the \"$patch: delete\" directive removes an element.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "patch"},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_ProcessSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ProcessSpec contains the Process Agent configuration",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enable this to activate live process monitoring. Note: /etc/passwd is automatically mounted to allow username resolution. ref: https://docs.datadoghq.com/graphing/infrastructure/process/#kubernetes-daemonset",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"env": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Datadog Process Agent resource requests and limits Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class Ref: http://kubernetes.io/docs/user-guide/compute-resources/",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the Process Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Datadog Agent resource requests and limits Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class Ref: http://kubernetes.io/docs/user-guide/compute-resources/",
-							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+							Description: "Override of the readiness probe of the Process Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
 						},
 					},
-					"criSocket": {
+					"startupProbe": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Configure the CRI Socket",
-							Ref:         ref("./api/v1alpha1.CRISocketConfig"),
+							Description: "Startup probe of the Process Agent container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
 						},
 					},
-					"dogstatsd": {
+					"lifecycle": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Configure Dogstatsd",
-							Ref:         ref("./api/v1alpha1.DogstatsdConfig"),
+							Description: "Lifecycle hooks of the Process Agent container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
 						},
 					},
-					"tolerations": {
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements"},
+	}
+}
+
+func schema__api_v1alpha1_ProxyConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ProxyConfig contains the configuration of the proxy used to reach Datadog",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"http": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of the proxy used for the HTTP requests, e.g. \"http://proxy.example.com:3128\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"https": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of the proxy used for the HTTPS requests, e.g. \"http://proxy.example.com:3128\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"noProxy": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
-								"x-kubernetes-list-type": "atomic",
+								"x-kubernetes-list-type": "set",
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "If specified, the Agent pod's tolerations.",
+							Description: "Hosts, domains (\".example.com\") and CIDRs reached without proxy",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.Toleration"),
+										Type:   []string{"string"},
+										Format: "",
 									},
 								},
 							},
 						},
 					},
-					"hostPort": {
+					"credentialsSecret": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Number of port to expose on the host. If specified, this must be a valid port number, 0 < x < 65536. If HostNetwork is specified, this must match ContainerPort. Most containers do not need this.",
-							Type:        []string{"integer"},
-							Format:      "int32",
+							Description: "Secret containing the username and the password of the proxy, added to the proxy URLs. The values must be URL-encoded.",
+							Ref:         ref("./api/v1alpha1.ProxyCredentialsSecret"),
 						},
 					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.CRISocketConfig", "./api/v1alpha1.ConfigDirSpec", "./api/v1alpha1.DogstatsdConfig", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+			"./api/v1alpha1.ProxyCredentialsSecret"},
 	}
 }
 
-func schema__api_v1alpha1_ProcessSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema__api_v1alpha1_ProxyCredentialsSecret(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "ProcessSpec contains the Process Agent configuration",
+				Description: "ProxyCredentialsSecret references the Secret containing the proxy credentials",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"enabled": {
+					"secretName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Enable this to activate live process monitoring. Note: /etc/passwd is automatically mounted to allow username resolution. ref: https://docs.datadoghq.com/graphing/infrastructure/process/#kubernetes-daemonset",
-							Type:        []string{"boolean"},
+							Description: "SecretName is the name of the secret",
+							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"env": {
-						VendorExtensible: spec.VendorExtensible{
-							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-								},
-								"x-kubernetes-list-type": "map",
-							},
-						},
+					"usernameKey": {
 						SchemaProps: spec.SchemaProps{
-							Description: "The Datadog Agent supports many environment variables Ref: https://docs.datadoghq.com/agent/docker/?tab=standard#environment-variables",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.EnvVar"),
-									},
-								},
-							},
+							Description: "UsernameKey is the key of the username, defaults to \"username\"",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"resources": {
+					"passwordKey": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Datadog Process Agent resource requests and limits Make sure to keep requests and limits equal to keep the pods in the Guaranteed QoS class Ref: http://kubernetes.io/docs/user-guide/compute-resources/",
-							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+							Description: "PasswordKey is the key of the password, defaults to \"password\"",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
 				},
+				Required: []string{"secretName"},
 			},
 		},
-		Dependencies: []string{
-			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements"},
 	}
 }
 
@@ -2072,6 +3400,76 @@ func schema__api_v1alpha1_RbacConfig(ref common.ReferenceCallback) common.OpenAP
 	}
 }
 
+func schema__api_v1alpha1_ResourceRecommendationsConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ResourceRecommendationsConfig configures the recommendation of the resources of the Agent containers from the usage reported by the PodMetrics of the metrics.k8s.io API",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enabled enables the recommendations. Default: false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Mode defines what is done with the recommendations: \"Recommend\" only reports them in the status, \"Auto\" applies them to the Agent containers, \"VPA\" creates a VerticalPodAutoscaler for each Agent DaemonSet. Default: Recommend",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Window is the duration of the usage history the recommendations are computed from. Default: 24h",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"percentile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Percentile of the usage recommended as requests, between 50 and 100. Default: 95",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"minAllowed": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinAllowed is the lower bound of the recommended requests and limits",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+									},
+								},
+							},
+						},
+					},
+					"maxAllowed": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxAllowed is the upper bound of the recommended requests and limits",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
 func schema__api_v1alpha1_RuntimeSecuritySpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2180,11 +3578,35 @@ func schema__api_v1alpha1_SecuritySpec(ref common.ReferenceCallback) common.Open
 							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
 						},
 					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the Security Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the Security Agent container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the Security Agent container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the Security Agent container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./api/v1alpha1.ComplianceSpec", "./api/v1alpha1.RuntimeSecuritySpec", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements"},
+			"./api/v1alpha1.ComplianceSpec", "./api/v1alpha1.RuntimeSecuritySpec", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements"},
 	}
 }
 
@@ -2319,6 +3741,30 @@ func schema__api_v1alpha1_SystemProbeSpec(ref common.ReferenceCallback) common.O
 							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
 						},
 					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the liveness probe of the System Probe container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Override of the readiness probe of the System Probe container: the fields that are set replace the ones of the default probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"startupProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Startup probe of the System Probe container, which delays the liveness and readiness probes until it succeeds. The fields that aren't set are taken from the liveness probe",
+							Ref:         ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"lifecycle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lifecycle hooks of the System Probe container",
+							Ref:         ref("k8s.io/api/core/v1.Lifecycle"),
+						},
+					},
 					"securityContext": {
 						SchemaProps: spec.SchemaProps{
 							Description: "You can modify the security context used to run the containers by modifying the label type",
@@ -2329,6 +3775,130 @@ func schema__api_v1alpha1_SystemProbeSpec(ref common.ReferenceCallback) common.O
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.SecurityContext"},
+			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.SecurityContext"},
+	}
+}
+
+func schema__api_v1alpha1_TaintPattern(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TaintPattern matches the taints of the nodes",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key is a shell pattern matching the key of the taint, e.g. \"dedicated.example.com/*\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"value": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value is a shell pattern matching the value of the taint, any value matches if it isn't set",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"effect": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Effect of the taint, any effect matches if it isn't set",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"key"},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_UnixDomainSocketConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UnixDomainSocketConfig contains the configuration of an Agent intake over Unix Domain Socket",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enable the intake over Unix Domain Socket, the hostPort isn't exposed anymore",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"hostFilepath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path of the socket on the host. Its directory is mounted in the Agent pods, it must be mounted at the same path in the application pods.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema__api_v1alpha1_WindowsAgentSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WindowsAgentSpec defines the Agent DaemonSet deployed on the Windows nodes",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"enabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Enabled deploys a second Agent DaemonSet on the nodes labelled `kubernetes.io/os: windows`, the Agent DaemonSet being restricted to the Linux nodes. The System Probe and the Security Agent only run on Linux and aren't deployed on the Windows nodes.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The container image of the Windows Agent, by default the image of the Agent (the Agent images are published for Linux and Windows)",
+							Ref:         ref("./api/v1alpha1.ImageConfig"),
+						},
+					},
+					"daemonsetName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the Windows Agent DaemonSet, by default `<name>-agent-windows`",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"criSocket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Named pipe of the container runtime of the Windows nodes, by default the Docker named pipe `\\\\.\\pipe\\docker_engine`. Use `criSocketPath: \\\\.\\pipe\\containerd-containerd` for containerd. The runtime detection isn't supported on Windows.",
+							Ref:         ref("./api/v1alpha1.CRISocketConfig"),
+						},
+					},
+					"podTemplatePatches": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "PodTemplatePatches are strategic merge patches applied in order to the pod template of the Windows Agent. The patches of the Agent (`spec.agent.podTemplatePatches`) aren't applied to the Windows Agent, they can target containers or node labels that only exist on Linux.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./api/v1alpha1.PodTemplatePatch"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./api/v1alpha1.CRISocketConfig", "./api/v1alpha1.ImageConfig", "./api/v1alpha1.PodTemplatePatch"},
 	}
 }
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/health"
+	"github.com/DataDog/datadog-operator/pkg/plugin/common"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var (
+	healthExample = `
+  # view the health of the Agent of DatadogAgent foo on every node
+  %[1]s health foo
+
+  # view only the nodes where the Agent of DatadogAgent foo isn't healthy
+  %[1]s health foo --unhealthy
+
+  # view the health of the Agent of DatadogAgent foo on every node in yaml
+  %[1]s health foo -o yaml
+`
+)
+
+// options provides information required by agent health command
+type options struct {
+	genericclioptions.IOStreams
+	common.Options
+	args                 []string
+	userDatadogAgentName string
+	unhealthy            bool
+	output               string
+}
+
+// newOptions provides an instance of options with default values
+func newOptions(streams genericclioptions.IOStreams) *options {
+	o := &options{
+		IOStreams: streams,
+	}
+	o.SetConfigFlags()
+	return o
+}
+
+// New provides a cobra command wrapping options for "health" sub command
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	o := newOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "health [DatadogAgent name] [flags]",
+		Short:        "Show the health of the Agent on every node",
+		Example:      fmt.Sprintf(healthExample, "kubectl datadog agent"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.complete(c, args); err != nil {
+				return err
+			}
+			if err := o.validate(); err != nil {
+				return err
+			}
+			return o.run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.unhealthy, "unhealthy", "", false, "Only show the nodes where the Agent isn't healthy")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Output format. One of: json|yaml")
+
+	o.ConfigFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// complete sets all information required for processing the command
+func (o *options) complete(cmd *cobra.Command, args []string) error {
+	o.args = args
+	if len(args) > 0 {
+		o.userDatadogAgentName = args[0]
+	}
+	return o.Init(cmd)
+}
+
+// validate ensures that all required arguments and flag values are provided
+func (o *options) validate() error {
+	if o.userDatadogAgentName == "" {
+		return errors.New("DatadogAgent name argument is missing")
+	}
+	if len(o.args) > 1 {
+		return fmt.Errorf("one argument is allowed, got %d", len(o.args))
+	}
+	switch o.output {
+	case "", outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("invalid output format %s, must be one of: json|yaml", o.output)
+	}
+	return nil
+}
+
+// run runs the health command
+func (o *options) run() error {
+	podList, err := o.Clientset.CoreV1().Pods(o.UserNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s", v1alpha1.AgentDeploymentNameLabelKey, o.userDatadogAgentName, common.AgentLabel),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list Agent pods: %v", err)
+	}
+	nodeList, err := o.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %v", err)
+	}
+
+	nodesHealth := health.GetNodesHealth(nodeList.Items, podList.Items)
+	if o.unhealthy {
+		nodesHealth = filterUnhealthy(nodesHealth)
+	}
+	health.SortByRestarts(nodesHealth)
+
+	switch o.output {
+	case outputJSON, outputYAML:
+		return o.printNodesHealth(nodesHealth)
+	default:
+		table := common.NewTable(o.Out, []string{"Node", "Pod", "Reason", "Restarts", "Message"})
+		for _, nodeHealth := range nodesHealth {
+			table.Append([]string{nodeHealth.Node, nodeHealth.Pod, string(nodeHealth.Reason), strconv.Itoa(int(nodeHealth.Restarts)), nodeHealth.Message})
+		}
+		table.Render()
+	}
+	return nil
+}
+
+// printNodesHealth prints the health of the Agent on the nodes in json or yaml
+func (o *options) printNodesHealth(nodesHealth []v1alpha1.NodeAgentHealth) error {
+	var data []byte
+	var err error
+	if o.output == outputJSON {
+		data, err = json.MarshalIndent(nodesHealth, "", "    ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(nodesHealth)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = o.Out.Write(data)
+	return err
+}
+
+// filterUnhealthy returns the nodes where the Agent isn't healthy
+func filterUnhealthy(nodesHealth []v1alpha1.NodeAgentHealth) []v1alpha1.NodeAgentHealth {
+	unhealthy := []v1alpha1.NodeAgentHealth{}
+	for _, nodeHealth := range nodesHealth {
+		if nodeHealth.Reason != v1alpha1.AgentHealthReasonHealthy {
+			unhealthy = append(unhealthy, nodeHealth)
+		}
+	}
+	return unhealthy
+}
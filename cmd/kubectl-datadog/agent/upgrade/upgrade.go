@@ -9,11 +9,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/plugin/common"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,6 +24,10 @@ import (
 var (
 	image          string
 	latest         bool
+	wait           bool
+	timeout        time.Duration
+	dryRun         bool
+	force          bool
 	latestImage    = "datadog/agent:latest"
 	upgradeExample = `
   # upgrade the version of the datadog agent to latest
@@ -32,6 +38,12 @@ var (
 
   # upgrade the datadog agent with a custom image
   %[1]s upgrade --image <account>/<repo>:<tag>
+
+  # upgrade the datadog agent and wait for the rollout, the upgrade is reverted if it fails
+  %[1]s upgrade --image <account>/<repo>:<tag> --wait --timeout 15m
+
+  # show the DatadogAgents that would be upgraded
+  %[1]s upgrade --latest --dry-run
 `
 )
 
@@ -73,6 +85,10 @@ func New(streams genericclioptions.IOStreams) *cobra.Command {
 
 	cmd.Flags().StringVarP(&image, "image", "i", "", "The image of the Datadog Agent")
 	cmd.Flags().BoolVarP(&latest, "latest", "l", false, "Upgrade to datadog/agent:latest")
+	cmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for the rollout to complete and revert the upgrade if it fails")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", common.DefaultRolloutTimeout, "The time to wait for the rollout to complete")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "Only print the DatadogAgents that would be upgraded")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip the image existence and version compatibility checks")
 
 	o.ConfigFlags.AddFlags(cmd.Flags())
 
@@ -90,6 +106,9 @@ func (o *options) complete(cmd *cobra.Command, args []string) error {
 
 // validate ensures that all required arguments and flag values are provided
 func (o *options) validate() error {
+	if timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
 	return common.ValidateUpgrade(image, latest)
 }
 
@@ -114,17 +133,114 @@ func (o *options) run(cmd *cobra.Command) error {
 		ddList.Items = append(ddList.Items, *dd)
 	}
 	image = getImage()
+	if !force {
+		if err := o.checkImage(cmd, &ddList.Items[0], image); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		o.printDryRun(cmd, ddList.Items, image)
+		return nil
+	}
+
+	var failed bool
 	for _, dd := range ddList.Items {
+		previous := dd.DeepCopy()
 		err := o.upgrade(dd, image)
 		if err != nil {
 			cmd.Println(fmt.Sprintf("Couldn't update %s/%s: %v", dd.GetNamespace(), dd.GetName(), err))
-		} else {
-			cmd.Println(fmt.Sprintf("Agent image updated successfully in %s/%s", dd.GetNamespace(), dd.GetName()))
+			continue
+		}
+		cmd.Println(fmt.Sprintf("Agent image updated successfully in %s/%s", dd.GetNamespace(), dd.GetName()))
+
+		if wait {
+			if err := o.waitForRollout(cmd, previous); err != nil {
+				failed = true
+			}
 		}
 	}
+
+	if failed {
+		return errors.New("the rollout failed for at least one DatadogAgent")
+	}
+
 	return nil
 }
 
+// checkImage verifies that the image exists with the pull secrets of the DatadogAgent.
+// Only a missing image fails the upgrade, a registry that can't be checked is reported as a warning.
+func (o *options) checkImage(cmd *cobra.Command, dd *v1alpha1.DatadogAgent, image string) error {
+	var pullSecrets []corev1.LocalObjectReference
+	if dd.Spec.Agent != nil && dd.Spec.Agent.Image.PullSecrets != nil {
+		pullSecrets = *dd.Spec.Agent.Image.PullSecrets
+	}
+
+	err := common.CheckImageExists(o.Client, image, dd.GetNamespace(), pullSecrets)
+	if common.IsImageNotFound(err) {
+		return fmt.Errorf("%v, use --force to skip this check", err)
+	}
+	if err != nil {
+		cmd.Println(fmt.Sprintf("Warning: couldn't check that image %s exists: %v", image, err))
+	}
+	return nil
+}
+
+// printDryRun prints the changes that would be made by the upgrade
+func (o *options) printDryRun(cmd *cobra.Command, ddList []v1alpha1.DatadogAgent, image string) {
+	for _, dd := range ddList {
+		if dd.Spec.Agent == nil || dd.Spec.Agent.Image.Name == image {
+			cmd.Println(fmt.Sprintf("%s/%s: unchanged", dd.GetNamespace(), dd.GetName()))
+			continue
+		}
+		cmd.Println(common.UpgradeSummary("agent", &dd, dd.Spec.Agent.Image.Name, image))
+		if dd.Spec.ClusterChecksRunner != nil {
+			cmd.Println(common.UpgradeSummary("cluster checks runner", &dd, dd.Spec.ClusterChecksRunner.Image.Name, image))
+		}
+	}
+}
+
+// waitForRollout waits for the upgraded components to be rolled out and reverts the upgrade on failure
+func (o *options) waitForRollout(cmd *cobra.Command, previous *v1alpha1.DatadogAgent) error {
+	key := client.ObjectKey{Namespace: previous.GetNamespace(), Name: previous.GetName()}
+
+	err := common.WaitForRollout(o.Client, previous, "Agent", common.AgentRolloutStatus, common.RolloutPollInterval, timeout, o.Out)
+	if err == nil && previous.Spec.ClusterChecksRunner != nil {
+		err = common.WaitForRollout(o.Client, previous, "Cluster Checks Runner", common.ClusterChecksRunnerRolloutStatus, common.RolloutPollInterval, timeout, o.Out)
+	}
+	if err == nil {
+		cmd.Println(fmt.Sprintf("Agent rollout completed in %s/%s", key.Namespace, key.Name))
+		return nil
+	}
+
+	cmd.Println(fmt.Sprintf("Upgrade of %s/%s failed: %v", key.Namespace, key.Name, err))
+	if rollbackErr := o.rollback(previous); rollbackErr != nil {
+		cmd.Println(fmt.Sprintf("Couldn't revert %s/%s: %v", key.Namespace, key.Name, rollbackErr))
+		return rollbackErr
+	}
+	cmd.Println(fmt.Sprintf("Agent image reverted to %s in %s/%s", previous.Spec.Agent.Image.Name, key.Namespace, key.Name))
+
+	return err
+}
+
+// rollback restores the agent images of the DatadogAgent before the upgrade
+func (o *options) rollback(previous *v1alpha1.DatadogAgent) error {
+	dd := &v1alpha1.DatadogAgent{}
+	if err := o.Client.Get(context.TODO(), client.ObjectKey{Namespace: previous.GetNamespace(), Name: previous.GetName()}, dd); err != nil {
+		return fmt.Errorf("unable to get DatadogAgent: %v", err)
+	}
+	if dd.Spec.Agent == nil {
+		return errors.New("agent is not enabled")
+	}
+
+	dd.Spec.Agent.Image.Name = previous.Spec.Agent.Image.Name
+	if dd.Spec.ClusterChecksRunner != nil && previous.Spec.ClusterChecksRunner != nil {
+		dd.Spec.ClusterChecksRunner.Image.Name = previous.Spec.ClusterChecksRunner.Image.Name
+	}
+
+	return o.Client.Update(context.TODO(), dd)
+}
+
 // upgrade updates the agent version in the DatadogAgent object
 func (o *options) upgrade(dd v1alpha1.DatadogAgent, image string) error {
 	if dd.Spec.Agent == nil {
@@ -141,6 +257,12 @@ func (o *options) upgrade(dd v1alpha1.DatadogAgent, image string) error {
 		dd.Spec.ClusterChecksRunner.Image.Name = image
 	}
 
+	if !force {
+		if err := common.CheckDatadogAgentCompatibility(&dd); err != nil {
+			return err
+		}
+	}
+
 	return o.Client.Update(context.TODO(), &dd)
 }
 
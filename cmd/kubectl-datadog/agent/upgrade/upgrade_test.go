@@ -134,6 +134,34 @@ func Test_options_upgrade(t *testing.T) {
 	}
 }
 
+func Test_options_rollback(t *testing.T) {
+	if err := datadoghqv1alpha1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("Unable to add DatadogAgent scheme: %v", err)
+	}
+
+	previous := buildDatadogAgent("datadog/agent:7.17.1")
+	previous.Spec.ClusterChecksRunner = &datadoghqv1alpha1.DatadogAgentSpecClusterChecksRunnerSpec{}
+	previous.Spec.ClusterChecksRunner.Image.Name = "datadog/agent:7.17.1"
+
+	current := previous.DeepCopy()
+	current.Spec.Agent.Image.Name = "datadog/agent:7.99.0"
+	current.Spec.ClusterChecksRunner.Image.Name = "datadog/agent:7.99.0"
+
+	o := &options{}
+	o.Client = fake.NewFakeClient(current)
+	if err := o.rollback(previous); err != nil {
+		t.Fatalf("options.rollback() error = %v", err)
+	}
+
+	dd := &datadoghqv1alpha1.DatadogAgent{}
+	if err := o.Client.Get(context.TODO(), types.NamespacedName{Name: "dd", Namespace: "datadog-agent"}, dd); err != nil {
+		t.Fatalf("unable to get DatadogAgent: %v", err)
+	}
+	if dd.Spec.Agent.Image.Name != "datadog/agent:7.17.1" || dd.Spec.ClusterChecksRunner.Image.Name != "datadog/agent:7.17.1" {
+		t.Errorf("images not reverted: agent %s, cluster checks runner %s", dd.Spec.Agent.Image.Name, dd.Spec.ClusterChecksRunner.Image.Name)
+	}
+}
+
 func buildDatadogAgent(image string) *datadoghqv1alpha1.DatadogAgent {
 	return &datadoghqv1alpha1.DatadogAgent{
 		TypeMeta: metav1.TypeMeta{
@@ -8,6 +8,7 @@ package datadog
 import (
 	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/agent/agent"
 	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/clusteragent/clusteragent"
+	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/describe"
 	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/flare"
 	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/get"
 	"github.com/DataDog/datadog-operator/cmd/kubectl-datadog/validate/validate"
@@ -38,6 +39,7 @@ func NewCmd(streams genericclioptions.IOStreams) *cobra.Command {
 
 	// Operator commands
 	cmd.AddCommand(get.New(streams))
+	cmd.AddCommand(describe.New(streams))
 	cmd.AddCommand(flare.New(streams))
 	cmd.AddCommand(validate.New(streams))
 
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package describe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/plugin/common"
+
+	edsv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	"github.com/hako/durafmt"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes/scheme"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxEvents limits the number of events displayed
+	maxEvents = 20
+)
+
+var (
+	describeExample = `
+  # describe the DatadogAgent foo, its managed objects and its recent events
+  %[1]s describe foo
+`
+)
+
+// options provides information required by Datadog describe command
+type options struct {
+	genericclioptions.IOStreams
+	common.Options
+	args                 []string
+	userDatadogAgentName string
+}
+
+// newOptions provides an instance of options with default values
+func newOptions(streams genericclioptions.IOStreams) *options {
+	o := &options{
+		IOStreams: streams,
+	}
+	o.SetConfigFlags()
+	return o
+}
+
+// New provides a cobra command wrapping options for "describe" sub command
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	o := newOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "describe [DatadogAgent name]",
+		Short:        "Describe a DatadogAgent deployment and the health of its managed objects",
+		Example:      fmt.Sprintf(describeExample, "kubectl datadog"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.complete(c, args); err != nil {
+				return err
+			}
+			if err := o.validate(); err != nil {
+				return err
+			}
+			return o.run()
+		},
+	}
+
+	o.ConfigFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// complete sets all information required for processing the command
+func (o *options) complete(cmd *cobra.Command, args []string) error {
+	o.args = args
+	if len(args) > 0 {
+		o.userDatadogAgentName = args[0]
+	}
+
+	// Register the types of the managed objects that are not part of the default scheme
+	if err := edsv1alpha1.AddToScheme(scheme.Scheme); err != nil {
+		return fmt.Errorf("unable register ExtendedDaemonSet apis: %v", err)
+	}
+	if err := apiregistrationv1.AddToScheme(scheme.Scheme); err != nil {
+		return fmt.Errorf("unable register APIService apis: %v", err)
+	}
+
+	return o.Init(cmd)
+}
+
+// validate ensures that all required arguments and flag values are provided
+func (o *options) validate() error {
+	if o.userDatadogAgentName == "" {
+		return errors.New("DatadogAgent name argument is missing")
+	}
+	if len(o.args) > 1 {
+		return errors.New("only one argument is allowed")
+	}
+	return nil
+}
+
+// run runs the describe command
+func (o *options) run() error {
+	dd := &v1alpha1.DatadogAgent{}
+	err := o.Client.Get(context.TODO(), client.ObjectKey{Namespace: o.UserNamespace, Name: o.userDatadogAgentName}, dd)
+	if err != nil && apierrors.IsNotFound(err) {
+		return fmt.Errorf("DatadogAgent %s/%s not found", o.UserNamespace, o.userDatadogAgentName)
+	} else if err != nil {
+		return fmt.Errorf("unable to get DatadogAgent: %v", err)
+	}
+
+	objects, listErrs := listManagedObjects(o.Client, dd)
+
+	events, err := o.listEvents(dd)
+	if err != nil {
+		listErrs = append(listErrs, fmt.Errorf("unable to list events: %v", err))
+	}
+
+	printDatadogAgent(o.Out, dd)
+	printManagedObjects(o.Out, objects)
+	printEvents(o.Out, events)
+
+	for _, err := range listErrs {
+		fmt.Fprintf(o.ErrOut, "Warning: %v\n", err)
+	}
+
+	return nil
+}
+
+// listEvents returns the most recent events recorded on the DatadogAgent
+func (o *options) listEvents(dd *v1alpha1.DatadogAgent) ([]corev1.Event, error) {
+	eventList := &corev1.EventList{}
+	err := o.Client.List(context.TODO(), eventList, client.InNamespace(dd.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []corev1.Event{}
+	for _, event := range eventList.Items {
+		if event.InvolvedObject.UID == dd.UID || (event.InvolvedObject.Kind == "DatadogAgent" && event.InvolvedObject.Name == dd.Name) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastTimestamp.After(events[j].LastTimestamp.Time)
+	})
+	if len(events) > maxEvents {
+		events = events[:maxEvents]
+	}
+
+	return events, nil
+}
+
+// printDatadogAgent prints the DatadogAgent summary and conditions
+func printDatadogAgent(out io.Writer, dd *v1alpha1.DatadogAgent) {
+	fmt.Fprintf(out, "Name:       %s\n", dd.Name)
+	fmt.Fprintf(out, "Namespace:  %s\n", dd.Namespace)
+	fmt.Fprintf(out, "Age:        %s\n", common.GetDurationAsString(&dd.ObjectMeta))
+
+	if dd.Status.Agent != nil {
+		fmt.Fprintf(out, "Agent:                  %s (%d/%d ready)\n", dd.Status.Agent.Status, dd.Status.Agent.Ready, dd.Status.Agent.Desired)
+	}
+	if dd.Status.ClusterAgent != nil {
+		fmt.Fprintf(out, "Cluster Agent:          %s (%d/%d ready)\n", dd.Status.ClusterAgent.Status, dd.Status.ClusterAgent.ReadyReplicas, dd.Status.ClusterAgent.Replicas)
+	}
+	if dd.Status.ClusterChecksRunner != nil {
+		fmt.Fprintf(out, "Cluster Checks Runner:  %s (%d/%d ready)\n", dd.Status.ClusterChecksRunner.Status, dd.Status.ClusterChecksRunner.ReadyReplicas, dd.Status.ClusterChecksRunner.Replicas)
+	}
+
+	fmt.Fprintln(out, "\nConditions:")
+	table := common.NewTable(out, []string{"Type", "Status", "Reason", "Message", "Last-Transition"})
+	for _, condition := range dd.Status.Conditions {
+		table.Append([]string{string(condition.Type), string(condition.Status), condition.Reason, condition.Message, getAge(condition.LastTransitionTime.Time)})
+	}
+	table.Render()
+}
+
+// printManagedObjects prints the objects managed by the DatadogAgent and their health
+func printManagedObjects(out io.Writer, objects []managedObject) {
+	fmt.Fprintln(out, "\nManaged objects:")
+	table := common.NewTable(out, []string{"Kind", "Namespace", "Name", "Health", "Details"})
+	for _, obj := range objects {
+		table.Append([]string{obj.kind, obj.namespace, obj.name, string(obj.health), obj.details})
+	}
+	table.Render()
+}
+
+// printEvents prints the recent events of the DatadogAgent
+func printEvents(out io.Writer, events []corev1.Event) {
+	fmt.Fprintln(out, "\nEvents:")
+	table := common.NewTable(out, []string{"Type", "Reason", "Age", "Count", "Message"})
+	for _, event := range events {
+		table.Append([]string{event.Type, event.Reason, getAge(event.LastTimestamp.Time), common.IntToString(event.Count), event.Message})
+	}
+	table.Render()
+}
+
+func getAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return durafmt.ParseShort(time.Since(t)).String()
+}
@@ -0,0 +1,326 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package describe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/kubernetes"
+
+	edsv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// health represents the health of a managed object
+type health string
+
+const (
+	healthHealthy  health = "Healthy"
+	healthDegraded health = "Degraded"
+)
+
+// managedObject describes an object managed by a DatadogAgent
+type managedObject struct {
+	kind      string
+	namespace string
+	name      string
+	health    health
+	details   string
+}
+
+// objectLister lists the objects of a kind and computes their health
+type objectLister struct {
+	kind       string
+	namespaced bool
+	list       func(c client.Client, opts ...client.ListOption) ([]managedObject, error)
+}
+
+var objectListers = []objectLister{
+	{kind: "ExtendedDaemonSet", namespaced: true, list: listExtendedDaemonSets},
+	{kind: "DaemonSet", namespaced: true, list: listDaemonSets},
+	{kind: "Deployment", namespaced: true, list: listDeployments},
+	{kind: "Secret", namespaced: true, list: listSecrets},
+	{kind: "ConfigMap", namespaced: true, list: listConfigMaps},
+	{kind: "ServiceAccount", namespaced: true, list: listServiceAccounts},
+	{kind: "Role", namespaced: true, list: listRoles},
+	{kind: "RoleBinding", namespaced: true, list: listRoleBindings},
+	{kind: "ClusterRole", namespaced: false, list: listClusterRoles},
+	{kind: "ClusterRoleBinding", namespaced: false, list: listClusterRoleBindings},
+	{kind: "Service", namespaced: true, list: listServices},
+	{kind: "APIService", namespaced: false, list: listAPIServices},
+	{kind: "PodDisruptionBudget", namespaced: true, list: listPDBs},
+	{kind: "NetworkPolicy", namespaced: true, list: listNetworkPolicies},
+}
+
+// listManagedObjects returns the objects managed by the DatadogAgent with their health
+// the kinds that can't be listed are reported in the returned errors
+func listManagedObjects(c client.Client, dd *v1alpha1.DatadogAgent) ([]managedObject, []error) {
+	selector := client.MatchingLabels{
+		kubernetes.AppKubernetesPartOfLabelKey:   dd.Name,
+		kubernetes.AppKubernetesManageByLabelKey: "datadog-operator",
+	}
+
+	objects := []managedObject{}
+	errs := []error{}
+	for _, lister := range objectListers {
+		opts := []client.ListOption{selector}
+		if lister.namespaced {
+			opts = append(opts, client.InNamespace(dd.Namespace))
+		}
+		objs, err := lister.list(c, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to list %s: %v", lister.kind, err))
+			continue
+		}
+		objects = append(objects, objs...)
+	}
+
+	return objects, errs
+}
+
+func newManagedObject(kind, namespace, name string, h health, details string) managedObject {
+	return managedObject{kind: kind, namespace: namespace, name: name, health: h, details: details}
+}
+
+// readinessHealth returns the health of a workload from its ready and desired pod counts
+func readinessHealth(ready, desired int32) (health, string) {
+	details := fmt.Sprintf("%d/%d ready", ready, desired)
+	if ready < desired {
+		return healthDegraded, details
+	}
+	return healthHealthy, details
+}
+
+func listExtendedDaemonSets(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &edsv1alpha1.ExtendedDaemonSetList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, eds := range list.Items {
+		h, details := readinessHealth(eds.Status.Ready, eds.Status.Desired)
+		if eds.Status.Canary != nil {
+			details = fmt.Sprintf("%s, canary %s", details, eds.Status.Canary.ReplicaSet)
+		}
+		if eds.Status.Reason != "" {
+			h = healthDegraded
+			details = fmt.Sprintf("%s, %s", details, eds.Status.Reason)
+		}
+		objects = append(objects, newManagedObject("ExtendedDaemonSet", eds.Namespace, eds.Name, h, details))
+	}
+	return objects, nil
+}
+
+func listDaemonSets(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &appsv1.DaemonSetList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, ds := range list.Items {
+		h, details := readinessHealth(ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
+		objects = append(objects, newManagedObject("DaemonSet", ds.Namespace, ds.Name, h, details))
+	}
+	return objects, nil
+}
+
+func listDeployments(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &appsv1.DeploymentList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, deploy := range list.Items {
+		desired := int32(1)
+		if deploy.Spec.Replicas != nil {
+			desired = *deploy.Spec.Replicas
+		}
+		h, details := readinessHealth(deploy.Status.ReadyReplicas, desired)
+		objects = append(objects, newManagedObject("Deployment", deploy.Namespace, deploy.Name, h, details))
+	}
+	return objects, nil
+}
+
+func listSecrets(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &corev1.SecretList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, secret := range list.Items {
+		h, details := healthHealthy, fmt.Sprintf("%d keys", len(secret.Data))
+		if len(secret.Data) == 0 {
+			h = healthDegraded
+		}
+		objects = append(objects, newManagedObject("Secret", secret.Namespace, secret.Name, h, details))
+	}
+	return objects, nil
+}
+
+func listConfigMaps(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &corev1.ConfigMapList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, cm := range list.Items {
+		objects = append(objects, newManagedObject("ConfigMap", cm.Namespace, cm.Name, healthHealthy, fmt.Sprintf("%d keys", len(cm.Data))))
+	}
+	return objects, nil
+}
+
+func listServiceAccounts(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &corev1.ServiceAccountList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, sa := range list.Items {
+		objects = append(objects, newManagedObject("ServiceAccount", sa.Namespace, sa.Name, healthHealthy, ""))
+	}
+	return objects, nil
+}
+
+func listRoles(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &rbacv1.RoleList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, role := range list.Items {
+		objects = append(objects, newManagedObject("Role", role.Namespace, role.Name, healthHealthy, fmt.Sprintf("%d rules", len(role.Rules))))
+	}
+	return objects, nil
+}
+
+func listRoleBindings(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &rbacv1.RoleBindingList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, binding := range list.Items {
+		objects = append(objects, newManagedObject("RoleBinding", binding.Namespace, binding.Name, healthHealthy, fmt.Sprintf("%s/%s", binding.RoleRef.Kind, binding.RoleRef.Name)))
+	}
+	return objects, nil
+}
+
+func listClusterRoles(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &rbacv1.ClusterRoleList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, role := range list.Items {
+		objects = append(objects, newManagedObject("ClusterRole", "", role.Name, healthHealthy, fmt.Sprintf("%d rules", len(role.Rules))))
+	}
+	return objects, nil
+}
+
+func listClusterRoleBindings(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, binding := range list.Items {
+		objects = append(objects, newManagedObject("ClusterRoleBinding", "", binding.Name, healthHealthy, fmt.Sprintf("%s/%s", binding.RoleRef.Kind, binding.RoleRef.Name)))
+	}
+	return objects, nil
+}
+
+func listServices(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &corev1.ServiceList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, svc := range list.Items {
+		h, details := serviceHealth(c, &svc)
+		objects = append(objects, newManagedObject("Service", svc.Namespace, svc.Name, h, details))
+	}
+	return objects, nil
+}
+
+// serviceHealth returns the health of a service from the number of ready endpoints
+func serviceHealth(c client.Client, svc *corev1.Service) (health, string) {
+	endpoints := &corev1.Endpoints{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: svc.Namespace, Name: svc.Name}, endpoints); err != nil {
+		return healthDegraded, fmt.Sprintf("endpoints not found: %v", err)
+	}
+	ready := 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+	}
+	if ready == 0 {
+		return healthDegraded, "no ready endpoint"
+	}
+	return healthHealthy, fmt.Sprintf("%d ready endpoints", ready)
+}
+
+func listAPIServices(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &apiregistrationv1.APIServiceList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, apiService := range list.Items {
+		h, details := apiServiceHealth(&apiService)
+		objects = append(objects, newManagedObject("APIService", "", apiService.Name, h, details))
+	}
+	return objects, nil
+}
+
+// apiServiceHealth returns the health of an APIService from its Available condition
+func apiServiceHealth(apiService *apiregistrationv1.APIService) (health, string) {
+	for _, condition := range apiService.Status.Conditions {
+		if condition.Type != apiregistrationv1.Available {
+			continue
+		}
+		if condition.Status == apiregistrationv1.ConditionTrue {
+			return healthHealthy, "available"
+		}
+		return healthDegraded, fmt.Sprintf("not available: %s", condition.Message)
+	}
+	return healthDegraded, "availability unknown"
+}
+
+func listPDBs(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, pdb := range list.Items {
+		h := healthHealthy
+		if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+			h = healthDegraded
+		}
+		details := fmt.Sprintf("%d/%d healthy, %d disruptions allowed", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy, pdb.Status.DisruptionsAllowed)
+		objects = append(objects, newManagedObject("PodDisruptionBudget", pdb.Namespace, pdb.Name, h, details))
+	}
+	return objects, nil
+}
+
+func listNetworkPolicies(c client.Client, opts ...client.ListOption) ([]managedObject, error) {
+	list := &networkingv1.NetworkPolicyList{}
+	if err := c.List(context.TODO(), list, opts...); err != nil {
+		return nil, err
+	}
+	objects := []managedObject{}
+	for _, policy := range list.Items {
+		objects = append(objects, newManagedObject("NetworkPolicy", policy.Namespace, policy.Name, healthHealthy, ""))
+	}
+	return objects, nil
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package describe
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/kubernetes"
+
+	edsv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_listManagedObjects(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.Nil(t, clientgoscheme.AddToScheme(s))
+	assert.Nil(t, v1alpha1.AddToScheme(s))
+	assert.Nil(t, edsv1alpha1.AddToScheme(s))
+	assert.Nil(t, apiregistrationv1.AddToScheme(s))
+
+	dd := &v1alpha1.DatadogAgent{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo"}}
+	labels := map[string]string{
+		kubernetes.AppKubernetesPartOfLabelKey:   "foo",
+		kubernetes.AppKubernetesManageByLabelKey: "datadog-operator",
+	}
+	meta := func(namespace, name string, l map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: l}
+	}
+
+	c := fake.NewFakeClientWithScheme(s,
+		&appsv1.Deployment{
+			ObjectMeta: meta("bar", "foo-cluster-agent", labels),
+			Spec:       appsv1.DeploymentSpec{Replicas: v1alpha1.NewInt32Pointer(2)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("bar", "foo", labels),
+			Data:       map[string][]byte{"token": []byte("secret")},
+		},
+		&corev1.Service{ObjectMeta: meta("bar", "foo-cluster-agent", labels)},
+		&corev1.Endpoints{
+			ObjectMeta: meta("bar", "foo-cluster-agent", nil),
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&apiregistrationv1.APIService{
+			ObjectMeta: meta("", "v1beta1.external.metrics.k8s.io", labels),
+			Status: apiregistrationv1.APIServiceStatus{Conditions: []apiregistrationv1.APIServiceCondition{
+				{Type: apiregistrationv1.Available, Status: apiregistrationv1.ConditionFalse, Message: "failing"},
+			}},
+		},
+		// Not managed by the DatadogAgent
+		&corev1.ConfigMap{ObjectMeta: meta("bar", "other", nil)},
+	)
+
+	objects, errs := listManagedObjects(c, dd)
+	assert.Empty(t, errs)
+	assert.Equal(t, []managedObject{
+		{kind: "Deployment", namespace: "bar", name: "foo-cluster-agent", health: healthDegraded, details: "1/2 ready"},
+		{kind: "Secret", namespace: "bar", name: "foo", health: healthHealthy, details: "1 keys"},
+		{kind: "Service", namespace: "bar", name: "foo-cluster-agent", health: healthHealthy, details: "1 ready endpoints"},
+		{kind: "APIService", namespace: "", name: "v1beta1.external.metrics.k8s.io", health: healthDegraded, details: "not available: failing"},
+	}, objects)
+}
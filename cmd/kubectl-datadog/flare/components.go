@@ -0,0 +1,398 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-operator/pkg/plugin/common"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	agentDirName        = "agents"
+	clusterAgentDirName = "cluster-agent"
+	clcRunnerDirName    = "cluster-checks-runners"
+	manifestFileName    = "manifest.yaml"
+)
+
+// flareArchiveRegex matches the path of the archive created by the agent flare command
+var flareArchiveRegex = regexp.MustCompile(`(/\S+\.zip)`)
+
+// component describes how to collect the data of a Datadog component
+type component struct {
+	// dir is the directory of the component in the flare
+	dir string
+	// container is the name of the container running the commands
+	container string
+	// binary is the component executable used to run the flare/status commands
+	binary string
+	// commands lists the sub commands whose output is collected
+	commands []string
+}
+
+var (
+	agentComponent = component{
+		dir:       agentDirName,
+		container: "agent",
+		binary:    "agent",
+		commands:  []string{"status", "configcheck", "health"},
+	}
+	clusterAgentComponent = component{
+		dir:       clusterAgentDirName,
+		container: "cluster-agent",
+		binary:    "datadog-cluster-agent",
+		commands:  []string{"status", "configcheck", "clusterchecks", "metamap"},
+	}
+	clcRunnerComponent = component{
+		dir:       clcRunnerDirName,
+		container: "cluster-checks-runner",
+		binary:    "agent",
+		commands:  []string{"status", "configcheck"},
+	}
+)
+
+// manifestEntry describes a file contained in the flare archive
+type manifestEntry struct {
+	Path      string `yaml:"path"`
+	Component string `yaml:"component"`
+	Size      int64  `yaml:"size"`
+}
+
+// collectComponents collects the data of the Datadog components selected by the user
+func (o *options) collectComponents(dir string, cmd *cobra.Command) {
+	if len(agentNodes) > 0 {
+		pods, err := o.getAgentPods(agentNodes, cmd)
+		if err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't list agent pods: %v", err))
+		}
+		o.collectComponent(agentComponent, pods, dir, cmd)
+	}
+
+	if collectClusterAgent {
+		pods, err := o.getComponentPods(common.ClusterAgentLabel)
+		if err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't list cluster agent pods: %v", err))
+		}
+		o.collectComponent(clusterAgentComponent, pods, dir, cmd)
+	}
+
+	if collectClcRunners {
+		pods, err := o.getComponentPods(common.ClcRunnerLabel)
+		if err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't list cluster checks runner pods: %v", err))
+		}
+		o.collectComponent(clcRunnerComponent, pods, dir, cmd)
+	}
+}
+
+// getAgentPods returns the agent pods running on the given nodes
+// The nodes without agent pod are skipped.
+func (o *options) getAgentPods(nodes []string, cmd *cobra.Command) ([]corev1.Pod, error) {
+	pods := []corev1.Pod{}
+	for _, node := range nodes {
+		podList, err := o.Clientset.CoreV1().Pods(o.UserNamespace).List(context.TODO(), metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("spec.nodeName=%s", node),
+			LabelSelector: common.AgentLabel,
+		})
+		if err != nil {
+			return pods, err
+		}
+		if len(podList.Items) == 0 {
+			cmd.Println(fmt.Sprintf("Skipping node %s: no agent pod found. Label selector used: %s", node, common.AgentLabel))
+			continue
+		}
+		pods = append(pods, podList.Items...)
+	}
+	return pods, nil
+}
+
+// getComponentPods returns the pods matching a component label selector
+func (o *options) getComponentPods(selector string) ([]corev1.Pod, error) {
+	podList, err := o.Clientset.CoreV1().Pods(o.UserNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(podList.Items) == 0 {
+		return nil, fmt.Errorf("no pod found. Label selector used: %s", selector)
+	}
+	return podList.Items, nil
+}
+
+// collectComponent collects commands output, logs, events and node description of the component pods
+func (o *options) collectComponent(comp component, pods []corev1.Pod, dir string, cmd *cobra.Command) {
+	nodes := map[string]bool{}
+	for i := range pods {
+		pod := &pods[i]
+		podDir := filepath.Join(dir, comp.dir, pod.Name)
+		if err := os.MkdirAll(podDir, os.ModePerm); err != nil {
+			cmd.Println(fmt.Sprintf("Skipping pod %s: %v", pod.Name, err))
+			continue
+		}
+
+		for _, subCmd := range comp.commands {
+			if err := o.createCommandFile(comp, subCmd, pod, podDir, cmd); err != nil {
+				cmd.Println(fmt.Sprintf("Couldn't collect %s %s of pod %s: %v", comp.binary, subCmd, pod.Name, err))
+			}
+		}
+
+		if err := o.createComponentFlareFile(comp, pod, podDir, cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't collect %s flare of pod %s: %v", comp.binary, pod.Name, err))
+		}
+
+		if err := o.createContainerLogFiles(pod, podDir, cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't collect logs of pod %s: %v", pod.Name, err))
+		}
+
+		if err := o.createEventsFile(pod, podDir, cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't collect events of pod %s: %v", pod.Name, err))
+		}
+
+		if pod.Spec.NodeName != "" {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	for node := range nodes {
+		if err := o.createNodeFile(node, filepath.Join(dir, comp.dir), cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't collect description of node %s: %v", node, err))
+		}
+	}
+}
+
+// createCommandFile runs a component command in a pod and stores its output in a file
+func (o *options) createCommandFile(comp component, subCmd string, pod *corev1.Pod, dir string, cmd *cobra.Command) error {
+	command := []string{"bash", "-c", fmt.Sprintf("%s %s", comp.binary, subCmd)}
+	output, err := o.execInContainer(command, pod, comp.container)
+	if err != nil {
+		return err
+	}
+
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("%s.log", subCmd)), output, cmd)
+}
+
+// createComponentFlareFile runs the component flare command without sending it and retrieves the archive.
+// The archive is unpacked so that its files go through the same redaction as the rest of the flare.
+func (o *options) createComponentFlareFile(comp component, pod *corev1.Pod, dir string, cmd *cobra.Command) error {
+	command := []string{"bash", "-c", fmt.Sprintf("echo n | %s flare", comp.binary)}
+	output, err := o.execInContainer(command, pod, comp.container)
+	if err != nil {
+		return err
+	}
+
+	match := flareArchiveRegex.FindSubmatch(output)
+	if match == nil {
+		return errors.New("flare archive path not found in command output")
+	}
+	archivePath := string(match[1])
+
+	archive, err := o.execInContainer([]string{"cat", archivePath}, pod, comp.container)
+	if err != nil {
+		return err
+	}
+
+	// Cleanup the archive in the container, a failure here is not an issue for the flare
+	_, _ = o.execInContainer([]string{"rm", "-f", archivePath}, pod, comp.container)
+
+	archiveName := strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+	return o.saveComponentFlare(archive, filepath.Join(dir, archiveName), cmd)
+}
+
+// saveComponentFlare redacts and stores every file of a component flare archive in dir
+func (o *options) saveComponentFlare(archive []byte, dir string, cmd *cobra.Command) error {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		// Ignore the entries that would be written outside of dir
+		name := filepath.Clean(filepath.FromSlash(entry.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			cmd.Println(fmt.Sprintf("Skipping flare file %s: invalid path", entry.Name))
+			continue
+		}
+
+		data, err := readZipEntry(entry)
+		if err != nil {
+			cmd.Println(fmt.Sprintf("Skipping flare file %s: %v", entry.Name, err))
+			continue
+		}
+
+		filePath := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return err
+		}
+		if err = o.redactAndSave(filePath, data, cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readZipEntry returns the uncompressed content of a file of a zip archive
+func readZipEntry(entry *zip.File) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// createContainerLogFiles stores the logs of every container of a pod, including the previous instance of restarted containers
+func (o *options) createContainerLogFiles(pod *corev1.Pod, dir string, cmd *cobra.Command) error {
+	restarted := map[string]bool{}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if err := o.saveContainerLogs(pod, container.Name, false, dir, cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Skipping logs of container %s/%s: %v", pod.Name, container.Name, err))
+		}
+		if !restarted[container.Name] {
+			continue
+		}
+		if err := o.saveContainerLogs(pod, container.Name, true, dir, cmd); err != nil {
+			cmd.Println(fmt.Sprintf("Skipping previous logs of container %s/%s: %v", pod.Name, container.Name, err))
+		}
+	}
+
+	return nil
+}
+
+// saveContainerLogs retrieves container logs and save them in a file
+func (o *options) saveContainerLogs(pod *corev1.Pod, container string, previous bool, dir string, cmd *cobra.Command) error {
+	podLogOpts := corev1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+	}
+	req := o.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &podLogOpts)
+	podLogs, err := req.Stream(context.TODO())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = podLogs.Close(); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't close pod-logs stream: %v", err))
+		}
+	}()
+
+	logBytes, err := common.StreamToBytes(podLogs)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s.log", container)
+	if previous {
+		fileName = fmt.Sprintf("%s-previous.log", container)
+	}
+
+	return o.redactAndSave(filepath.Join(dir, fileName), logBytes, cmd)
+}
+
+// createEventsFile stores the events involving a pod in a file
+func (o *options) createEventsFile(pod *corev1.Pod, dir string, cmd *cobra.Command) error {
+	events, err := o.Clientset.CoreV1().Events(pod.Namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.name=%s", pod.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(events.Items)
+	if err != nil {
+		return err
+	}
+
+	return o.redactAndSave(filepath.Join(dir, "events.yaml"), data, cmd)
+}
+
+// createNodeFile stores the description of a node in a file
+func (o *options) createNodeFile(nodeName, dir string, cmd *cobra.Command) error {
+	node, err := o.Clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("node-%s.yaml", nodeName)), data, cmd)
+}
+
+// createManifestFile indexes every file of the flare directory
+func (o *options) createManifestFile(dir string, cmd *cobra.Command) error {
+	entries := []manifestEntry{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, manifestEntry{
+			Path:      filepath.ToSlash(relPath),
+			Component: getManifestComponent(relPath),
+			Size:      info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return o.redactAndSave(filepath.Join(dir, manifestFileName), data, cmd)
+}
+
+// getManifestComponent returns the component owning a file of the flare
+func getManifestComponent(relPath string) string {
+	switch strings.Split(filepath.ToSlash(relPath), "/")[0] {
+	case agentDirName:
+		return "agent"
+	case clusterAgentDirName:
+		return "cluster-agent"
+	case clcRunnerDirName:
+		return "cluster-checks-runner"
+	default:
+		return "operator"
+	}
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCreateManifestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flare-manifest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"datadog-custom-resources.yaml":         "spec: {}",
+		"agents/agent-abcde/status.log":         "status",
+		"cluster-agent/cluster-agent-xyz/a.log": "cluster-agent",
+		"cluster-checks-runners/clc-1/b.log":    "runner",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(fullPath), os.ModePerm))
+		assert.Nil(t, ioutil.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	o := &options{scrubber: newScrubber(nil)}
+	assert.Nil(t, o.createManifestFile(dir, &cobra.Command{}))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFileName))
+	assert.Nil(t, err)
+
+	entries := []manifestEntry{}
+	assert.Nil(t, yaml.Unmarshal(data, &entries))
+
+	assert.Equal(t, []manifestEntry{
+		{Path: "agents/agent-abcde/status.log", Component: "agent", Size: 6},
+		{Path: "cluster-agent/cluster-agent-xyz/a.log", Component: "cluster-agent", Size: 13},
+		{Path: "cluster-checks-runners/clc-1/b.log", Component: "cluster-checks-runner", Size: 6},
+		{Path: "datadog-custom-resources.yaml", Component: "operator", Size: 8},
+	}, entries)
+}
+
+func TestSaveComponentFlare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flare-component")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	files := map[string]string{
+		"datadog-agent/etc/datadog.yaml": "api_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaabbbb\n",
+		"datadog-agent/status.log":       "running",
+		"../outside.log":                 "outside",
+	}
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+
+	o := &options{scrubber: newScrubber(nil)}
+	assert.Nil(t, o.saveComponentFlare(buf.Bytes(), filepath.Join(dir, "flare"), &cobra.Command{}))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "flare", "datadog-agent", "etc", "datadog.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "api_key: ***************************abbbb\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "flare", "datadog-agent", "status.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "running", string(data))
+
+	_, err = os.Stat(filepath.Join(dir, "outside.log"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils"
 	"github.com/DataDog/datadog-operator/pkg/plugin/common"
 	"github.com/DataDog/datadog-operator/pkg/version"
 
@@ -41,18 +42,41 @@ import (
 const (
 	httpTimeout = 60 * time.Second
 	flareURL    = "https://%s-flare.agent.datadoghq.%s/support/flare"
+	// defaultOperatorVersion is used to build the flare URL when the operator version is unknown
+	defaultOperatorVersion = "0.1.0"
 )
 
 var (
-	email        string
-	apiKey       string
-	ddSite       string
-	flareExample = `
+	email               string
+	apiKey              string
+	ddSite              string
+	agentNodes          []string
+	collectClusterAgent bool
+	collectClcRunners   bool
+	redactionRulesPath  string
+	dryRun              bool
+	outputPath          string
+	encryptKeyPath      string
+	proxyURL            string
+	flareExample        = `
   # send flare for an existing case 123 (api key from stdin)
   %[1]s flare 123 --email foo@bar.com
 
   # send flare and create a new case (email and api key from stdin)
   %[1]s flare
+
+  # send flare including the agents running on node1 and node2, the cluster agent and the cluster checks runners
+  %[1]s flare 123 --agents node1,node2 --cluster-agent --clc-runners
+
+  # build the flare with custom redaction rules and report what gets scrubbed without sending it
+  %[1]s flare --redaction-rules rules.yaml --dry-run
+
+  # write the flare encrypted to a public key without sending it, to upload it later from a connected machine
+  %[1]s flare --output flare.zip --encrypt-to datadog.asc
+  %[1]s flare upload flare.zip.gpg 123 --email foo@bar.com
+
+  # send flare through a proxy, instead of the proxy configured in the DatadogAgent
+  %[1]s flare 123 --proxy http://proxy.example.com:3128
 `
 )
 
@@ -60,10 +84,11 @@ var (
 type options struct {
 	genericclioptions.IOStreams
 	common.Options
-	args   []string
-	zip    *archiver.Zip
-	site   string
-	caseID string
+	args     []string
+	zip      *archiver.Zip
+	site     string
+	caseID   string
+	scrubber *scrubber
 }
 
 // newOptions provides an instance of options with default values
@@ -98,6 +123,16 @@ func New(streams genericclioptions.IOStreams) *cobra.Command {
 	cmd.Flags().StringVarP(&email, "email", "e", "", "Your email")
 	cmd.Flags().StringVarP(&apiKey, "apiKey", "k", "", "Your api key, could also be taken from stdin")
 	cmd.Flags().StringVarP(&ddSite, "ddSite", "d", "us", "Your Datadog site US or EU (default: US)")
+	cmd.Flags().StringSliceVar(&agentNodes, "agents", []string{}, "Collect the data of the agents running on the given nodes")
+	cmd.Flags().BoolVar(&collectClusterAgent, "cluster-agent", false, "Collect the data of the cluster agent")
+	cmd.Flags().BoolVar(&collectClcRunners, "clc-runners", false, "Collect the data of the cluster checks runners")
+	cmd.Flags().StringVar(&redactionRulesPath, "redaction-rules", "", "Path to a file defining additional redaction rules")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Build the flare and report the redaction matches per rule and file without sending it")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the flare archive to this path (.zip) without sending it")
+	cmd.Flags().StringVar(&encryptKeyPath, "encrypt-to", "", "Encrypt the flare archive to the armored OpenPGP public key stored in this file")
+	cmd.Flags().StringVar(&proxyURL, "proxy", "", "URL of the proxy used to send the flare, defaults to the proxy of the DatadogAgent or of the environment")
+
+	cmd.AddCommand(newUploadCmd(streams))
 
 	o.ConfigFlags.AddFlags(cmd.Flags())
 
@@ -117,6 +152,26 @@ func (o *options) complete(cmd *cobra.Command, args []string) error {
 		o.caseID = args[0]
 	}
 
+	var rules []replacer
+	if redactionRulesPath != "" {
+		rules, err = loadRedactionRules(redactionRulesPath)
+		if err != nil {
+			return err
+		}
+	}
+	o.scrubber = newScrubber(rules)
+
+	if o.isOffline() {
+		// Nothing is sent, no need for credentials
+		return nil
+	}
+
+	return completeCredentials()
+}
+
+// completeCredentials asks for the credentials required to send a flare
+func completeCredentials() error {
+	var err error
 	if email == "" {
 		email, err = common.AskForInput("Please enter your email: ")
 		if err != nil {
@@ -147,6 +202,19 @@ func (o *options) validate() error {
 		return errors.New("either one or no arguments are allowed")
 	}
 
+	if outputPath != "" && filepath.Ext(outputPath) != ".zip" {
+		return fmt.Errorf("output %s must have the .zip extension", outputPath)
+	}
+
+	if o.isOffline() {
+		return nil
+	}
+
+	return o.validateCredentials()
+}
+
+// validateCredentials ensures that the credentials required to send a flare are provided
+func (o *options) validateCredentials() error {
 	if email == "" {
 		return errors.New("email is missing")
 	}
@@ -167,13 +235,27 @@ func (o *options) validate() error {
 	return nil
 }
 
+// isOffline returns true if the flare must not be sent
+func (o *options) isOffline() bool {
+	return dryRun || outputPath != ""
+}
+
 // run runs the flare command
 func (o *options) run(cmd *cobra.Command) error {
-	// Prepare base directory
+	// Prepare base directory, removing leftovers of a previous flare
 	baseDir := filepath.Join(os.TempDir(), "datadog-operator")
+	if err := os.RemoveAll(baseDir); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
 		return err
 	}
+	// The collected files are only kept in the archive, that can be encrypted
+	defer func() {
+		if err := os.RemoveAll(baseDir); err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't remove the collected files in %s: %v", baseDir, err))
+		}
+	}()
 
 	// Collect the existing datadogagent custom resource definitons
 	if err := o.createCRFiles(baseDir, cmd); err != nil {
@@ -211,11 +293,8 @@ func (o *options) run(cmd *cobra.Command) error {
 		cmd.Println(fmt.Sprintf("Couldn't collect operator version: %v", err))
 	}
 
-	// Create zip with the collected files
-	zipFilePath := getArchivePath()
-	if err = o.zip.Archive([]string{baseDir}, zipFilePath); err != nil {
-		return err
-	}
+	// Collect data of the Datadog components
+	o.collectComponents(baseDir, cmd)
 
 	// Get the operator version
 	version, err := o.getVersion(leaderPod)
@@ -223,7 +302,44 @@ func (o *options) run(cmd *cobra.Command) error {
 		cmd.Println(fmt.Sprintf("Couldn't get operator version: %v", err))
 
 		// Fallback to a default version used to build the flare URL
-		version = "0.1.0"
+		version = defaultOperatorVersion
+	}
+
+	// Describe the flare
+	if err = o.createMetadataFile(version, baseDir, cmd); err != nil {
+		cmd.Println(fmt.Sprintf("Couldn't create metadata file: %v", err))
+	}
+
+	// Index the collected files
+	if err = o.createManifestFile(baseDir, cmd); err != nil {
+		cmd.Println(fmt.Sprintf("Couldn't create manifest file: %v", err))
+	}
+
+	// Create zip with the collected files
+	zipFilePath := outputPath
+	if zipFilePath == "" {
+		zipFilePath = getArchivePath()
+	}
+	if err = o.zip.Archive([]string{baseDir}, zipFilePath); err != nil {
+		return err
+	}
+
+	// Encrypt the archive
+	if encryptKeyPath != "" {
+		if zipFilePath, err = encryptArchive(zipFilePath, encryptKeyPath); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		printRedactionReport(o.Out, baseDir, o.scrubber.getMatches())
+		cmd.Println(fmt.Sprintf("Dry run, the flare was not sent. (You can inspect %s)", zipFilePath))
+		return nil
+	}
+
+	if outputPath != "" {
+		cmd.Println(fmt.Sprintf("Flare written to %s. (You can send it later with \"kubectl datadog flare upload %s\")", zipFilePath, zipFilePath))
+		return nil
 	}
 
 	// ask for confirmation before sending the flare file and opening the support ticket
@@ -259,11 +375,11 @@ func (o *options) createCRFiles(dir string, cmd *cobra.Command) error {
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, "datadog-custom-resources.yaml"), template, cmd)
+	return o.redactAndSave(filepath.Join(dir, "datadog-custom-resources.yaml"), template, cmd)
 }
 
 // redactAndSave uses a redacting writer to write a new file
-func redactAndSave(filePath string, data []byte, cmd *cobra.Command) error {
+func (o *options) redactAndSave(filePath string, data []byte, cmd *cobra.Command) error {
 	file, err := createFile(filePath)
 	if err != nil {
 		return err
@@ -274,10 +390,13 @@ func redactAndSave(filePath string, data []byte, cmd *cobra.Command) error {
 		}
 	}()
 
-	writer := newRedactingWriter(file)
+	writer := newRedactingWriter(file, o.scrubber, filePath)
 
-	_, err = writer.Write(data)
-	return err
+	if _, err = writer.Write(data); err != nil {
+		return err
+	}
+
+	return writer.Flush()
 }
 
 // createLogFiles gets log files of the operator pods
@@ -317,7 +436,7 @@ func (o *options) createDeploymentTemplate(dir string, cmd *cobra.Command) error
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, "datadog-operator-deployment.yaml"), template, cmd)
+	return o.redactAndSave(filepath.Join(dir, "datadog-operator-deployment.yaml"), template, cmd)
 }
 
 // createMetricsFile gets metrics payload and stores it in a file
@@ -340,7 +459,7 @@ func (o *options) createMetricsFile(pod *corev1.Pod, dir string, cmd *cobra.Comm
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-metrics.txt", pod.Name)), metrics, cmd)
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-metrics.txt", pod.Name)), metrics, cmd)
 }
 
 // createStatusFile gets status of a pod and stores it in a file
@@ -355,7 +474,7 @@ func (o *options) createStatusFile(pod *corev1.Pod, dir string, cmd *cobra.Comma
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-status.txt", pod.Name)), status, cmd)
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-status.txt", pod.Name)), status, cmd)
 }
 
 // createVersionFile gets the version from the operator pod and stores it in a file
@@ -371,7 +490,7 @@ func (o *options) createVersionFile(pod *corev1.Pod, dir string, cmd *cobra.Comm
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-version.txt", pod.Name)), version, cmd)
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("%s-version.txt", pod.Name)), version, cmd)
 }
 
 // getOperatorVersion gets the version from the operator pod
@@ -427,10 +546,16 @@ func (o *options) getLeader() (*corev1.Pod, error) {
 
 // execInPod execs a given command in a given pod
 func (o *options) execInPod(command []string, pod *corev1.Pod) ([]byte, error) {
+	return o.execInContainer(command, pod, "")
+}
+
+// execInContainer execs a given command in a given container of a pod
+// the default container of the pod is used if container is empty
+func (o *options) execInContainer(command []string, pod *corev1.Pod, container string) ([]byte, error) {
 	req := o.Clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(pod.Name).
-		Namespace(o.UserNamespace).
+		Namespace(pod.Namespace).
 		SubResource("exec")
 
 	scheme := runtime.NewScheme()
@@ -441,11 +566,12 @@ func (o *options) execInPod(command []string, pod *corev1.Pod) ([]byte, error) {
 
 	parameterCodec := runtime.NewParameterCodec(scheme)
 	req.VersionedParams(&corev1.PodExecOptions{
-		Command: command,
-		Stdin:   false,
-		Stdout:  true,
-		Stderr:  false,
-		TTY:     false,
+		Command:   command,
+		Container: container,
+		Stdin:     false,
+		Stdout:    true,
+		Stderr:    false,
+		TTY:       false,
 	}, parameterCodec)
 
 	restConfig, err := o.ConfigFlags.ToRESTConfig()
@@ -486,7 +612,7 @@ func (o *options) savePodLogs(pod corev1.Pod, dir string, cmd *cobra.Command) er
 		return err
 	}
 
-	return redactAndSave(filepath.Join(dir, fmt.Sprintf("%s.json", pod.Name)), logBytes, cmd)
+	return o.redactAndSave(filepath.Join(dir, fmt.Sprintf("%s.json", pod.Name)), logBytes, cmd)
 }
 
 // getArchivePath builds the zip file path in a temporary directory
@@ -570,13 +696,53 @@ func (o *options) readAndPostFlareFile(archivePath, url, version string, cmd *co
 		return o.getFlareReader(boundaryWriter.Boundary(), archivePath, version, cmd)
 	}
 
+	proxy, err := o.getProxy()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
 	client := &http.Client{
-		Timeout: httpTimeout,
+		Timeout:   httpTimeout,
+		Transport: transport,
 	}
 
 	return client.Do(request)
 }
 
+// getProxy returns the proxy used to send the flare: the one of the --proxy flag,
+// the one configured in the DatadogAgent, or the one of the environment
+func (o *options) getProxy() (utils.ProxyFunc, error) {
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %w", proxyURL, err)
+		}
+		return http.ProxyURL(u), nil
+	}
+
+	if o.Client != nil {
+		ddList := &v1alpha1.DatadogAgentList{}
+		if err := o.Client.List(context.TODO(), ddList, &client.ListOptions{Namespace: o.UserNamespace}); err == nil {
+			for _, dda := range ddList.Items {
+				if dda.Spec.Proxy == nil {
+					continue
+				}
+				username, password, err := utils.GetProxyCredentials(o.Client, dda.Namespace, dda.Spec.Proxy)
+				if err != nil {
+					return nil, fmt.Errorf("cannot get the proxy credentials: %w", err)
+				}
+				proxy, err := utils.NewProxyFunc(dda.Spec.Proxy, username, password)
+				if err != nil || proxy != nil {
+					return proxy, err
+				}
+			}
+		}
+	}
+
+	return http.ProxyFromEnvironment, nil
+}
+
 func (o *options) getFlareReader(multipartBoundary, archivePath, version string, cmd *cobra.Command) (io.ReadCloser, error) {
 	// No need to close the reader, http.Client does it for us
 	bodyReader, bodyWriter := io.Pipe()
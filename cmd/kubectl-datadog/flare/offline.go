@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	pluginversion "github.com/DataDog/datadog-operator/pkg/version"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/openpgp"
+	// Register RIPEMD160, the default hash of OpenPGP keys without hash preferences
+	_ "golang.org/x/crypto/ripemd160"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	metadataFileName = "metadata.yaml"
+	encryptedExt     = ".gpg"
+)
+
+// flareMetadata describes the context in which a flare has been collected
+type flareMetadata struct {
+	Cluster           string `yaml:"cluster"`
+	KubernetesVersion string `yaml:"kubernetesVersion,omitempty"`
+	Namespace         string `yaml:"namespace"`
+	OperatorVersion   string `yaml:"operatorVersion"`
+	PluginVersion     string `yaml:"pluginVersion"`
+	CollectionTime    string `yaml:"collectionTime"`
+	CaseID            string `yaml:"caseID,omitempty"`
+}
+
+// createMetadataFile stores the flare metadata in a file
+func (o *options) createMetadataFile(operatorVersion, dir string, cmd *cobra.Command) error {
+	metadata := flareMetadata{
+		Cluster:         o.getClusterName(),
+		Namespace:       o.UserNamespace,
+		OperatorVersion: operatorVersion,
+		PluginVersion:   pluginversion.Version,
+		CollectionTime:  time.Now().UTC().Format(time.RFC3339),
+		CaseID:          o.caseID,
+	}
+
+	if serverVersion, err := o.Clientset.Discovery().ServerVersion(); err == nil {
+		metadata.KubernetesVersion = serverVersion.GitVersion
+	} else {
+		cmd.Println(fmt.Sprintf("Couldn't get Kubernetes version: %v", err))
+	}
+
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	return o.redactAndSave(filepath.Join(dir, metadataFileName), data, cmd)
+}
+
+// getClusterName returns the name of the cluster targeted by the kubeconfig context
+func (o *options) getClusterName() string {
+	rawConfig, err := o.GetClientConfig().RawConfig()
+	if err != nil {
+		return ""
+	}
+
+	contextName := rawConfig.CurrentContext
+	if o.ConfigFlags.Context != nil && *o.ConfigFlags.Context != "" {
+		contextName = *o.ConfigFlags.Context
+	}
+
+	if context, found := rawConfig.Contexts[contextName]; found {
+		return context.Cluster
+	}
+
+	return ""
+}
+
+// encryptArchive encrypts an archive to the armored OpenPGP public key stored in keyPath.
+// The clear archive is removed and the path of the encrypted archive is returned.
+func encryptArchive(archivePath, keyPath string) (string, error) {
+	keyFile, err := os.Open(keyPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to open public key: %v", err)
+	}
+	defer keyFile.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read public key: %v", err)
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	encryptedPath := archivePath + encryptedExt
+	encrypted, err := createFile(encryptedPath)
+	if err != nil {
+		return "", err
+	}
+
+	hints := &openpgp.FileHints{IsBinary: true, FileName: filepath.Base(archivePath)}
+	writer, err := openpgp.Encrypt(encrypted, entities, nil, hints, nil)
+	if err != nil {
+		_ = encrypted.Close()
+		return "", fmt.Errorf("unable to encrypt archive: %v", err)
+	}
+
+	if _, err = io.Copy(writer, archive); err != nil {
+		_ = encrypted.Close()
+		return "", fmt.Errorf("unable to encrypt archive: %v", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		_ = encrypted.Close()
+		return "", fmt.Errorf("unable to encrypt archive: %v", err)
+	}
+
+	if err = encrypted.Close(); err != nil {
+		return "", err
+	}
+
+	// The clear archive must not be left behind
+	if err = os.Remove(archivePath); err != nil {
+		return "", err
+	}
+
+	return encryptedPath, nil
+}
+
+// readArchiveMetadata reads the metadata file of a clear flare archive
+func readArchiveMetadata(archivePath string) (*flareMetadata, error) {
+	if filepath.Ext(archivePath) == encryptedExt {
+		return nil, errors.New("the archive is encrypted")
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if path.Base(file.Name) != metadataFileName {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		data, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := &flareMetadata{}
+		if err := yaml.Unmarshal(data, metadata); err != nil {
+			return nil, err
+		}
+
+		return metadata, nil
+	}
+
+	return nil, errors.New("metadata file not found in archive")
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEncryptArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flare-encrypt")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	entity, err := openpgp.NewEntity("support", "", "support@example.com", nil)
+	assert.Nil(t, err)
+
+	keyPath := filepath.Join(dir, "key.asc")
+	writePublicKey(t, entity, keyPath)
+
+	archivePath := filepath.Join(dir, "flare.zip")
+	assert.Nil(t, ioutil.WriteFile(archivePath, []byte("flare content"), 0644))
+
+	encryptedPath, err := encryptArchive(archivePath, keyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, archivePath+".gpg", encryptedPath)
+
+	_, err = os.Stat(archivePath)
+	assert.True(t, os.IsNotExist(err), "the clear archive should be removed")
+
+	encrypted, err := os.Open(encryptedPath)
+	assert.Nil(t, err)
+	defer encrypted.Close()
+
+	md, err := openpgp.ReadMessage(encrypted, openpgp.EntityList{entity}, nil, nil)
+	assert.Nil(t, err)
+	content, err := ioutil.ReadAll(md.UnverifiedBody)
+	assert.Nil(t, err)
+	assert.Equal(t, "flare content", string(content))
+}
+
+func TestRunEncryptedLeavesNoClearFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flare-run")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The collected files are written to the temporary directory
+	tmpDir := filepath.Join(dir, "tmp")
+	assert.Nil(t, os.Mkdir(tmpDir, 0755))
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	assert.Nil(t, os.Setenv("TMPDIR", tmpDir))
+
+	entity, err := openpgp.NewEntity("support", "", "support@example.com", nil)
+	assert.Nil(t, err)
+	keyPath := filepath.Join(dir, "key.asc")
+	writePublicKey(t, entity, keyPath)
+
+	defer func(dryRunValue bool, outputPathValue, encryptKeyPathValue string) {
+		dryRun, outputPath, encryptKeyPath = dryRunValue, outputPathValue, encryptKeyPathValue
+	}(dryRun, outputPath, encryptKeyPath)
+	outputPath = filepath.Join(dir, "flare.zip")
+	encryptKeyPath = keyPath
+
+	for _, dryRunValue := range []bool{false, true} {
+		dryRun = dryRunValue
+
+		// The API server is unreachable, the flare only contains the files that don't need it
+		o := newOptions(genericclioptions.NewTestIOStreamsDiscard())
+		o.scrubber = newScrubber(nil)
+		o.Client = fake.NewFakeClient()
+		o.Clientset = kubernetes.NewForConfigOrDie(&rest.Config{Host: "http://127.0.0.1:1"})
+		cmd := &cobra.Command{}
+		cmd.SetOut(ioutil.Discard)
+
+		assert.Nil(t, o.run(cmd))
+
+		_, err = os.Stat(outputPath + encryptedExt)
+		assert.Nil(t, err, "dry run: %v", dryRun)
+		_, err = os.Stat(outputPath)
+		assert.True(t, os.IsNotExist(err), "dry run: %v, the clear archive should be removed", dryRun)
+		leftovers, err := ioutil.ReadDir(tmpDir)
+		assert.Nil(t, err)
+		assert.Empty(t, leftovers, "dry run: %v, the collected files should be removed", dryRun)
+	}
+}
+
+// writePublicKey exports the armored public key of the entity
+func writePublicKey(t *testing.T, entity *openpgp.Entity, keyPath string) {
+	keyFile, err := os.Create(keyPath)
+	assert.Nil(t, err)
+	armored, err := armor.Encode(keyFile, openpgp.PublicKeyType, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, entity.Serialize(armored))
+	assert.Nil(t, armored.Close())
+	assert.Nil(t, keyFile.Close())
+}
+
+func TestReadArchiveMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flare-metadata")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	archivePath := filepath.Join(dir, "flare.zip")
+	archive, err := os.Create(archivePath)
+	assert.Nil(t, err)
+	writer := zip.NewWriter(archive)
+	file, err := writer.Create("datadog-operator/metadata.yaml")
+	assert.Nil(t, err)
+	_, err = file.Write([]byte("cluster: foo\noperatorVersion: 0.3.0\ncaseID: \"123\"\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, archive.Close())
+
+	metadata, err := readArchiveMetadata(archivePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", metadata.Cluster)
+	assert.Equal(t, "0.3.0", metadata.OperatorVersion)
+	assert.Equal(t, "123", metadata.CaseID)
+
+	_, err = readArchiveMetadata(archivePath + ".gpg")
+	assert.NotNil(t, err)
+}
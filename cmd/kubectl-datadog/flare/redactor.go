@@ -6,39 +6,162 @@
 package flare
 
 import (
+	"bytes"
 	"io"
-	"os"
 )
 
-// redactingWriter is a writer that will redact content before writing to target
+const (
+	// maxBlockSize limits the amount of data buffered while waiting for the end of a multi-line block
+	maxBlockSize = 1 << 20
+)
+
+var (
+	blockStartMarker = []byte("-----BEGIN")
+	blockEndMarker   = []byte("-----END")
+)
+
+// redactingWriter is a writer that will redact content before writing to target.
+// It is binary-safe: the content is processed as a stream of lines, whatever the
+// chunk boundaries are, and bytes that don't match any rule are written unchanged.
 type redactingWriter struct {
-	target *os.File
-	r      replacer
+	target   io.Writer
+	scrubber *scrubber
+	file     string
+
+	// pending holds the last incomplete line
+	pending []byte
+	// block holds the lines of a multi-line block that hasn't been closed yet
+	block []byte
+	// discarding is true while the lines of a block too large to be buffered are dropped, until its END marker
+	discarding bool
 }
 
-// newRedactingWriter instantiates a redactingWriter to target
-func newRedactingWriter(target *os.File) *redactingWriter {
+// newRedactingWriter instantiates a redactingWriter to target, file identifies the content in the scrubber statistics
+func newRedactingWriter(target io.Writer, s *scrubber, file string) *redactingWriter {
 	return &redactingWriter{
-		target: target,
-		r:      replacer{},
+		target:   target,
+		scrubber: s,
+		file:     file,
 	}
 }
 
 // Write writes the redacted byte stream, applying all replacers and credential cleanup to target
 func (f *redactingWriter) Write(p []byte) (int, error) {
-	cleaned, err := credentialsCleanerBytes(p)
-	if err != nil {
-		return 0, err
+	f.pending = append(f.pending, p...)
+	for {
+		i := bytes.IndexByte(f.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := f.pending[:i+1]
+		if err := f.writeLine(line); err != nil {
+			return 0, err
+		}
+		f.pending = f.pending[i+1:]
+	}
+
+	// Copy the remaining bytes so that the underlying array doesn't keep growing
+	f.pending = append([]byte(nil), f.pending...)
+
+	return len(p), nil
+}
+
+// Flush redacts and writes the content still buffered, it must be called once all the content has been written
+func (f *redactingWriter) Flush() error {
+	if len(f.pending) > 0 {
+		if err := f.writeLine(f.pending); err != nil {
+			return err
+		}
+		f.pending = nil
+	}
+	if f.discarding {
+		// The END marker of the redacted block is missing, terminate the placeholder line
+		f.discarding = false
+		return f.write([]byte("\n"))
+	}
+	// A block without END marker can't be matched by the multi-line replacers, it is redacted as a whole
+	return f.redactBlock(true)
+}
+
+// writeLine applies the single-line replacers and buffers the multi-line blocks
+func (f *redactingWriter) writeLine(line []byte) error {
+	if f.discarding {
+		i := bytes.Index(line, blockEndMarker)
+		if i < 0 {
+			return nil
+		}
+		f.discarding = false
+		return f.writeLine(getBlockEndRemainder(line[i:]))
 	}
 
-	if f.r.regex != nil && f.r.replFunc != nil {
-		cleaned = f.r.regex.ReplaceAllFunc(cleaned, f.r.replFunc)
+	cleaned := f.scrubber.scrubLine(f.file, line)
+
+	if f.block != nil {
+		f.block = append(f.block, cleaned...)
+		if bytes.Contains(cleaned, blockEndMarker) {
+			return f.writeBlock()
+		}
+		if len(f.block) > maxBlockSize {
+			// Drop the rest of the block rather than writing it unredacted
+			f.discarding = true
+			return f.redactBlock(false)
+		}
+		return nil
 	}
 
-	n, err := f.target.Write(cleaned)
-	if n != len(cleaned) {
-		err = io.ErrShortWrite
+	if bytes.Contains(cleaned, blockStartMarker) && !bytes.Contains(cleaned, blockEndMarker) {
+		f.block = append([]byte{}, cleaned...)
+		return nil
 	}
 
-	return len(p), err
+	return f.write(f.scrubber.scrubBlock(f.file, cleaned))
+}
+
+// writeBlock applies the multi-line replacers on the buffered block
+func (f *redactingWriter) writeBlock() error {
+	if f.block == nil {
+		return nil
+	}
+	block := f.block
+	f.block = nil
+	return f.write(f.scrubber.scrubBlock(f.file, block))
+}
+
+// redactBlock replaces the buffered block, from its BEGIN marker, with the certificate placeholder.
+// The line end of the block is kept if endLine is true, otherwise it comes with the END marker line.
+func (f *redactingWriter) redactBlock(endLine bool) error {
+	if f.block == nil {
+		return nil
+	}
+	block := f.block
+	f.block = nil
+
+	redacted := append([]byte{}, block[:bytes.Index(block, blockStartMarker)]...)
+	redacted = append(redacted, certPlaceholder...)
+	if endLine && bytes.HasSuffix(block, []byte("\n")) {
+		redacted = append(redacted, '\n')
+	}
+	f.scrubber.record(f.file, certReplacerName, 1)
+	return f.write(redacted)
+}
+
+// getBlockEndRemainder returns what follows the END marker of a block, line starts with the marker
+func getBlockEndRemainder(line []byte) []byte {
+	// Skip the label of the marker: -----END <label>-----
+	rest := line[len(blockEndMarker):]
+	if i := bytes.Index(rest, []byte("-----")); i >= 0 {
+		return rest[i+len("-----"):]
+	}
+	if bytes.HasSuffix(line, []byte("\n")) {
+		return []byte("\n")
+	}
+	return nil
+}
+
+func (f *redactingWriter) write(data []byte) error {
+	n, err := f.target.Write(data)
+	if err == nil && n != len(data) {
+		err = io.ErrShortWrite
+	}
+	return err
 }
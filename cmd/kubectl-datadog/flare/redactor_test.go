@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactingWriterLargeBlocks(t *testing.T) {
+	body := strings.Repeat("MIICdQIBADANBgkqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJKRals8tGoy7K\n", 2*maxBlockSize/64)
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "block larger than the buffer",
+			in:   "cert: |\n  -----BEGIN CERTIFICATE-----\n" + body + "  -----END CERTIFICATE-----\nkey: value\n",
+			want: "cert: |\n  ********\nkey: value\n",
+		},
+		{
+			name: "unterminated block larger than the buffer",
+			in:   "cert: |\n  -----BEGIN CERTIFICATE-----\n" + body,
+			want: "cert: |\n  ********\n",
+		},
+		{
+			name: "unterminated block",
+			in:   "cert: |\n  -----BEGIN CERTIFICATE-----\n  MIICdQIBADANBgkqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJKRals8tGoy7K\n",
+			want: "cert: |\n  ********\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScrubber(nil)
+			var out bytes.Buffer
+			w := newRedactingWriter(&out, s, "file.yaml")
+
+			// Write by chunks to make sure the block is split across writes
+			in := []byte(tt.in)
+			for len(in) > 0 {
+				n := 4096
+				if n > len(in) {
+					n = len(in)
+				}
+				_, err := w.Write(in[:n])
+				assert.Nil(t, err)
+				in = in[n:]
+			}
+			assert.Nil(t, w.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+			assert.Equal(t, map[string]map[string]int{"file.yaml": {"certificate": 1}}, s.getMatches())
+		})
+	}
+}
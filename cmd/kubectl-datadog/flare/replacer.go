@@ -6,60 +6,100 @@
 package flare
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"regexp"
-	"strings"
+	"sync"
 )
 
 // replacer structure to store regex matching and replacement functions
 type replacer struct {
+	name     string
 	regex    *regexp.Regexp
 	hints    []string // If any of these hints do not exist in the line, then we know the regex wont match either
 	repl     []byte
 	replFunc func(b []byte) []byte
 }
 
-var commentRegex = regexp.MustCompile(`^\s*#.*$`)
+// matches returns true if the replacer hints allow the regex to match the data
+func (r *replacer) matches(data []byte) bool {
+	if len(r.hints) == 0 {
+		return true
+	}
+	for _, hint := range r.hints {
+		if bytes.Contains(data, []byte(hint)) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace applies the replacer on data and returns the result with the number of matches
+func (r *replacer) replace(data []byte) ([]byte, int) {
+	if !r.matches(data) {
+		return data, 0
+	}
+	count := len(r.regex.FindAllIndex(data, -1))
+	if count == 0 {
+		return data, 0
+	}
+	if r.replFunc != nil {
+		return r.regex.ReplaceAllFunc(data, r.replFunc), count
+	}
+	return r.regex.ReplaceAll(data, r.repl), count
+}
+
 var blankRegex = regexp.MustCompile(`^\s*$`)
-var singleLineReplacers, multiLineReplacers []replacer
 
-func init() {
+const certReplacerName = "certificate"
+
+// certPlaceholder replaces the certificates and private keys
+var certPlaceholder = []byte(`********`)
+
+// defaultReplacers returns the built-in single-line and multi-line replacers
+func defaultReplacers() ([]replacer, []replacer) {
 	apiKeyReplacer := replacer{
+		name:  "api_key",
 		regex: regexp.MustCompile(`\b[a-fA-F0-9]{27}([a-fA-F0-9]{5})\b`),
 		repl:  []byte(`***************************$1`),
 	}
 	appKeyReplacer := replacer{
+		name:  "app_key",
 		regex: regexp.MustCompile(`\b[a-fA-F0-9]{35}([a-fA-F0-9]{5})\b`),
 		repl:  []byte(`***********************************$1`),
 	}
 	uriPasswordReplacer := replacer{
+		name:  "uri_password",
 		regex: regexp.MustCompile(`([A-Za-z]+\:\/\/|\b)([A-Za-z0-9_]+)\:([^\s-]+)\@`),
 		repl:  []byte(`$1$2:********@`),
 	}
 	passwordReplacer := replacer{
+		name:  "password",
 		regex: matchYAMLKeyPart(`(pass(word)?|pwd)`),
 		hints: []string{"pass", "pwd"},
 		repl:  []byte(`$1 ********`),
 	}
 	tokenReplacer := replacer{
+		name:  "token",
 		regex: matchYAMLKeyPart(`token`),
 		hints: []string{"token"},
 		repl:  []byte(`$1 ********`),
 	}
 	certReplacer := replacer{
+		name:  certReplacerName,
 		regex: matchCert(),
 		hints: []string{"BEGIN"},
-		repl:  []byte(`********`),
+		repl:  certPlaceholder,
 	}
-	singleLineReplacers = []replacer{apiKeyReplacer, appKeyReplacer, uriPasswordReplacer, passwordReplacer, tokenReplacer}
-	multiLineReplacers = []replacer{certReplacer}
+	return []replacer{apiKeyReplacer, appKeyReplacer, uriPasswordReplacer, passwordReplacer, tokenReplacer}, []replacer{certReplacer}
+}
+
+func yamlKeyPartPattern(part string) string {
+	return fmt.Sprintf(`(\s*(\w|_)*%s(\w|_)*\s*:).+`, part)
 }
 
 func matchYAMLKeyPart(part string) *regexp.Regexp {
-	return regexp.MustCompile(fmt.Sprintf(`(\s*(\w|_)*%s(\w|_)*\s*:).+`, part))
+	return regexp.MustCompile(yamlKeyPartPattern(part))
 }
 
 func matchCert() *regexp.Regexp {
@@ -73,69 +113,82 @@ func matchCert() *regexp.Regexp {
 	)
 }
 
-// credentialsCleanerBytes scrubs credentials from slice of bytes
-func credentialsCleanerBytes(data []byte) ([]byte, error) {
-	r := bytes.NewReader(data)
-	return credentialsCleaner(r)
+// scrubber applies a set of replacers and keeps track of the number of matches per rule and file
+type scrubber struct {
+	singleLineReplacers []replacer
+	multiLineReplacers  []replacer
+
+	mutex sync.Mutex
+	// matches counts the number of matches per file and rule name
+	matches map[string]map[string]int
 }
 
-func credentialsCleaner(file io.Reader) ([]byte, error) {
-	var cleanedFile []byte
-
-	scanner := bufio.NewScanner(file)
-
-	// First, we go through the file line by line, applying any
-	// single-line replacer that matches the line.
-	first := true
-	for scanner.Scan() {
-		b := scanner.Bytes()
-		if !commentRegex.Match(b) && !blankRegex.Match(b) && string(b) != "" {
-			for _, repl := range singleLineReplacers {
-				containsHint := false
-				for _, hint := range repl.hints {
-					if strings.Contains(string(b), hint) {
-						containsHint = true
-						break
-					}
-				}
-				if len(repl.hints) == 0 || containsHint {
-					if repl.replFunc != nil {
-						b = repl.regex.ReplaceAllFunc(b, repl.replFunc)
-					} else {
-						b = repl.regex.ReplaceAll(b, repl.repl)
-					}
-				}
-			}
-			if !first {
-				cleanedFile = append(cleanedFile, byte('\n'))
-			}
-
-			cleanedFile = append(cleanedFile, b...)
-			first = false
-		}
+// newScrubber returns a scrubber using the built-in replacers followed by the extra replacers
+func newScrubber(extra []replacer) *scrubber {
+	single, multi := defaultReplacers()
+	return &scrubber{
+		singleLineReplacers: append(single, extra...),
+		multiLineReplacers:  multi,
+		matches:             map[string]map[string]int{},
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// scrubLine applies the single-line replacers on a line of the file
+func (s *scrubber) scrubLine(file string, line []byte) []byte {
+	if blankRegex.Match(line) {
+		return line
 	}
+	return s.apply(file, line, s.singleLineReplacers)
+}
+
+// scrubBlock applies the multi-line replacers on a block of lines of the file
+func (s *scrubber) scrubBlock(file string, block []byte) []byte {
+	return s.apply(file, block, s.multiLineReplacers)
+}
 
-	// Then we apply multiline replacers on the cleaned file
-	for _, repl := range multiLineReplacers {
-		containsHint := false
-		for _, hint := range repl.hints {
-			if strings.Contains(string(cleanedFile), hint) {
-				containsHint = true
-				break
-			}
+func (s *scrubber) apply(file string, data []byte, replacers []replacer) []byte {
+	for i := range replacers {
+		var count int
+		data, count = replacers[i].replace(data)
+		if count > 0 {
+			s.record(file, replacers[i].name, count)
 		}
-		if len(repl.hints) == 0 || containsHint {
-			if repl.replFunc != nil {
-				cleanedFile = repl.regex.ReplaceAllFunc(cleanedFile, repl.replFunc)
-			} else {
-				cleanedFile = repl.regex.ReplaceAll(cleanedFile, repl.repl)
-			}
+	}
+	return data
+}
+
+func (s *scrubber) record(file, rule string, count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, found := s.matches[file]; !found {
+		s.matches[file] = map[string]int{}
+	}
+	s.matches[file][rule] += count
+}
+
+// getMatches returns a copy of the number of matches per file and rule name
+func (s *scrubber) getMatches() map[string]map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	matches := make(map[string]map[string]int, len(s.matches))
+	for file, rules := range s.matches {
+		matches[file] = make(map[string]int, len(rules))
+		for rule, count := range rules {
+			matches[file][rule] = count
 		}
 	}
+	return matches
+}
 
-	return cleanedFile, nil
+// credentialsCleanerBytes scrubs credentials from slice of bytes using the built-in replacers
+func credentialsCleanerBytes(data []byte) ([]byte, error) {
+	var cleaned bytes.Buffer
+	writer := newRedactingWriter(&cleaned, newScrubber(nil), "")
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return cleaned.Bytes(), nil
 }
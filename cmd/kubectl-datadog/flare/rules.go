@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/DataDog/datadog-operator/pkg/plugin/common"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultRuleReplacement    = "********"
+	defaultYAMLKeyReplacement = "$1 ********"
+)
+
+// redactionRules is the content of a redaction rules file
+type redactionRules struct {
+	Rules []redactionRule `json:"rules"`
+}
+
+// redactionRule describes a custom redaction rule.
+// Either Regex or YAMLKey must be set.
+type redactionRule struct {
+	// Name identifies the rule in the dry-run report
+	Name string `json:"name"`
+	// Regex redacts every match of the regular expression
+	Regex string `json:"regex,omitempty"`
+	// YAMLKey redacts the value of the YAML keys containing this pattern
+	YAMLKey string `json:"yamlKey,omitempty"`
+	// Replacement replaces the matches, it can reference the regex capturing groups ($1)
+	Replacement *string `json:"replacement,omitempty"`
+}
+
+// loadRedactionRules reads a redaction rules file and builds the corresponding replacers
+func loadRedactionRules(path string) ([]replacer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read redaction rules file: %v", err)
+	}
+	return parseRedactionRules(data)
+}
+
+// parseRedactionRules validates the redaction rules and builds the corresponding replacers
+func parseRedactionRules(data []byte) ([]replacer, error) {
+	rules := redactionRules{}
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid redaction rules: %v", err)
+	}
+
+	names := map[string]bool{}
+	replacers := make([]replacer, 0, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("redaction rule #%d: name is missing", i)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("redaction rule %s: duplicated name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		r, err := rule.toReplacer()
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %s: %v", rule.Name, err)
+		}
+		replacers = append(replacers, r)
+	}
+
+	return replacers, nil
+}
+
+// toReplacer builds the replacer corresponding to the rule
+func (r redactionRule) toReplacer() (replacer, error) {
+	if (r.Regex == "") == (r.YAMLKey == "") {
+		return replacer{}, fmt.Errorf("exactly one of regex and yamlKey must be set")
+	}
+
+	pattern, replacement := r.Regex, defaultRuleReplacement
+	if r.YAMLKey != "" {
+		pattern, replacement = yamlKeyPartPattern(r.YAMLKey), defaultYAMLKeyReplacement
+	}
+	if r.Replacement != nil {
+		replacement = *r.Replacement
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return replacer{}, fmt.Errorf("invalid regex: %v", err)
+	}
+
+	return replacer{
+		name:  r.Name,
+		regex: regex,
+		repl:  []byte(replacement),
+	}, nil
+}
+
+// printRedactionReport renders the number of matches per rule and file
+func printRedactionReport(out io.Writer, baseDir string, matches map[string]map[string]int) {
+	type row struct {
+		file  string
+		rule  string
+		count int
+	}
+
+	rows := []row{}
+	for file, rules := range matches {
+		if relPath, err := filepath.Rel(baseDir, file); err == nil {
+			file = filepath.ToSlash(relPath)
+		}
+		for rule, count := range rules {
+			rows = append(rows, row{file: file, rule: rule, count: count})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].file != rows[j].file {
+			return rows[i].file < rows[j].file
+		}
+		return rows[i].rule < rows[j].rule
+	})
+
+	table := common.NewTable(out, []string{"File", "Rule", "Matches"})
+	for _, r := range rows {
+		table.Append([]string{r.file, r.rule, strconv.Itoa(r.count)})
+	}
+	table.Render()
+}
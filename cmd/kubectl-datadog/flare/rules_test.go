@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRedactionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{
+			name: "valid rules",
+			rules: `
+rules:
+- name: hostname
+  regex: '[a-z0-9-]+\.corp\.example\.com'
+  replacement: '<host>'
+- name: customer-id
+  yamlKey: customer_id
+`,
+		},
+		{
+			name: "missing name",
+			rules: `
+rules:
+- regex: 'foo'
+`,
+			wantErr: true,
+		},
+		{
+			name: "duplicated name",
+			rules: `
+rules:
+- name: foo
+  regex: 'foo'
+- name: foo
+  regex: 'bar'
+`,
+			wantErr: true,
+		},
+		{
+			name: "both regex and yamlKey",
+			rules: `
+rules:
+- name: foo
+  regex: 'foo'
+  yamlKey: bar
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid regex",
+			rules: `
+rules:
+- name: foo
+  regex: '(foo'
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			rules: `
+rules:
+- name: foo
+  regexp: 'foo'
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRedactionRules([]byte(tt.rules))
+			assert.Equal(t, tt.wantErr, err != nil, "parseRedactionRules() error = %v", err)
+		})
+	}
+}
+
+func TestCustomRedactionRules(t *testing.T) {
+	rules, err := parseRedactionRules([]byte(`
+rules:
+- name: hostname
+  regex: '[a-z0-9-]+\.corp\.example\.com'
+  replacement: '<host>'
+- name: customer-id
+  yamlKey: customer_id
+`))
+	assert.Nil(t, err)
+
+	s := newScrubber(rules)
+	var out bytes.Buffer
+	w := newRedactingWriter(&out, s, "file.yaml")
+
+	// Write byte per byte to make sure lines split across writes are redacted
+	in := []byte("host: db-1.corp.example.com\ncustomer_id: 1234\r\nbinary: \x00\xff\napi_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaabbbb")
+	for i := range in {
+		_, err = w.Write(in[i : i+1])
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Flush())
+
+	assert.Equal(t, "host: <host>\ncustomer_id: ********\nbinary: \x00\xff\napi_key: ***************************abbbb", out.String())
+	assert.Equal(t, map[string]map[string]int{
+		"file.yaml": {
+			"hostname":    1,
+			"customer-id": 1,
+			"api_key":     1,
+		},
+	}, s.getMatches())
+}
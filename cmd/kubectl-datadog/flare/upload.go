@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	operatorVersion string
+	uploadExample   = `
+  # send a flare archive previously built with --output for an existing case 123
+  %[1]s upload flare.zip 123 --email foo@bar.com
+
+  # send an encrypted flare archive and create a new case
+  %[1]s upload flare.zip.gpg --operator-version 0.3.0
+`
+)
+
+// uploadOptions provides information required by Datadog flare upload command
+type uploadOptions struct {
+	options
+	archivePath string
+}
+
+// newUploadCmd provides a cobra command wrapping uploadOptions for "flare upload" sub command
+func newUploadCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	o := &uploadOptions{
+		options: options{
+			IOStreams: streams,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:          "upload <archive> [Case ID]",
+		Short:        "Send a flare archive built with --output to Datadog",
+		Example:      fmt.Sprintf(uploadExample, "kubectl datadog flare"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.complete(args); err != nil {
+				return err
+			}
+			if err := o.validate(); err != nil {
+				return err
+			}
+			return o.run(c)
+		},
+	}
+
+	cmd.Flags().StringVarP(&email, "email", "e", "", "Your email")
+	cmd.Flags().StringVarP(&apiKey, "apiKey", "k", "", "Your api key, could also be taken from stdin")
+	cmd.Flags().StringVarP(&ddSite, "ddSite", "d", "us", "Your Datadog site US or EU (default: US)")
+	cmd.Flags().StringVar(&operatorVersion, "operator-version", "", "The operator version used to build the flare URL, read from the archive metadata by default")
+	cmd.Flags().StringVar(&proxyURL, "proxy", "", "URL of the proxy used to send the flare, defaults to the proxy of the environment")
+
+	return cmd
+}
+
+// complete sets all information required for processing the command
+func (o *uploadOptions) complete(args []string) error {
+	o.args = args
+
+	if len(args) > 0 {
+		o.archivePath = args[0]
+	}
+
+	if len(args) > 1 {
+		o.caseID = args[1]
+	}
+
+	return completeCredentials()
+}
+
+// validate ensures that all required arguments and flag values are provided
+func (o *uploadOptions) validate() error {
+	if o.archivePath == "" {
+		return errors.New("archive argument is missing")
+	}
+
+	if len(o.args) > 2 {
+		return errors.New("at most two arguments are allowed")
+	}
+
+	if _, err := os.Stat(o.archivePath); err != nil {
+		return fmt.Errorf("invalid archive: %v", err)
+	}
+
+	return o.validateCredentials()
+}
+
+// run runs the flare upload command
+func (o *uploadOptions) run(cmd *cobra.Command) error {
+	version := operatorVersion
+	if version == "" {
+		metadata, err := readArchiveMetadata(o.archivePath)
+		if err != nil {
+			cmd.Println(fmt.Sprintf("Couldn't read archive metadata: %v", err))
+		} else {
+			version = metadata.OperatorVersion
+			if o.caseID == "" {
+				o.caseID = metadata.CaseID
+			}
+		}
+	}
+	if version == "" {
+		version = defaultOperatorVersion
+	}
+
+	caseID, err := o.sendFlare(o.archivePath, version, cmd)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Flare were successfully uploaded. For future reference, your internal case id is", caseID)
+	return nil
+}
@@ -7,18 +7,26 @@ package get
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"strings"
 
 	"github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/plugin/common"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputWide = "wide"
+	outputJSON = "json"
+	outputYAML = "yaml"
 )
 
 var (
@@ -28,6 +36,12 @@ var (
 
   # view DatadogAgent foo
   %[1]s get foo
+
+  # view all DatadogAgent with images, pod counts, hashes and conditions
+  %[1]s get -o wide
+
+  # view DatadogAgent foo in yaml
+  %[1]s get foo -o yaml
 `
 )
 
@@ -37,6 +51,7 @@ type options struct {
 	common.Options
 	args                 []string
 	userDatadogAgentName string
+	output               string
 }
 
 // newOptions provides an instance of getOptions with default values
@@ -67,6 +82,8 @@ func New(streams genericclioptions.IOStreams) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Output format. One of: wide|json|yaml")
+
 	o.ConfigFlags.AddFlags(cmd.Flags())
 
 	return cmd
@@ -86,6 +103,11 @@ func (o *options) validate() error {
 	if len(o.args) > 1 {
 		return errors.New("either one or no arguments are allowed")
 	}
+	switch o.output {
+	case "", outputWide, outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("invalid output format %s, must be one of: wide|json|yaml", o.output)
+	}
 	return nil
 }
 
@@ -107,44 +129,145 @@ func (o *options) run() error {
 		ddList.Items = append(ddList.Items, *dd)
 	}
 
-	table := newTable(o.Out)
-	for _, item := range ddList.Items {
-		data := []string{item.Namespace, item.Name}
-		if item.Status.Agent != nil {
-			data = append(data, item.Status.Agent.Status)
-		} else {
-			data = append(data, "")
-		}
-		if item.Status.ClusterAgent != nil {
-			data = append(data, item.Status.ClusterAgent.Status)
-		} else {
-			data = append(data, "")
+	switch o.output {
+	case outputJSON, outputYAML:
+		return o.printObjects(ddList)
+	case outputWide:
+		table := common.NewTable(o.Out, wideHeader)
+		for _, item := range ddList.Items {
+			table.Append(getWideRow(&item))
 		}
-		if item.Status.ClusterChecksRunner != nil {
-			data = append(data, item.Status.ClusterChecksRunner.Status)
-		} else {
-			data = append(data, "")
+		table.Render()
+	default:
+		table := common.NewTable(o.Out, defaultHeader)
+		for _, item := range ddList.Items {
+			table.Append(getRow(&item))
 		}
-		data = append(data, common.GetDurationAsString(&item.ObjectMeta))
-		table.Append(data)
+		table.Render()
 	}
 
-	// Send output
-	table.Render()
-
 	return nil
 }
 
-func newTable(out io.Writer) *tablewriter.Table {
-	table := tablewriter.NewWriter(out)
-	table.SetHeader([]string{"Namespace", "Name", "Agent", "Cluster-Agent", "Cluster-Checks-Runner", "Age"})
-	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetRowLine(false)
-	table.SetCenterSeparator("")
-	table.SetColumnSeparator("")
-	table.SetRowSeparator("")
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetHeaderLine(false)
-	return table
+// printObjects prints the DatadogAgent objects in json or yaml
+// a single object is printed when a DatadogAgent name is provided
+func (o *options) printObjects(ddList *v1alpha1.DatadogAgentList) error {
+	var obj interface{}
+	if o.userDatadogAgentName != "" && len(ddList.Items) == 1 {
+		dd := ddList.Items[0].DeepCopy()
+		dd.APIVersion = v1alpha1.GroupVersion.String()
+		dd.Kind = "DatadogAgent"
+		obj = dd
+	} else {
+		ddList.APIVersion = v1alpha1.GroupVersion.String()
+		ddList.Kind = "DatadogAgentList"
+		obj = ddList
+	}
+
+	var data []byte
+	var err error
+	if o.output == outputJSON {
+		data, err = json.MarshalIndent(obj, "", "    ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = o.Out.Write(data)
+	return err
+}
+
+var (
+	defaultHeader = []string{"Namespace", "Name", "Agent", "Cluster-Agent", "Cluster-Checks-Runner", "Age"}
+	wideHeader    = []string{"Namespace", "Name", "Agent", "Agent-Image", "Agent-Ready", "Agent-Hash", "Cluster-Agent", "Cluster-Agent-Image", "Cluster-Agent-Ready", "Cluster-Agent-Hash", "Cluster-Checks-Runner", "Cluster-Checks-Runner-Image", "Cluster-Checks-Runner-Ready", "Cluster-Checks-Runner-Hash", "Conditions", "Metrics-Forwarder", "Age"}
+)
+
+// getRow returns the default table row of a DatadogAgent
+func getRow(dd *v1alpha1.DatadogAgent) []string {
+	data := []string{dd.Namespace, dd.Name}
+	if dd.Status.Agent != nil {
+		data = append(data, dd.Status.Agent.Status)
+	} else {
+		data = append(data, "")
+	}
+	if dd.Status.ClusterAgent != nil {
+		data = append(data, dd.Status.ClusterAgent.Status)
+	} else {
+		data = append(data, "")
+	}
+	if dd.Status.ClusterChecksRunner != nil {
+		data = append(data, dd.Status.ClusterChecksRunner.Status)
+	} else {
+		data = append(data, "")
+	}
+	return append(data, common.GetDurationAsString(&dd.ObjectMeta))
+}
+
+// getWideRow returns the wide table row of a DatadogAgent
+func getWideRow(dd *v1alpha1.DatadogAgent) []string {
+	data := []string{dd.Namespace, dd.Name}
+
+	agentImage := ""
+	if dd.Spec.Agent != nil {
+		agentImage = dd.Spec.Agent.Image.Name
+	}
+	if status := dd.Status.Agent; status != nil {
+		data = append(data, status.Status, agentImage, fmt.Sprintf("%d/%d", status.Ready, status.Desired), status.CurrentHash)
+	} else {
+		data = append(data, "", agentImage, "", "")
+	}
+
+	clusterAgentImage := ""
+	if dd.Spec.ClusterAgent != nil {
+		clusterAgentImage = dd.Spec.ClusterAgent.Image.Name
+	}
+	data = append(data, getDeploymentColumns(dd.Status.ClusterAgent, clusterAgentImage)...)
+
+	clcImage := ""
+	if dd.Spec.ClusterChecksRunner != nil {
+		clcImage = dd.Spec.ClusterChecksRunner.Image.Name
+	}
+	data = append(data, getDeploymentColumns(dd.Status.ClusterChecksRunner, clcImage)...)
+
+	data = append(data, getConditions(dd), getMetricsForwarderState(dd))
+
+	return append(data, common.GetDurationAsString(&dd.ObjectMeta))
+}
+
+// getDeploymentColumns returns the status, image, ready count and hash columns of a deployment
+func getDeploymentColumns(status *v1alpha1.DeploymentStatus, image string) []string {
+	if status == nil {
+		return []string{"", image, "", ""}
+	}
+	return []string{status.Status, image, fmt.Sprintf("%d/%d", status.ReadyReplicas, status.Replicas), status.CurrentHash}
+}
+
+// getConditions returns the types of the true conditions
+func getConditions(dd *v1alpha1.DatadogAgent) string {
+	conditions := []string{}
+	for _, condition := range dd.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			conditions = append(conditions, string(condition.Type))
+		}
+	}
+	return strings.Join(conditions, ",")
+}
+
+// getMetricsForwarderState returns the state of the metrics forwarder
+func getMetricsForwarderState(dd *v1alpha1.DatadogAgent) string {
+	for _, condition := range dd.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case v1alpha1.ConditionTypeDatadogMetricsError:
+			return "Error"
+		case v1alpha1.ConditionTypeActiveDatadogMetrics:
+			return "Active"
+		}
+	}
+	return ""
 }
@@ -16,15 +16,14 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
-	"github.com/DataDog/datadog-operator/pkg/kubernetes"
 	"github.com/DataDog/datadog-operator/pkg/version"
 	edsdatadoghqv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
 )
@@ -39,6 +38,34 @@ func (r *Reconciler) reconcileAgent(logger logr.Logger, dda *datadoghqv1alpha1.D
 		return result, fmt.Errorf("the Datadog agent DaemonSet cannot be renamed once created")
 	}
 
+	variants, err := r.getAgentVariants(logger, dda, newStatus)
+	if err != nil {
+		return result, err
+	}
+	if isWindowsAgentEnabled(dda) {
+		variants = append(variants, newWindowsAgentVariant(dda))
+	} else {
+		newStatus.WindowsAgent = nil
+	}
+	result, err = r.reconcileAgentVariants(logger, dda, variants[1:], newStatus)
+	if shouldReturn(result, err) {
+		return result, err
+	}
+	if err = r.updateAgentHealth(dda, newStatus); err != nil {
+		return result, err
+	}
+	if err = r.updateAgentCoverage(dda, variants, newStatus); err != nil {
+		return result, err
+	}
+	if err = r.updateResourceRecommendations(logger, dda, newStatus); err != nil {
+		return result, err
+	}
+	result, err = r.reconcileAgentVPAs(logger, dda, variants)
+	if shouldReturn(result, err) {
+		return result, err
+	}
+	agent := variants[0]
+
 	nameNamespace := types.NamespacedName{
 		Name:      daemonsetName(dda),
 		Namespace: dda.ObjectMeta.Namespace,
@@ -90,10 +117,10 @@ func (r *Reconciler) reconcileAgent(logger logr.Logger, dda *datadoghqv1alpha1.D
 			return result, nil
 		}
 		if eds == nil {
-			return r.createNewExtendedDaemonSet(logger, dda, newStatus)
+			return r.createNewExtendedDaemonSet(logger, agent.dda, agent.placement, newStatus)
 		}
 
-		return r.updateExtendedDaemonSet(logger, dda, eds, newStatus)
+		return r.updateExtendedDaemonSet(logger, agent.dda, agent.placement, eds, newStatus)
 	}
 
 	// Case when Daemonset is requested
@@ -107,10 +134,10 @@ func (r *Reconciler) reconcileAgent(logger logr.Logger, dda *datadoghqv1alpha1.D
 		return result, nil
 	}
 	if ds == nil {
-		return r.createNewDaemonSet(logger, dda, newStatus)
+		return r.createNewDaemonSet(logger, agent.dda, agent.placement, newStatus)
 	}
 
-	return r.updateDaemonSet(logger, dda, ds, newStatus)
+	return r.updateDaemonSet(logger, agent.dda, agent.placement, ds, newStatus)
 
 }
 
@@ -136,7 +163,7 @@ func (r *Reconciler) deleteExtendedDaemonSet(logger logr.Logger, dda *datadoghqv
 	return err
 }
 
-func (r *Reconciler) createNewExtendedDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+func (r *Reconciler) createNewExtendedDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, placement *corev1.NodeSelectorRequirement, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
 	var err error
 	// ExtendedDaemonSet up to date didn't exist yet, create a new one
 	var newEDS *edsdatadoghqv1alpha1.ExtendedDaemonSet
@@ -144,6 +171,10 @@ func (r *Reconciler) createNewExtendedDaemonSet(logger logr.Logger, dda *datadog
 	if newEDS, hash, err = newExtendedDaemonSetFromInstance(dda, nil); err != nil {
 		return reconcile.Result{}, err
 	}
+	setNodePlacement(&newEDS.Spec.Template, placement)
+	if hash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, &newEDS.ObjectMeta, &newEDS.Spec.Template, &newEDS.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	// Set ExtendedDaemonSet instance as the owner and controller
 	if err = controllerutil.SetControllerReference(dda, newEDS, r.scheme); err != nil {
@@ -164,7 +195,7 @@ func (r *Reconciler) createNewExtendedDaemonSet(logger logr.Logger, dda *datadog
 	return reconcile.Result{}, nil
 }
 
-func (r *Reconciler) createNewDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+func (r *Reconciler) createNewDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, placement *corev1.NodeSelectorRequirement, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
 	var err error
 	// DaemonSet up to date didn't exist yet, create a new one
 	var newDS *appsv1.DaemonSet
@@ -172,6 +203,10 @@ func (r *Reconciler) createNewDaemonSet(logger logr.Logger, dda *datadoghqv1alph
 	if newDS, hash, err = newDaemonSetFromInstance(dda, nil); err != nil {
 		return reconcile.Result{}, err
 	}
+	setNodePlacement(&newDS.Spec.Template, placement)
+	if hash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, &newDS.ObjectMeta, &newDS.Spec.Template, &newDS.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	// Set DaemonSet instance as the owner and controller
 	if err = controllerutil.SetControllerReference(dda, newDS, r.scheme); err != nil {
@@ -190,15 +225,20 @@ func (r *Reconciler) createNewDaemonSet(logger logr.Logger, dda *datadoghqv1alph
 	return reconcile.Result{}, nil
 }
 
-func (r *Reconciler) updateExtendedDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, eds *edsdatadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+func (r *Reconciler) updateExtendedDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, placement *corev1.NodeSelectorRequirement, eds *edsdatadoghqv1alpha1.ExtendedDaemonSet, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
 	now := metav1.NewTime(time.Now())
 	newEDS, newHash, err := newExtendedDaemonSetFromInstance(dda, eds.Spec.Selector)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	setNodePlacement(&newEDS.Spec.Template, placement)
+	if newHash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, &newEDS.ObjectMeta, &newEDS.Spec.Template, &newEDS.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	if comparison.IsSameSpecMD5Hash(newHash, eds.GetAnnotations()) {
 		// no update needed so return, update the status and return
+		r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
 		newStatus.Agent = updateExtendedDaemonSetStatus(eds, newStatus.Agent, &now)
 		return reconcile.Result{}, nil
 	}
@@ -225,6 +265,7 @@ func (r *Reconciler) updateExtendedDaemonSet(logger logr.Logger, dda *datadoghqv
 	}
 	event := buildEventInfo(updatedEds.Name, updatedEds.Namespace, extendedDaemonSetKind, datadog.UpdateEvent)
 	r.recordEvent(dda, event)
+	r.startRollout(dda, &eds.Spec.Template, &newEDS.Spec.Template, newExtendedDaemonSetWorkload(updatedEds))
 	newStatus.Agent = updateExtendedDaemonSetStatus(updatedEds, newStatus.Agent, &now)
 	return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
 }
@@ -233,7 +274,7 @@ func getHashAnnotation(annotations map[string]string) string {
 	return annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
 }
 
-func (r *Reconciler) updateDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, ds *appsv1.DaemonSet, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+func (r *Reconciler) updateDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, placement *corev1.NodeSelectorRequirement, ds *appsv1.DaemonSet, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
 	// Update values from current DS in any case
 	newStatus.Agent = updateDaemonSetStatus(ds, newStatus.Agent, nil)
 
@@ -241,9 +282,14 @@ func (r *Reconciler) updateDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	setNodePlacement(&newDS.Spec.Template, placement)
+	if newHash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, &newDS.ObjectMeta, &newDS.Spec.Template, &newDS.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 	now := metav1.NewTime(time.Now())
 	if comparison.IsSameSpecMD5Hash(newHash, ds.GetAnnotations()) {
 		// no update needed so update the status and return
+		r.observeWorkload(dda, newDaemonSetWorkload(ds))
 		newStatus.Agent = updateDaemonSetStatus(ds, newStatus.Agent, &now)
 		return reconcile.Result{}, nil
 	}
@@ -269,6 +315,7 @@ func (r *Reconciler) updateDaemonSet(logger logr.Logger, dda *datadoghqv1alpha1.
 	}
 	event := buildEventInfo(updatedDS.Name, updatedDS.Namespace, daemonSetKind, datadog.UpdateEvent)
 	r.recordEvent(dda, event)
+	r.startRollout(dda, &ds.Spec.Template, &newDS.Spec.Template, newDaemonSetWorkload(updatedDS))
 	newStatus.Agent = updateDaemonSetStatus(updatedDS, newStatus.Agent, &now)
 	return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
 }
@@ -308,77 +355,54 @@ func (r *Reconciler) manageAgentDependencies(logger logr.Logger, dda *datadoghqv
 }
 
 func (r *Reconciler) manageAgentNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	policyName := fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultAgentResourceSuffix)
+	policyName := getNetworkPolicyName(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix)
 
-	spec := dda.Spec.Agent
-	if spec == nil || !datadoghqv1alpha1.BoolValue(spec.NetworkPolicy.Create) {
-		return r.cleanupNetworkPolicy(logger, dda, policyName)
+	var spec *datadoghqv1alpha1.NetworkPolicySpec
+	if dda.Spec.Agent != nil {
+		spec = &dda.Spec.Agent.NetworkPolicy
 	}
 
-	return r.ensureNetworkPolicy(logger, dda, policyName, buildAgentNetworkPolicy)
+	return r.manageNetworkPolicy(logger, dda, policyName, spec, buildAgentNetworkPolicy, buildAgentCiliumNetworkPolicy)
 }
 
 func buildAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *networkingv1.NetworkPolicy {
-	egressRules := []networkingv1.NetworkPolicyEgressRule{
-		// Egress to datadog intake and
-		// kubeapi server
-		{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: 443,
-					},
-				},
-			},
-		},
-
-		// The agents are susceptible to connect to any pod that would
-		// be annotated with auto-discovery annotations.
-		//
-		// When a user wants to add a check on one of its pod, he needs
-		// to
-		// * annotate its pod
-		// * add an ingress policy from the agent on its own pod
-		// In order to not ask end-users to inject NetworkPolicy on the
-		// agent in the agent namespace, the agent must be allowed to
-		// probe any pod.
-		{},
-	}
-
-	protocolUDP := corev1.ProtocolUDP
-	protocolTCP := corev1.ProtocolTCP
-	ingressRules := []networkingv1.NetworkPolicyIngressRule{
+	egressRules := getCommonEgressRules(dda)
+
+	// Egress to the kubelet
+	egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{
+		Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, kubeletPort),
+	})
+
+	if dda.Spec.ClusterAgent != nil {
+		egressRules = append(egressRules, getClusterAgentEgressRule(dda))
+	}
+
+	// The agents are susceptible to connect to any pod that would
+	// be annotated with auto-discovery annotations.
+	//
+	// When a user wants to add a check on one of its pod, they need
+	// to
+	// * annotate its pod
+	// * add an ingress policy from the agent on its own pod
+	// In order to not ask end-users to inject NetworkPolicy on the
+	// agent in the agent namespace, the agent can be allowed to
+	// probe any pod.
+	if isAutodiscoveryEgressAllowed(dda.Spec.Agent.NetworkPolicy) {
+		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{})
+	}
+
+	// The intakes exposed over Unix Domain Socket aren't reachable through the network
+	ingressRules := []networkingv1.NetworkPolicyIngressRule{}
+	if !isDogstatsdUDPDisabled(&dda.Spec) {
 		// Ingress for dogstatsd
-		{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: datadoghqv1alpha1.DefaultDogstatsdPort,
-					},
-					Protocol: &protocolUDP,
-				},
-			},
-		},
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: getNetworkPolicyPorts(corev1.ProtocolUDP, datadoghqv1alpha1.DefaultDogstatsdPort),
+		})
 	}
 
-	if isAPMEnabled(dda) {
-		port := datadoghqv1alpha1.DefaultAPMAgentTCPPort
-		if dda.Spec.Agent.Apm.HostPort != nil {
-			port = *dda.Spec.Agent.Apm.HostPort
-		}
-
+	if isAPMEnabled(dda) && !isAPMSocketEnabled(dda) {
 		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: port,
-					},
-					Protocol: &protocolTCP,
-				},
-			},
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, getAPMPort(dda)),
 		})
 	}
 
@@ -390,10 +414,7 @@ func buildAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					kubernetes.AppKubernetesInstanceLabelKey: datadoghqv1alpha1.DefaultAgentResourceSuffix,
-					kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
-				},
+				MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix),
 			},
 			Ingress: ingressRules,
 			Egress:  egressRules,
@@ -407,12 +428,65 @@ func buildAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *
 	return policy
 }
 
+func buildAgentCiliumNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *unstructured.Unstructured {
+	egressRules := getCiliumCommonEgressRules(dda, dda.Spec.Agent.NetworkPolicy)
+
+	// Egress to the kubelet
+	egressRules = append(egressRules, ciliumEgressRule{
+		ToEntities: []string{ciliumEntityHost, ciliumEntityRemoteNode},
+		ToPorts:    []ciliumPortRule{getCiliumPortRule("TCP", kubeletPort)},
+	})
+
+	if dda.Spec.ClusterAgent != nil {
+		egressRules = append(egressRules, getCiliumClusterAgentEgressRule(dda))
+	}
+
+	if isAutodiscoveryEgressAllowed(dda.Spec.Agent.NetworkPolicy) {
+		egressRules = append(egressRules, getCiliumAutodiscoveryEgressRule())
+	}
+
+	// The intakes exposed over Unix Domain Socket aren't reachable through the network
+	ingressRules := []ciliumIngressRule{}
+	if !isDogstatsdUDPDisabled(&dda.Spec) {
+		ingressRules = append(ingressRules, ciliumIngressRule{
+			FromEntities: []string{ciliumEntityAll},
+			ToPorts:      []ciliumPortRule{getCiliumPortRule("UDP", datadoghqv1alpha1.DefaultDogstatsdPort)},
+		})
+	}
+
+	if isAPMEnabled(dda) && !isAPMSocketEnabled(dda) {
+		ingressRules = append(ingressRules, ciliumIngressRule{
+			FromEntities: []string{ciliumEntityAll},
+			ToPorts:      []ciliumPortRule{getCiliumPortRule("TCP", getAPMPort(dda))},
+		})
+	}
+
+	return newCiliumNetworkPolicy(dda, name, name, getAgentVersion(dda), ciliumNetworkPolicySpec{
+		Description: "Egress to the Datadog intake, the kube API server, the kubelet and the Cluster Agent, ingress for DogStatsD and APM",
+		EndpointSelector: metav1.LabelSelector{
+			MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix),
+		},
+		Ingress: ingressRules,
+		Egress:  egressRules,
+	})
+}
+
+func getAPMPort(dda *datadoghqv1alpha1.DatadogAgent) int32 {
+	if dda.Spec.Agent.Apm.HostPort != nil {
+		return *dda.Spec.Agent.Apm.HostPort
+	}
+	return datadoghqv1alpha1.DefaultAPMAgentTCPPort
+}
+
 // newExtendedDaemonSetFromInstance creates an ExtendedDaemonSet from a given DatadogAgent
 func newExtendedDaemonSetFromInstance(dda *datadoghqv1alpha1.DatadogAgent, selector *metav1.LabelSelector) (*edsdatadoghqv1alpha1.ExtendedDaemonSet, string, error) {
 	template, err := newAgentPodTemplate(dda, selector)
 	if err != nil {
 		return nil, "", err
 	}
+	if err = applyPodTemplatePatches(template, dda.Spec.Agent.PodTemplatePatches); err != nil {
+		return nil, "", err
+	}
 	eds := &edsdatadoghqv1alpha1.ExtendedDaemonSet{
 		ObjectMeta: newDaemonsetObjectMetaData(dda),
 		Spec: edsdatadoghqv1alpha1.ExtendedDaemonSetSpec{
@@ -444,6 +518,9 @@ func newDaemonSetFromInstance(dda *datadoghqv1alpha1.DatadogAgent, selector *met
 	if err != nil {
 		return nil, "", err
 	}
+	if err = applyPodTemplatePatches(template, dda.Spec.Agent.PodTemplatePatches); err != nil {
+		return nil, "", err
+	}
 
 	if selector == nil {
 		selector = &metav1.LabelSelector{
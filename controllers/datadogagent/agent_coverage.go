@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/condition"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/health"
+)
+
+const (
+	// maxUncoveredNodesInCondition is the maximum number of nodes listed in the UncoveredNodes condition
+	maxUncoveredNodesInCondition = 10
+	taintReasonPrefix            = "taint "
+)
+
+// applyAutoTolerations returns a copy of the DatadogAgent tolerating the taints of the nodes matching the auto-tolerations patterns
+func (r *Reconciler) applyAutoTolerations(dda *datadoghqv1alpha1.DatadogAgent) (*datadoghqv1alpha1.DatadogAgent, error) {
+	if dda.Spec.Agent == nil || len(dda.Spec.Agent.Config.AutoTolerations) == 0 {
+		return dda, nil
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodeList); err != nil {
+		return dda, err
+	}
+	tolerations := getAutoTolerations(dda.Spec.Agent.Config.AutoTolerations, dda.Spec.Agent.Config.Tolerations, nodeList.Items)
+	if len(tolerations) == 0 {
+		return dda, nil
+	}
+	tolerated := dda.DeepCopy()
+	tolerated.Spec.Agent.Config.Tolerations = append(tolerated.Spec.Agent.Config.Tolerations, tolerations...)
+	return tolerated, nil
+}
+
+// getAutoTolerations returns the tolerations of the taints of the nodes matching one of the patterns and not tolerated yet,
+// sorted to keep the pod template stable
+func getAutoTolerations(patterns []datadoghqv1alpha1.TaintPattern, tolerations []corev1.Toleration, nodes []corev1.Node) []corev1.Toleration {
+	var autoTolerations []corev1.Toleration
+	for _, node := range nodes {
+		for i := range node.Spec.Taints {
+			taint := &node.Spec.Taints[i]
+			if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+				continue
+			}
+			matched, anyValue := matchTaintPatterns(patterns, taint)
+			if !matched {
+				continue
+			}
+			if health.GetUntoleratedTaint(append(append([]corev1.Toleration{}, tolerations...), autoTolerations...), []corev1.Taint{*taint}) == nil {
+				continue
+			}
+			// The patterns matching any value tolerate the key, so that the new values don't change the pod template
+			toleration := corev1.Toleration{Key: taint.Key, Operator: corev1.TolerationOpExists, Effect: taint.Effect}
+			if !anyValue && taint.Value != "" {
+				toleration.Operator = corev1.TolerationOpEqual
+				toleration.Value = taint.Value
+			}
+			autoTolerations = append(autoTolerations, toleration)
+		}
+	}
+	sort.Slice(autoTolerations, func(i, j int) bool {
+		if autoTolerations[i].Key != autoTolerations[j].Key {
+			return autoTolerations[i].Key < autoTolerations[j].Key
+		}
+		if autoTolerations[i].Value != autoTolerations[j].Value {
+			return autoTolerations[i].Value < autoTolerations[j].Value
+		}
+		return autoTolerations[i].Effect < autoTolerations[j].Effect
+	})
+	return autoTolerations
+}
+
+// matchTaintPatterns returns true if one of the patterns matches the taint,
+// and anyValue if one of the matching patterns doesn't restrict the value to a fixed string
+func matchTaintPatterns(patterns []datadoghqv1alpha1.TaintPattern, taint *corev1.Taint) (matched, anyValue bool) {
+	for _, pattern := range patterns {
+		if pattern.Effect != "" && pattern.Effect != taint.Effect {
+			continue
+		}
+		if ok, _ := path.Match(pattern.Key, taint.Key); !ok {
+			continue
+		}
+		if pattern.Value == "" || strings.ContainsAny(pattern.Value, `*?[\`) {
+			if ok, _ := path.Match(pattern.Value, taint.Value); ok || pattern.Value == "" {
+				return true, true
+			}
+			continue
+		}
+		if pattern.Value == taint.Value {
+			matched = true
+		}
+	}
+	return matched, false
+}
+
+// updateAgentCoverage counts the nodes where none of the Agent DaemonSets can run a pod,
+// and sets the UncoveredNodes condition listing them with the reason
+func (r *Reconciler) updateAgentCoverage(dda *datadoghqv1alpha1.DatadogAgent, variants []agentVariant, newStatus *datadoghqv1alpha1.DatadogAgentStatus) error {
+	now := metav1.NewTime(time.Now())
+	if dda.Spec.Agent == nil {
+		newStatus.AgentCoverage = nil
+		condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeUncoveredNodes, corev1.ConditionFalse, "The Agent is disabled", false)
+		return nil
+	}
+
+	podSpecs := make([]corev1.PodSpec, 0, len(variants))
+	for _, variant := range variants {
+		template, err := newAgentPodTemplate(variant.dda, nil)
+		if err != nil {
+			return err
+		}
+		if err = applyPodTemplatePatches(template, variant.dda.Spec.Agent.PodTemplatePatches); err != nil {
+			return err
+		}
+		setNodePlacement(template, variant.placement)
+		podSpecs = append(podSpecs, template.Spec)
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodeList); err != nil {
+		return err
+	}
+
+	coverage := &datadoghqv1alpha1.AgentCoverageStatus{}
+	var uncovered []string
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		reason := getUncoveredReason(node, podSpecs)
+		if reason == "" {
+			coverage.CoveredNodes++
+			continue
+		}
+		coverage.UncoveredNodes++
+		uncovered = append(uncovered, fmt.Sprintf("%s (%s)", node.Name, reason))
+	}
+	newStatus.AgentCoverage = coverage
+
+	if len(uncovered) == 0 {
+		condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeUncoveredNodes, corev1.ConditionFalse, "Every node can run an Agent pod", false)
+		return nil
+	}
+	sort.Strings(uncovered)
+	message := fmt.Sprintf("%d nodes can't run an Agent pod: %s", len(uncovered), strings.Join(truncateStrings(uncovered, maxUncoveredNodesInCondition), ", "))
+	condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeUncoveredNodes, corev1.ConditionTrue, message, false)
+	return nil
+}
+
+// getUncoveredReason returns why none of the pod specs can run on the node, empty if one of them can.
+// A taint is reported rather than a label mismatch, since the variants are placed on distinct nodes by their labels
+func getUncoveredReason(node *corev1.Node, podSpecs []corev1.PodSpec) string {
+	var uncoveredReason string
+	for i := range podSpecs {
+		reason := health.GetUncoveredReason(node, &podSpecs[i])
+		if reason == "" {
+			return ""
+		}
+		if uncoveredReason == "" || strings.HasPrefix(reason, taintReasonPrefix) {
+			uncoveredReason = reason
+		}
+	}
+	return uncoveredReason
+}
+
+// truncateStrings returns the first max values, followed by the number of the other ones
+func truncateStrings(values []string, max int) []string {
+	if len(values) <= max {
+		return values
+	}
+	return append(values[:max:max], fmt.Sprintf("and %d more", len(values)-max))
+}
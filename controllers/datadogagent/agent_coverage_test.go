@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+)
+
+func newCoverageTestNode(name, os string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelOSStable: os}},
+		Spec:       corev1.NodeSpec{Taints: taints},
+	}
+}
+
+func TestGetAutoTolerations(t *testing.T) {
+	nodes := []corev1.Node{
+		*newCoverageTestNode("gpu", linuxOS, corev1.Taint{Key: "dedicated.example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}),
+		*newCoverageTestNode("spot", linuxOS,
+			corev1.Taint{Key: "cloud.example.com/spot", Effect: corev1.TaintEffectNoExecute},
+			corev1.Taint{Key: "dedicated.example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			corev1.Taint{Key: "dedicated.example.com/ingress", Value: "true", Effect: corev1.TaintEffectPreferNoSchedule},
+		),
+		*newCoverageTestNode("db", linuxOS, corev1.Taint{Key: "dedicated.example.com/db", Value: "true", Effect: corev1.TaintEffectNoSchedule}),
+		*newCoverageTestNode("other", linuxOS, corev1.Taint{Key: "other", Value: "true", Effect: corev1.TaintEffectNoSchedule}),
+	}
+	patterns := []datadoghqv1alpha1.TaintPattern{
+		{Key: "dedicated.example.com/*"},
+		{Key: "cloud.example.com/spot", Effect: corev1.TaintEffectNoExecute},
+	}
+	tolerations := []corev1.Toleration{{Key: "dedicated.example.com/db", Operator: corev1.TolerationOpExists}}
+
+	// Without value pattern, any value of the key is tolerated
+	want := []corev1.Toleration{
+		{Key: "cloud.example.com/spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: "dedicated.example.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	assert.Equal(t, want, getAutoTolerations(patterns, tolerations, nodes))
+
+	// A new value of a tolerated key doesn't change the tolerations
+	nodes = append(nodes, *newCoverageTestNode("gpu-2", linuxOS, corev1.Taint{Key: "dedicated.example.com/gpu", Value: "a100", Effect: corev1.TaintEffectNoSchedule}))
+	assert.Equal(t, want, getAutoTolerations(patterns, tolerations, nodes))
+
+	// So does a value pattern with wildcards
+	patterns = []datadoghqv1alpha1.TaintPattern{{Key: "dedicated.example.com/gpu", Value: "*"}}
+	assert.Equal(t, want[1:], getAutoTolerations(patterns, nil, nodes))
+
+	// A fixed value is tolerated as is
+	patterns = []datadoghqv1alpha1.TaintPattern{{Key: "dedicated.example.com/*", Value: "true"}}
+	assert.Equal(t, []corev1.Toleration{
+		{Key: "dedicated.example.com/db", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "dedicated.example.com/gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule},
+	}, getAutoTolerations(patterns, nil, nodes))
+
+	// The value pattern restricts the tolerated taints
+	patterns = []datadoghqv1alpha1.TaintPattern{{Key: "dedicated.example.com/*", Value: "false"}}
+	assert.Empty(t, getAutoTolerations(patterns, nil, nodes))
+	patterns = []datadoghqv1alpha1.TaintPattern{{Key: "dedicated.example.com/*", Value: "f*"}}
+	assert.Empty(t, getAutoTolerations(patterns, nil, nodes))
+}
+
+func TestReconciler_updateAgentCoverage(t *testing.T) {
+	gpuTaint := corev1.Taint{Key: "dedicated.example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	objects := []runtime.Object{
+		newCoverageTestNode("linux", linuxOS),
+		newCoverageTestNode("cordoned", linuxOS, corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}),
+		newCoverageTestNode("gpu", linuxOS, gpuTaint),
+		newCoverageTestNode("windows", windowsOS),
+	}
+	r := newCertificatesTestReconciler(fake.NewFakeClient(objects...), ReconcilerOptions{})
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	status := &datadoghqv1alpha1.DatadogAgentStatus{}
+
+	assert.NoError(t, r.updateAgentCoverage(dda, []agentVariant{{dda: dda}}, status))
+	assert.Equal(t, &datadoghqv1alpha1.AgentCoverageStatus{CoveredNodes: 2, UncoveredNodes: 2}, status.AgentCoverage)
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, datadoghqv1alpha1.ConditionTypeUncoveredNodes, status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionTrue, status.Conditions[0].Status)
+	assert.Equal(t, "2 nodes can't run an Agent pod: gpu (taint dedicated.example.com/gpu=true:NoSchedule), windows (node selector)", status.Conditions[0].Message)
+
+	// The auto-tolerations and the Windows DaemonSet cover every node
+	dda.Spec.Agent.Config.AutoTolerations = []datadoghqv1alpha1.TaintPattern{{Key: "dedicated.example.com/*"}}
+	dda.Spec.Agent.Windows = &datadoghqv1alpha1.WindowsAgentSpec{Enabled: datadoghqv1alpha1.NewBoolPointer(true)}
+	tolerated, err := r.applyAutoTolerations(dda)
+	assert.NoError(t, err)
+	assert.Empty(t, dda.Spec.Agent.Config.Tolerations)
+	assert.NoError(t, r.updateAgentCoverage(tolerated, []agentVariant{{dda: tolerated}, newWindowsAgentVariant(tolerated)}, status))
+	assert.Equal(t, &datadoghqv1alpha1.AgentCoverageStatus{CoveredNodes: 4}, status.AgentCoverage)
+	assert.Equal(t, corev1.ConditionFalse, status.Conditions[0].Status)
+}
+
+func TestTruncateStrings(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, truncateStrings([]string{"a", "b"}, 2))
+	assert.Equal(t, []string{"a", "b", "and 2 more"}, truncateStrings([]string{"a", "b", "c", "d"}, 2))
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/health"
+)
+
+// updateAgentHealth summarizes the health of the Agent pods on the nodes in the status.
+// The pods aren't watched: they are listed from the API server, without cache, at every reconcile,
+// so the health is refreshed at least every defaultRequeuePeriod.
+func (r *Reconciler) updateAgentHealth(dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) error {
+	if dda.Spec.Agent == nil {
+		newStatus.AgentHealth = nil
+		return nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.apiReader.List(context.TODO(), podList, client.InNamespace(dda.Namespace), client.MatchingLabels{
+		datadoghqv1alpha1.AgentDeploymentNameLabelKey:      dda.Name,
+		datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultAgentResourceSuffix,
+	}); err != nil {
+		return err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodeList); err != nil {
+		return err
+	}
+
+	newStatus.AgentHealth = health.NewAgentHealthStatus(health.GetNodesHealth(nodeList.Items, podList.Items), health.MaxStatusNodes)
+	return nil
+}
@@ -0,0 +1,594 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	edsdatadoghqv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	"github.com/go-logr/logr"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/recommendation"
+)
+
+const (
+	defaultResourceRecommendationsWindow     = 24 * time.Hour
+	defaultResourceRecommendationsPercentile = 95
+
+	// resourceUsageSampleInterval is the minimum duration between two usage samples, the metrics server refreshes them every minute
+	resourceUsageSampleInterval = time.Minute
+	// resourceRecommendationMinSamples is the number of samples of a container needed to recommend its resources
+	resourceRecommendationMinSamples = 30
+	// resourceRecommendationTolerance is the relative change of a resource below which a recommendation isn't updated
+	resourceRecommendationTolerance = 0.1
+	// resourceRecommendationMinUpdateInterval is the minimum duration between two decreases of a recommendation,
+	// to limit the rollouts of the Agent in the Auto mode. The increases are applied right away to prevent OOMKills.
+	resourceRecommendationMinUpdateInterval = time.Hour
+	// requestsMargin is applied to the usage percentile recommended as requests
+	requestsMargin = 1.15
+	// memoryLimitMargin is applied to the highest memory usage recommended as limit
+	memoryLimitMargin = 1.3
+
+	mebibyte = 1024 * 1024
+
+	vpaUpdateModeAuto = "Auto"
+)
+
+var (
+	podMetricsListGVK            = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+	verticalPodAutoscalerGVK     = schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: verticalPodAutoscalerKind}
+	verticalPodAutoscalerListGVK = schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: verticalPodAutoscalerKind + "List"}
+)
+
+// The following types describe the subset of the VerticalPodAutoscaler spec used by the operator
+
+type vpaSpec struct {
+	TargetRef      autoscalingv1.CrossVersionObjectReference `json:"targetRef"`
+	UpdatePolicy   vpaUpdatePolicy                           `json:"updatePolicy"`
+	ResourcePolicy *vpaResourcePolicy                        `json:"resourcePolicy,omitempty"`
+}
+
+type vpaUpdatePolicy struct {
+	UpdateMode string `json:"updateMode"`
+}
+
+type vpaResourcePolicy struct {
+	ContainerPolicies []vpaContainerPolicy `json:"containerPolicies"`
+}
+
+type vpaContainerPolicy struct {
+	ContainerName string              `json:"containerName"`
+	MinAllowed    corev1.ResourceList `json:"minAllowed,omitempty"`
+	MaxAllowed    corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// containerUsage is a sample of the resource usage of an Agent container
+type containerUsage struct {
+	container string
+	// cpu is the CPU usage in cores
+	cpu float64
+	// memory is the memory usage in bytes
+	memory float64
+}
+
+// resourceUsage is the usage history of the Agent containers of a DatadogAgent
+type resourceUsage struct {
+	lastSample time.Time
+	windows    map[string]*recommendation.Window
+}
+
+// usageTracker records the resource usage of the Agent containers of each DatadogAgent.
+// The history is kept in memory: it is rebuilt when the operator restarts.
+type usageTracker struct {
+	mutex  sync.Mutex
+	usages map[types.NamespacedName]*resourceUsage
+}
+
+func (t *usageTracker) shouldSample(dda types.NamespacedName, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	usage, found := t.usages[dda]
+	return !found || now.Sub(usage.lastSample) >= resourceUsageSampleInterval
+}
+
+func (t *usageTracker) add(dda types.NamespacedName, now time.Time, window time.Duration, samples []containerUsage) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.usages == nil {
+		t.usages = map[types.NamespacedName]*resourceUsage{}
+	}
+	usage := t.usages[dda]
+	if usage == nil {
+		usage = &resourceUsage{windows: map[string]*recommendation.Window{}}
+		t.usages[dda] = usage
+	}
+	usage.lastSample = now
+	for _, sample := range samples {
+		w := usage.windows[sample.container]
+		if w == nil || w.Duration() != window {
+			w = recommendation.NewWindow(window)
+			usage.windows[sample.container] = w
+		}
+		w.Add(now, sample.cpu, sample.memory)
+	}
+}
+
+func (t *usageTracker) get(dda types.NamespacedName, now time.Time, percentile float64) map[string]recommendation.Usage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	usages := map[string]recommendation.Usage{}
+	if usage := t.usages[dda]; usage != nil {
+		for container, w := range usage.windows {
+			usages[container] = w.Usage(now, percentile)
+		}
+	}
+	return usages
+}
+
+func (t *usageTracker) delete(dda types.NamespacedName) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.usages, dda)
+}
+
+func isResourceRecommendationsEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	return dda.Spec.Agent != nil && dda.Spec.Agent.ResourceRecommendations != nil &&
+		datadoghqv1alpha1.BoolValue(dda.Spec.Agent.ResourceRecommendations.Enabled)
+}
+
+func getResourceRecommendationsMode(config *datadoghqv1alpha1.ResourceRecommendationsConfig) datadoghqv1alpha1.ResourceRecommendationsMode {
+	if config.Mode == "" {
+		return datadoghqv1alpha1.ResourceRecommendationsModeRecommend
+	}
+	return config.Mode
+}
+
+func getResourceRecommendationsWindow(config *datadoghqv1alpha1.ResourceRecommendationsConfig) time.Duration {
+	if config.Window != nil && config.Window.Duration > 0 {
+		return config.Window.Duration
+	}
+	return defaultResourceRecommendationsWindow
+}
+
+func getResourceRecommendationsPercentile(config *datadoghqv1alpha1.ResourceRecommendationsConfig) int32 {
+	if config.Percentile != nil {
+		return *config.Percentile
+	}
+	return defaultResourceRecommendationsPercentile
+}
+
+// updateResourceRecommendations samples the usage of the Agent containers from their PodMetrics,
+// and recommends their resources in the status
+func (r *Reconciler) updateResourceRecommendations(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) error {
+	ddaName := types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}
+	if !isResourceRecommendationsEnabled(dda) {
+		r.usage.delete(ddaName)
+		newStatus.ResourceRecommendations = nil
+		return nil
+	}
+	if !r.options.SupportMetricsAPI {
+		logger.V(1).Info("The metrics.k8s.io API isn't available, the resources of the Agent containers aren't recommended")
+		return nil
+	}
+
+	config := dda.Spec.Agent.ResourceRecommendations
+	now := time.Now()
+	if r.usage.shouldSample(ddaName, now) {
+		samples, err := r.getAgentContainersUsage(dda)
+		if err != nil {
+			// The metrics server can be temporarily unavailable, the sample is skipped
+			logger.Error(err, "Unable to get the resource usage of the Agent containers")
+		} else {
+			r.usage.add(ddaName, now, getResourceRecommendationsWindow(config), samples)
+		}
+	}
+
+	containers, err := getAgentContainerNames(dda)
+	if err != nil {
+		return err
+	}
+	usages := r.usage.get(ddaName, now, float64(getResourceRecommendationsPercentile(config)))
+	newStatus.ResourceRecommendations = getResourceRecommendations(config, containers, usages, newStatus.ResourceRecommendations, metav1.NewTime(now))
+	return nil
+}
+
+// getAgentContainerNames returns the names of the containers of the Agent pod template
+func getAgentContainerNames(dda *datadoghqv1alpha1.DatadogAgent) (map[string]bool, error) {
+	template, err := newAgentPodTemplate(dda, nil)
+	if err != nil {
+		return nil, err
+	}
+	containers := make(map[string]bool, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		containers[container.Name] = true
+	}
+	return containers, nil
+}
+
+// getAgentContainersUsage returns the usage of the Agent containers reported by the PodMetrics of the Agent pods
+func (r *Reconciler) getAgentContainersUsage(dda *datadoghqv1alpha1.DatadogAgent) ([]containerUsage, error) {
+	podMetricsList := &unstructured.UnstructuredList{}
+	podMetricsList.SetGroupVersionKind(podMetricsListGVK)
+	if err := r.client.List(context.TODO(), podMetricsList, client.InNamespace(dda.Namespace), client.MatchingLabels{
+		datadoghqv1alpha1.AgentDeploymentNameLabelKey:      dda.Name,
+		datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultAgentResourceSuffix,
+	}); err != nil {
+		return nil, err
+	}
+	return getContainersUsage(podMetricsList.Items), nil
+}
+
+// getContainersUsage extracts the usage of each container of the PodMetrics
+func getContainersUsage(podMetrics []unstructured.Unstructured) []containerUsage {
+	var samples []containerUsage
+	for _, metrics := range podMetrics {
+		containers, _, _ := unstructured.NestedSlice(metrics.Object, "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			usage, _, _ := unstructured.NestedStringMap(container, "usage")
+			cpu, err := resource.ParseQuantity(usage[string(corev1.ResourceCPU)])
+			if err != nil {
+				continue
+			}
+			memory, err := resource.ParseQuantity(usage[string(corev1.ResourceMemory)])
+			if err != nil {
+				continue
+			}
+			samples = append(samples, containerUsage{
+				container: name,
+				cpu:       float64(cpu.MilliValue()) / 1000,
+				memory:    float64(memory.Value()),
+			})
+		}
+	}
+	return samples
+}
+
+// getResourceRecommendations returns the recommendations of the containers with enough usage samples.
+// The current recommendations are kept while they are close to the usage, and while the history is rebuilt.
+// Only the containers of the Agent pod template are recommended: the ones that were removed are dropped.
+func getResourceRecommendations(config *datadoghqv1alpha1.ResourceRecommendationsConfig, containers map[string]bool, usages map[string]recommendation.Usage,
+	current []datadoghqv1alpha1.ContainerResourceRecommendation, now metav1.Time) []datadoghqv1alpha1.ContainerResourceRecommendation {
+	currentByContainer := map[string]datadoghqv1alpha1.ContainerResourceRecommendation{}
+	for _, rec := range current {
+		if containers[rec.Container] {
+			currentByContainer[rec.Container] = rec
+		}
+	}
+
+	var recommendations []datadoghqv1alpha1.ContainerResourceRecommendation
+	for container, usage := range usages {
+		if !containers[container] {
+			continue
+		}
+		currentRec, found := currentByContainer[container]
+		delete(currentByContainer, container)
+		if usage.Samples < resourceRecommendationMinSamples {
+			if found {
+				recommendations = append(recommendations, currentRec)
+			}
+			continue
+		}
+		rec := newResourceRecommendation(config, container, usage, now)
+		if found && !shouldUpdateResourceRecommendation(currentRec, rec, now.Time) {
+			rec = currentRec
+		}
+		recommendations = append(recommendations, rec)
+	}
+	for _, rec := range currentByContainer {
+		recommendations = append(recommendations, rec)
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].Container < recommendations[j].Container
+	})
+	return recommendations
+}
+
+// newResourceRecommendation recommends the usage percentile as requests and the highest memory usage as memory limit,
+// with a margin, within the allowed bounds
+func newResourceRecommendation(config *datadoghqv1alpha1.ResourceRecommendationsConfig, container string, usage recommendation.Usage, now metav1.Time) datadoghqv1alpha1.ContainerResourceRecommendation {
+	cpuMillis := int64(math.Ceil(usage.CPU * requestsMargin * 1000))
+	if cpuMillis < 1 {
+		cpuMillis = 1
+	}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuMillis, resource.DecimalSI),
+		corev1.ResourceMemory: newMemoryQuantity(usage.Memory * requestsMargin),
+	}
+	limits := corev1.ResourceList{
+		corev1.ResourceMemory: newMemoryQuantity(usage.MaxMemory * memoryLimitMargin),
+	}
+	boundResources(requests, config)
+	boundResources(limits, config)
+	if requests.Memory().Cmp(*limits.Memory()) > 0 {
+		limits[corev1.ResourceMemory] = requests.Memory().DeepCopy()
+	}
+
+	return datadoghqv1alpha1.ContainerResourceRecommendation{
+		Container:      container,
+		Requests:       requests,
+		Limits:         limits,
+		LastUpdateTime: now,
+	}
+}
+
+// newMemoryQuantity rounds the bytes up to the next mebibyte
+func newMemoryQuantity(bytes float64) resource.Quantity {
+	mebibytes := int64(math.Ceil(bytes / mebibyte))
+	if mebibytes < 1 {
+		mebibytes = 1
+	}
+	return *resource.NewQuantity(mebibytes*mebibyte, resource.BinarySI)
+}
+
+// boundResources raises the resources to the minimum allowed and lowers them to the maximum allowed
+func boundResources(resources corev1.ResourceList, config *datadoghqv1alpha1.ResourceRecommendationsConfig) {
+	for name, quantity := range resources {
+		if min, found := config.MinAllowed[name]; found && quantity.Cmp(min) < 0 {
+			quantity = min.DeepCopy()
+		}
+		if max, found := config.MaxAllowed[name]; found && quantity.Cmp(max) > 0 {
+			quantity = max.DeepCopy()
+		}
+		resources[name] = quantity
+	}
+}
+
+// shouldUpdateResourceRecommendation returns true when a resource changed by more than the tolerance,
+// right away when it increased and after the minimum update interval when it decreased
+func shouldUpdateResourceRecommendation(current, next datadoghqv1alpha1.ContainerResourceRecommendation, now time.Time) bool {
+	changed, increased := false, false
+	compare := func(currentResources, nextResources corev1.ResourceList) {
+		for name, quantity := range nextResources {
+			currentQuantity, found := currentResources[name]
+			if !found || currentQuantity.IsZero() {
+				changed, increased = true, true
+				continue
+			}
+			ratio := float64(quantity.MilliValue()) / float64(currentQuantity.MilliValue())
+			if math.Abs(ratio-1) > resourceRecommendationTolerance {
+				changed = true
+				increased = increased || ratio > 1
+			}
+		}
+	}
+	compare(current.Requests, next.Requests)
+	compare(current.Limits, next.Limits)
+
+	if !changed {
+		return false
+	}
+	return increased || now.Sub(current.LastUpdateTime.Time) >= resourceRecommendationMinUpdateInterval
+}
+
+// applyResourceRecommendations returns a copy of the DatadogAgent whose Agent containers use the resources
+// recommended in the status, in the Auto mode. The DaemonSet update strategy controls the rollout of the changes.
+func applyResourceRecommendations(dda *datadoghqv1alpha1.DatadogAgent) *datadoghqv1alpha1.DatadogAgent {
+	if !isResourceRecommendationsEnabled(dda) || len(dda.Status.ResourceRecommendations) == 0 ||
+		getResourceRecommendationsMode(dda.Spec.Agent.ResourceRecommendations) != datadoghqv1alpha1.ResourceRecommendationsModeAuto {
+		return dda
+	}
+
+	applied := dda.DeepCopy()
+	agent := applied.Spec.Agent
+	for _, rec := range applied.Status.ResourceRecommendations {
+		switch rec.Container {
+		case "agent":
+			agent.Config.Resources = applyResourceRecommendation(agent.Config.Resources, rec)
+		case "trace-agent":
+			agent.Apm.Resources = applyResourceRecommendation(agent.Apm.Resources, rec)
+		case "process-agent":
+			agent.Process.Resources = applyResourceRecommendation(agent.Process.Resources, rec)
+		case "system-probe":
+			agent.SystemProbe.Resources = applyResourceRecommendation(agent.SystemProbe.Resources, rec)
+		case "security-agent":
+			agent.Security.Resources = applyResourceRecommendation(agent.Security.Resources, rec)
+		}
+	}
+	return applied
+}
+
+// applyResourceRecommendation sets the recommended resources of a container.
+// The CPU limit isn't recommended: it is raised to the CPU request when it is lower.
+func applyResourceRecommendation(resources *corev1.ResourceRequirements, rec datadoghqv1alpha1.ContainerResourceRecommendation) *corev1.ResourceRequirements {
+	applied := &corev1.ResourceRequirements{}
+	if resources != nil {
+		applied = resources.DeepCopy()
+	}
+	if applied.Requests == nil {
+		applied.Requests = corev1.ResourceList{}
+	}
+	if applied.Limits == nil {
+		applied.Limits = corev1.ResourceList{}
+	}
+	for name, quantity := range rec.Requests {
+		applied.Requests[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range rec.Limits {
+		applied.Limits[name] = quantity.DeepCopy()
+	}
+	for name, request := range applied.Requests {
+		if limit, found := applied.Limits[name]; found && limit.Cmp(request) < 0 {
+			applied.Limits[name] = request.DeepCopy()
+		}
+	}
+	return applied
+}
+
+// reconcileAgentVPAs creates a VerticalPodAutoscaler for each Agent DaemonSet in the VPA mode,
+// and deletes the ones that aren't needed anymore
+func (r *Reconciler) reconcileAgentVPAs(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, variants []agentVariant) (reconcile.Result, error) {
+	var newVPAs []*unstructured.Unstructured
+	if isResourceRecommendationsEnabled(dda) &&
+		getResourceRecommendationsMode(dda.Spec.Agent.ResourceRecommendations) == datadoghqv1alpha1.ResourceRecommendationsModeVPA {
+		if !r.options.SupportVPA {
+			return reconcile.Result{}, fmt.Errorf("unable to create the %ss of the Agent: the autoscaling.k8s.io/v1 API isn't available", verticalPodAutoscalerKind)
+		}
+		for _, variant := range variants {
+			useEDS := r.options.SupportExtendedDaemonset && datadoghqv1alpha1.BoolValue(variant.dda.Spec.Agent.UseExtendedDaemonset)
+			newVPAs = append(newVPAs, newAgentVPA(dda, daemonsetName(variant.dda), useEDS))
+		}
+	}
+	if !r.options.SupportVPA {
+		return reconcile.Result{}, nil
+	}
+
+	vpaList := &unstructured.UnstructuredList{}
+	vpaList.SetGroupVersionKind(verticalPodAutoscalerListGVK)
+	if err := r.client.List(context.TODO(), vpaList, client.InNamespace(dda.Namespace), client.MatchingLabels{
+		datadoghqv1alpha1.AgentDeploymentNameLabelKey:      dda.Name,
+		datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultAgentResourceSuffix,
+	}); err != nil {
+		if meta.IsNoMatchError(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	vpas := map[string]*unstructured.Unstructured{}
+	for i := range vpaList.Items {
+		vpa := &vpaList.Items[i]
+		if ownedByDatadogOperator(vpa.GetOwnerReferences()) {
+			vpas[vpa.GetName()] = vpa
+		}
+	}
+
+	for _, newVPA := range newVPAs {
+		vpa, found := vpas[newVPA.GetName()]
+		delete(vpas, newVPA.GetName())
+		var err error
+		if found {
+			err = r.updateVPA(logger, dda, vpa, newVPA)
+		} else {
+			err = r.createVPA(logger, dda, newVPA)
+		}
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	for _, vpa := range vpas {
+		if err := r.deleteVPA(logger, dda, vpa); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) createVPA(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, vpa *unstructured.Unstructured) error {
+	if err := controllerutil.SetControllerReference(dda, vpa, r.scheme); err != nil {
+		return err
+	}
+
+	logger.V(1).Info("createVerticalPodAutoscaler", "verticalPodAutoscaler.name", vpa.GetName(), "verticalPodAutoscaler.Namespace", vpa.GetNamespace())
+	event := buildEventInfo(vpa.GetName(), vpa.GetNamespace(), verticalPodAutoscalerKind, datadog.CreationEvent)
+	r.recordEvent(dda, event)
+
+	return r.client.Create(context.TODO(), vpa)
+}
+
+func (r *Reconciler) updateVPA(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, vpa, newVPA *unstructured.Unstructured) error {
+	hash := newVPA.GetAnnotations()[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
+	if comparison.IsSameSpecMD5Hash(hash, vpa.GetAnnotations()) {
+		return nil
+	}
+
+	updated := vpa.DeepCopy()
+	updated.SetLabels(newVPA.GetLabels())
+	updated.SetAnnotations(newVPA.GetAnnotations())
+	updated.Object["spec"] = newVPA.Object["spec"]
+
+	logger.V(1).Info("updateVerticalPodAutoscaler", "verticalPodAutoscaler.name", vpa.GetName(), "verticalPodAutoscaler.Namespace", vpa.GetNamespace())
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return err
+	}
+
+	event := buildEventInfo(vpa.GetName(), vpa.GetNamespace(), verticalPodAutoscalerKind, datadog.UpdateEvent)
+	r.recordEvent(dda, event)
+	return nil
+}
+
+func (r *Reconciler) deleteVPA(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, vpa *unstructured.Unstructured) error {
+	logger.V(1).Info("deleteVerticalPodAutoscaler", "verticalPodAutoscaler.name", vpa.GetName(), "verticalPodAutoscaler.Namespace", vpa.GetNamespace())
+	event := buildEventInfo(vpa.GetName(), vpa.GetNamespace(), verticalPodAutoscalerKind, datadog.DeletionEvent)
+	r.recordEvent(dda, event)
+
+	return r.client.Delete(context.TODO(), vpa)
+}
+
+// newAgentVPA returns the VerticalPodAutoscaler of an Agent DaemonSet or ExtendedDaemonSet,
+// bounded by the allowed resources of the recommendations
+func newAgentVPA(dda *datadoghqv1alpha1.DatadogAgent, name string, useEDS bool) *unstructured.Unstructured {
+	config := dda.Spec.Agent.ResourceRecommendations
+	spec := vpaSpec{
+		TargetRef: autoscalingv1.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       daemonSetKind,
+			Name:       name,
+		},
+		UpdatePolicy: vpaUpdatePolicy{UpdateMode: vpaUpdateModeAuto},
+	}
+	if useEDS {
+		spec.TargetRef.APIVersion = edsdatadoghqv1alpha1.GroupVersion.String()
+		spec.TargetRef.Kind = extendedDaemonSetKind
+	}
+	if len(config.MinAllowed) > 0 || len(config.MaxAllowed) > 0 {
+		spec.ResourcePolicy = &vpaResourcePolicy{
+			ContainerPolicies: []vpaContainerPolicy{
+				{
+					ContainerName: "*",
+					MinAllowed:    config.MinAllowed,
+					MaxAllowed:    config.MaxAllowed,
+				},
+			},
+		}
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		// The spec only contains serializable fields
+		content = map[string]interface{}{}
+	}
+
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content}}
+	vpa.SetGroupVersionKind(verticalPodAutoscalerGVK)
+	vpa.SetName(name)
+	vpa.SetNamespace(dda.Namespace)
+	labels := getDefaultLabels(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, getAgentVersion(dda))
+	labels[datadoghqv1alpha1.AgentDeploymentNameLabelKey] = dda.Name
+	labels[datadoghqv1alpha1.AgentDeploymentComponentLabelKey] = datadoghqv1alpha1.DefaultAgentResourceSuffix
+	vpa.SetLabels(labels)
+
+	annotations := getDefaultAnnotations(dda)
+	if hash, err := comparison.GenerateMD5ForSpec(content); err == nil {
+		annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey] = hash
+	}
+	vpa.SetAnnotations(annotations)
+	return vpa
+}
@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/recommendation"
+)
+
+func TestGetContainersUsage(t *testing.T) {
+	podMetrics := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "agent", "usage": map[string]interface{}{"cpu": "250m", "memory": "256Mi"}},
+				map[string]interface{}{"name": "trace-agent", "usage": map[string]interface{}{"cpu": "1234567n", "memory": "64Mi"}},
+				map[string]interface{}{"name": "invalid", "usage": map[string]interface{}{"cpu": "foo", "memory": "64Mi"}},
+			},
+		}},
+	}
+
+	assert.Equal(t, []containerUsage{
+		{container: "agent", cpu: 0.25, memory: 256 * mebibyte},
+		{container: "trace-agent", cpu: 0.002, memory: 64 * mebibyte},
+	}, getContainersUsage(podMetrics))
+}
+
+func TestGetResourceRecommendations(t *testing.T) {
+	config := &datadoghqv1alpha1.ResourceRecommendationsConfig{
+		MaxAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	now := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	containers := map[string]bool{"agent": true, "trace-agent": true, "process-agent": true}
+	usages := map[string]recommendation.Usage{
+		"agent":       {CPU: 0.2, Memory: 200 * mebibyte, MaxMemory: 300 * mebibyte, Samples: 60},
+		"trace-agent": {CPU: 0.1, Memory: 1000 * mebibyte, MaxMemory: 2000 * mebibyte, Samples: 60},
+		// Not enough samples to recommend the resources of the Process Agent
+		"process-agent": {CPU: 0.1, Memory: 100 * mebibyte, MaxMemory: 100 * mebibyte, Samples: 10},
+	}
+
+	recommendations := getResourceRecommendations(config, containers, usages, nil, now)
+	assert.Equal(t, []datadoghqv1alpha1.ContainerResourceRecommendation{
+		{
+			Container:      "agent",
+			Requests:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("230m"), corev1.ResourceMemory: resource.MustParse("230Mi")},
+			Limits:         corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("390Mi")},
+			LastUpdateTime: now,
+		},
+		{
+			// The memory is bounded by the maximum allowed
+			Container:      "trace-agent",
+			Requests:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("115m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			Limits:         corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			LastUpdateTime: now,
+		},
+	}, normalizeRecommendations(recommendations))
+
+	// The small changes don't update the recommendations
+	later := metav1.NewTime(now.Add(2 * time.Hour))
+	usages["agent"] = recommendation.Usage{CPU: 0.21, Memory: 200 * mebibyte, MaxMemory: 300 * mebibyte, Samples: 60}
+	assert.Equal(t, recommendations, getResourceRecommendations(config, containers, usages, recommendations, later))
+
+	// The recommendations are kept while the usage history is rebuilt
+	assert.Equal(t, recommendations, getResourceRecommendations(config, containers, map[string]recommendation.Usage{}, recommendations, later))
+
+	// An increase is recommended right away
+	soon := metav1.NewTime(now.Add(time.Minute))
+	usages["agent"] = recommendation.Usage{CPU: 0.2, Memory: 200 * mebibyte, MaxMemory: 400 * mebibyte, Samples: 60}
+	updated := normalizeRecommendations(getResourceRecommendations(config, containers, usages, recommendations, soon))
+	assert.Equal(t, resource.MustParse("520Mi"), updated[0].Limits[corev1.ResourceMemory])
+	assert.Equal(t, soon, updated[0].LastUpdateTime)
+
+	// A decrease waits for the minimum update interval
+	usages["agent"] = recommendation.Usage{CPU: 0.1, Memory: 200 * mebibyte, MaxMemory: 300 * mebibyte, Samples: 60}
+	assert.Equal(t, recommendations, getResourceRecommendations(config, containers, usages, recommendations, soon))
+	updated = normalizeRecommendations(getResourceRecommendations(config, containers, usages, recommendations, later))
+	assert.Equal(t, resource.MustParse("115m"), updated[0].Requests[corev1.ResourceCPU])
+	assert.Equal(t, later, updated[0].LastUpdateTime)
+
+	// The recommendations of the containers removed from the pod template are dropped
+	containers = map[string]bool{"agent": true}
+	updated = getResourceRecommendations(config, containers, usages, recommendations, soon)
+	assert.Len(t, updated, 1)
+	assert.Equal(t, "agent", updated[0].Container)
+	updated = getResourceRecommendations(config, containers, map[string]recommendation.Usage{}, recommendations, soon)
+	assert.Equal(t, recommendations[:1], updated)
+}
+
+// normalizeRecommendations formats the quantities like the ones parsed from the status, to compare them
+func normalizeRecommendations(recommendations []datadoghqv1alpha1.ContainerResourceRecommendation) []datadoghqv1alpha1.ContainerResourceRecommendation {
+	normalize := func(resources corev1.ResourceList) {
+		for name, quantity := range resources {
+			resources[name] = resource.MustParse(quantity.String())
+		}
+	}
+	for _, rec := range recommendations {
+		normalize(rec.Requests)
+		normalize(rec.Limits)
+	}
+	return recommendations
+}
+
+func TestApplyResourceRecommendations(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	dda.Spec.Agent.Config.Resources = &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+	}
+	dda.Status.ResourceRecommendations = []datadoghqv1alpha1.ContainerResourceRecommendation{
+		{
+			Container: "agent",
+			Requests:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m"), corev1.ResourceMemory: resource.MustParse("200Mi")},
+			Limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("400Mi")},
+		},
+		{
+			Container: "trace-agent",
+			Requests:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("50Mi")},
+			Limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+		},
+	}
+
+	// The recommendations are only applied in the Auto mode
+	dda.Spec.Agent.ResourceRecommendations = &datadoghqv1alpha1.ResourceRecommendationsConfig{Enabled: datadoghqv1alpha1.NewBoolPointer(true)}
+	assert.Equal(t, dda, applyResourceRecommendations(dda))
+
+	dda.Spec.Agent.ResourceRecommendations.Mode = datadoghqv1alpha1.ResourceRecommendationsModeAuto
+	applied := applyResourceRecommendations(dda)
+	assert.Equal(t, resource.MustParse("200m"), dda.Spec.Agent.Config.Resources.Limits[corev1.ResourceCPU])
+	// The CPU limit is raised to the recommended request
+	assert.Equal(t, &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m"), corev1.ResourceMemory: resource.MustParse("200Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m"), corev1.ResourceMemory: resource.MustParse("400Mi")},
+	}, applied.Spec.Agent.Config.Resources)
+	assert.Equal(t, &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("50Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+	}, applied.Spec.Agent.Apm.Resources)
+}
+
+func TestReconciler_reconcileAgentVPAs(t *testing.T) {
+	logger := logf.Log.WithName("TestReconciler_reconcileAgentVPAs")
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	dda.Spec.Agent.ResourceRecommendations = &datadoghqv1alpha1.ResourceRecommendationsConfig{
+		Enabled:    datadoghqv1alpha1.NewBoolPointer(true),
+		Mode:       datadoghqv1alpha1.ResourceRecommendationsModeVPA,
+		MaxAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	c := fake.NewFakeClient()
+	vpaKey := types.NamespacedName{Namespace: "bar", Name: "foo-agent"}
+
+	// The VPA mode requires the VerticalPodAutoscaler API
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	_, err := r.reconcileAgentVPAs(logger, dda, []agentVariant{{dda: dda}})
+	assert.Error(t, err)
+
+	r = newCertificatesTestReconciler(c, ReconcilerOptions{SupportVPA: true})
+	vpa := newAgentVPA(dda, daemonsetName(dda), false)
+	assert.NoError(t, r.createVPA(logger, dda, vpa))
+	vpa = &unstructured.Unstructured{}
+	vpa.SetGroupVersionKind(verticalPodAutoscalerGVK)
+	assert.NoError(t, c.Get(context.TODO(), vpaKey, vpa))
+	assert.True(t, ownedByDatadogOperator(vpa.GetOwnerReferences()))
+	targetRef, _, _ := unstructured.NestedStringMap(vpa.Object, "spec", "targetRef")
+	assert.Equal(t, map[string]string{"apiVersion": "apps/v1", "kind": "DaemonSet", "name": "foo-agent"}, targetRef)
+	policies, _, _ := unstructured.NestedSlice(vpa.Object, "spec", "resourcePolicy", "containerPolicies")
+	assert.Equal(t, []interface{}{map[string]interface{}{"containerName": "*", "maxAllowed": map[string]interface{}{"memory": "1Gi"}}}, policies)
+
+	// The VerticalPodAutoscaler follows the spec
+	dda.Spec.Agent.ResourceRecommendations.MaxAllowed = nil
+	assert.NoError(t, r.updateVPA(logger, dda, vpa, newAgentVPA(dda, daemonsetName(dda), true)))
+	assert.NoError(t, c.Get(context.TODO(), vpaKey, vpa))
+	targetRef, _, _ = unstructured.NestedStringMap(vpa.Object, "spec", "targetRef")
+	assert.Equal(t, "ExtendedDaemonSet", targetRef["kind"])
+	_, found, _ := unstructured.NestedFieldNoCopy(vpa.Object, "spec", "resourcePolicy")
+	assert.False(t, found)
+
+	assert.NoError(t, r.deleteVPA(logger, dda, vpa))
+	err = c.Get(context.TODO(), vpaKey, vpa)
+	assert.True(t, apierrors.IsNotFound(err))
+}
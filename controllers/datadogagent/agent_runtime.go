@@ -0,0 +1,395 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	edsdatadoghqv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+)
+
+const (
+	containerRuntimeDocker     = "docker"
+	containerRuntimeContainerd = "containerd"
+	containerRuntimeCRIO       = "cri-o"
+	containerRuntimeK3s        = "k3s"
+
+	dockerSocketPath        = "/var/run/docker.sock"
+	containerdSocketPath    = "/var/run/containerd/containerd.sock"
+	crioSocketPath          = "/var/run/crio/crio.sock"
+	k3sContainerdSocketPath = "/run/k3s/containerd/containerd.sock"
+)
+
+// supportedContainerRuntimes are the container runtimes whose socket is known
+var supportedContainerRuntimes = []string{containerRuntimeDocker, containerRuntimeContainerd, containerRuntimeCRIO, containerRuntimeK3s}
+
+// agentVariant describes an Agent DaemonSet, and the nodes it runs on when the nodes run several container runtimes
+type agentVariant struct {
+	// dda is the DatadogAgent configured with the name of the DaemonSet and the socket of its runtime
+	dda *datadoghqv1alpha1.DatadogAgent
+	// placement restricts the pods to the nodes running the runtime of the variant, nil if the pods run on every node
+	placement *corev1.NodeSelectorRequirement
+}
+
+// getContainerRuntime returns the container runtime of a node from its status, e.g. "containerd" for "containerd://1.4.3"
+func getContainerRuntime(node *corev1.Node) string {
+	version := node.Status.NodeInfo.ContainerRuntimeVersion
+	runtime := strings.SplitN(version, "://", 2)[0]
+	if runtime == containerRuntimeContainerd && strings.Contains(version, "k3s") {
+		// k3s embeds its own containerd, with its own socket
+		return containerRuntimeK3s
+	}
+	return runtime
+}
+
+// getContainerRuntimeSocket returns the socket configuration of a container runtime, nil if the runtime isn't supported
+func getContainerRuntimeSocket(runtime string) *datadoghqv1alpha1.CRISocketConfig {
+	switch runtime {
+	case containerRuntimeDocker:
+		return &datadoghqv1alpha1.CRISocketConfig{DockerSocketPath: datadoghqv1alpha1.NewStringPointer(dockerSocketPath)}
+	case containerRuntimeContainerd:
+		return &datadoghqv1alpha1.CRISocketConfig{CriSocketPath: datadoghqv1alpha1.NewStringPointer(containerdSocketPath)}
+	case containerRuntimeCRIO:
+		return &datadoghqv1alpha1.CRISocketConfig{CriSocketPath: datadoghqv1alpha1.NewStringPointer(crioSocketPath)}
+	case containerRuntimeK3s:
+		return &datadoghqv1alpha1.CRISocketConfig{CriSocketPath: datadoghqv1alpha1.NewStringPointer(k3sContainerdSocketPath)}
+	}
+	return nil
+}
+
+// getCRISocketPath returns the path of the socket used by the Agent, the CRI socket taking precedence like in its env vars
+func getCRISocketPath(config *datadoghqv1alpha1.CRISocketConfig) string {
+	switch {
+	case config == nil:
+		return ""
+	case config.CriSocketPath != nil:
+		return *config.CriSocketPath
+	case config.DockerSocketPath != nil:
+		return *config.DockerSocketPath
+	}
+	return ""
+}
+
+func isContainerRuntimeDetectionEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	if dda.Spec.Agent == nil || dda.Spec.Agent.Config.CriSocket == nil {
+		return false
+	}
+	return datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Config.CriSocket.AutoDetect)
+}
+
+func getAgentVariantName(dda *datadoghqv1alpha1.DatadogAgent, runtime string) string {
+	return fmt.Sprintf("%s-%s", daemonsetName(dda), runtime)
+}
+
+func newAgentVariant(dda *datadoghqv1alpha1.DatadogAgent, name, runtime string) *datadoghqv1alpha1.DatadogAgent {
+	variant := dda.DeepCopy()
+	variant.Spec.Agent.DaemonsetName = name
+	variant.Spec.Agent.Config.CriSocket = getContainerRuntimeSocket(runtime)
+	return variant
+}
+
+// getAgentVariants detects the container runtimes of the nodes and returns the Agent DaemonSets to deploy, the first one
+// being the main DaemonSet. The main DaemonSet uses the socket of the most common runtime and runs on the nodes of this runtime
+// and of the runtimes that aren't supported; each other supported runtime gets its own DaemonSet, running on the nodes
+// labelled with it.
+func (r *Reconciler) getAgentVariants(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) ([]agentVariant, error) {
+	main := agentVariant{dda: dda}
+	if !isContainerRuntimeDetectionEnabled(dda) {
+		newStatus.ContainerRuntimes = nil
+		return []agentVariant{main}, nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodeList); err != nil {
+		return nil, err
+	}
+	nodesByRuntime := map[string][]*corev1.Node{}
+	var runtimes []string
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if node.Labels[corev1.LabelOSStable] == windowsOS {
+			// The Windows nodes are run by the Windows Agent DaemonSet
+			continue
+		}
+		runtime := getContainerRuntime(node)
+		if runtime == "" {
+			// The runtime isn't reported until the kubelet is ready
+			continue
+		}
+		if _, found := nodesByRuntime[runtime]; !found {
+			runtimes = append(runtimes, runtime)
+		}
+		nodesByRuntime[runtime] = append(nodesByRuntime[runtime], node)
+	}
+	sort.Strings(runtimes)
+
+	mainRuntime := ""
+	for _, runtime := range runtimes {
+		if getContainerRuntimeSocket(runtime) == nil {
+			continue
+		}
+		if mainRuntime == "" || len(nodesByRuntime[runtime]) > len(nodesByRuntime[mainRuntime]) {
+			mainRuntime = runtime
+		}
+	}
+	if mainRuntime != "" {
+		main.dda = newAgentVariant(dda, daemonsetName(dda), mainRuntime)
+	}
+
+	variants := []agentVariant{main}
+	var otherRuntimes []string
+	for _, runtime := range runtimes {
+		if runtime == mainRuntime || getContainerRuntimeSocket(runtime) == nil {
+			continue
+		}
+		otherRuntimes = append(otherRuntimes, runtime)
+		variants = append(variants, agentVariant{
+			dda: newAgentVariant(dda, getAgentVariantName(dda, runtime), runtime),
+			placement: &corev1.NodeSelectorRequirement{
+				Key:      datadoghqv1alpha1.ContainerRuntimeLabelKey,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{runtime},
+			},
+		})
+	}
+
+	if len(otherRuntimes) > 0 {
+		// The nodes that aren't labelled yet, e.g. the nodes that just joined the cluster, are run by the main DaemonSet
+		// until they are labelled
+		variants[0].placement = &corev1.NodeSelectorRequirement{
+			Key:      datadoghqv1alpha1.ContainerRuntimeLabelKey,
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   otherRuntimes,
+		}
+		for _, runtime := range runtimes {
+			label := runtime
+			if getContainerRuntimeSocket(runtime) == nil {
+				label = ""
+			}
+			for _, node := range nodesByRuntime[runtime] {
+				if err := r.setNodeContainerRuntimeLabel(logger, node, label); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	newStatus.ContainerRuntimes = make([]datadoghqv1alpha1.ContainerRuntimeStatus, 0, len(runtimes))
+	for _, runtime := range runtimes {
+		variant := variants[0]
+		for _, v := range variants[1:] {
+			if v.placement.Values[0] == runtime {
+				variant = v
+			}
+		}
+		newStatus.ContainerRuntimes = append(newStatus.ContainerRuntimes, datadoghqv1alpha1.ContainerRuntimeStatus{
+			Runtime:       runtime,
+			Nodes:         int32(len(nodesByRuntime[runtime])),
+			SocketPath:    getCRISocketPath(variant.dda.Spec.Agent.Config.CriSocket),
+			DaemonsetName: daemonsetName(variant.dda),
+		})
+	}
+
+	return variants, nil
+}
+
+// setNodeContainerRuntimeLabel sets the container runtime label of a node, or removes it if label is empty
+func (r *Reconciler) setNodeContainerRuntimeLabel(logger logr.Logger, node *corev1.Node, label string) error {
+	if node.Labels[datadoghqv1alpha1.ContainerRuntimeLabelKey] == label {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	if label == "" {
+		delete(node.Labels, datadoghqv1alpha1.ContainerRuntimeLabelKey)
+	} else {
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		node.Labels[datadoghqv1alpha1.ContainerRuntimeLabelKey] = label
+	}
+	logger.Info("Labelling Node with its container runtime", "node.Name", node.Name, "runtime", label)
+	return r.client.Patch(context.TODO(), node, patch)
+}
+
+// setNodePlacement restricts the pods of the template to the nodes matching the requirement
+func setNodePlacement(template *corev1.PodTemplateSpec, placement *corev1.NodeSelectorRequirement) {
+	if placement == nil {
+		return
+	}
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	if template.Spec.Affinity.NodeAffinity == nil {
+		template.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := template.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		terms = []corev1.NodeSelectorTerm{{}}
+	}
+	// The terms are ORed, the requirement is added to each of them
+	for i := range terms {
+		terms[i].MatchExpressions = append(terms[i].MatchExpressions, *placement.DeepCopy())
+	}
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+}
+
+// reconcileAgentVariants creates or updates the DaemonSets of the additional variants, and deletes the ones that aren't needed anymore
+func (r *Reconciler) reconcileAgentVariants(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, variants []agentVariant, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	names := map[string]bool{}
+	for _, variant := range variants {
+		names[daemonsetName(variant.dda)] = true
+		// The status of the runtime variants is reported in the container runtimes status,
+		// the Windows variant has its own status
+		variantStatus := &datadoghqv1alpha1.DatadogAgentStatus{}
+		windows := isWindowsAgent(variant.dda)
+		if windows {
+			variantStatus.Agent = newStatus.WindowsAgent
+		}
+		result, err := r.reconcileAgentVariant(logger, variant, variantStatus)
+		if windows {
+			newStatus.WindowsAgent = variantStatus.Agent
+		}
+		if shouldReturn(result, err) {
+			return result, err
+		}
+	}
+	return reconcile.Result{}, r.cleanupAgentVariants(logger, dda, names)
+}
+
+func (r *Reconciler) reconcileAgentVariant(logger logr.Logger, variant agentVariant, variantStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	nameNamespace := types.NamespacedName{
+		Name:      daemonsetName(variant.dda),
+		Namespace: variant.dda.Namespace,
+	}
+
+	eds := &edsdatadoghqv1alpha1.ExtendedDaemonSet{}
+	if r.options.SupportExtendedDaemonset {
+		if err := r.client.Get(context.TODO(), nameNamespace, eds); err != nil {
+			if !errors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+			eds = nil
+		}
+	} else {
+		eds = nil
+	}
+
+	ds := &appsv1.DaemonSet{}
+	if err := r.client.Get(context.TODO(), nameNamespace, ds); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		ds = nil
+	}
+
+	if r.options.SupportExtendedDaemonset && datadoghqv1alpha1.BoolValue(variant.dda.Spec.Agent.UseExtendedDaemonset) {
+		if ds != nil {
+			if err := r.deleteDaemonSet(logger, variant.dda, ds); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if eds == nil {
+			return r.createNewExtendedDaemonSet(logger, variant.dda, variant.placement, variantStatus)
+		}
+		return r.updateExtendedDaemonSet(logger, variant.dda, variant.placement, eds, variantStatus)
+	}
+
+	if eds != nil {
+		if err := r.deleteExtendedDaemonSet(logger, variant.dda, eds); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if ds == nil {
+		return r.createNewDaemonSet(logger, variant.dda, variant.placement, variantStatus)
+	}
+	return r.updateDaemonSet(logger, variant.dda, variant.placement, ds, variantStatus)
+}
+
+// cleanupAgentVariants deletes the DaemonSets of the variants that aren't in names
+func (r *Reconciler) cleanupAgentVariants(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, names map[string]bool) error {
+	isOwned := func(obj metav1.Object) bool {
+		return ownedByDatadogOperator(obj.GetOwnerReferences()) && !ownedByOtherDatadogAgent(obj.GetOwnerReferences(), dda)
+	}
+
+	variantNames := []string{getWindowsAgentDaemonsetName(dda)}
+	for _, runtime := range supportedContainerRuntimes {
+		variantNames = append(variantNames, getAgentVariantName(dda, runtime))
+	}
+
+	for _, name := range variantNames {
+		nameNamespace := types.NamespacedName{
+			Name:      name,
+			Namespace: dda.Namespace,
+		}
+		if names[nameNamespace.Name] {
+			continue
+		}
+
+		ds := &appsv1.DaemonSet{}
+		if err := r.client.Get(context.TODO(), nameNamespace, ds); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+		} else if isOwned(ds) {
+			if err = r.deleteDaemonSet(logger, dda, ds); err != nil {
+				return err
+			}
+		}
+
+		if !r.options.SupportExtendedDaemonset {
+			continue
+		}
+		eds := &edsdatadoghqv1alpha1.ExtendedDaemonSet{}
+		if err := r.client.Get(context.TODO(), nameNamespace, eds); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+		} else if isOwned(eds) {
+			if err = r.deleteExtendedDaemonSet(logger, dda, eds); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetNodeDatadogAgents returns the requests of the DatadogAgents detecting the container runtime or the platform of the nodes
+func (r *Reconciler) GetNodeDatadogAgents(obj runtime.Object, meta metav1.Object) []reconcile.Request {
+	if _, isNode := obj.(*corev1.Node); !isNode {
+		return nil
+	}
+	ddaList := &datadoghqv1alpha1.DatadogAgentList{}
+	if err := r.client.List(context.TODO(), ddaList); err != nil {
+		r.log.Error(err, "Unable to list the DatadogAgents", "node.Name", meta.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range ddaList.Items {
+		dda := &ddaList.Items[i]
+		if isContainerRuntimeDetectionEnabled(dda) || dda.Spec.Platform == datadoghqv1alpha1.PlatformAuto {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}})
+		}
+	}
+	return requests
+}
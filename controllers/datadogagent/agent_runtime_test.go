@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func newTestNode(name, runtimeVersion string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: runtimeVersion},
+		},
+	}
+}
+
+func getTestNodeRuntimeLabel(t *testing.T, c client.Client, name string) string {
+	node := &corev1.Node{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: name}, node))
+	return node.Labels[datadoghqv1alpha1.ContainerRuntimeLabelKey]
+}
+
+func TestGetContainerRuntime(t *testing.T) {
+	tests := map[string]string{
+		"docker://19.3.13":        containerRuntimeDocker,
+		"containerd://1.4.3":      containerRuntimeContainerd,
+		"containerd://1.4.3-k3s1": containerRuntimeK3s,
+		"cri-o://1.20.0":          containerRuntimeCRIO,
+		"frakti://1.0.0":          "frakti",
+		"":                        "",
+	}
+	for version, runtime := range tests {
+		assert.Equal(t, runtime, getContainerRuntime(newTestNode("node", version)), version)
+	}
+}
+
+func TestSetNodePlacement(t *testing.T) {
+	placement := &corev1.NodeSelectorRequirement{Key: "foo", Operator: corev1.NodeSelectorOpIn, Values: []string{"bar"}}
+	other := corev1.NodeSelectorRequirement{Key: "baz", Operator: corev1.NodeSelectorOpExists}
+
+	template := &corev1.PodTemplateSpec{}
+	setNodePlacement(template, nil)
+	assert.Nil(t, template.Spec.Affinity)
+
+	setNodePlacement(template, placement)
+	assert.Equal(t, []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{*placement}}},
+		template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+
+	// The requirement is added to each term
+	template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = []corev1.NodeSelectorTerm{
+		{MatchExpressions: []corev1.NodeSelectorRequirement{other}},
+		{MatchExpressions: []corev1.NodeSelectorRequirement{other}},
+	}
+	setNodePlacement(template, placement)
+	for _, term := range template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		assert.Equal(t, []corev1.NodeSelectorRequirement{other, *placement}, term.MatchExpressions)
+	}
+}
+
+func TestReconcileDatadogAgent_getAgentVariants(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_getAgentVariants")
+
+	c := fake.NewFakeClient(
+		newTestNode("containerd-1", "containerd://1.4.3"),
+		newTestNode("containerd-2", "containerd://1.4.3"),
+		newTestNode("docker", "docker://19.3.13"),
+		newTestNode("k3s", "containerd://1.4.3-k3s1"),
+		newTestNode("frakti", "frakti://1.0.0"),
+		newTestNode("not-ready", ""),
+	)
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	dda.Spec.Agent.Config.CriSocket = &datadoghqv1alpha1.CRISocketConfig{
+		DockerSocketPath: datadoghqv1alpha1.NewStringPointer("/var/run/docker.sock"),
+		AutoDetect:       datadoghqv1alpha1.NewBoolPointer(true),
+	}
+	newStatus := &datadoghqv1alpha1.DatadogAgentStatus{}
+
+	// The most common runtime is run by the main DaemonSet, the others by a DaemonSet each
+	variants, err := r.getAgentVariants(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 3)
+	assert.Equal(t, "foo-agent", daemonsetName(variants[0].dda))
+	assert.Equal(t, containerdSocketPath, *variants[0].dda.Spec.Agent.Config.CriSocket.CriSocketPath)
+	assert.Equal(t, &corev1.NodeSelectorRequirement{
+		Key:      datadoghqv1alpha1.ContainerRuntimeLabelKey,
+		Operator: corev1.NodeSelectorOpNotIn,
+		Values:   []string{containerRuntimeDocker, containerRuntimeK3s},
+	}, variants[0].placement)
+	assert.Equal(t, "foo-agent-docker", daemonsetName(variants[1].dda))
+	assert.Equal(t, dockerSocketPath, *variants[1].dda.Spec.Agent.Config.CriSocket.DockerSocketPath)
+	assert.Equal(t, []string{containerRuntimeDocker}, variants[1].placement.Values)
+	assert.Equal(t, "foo-agent-k3s", daemonsetName(variants[2].dda))
+	assert.Equal(t, k3sContainerdSocketPath, *variants[2].dda.Spec.Agent.Config.CriSocket.CriSocketPath)
+
+	// The nodes are labelled with their runtime
+	assert.Equal(t, containerRuntimeContainerd, getTestNodeRuntimeLabel(t, c, "containerd-1"))
+	assert.Equal(t, containerRuntimeDocker, getTestNodeRuntimeLabel(t, c, "docker"))
+	assert.Equal(t, containerRuntimeK3s, getTestNodeRuntimeLabel(t, c, "k3s"))
+	assert.Equal(t, "", getTestNodeRuntimeLabel(t, c, "frakti"))
+
+	assert.Equal(t, []datadoghqv1alpha1.ContainerRuntimeStatus{
+		{Runtime: containerRuntimeContainerd, Nodes: 2, SocketPath: containerdSocketPath, DaemonsetName: "foo-agent"},
+		{Runtime: containerRuntimeDocker, Nodes: 1, SocketPath: dockerSocketPath, DaemonsetName: "foo-agent-docker"},
+		{Runtime: "frakti", Nodes: 1, SocketPath: containerdSocketPath, DaemonsetName: "foo-agent"},
+		{Runtime: containerRuntimeK3s, Nodes: 1, SocketPath: k3sContainerdSocketPath, DaemonsetName: "foo-agent-k3s"},
+	}, newStatus.ContainerRuntimes)
+
+	// The DaemonSets of the variants are created with their placement, and deleted when their runtime disappears
+	_, err = r.reconcileAgentVariants(logger, dda, variants[1:], newStatus)
+	assert.NoError(t, err)
+	ds := &appsv1.DaemonSet{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-agent-k3s"}, ds))
+	assert.Equal(t, []corev1.NodeSelectorRequirement{*variants[2].placement},
+		ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions)
+
+	_, err = r.reconcileAgentVariants(logger, dda, variants[1:2], newStatus)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-agent-docker"}, ds))
+	err = c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-agent-k3s"}, ds)
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Without the detection, the configured socket is used on every node
+	dda.Spec.Agent.Config.CriSocket.AutoDetect = datadoghqv1alpha1.NewBoolPointer(false)
+	variants, err = r.getAgentVariants(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, []agentVariant{{dda: dda}}, variants)
+	assert.Nil(t, newStatus.ContainerRuntimes)
+}
@@ -19,6 +19,7 @@ import (
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -27,7 +28,6 @@ import (
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
-	"github.com/DataDog/datadog-operator/pkg/kubernetes"
 )
 
 func (r *Reconciler) reconcileClusterAgent(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
@@ -92,6 +92,9 @@ func (r *Reconciler) createNewClusterAgentDeployment(logger logr.Logger, agentde
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if hash, err = r.setReferencesHash(agentdeployment, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, &newDCA.ObjectMeta, &newDCA.Spec.Template, &newDCA.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	// Set DatadogAgent instance  instance as the owner and controller
 	if err = controllerutil.SetControllerReference(agentdeployment, newDCA, r.scheme); err != nil {
@@ -121,6 +124,9 @@ func (r *Reconciler) updateClusterAgentDeployment(logger logr.Logger, agentdeplo
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if hash, err = r.setReferencesHash(agentdeployment, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, &newDCA.ObjectMeta, &newDCA.Spec.Template, &newDCA.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	var needUpdate bool
 	if !comparison.IsSameSpecMD5Hash(hash, dca.GetAnnotations()) {
@@ -130,6 +136,7 @@ func (r *Reconciler) updateClusterAgentDeployment(logger logr.Logger, agentdeplo
 	updateStatusWithClusterAgent(dca, newStatus, nil)
 
 	if !needUpdate {
+		r.observeWorkload(agentdeployment, newDeploymentWorkload(dca))
 		return reconcile.Result{}, nil
 	}
 	logger.Info("update ClusterAgent deployment", "name", dca.Name, "namespace", dca.Namespace)
@@ -156,6 +163,7 @@ func (r *Reconciler) updateClusterAgentDeployment(logger logr.Logger, agentdeplo
 	}
 	event := buildEventInfo(updateDca.Name, updateDca.Namespace, deploymentKind, datadog.UpdateEvent)
 	r.recordEvent(agentdeployment, event)
+	r.startRollout(agentdeployment, &dca.Spec.Template, &newDCA.Spec.Template, newDeploymentWorkload(updateDca))
 	updateStatusWithClusterAgent(updateDca, newStatus, &now)
 	return reconcile.Result{}, nil
 }
@@ -191,6 +199,14 @@ func newClusterAgentDeploymentFromInstance(agentdeployment *datadoghqv1alpha1.Da
 		annotations[key] = val
 	}
 
+	template, err := newClusterAgentPodTemplate(agentdeployment, labels, annotations)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = applyPodTemplatePatches(&template, agentdeployment.Spec.ClusterAgent.PodTemplatePatches); err != nil {
+		return nil, "", err
+	}
+
 	dca := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        getClusterAgentName(agentdeployment),
@@ -199,7 +215,7 @@ func newClusterAgentDeploymentFromInstance(agentdeployment *datadoghqv1alpha1.Da
 			Annotations: annotations,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Template: newClusterAgentPodTemplate(agentdeployment, labels, annotations),
+			Template: template,
 			Replicas: agentdeployment.Spec.ClusterAgent.Replicas,
 			Selector: selector,
 		},
@@ -219,6 +235,11 @@ func (r *Reconciler) manageClusterAgentDependencies(logger logr.Logger, dda *dat
 		return result, err
 	}
 
+	result, err = r.manageClusterAgentCertificates(logger, dda, newStatus)
+	if shouldReturn(result, err) {
+		return result, err
+	}
+
 	result, err = r.manageClusterAgentService(logger, dda)
 	if shouldReturn(result, err) {
 		return result, err
@@ -239,6 +260,11 @@ func (r *Reconciler) manageClusterAgentDependencies(logger logr.Logger, dda *dat
 		return result, err
 	}
 
+	result, err = r.manageAdmissionControllerWebhook(logger, dda)
+	if shouldReturn(result, err) {
+		return result, err
+	}
+
 	result, err = r.manageClusterAgentPDB(logger, dda)
 	if shouldReturn(result, err) {
 		return result, err
@@ -270,6 +296,11 @@ func buildClusterAgentConfigurationConfigMap(dda *datadoghqv1alpha1.DatadogAgent
 }
 
 func (r *Reconciler) cleanupClusterAgent(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	// The webhook would block pod creations without the Cluster Agent
+	if result, err := r.cleanupAdmissionControllerWebhook(logger, dda); shouldReturn(result, err) {
+		return result, err
+	}
+
 	nsName := types.NamespacedName{
 		Name:      getClusterAgentName(dda),
 		Namespace: dda.Namespace,
@@ -293,23 +324,13 @@ func (r *Reconciler) cleanupClusterAgent(logger logr.Logger, dda *datadoghqv1alp
 }
 
 // newClusterAgentPodTemplate generates a PodTemplate from a DatadogClusterAgentDeployment spec
-func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent, labels, annotations map[string]string) corev1.PodTemplateSpec {
+func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent, labels, annotations map[string]string) (corev1.PodTemplateSpec, error) {
 	// copy Spec to configure the Cluster Agent Pod Template
 	clusterAgentSpec := agentdeployment.Spec.ClusterAgent.DeepCopy()
 
 	// confd volumes configuration
-	confdVolumeSource := corev1.VolumeSource{
-		EmptyDir: &corev1.EmptyDirVolumeSource{},
-	}
-	if agentdeployment.Spec.ClusterAgent.Config.Confd != nil {
-		confdVolumeSource = corev1.VolumeSource{
-			ConfigMap: &corev1.ConfigMapVolumeSource{
-				LocalObjectReference: corev1.LocalObjectReference{
-					Name: agentdeployment.Spec.ClusterAgent.Config.Confd.ConfigMapName,
-				},
-			},
-		}
-	}
+	clusterChecks := getChecks(agentdeployment, true)
+	confdVolumeSource := getConfdVolumeSource(agentdeployment, agentdeployment.Spec.ClusterAgent.Config.Confd, clusterChecks)
 	volumes := []corev1.Volume{
 		{
 			Name: datadoghqv1alpha1.InstallInfoVolumeName,
@@ -376,6 +397,27 @@ func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent,
 		}
 	}
 
+	if isMetricsProviderEnabled(agentdeployment.Spec.ClusterAgent) {
+		// Serving certificate of the external metrics server
+		volumes = append(volumes, corev1.Volume{
+			Name: datadoghqv1alpha1.ClusterAgentCertificatesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: getClusterAgentCertificatesSecretName(agentdeployment),
+					Items: []corev1.KeyToPath{
+						{Key: corev1.TLSCertKey, Path: apiServerCertificateFile},
+						{Key: corev1.TLSPrivateKeyKey, Path: apiServerPrivateKeyFile},
+					},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      datadoghqv1alpha1.ClusterAgentCertificatesVolumeName,
+			MountPath: datadoghqv1alpha1.ClusterAgentCertificatesVolumePath,
+			ReadOnly:  true,
+		})
+	}
+
 	// Add other volumes
 	volumes = append(volumes, agentdeployment.Spec.ClusterAgent.Config.Volumes...)
 	volumeMounts = append(volumeMounts, agentdeployment.Spec.ClusterAgent.Config.VolumeMounts...)
@@ -398,10 +440,12 @@ func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent,
 				VolumeMounts: volumeMounts,
 			},
 		},
-		Affinity:          clusterAgentSpec.Affinity,
-		Tolerations:       clusterAgentSpec.Tolerations,
-		PriorityClassName: agentdeployment.Spec.ClusterAgent.PriorityClassName,
-		Volumes:           volumes,
+		Affinity:                      getAffinity(clusterAgentSpec.Affinity, clusterAgentSpec.AntiAffinityPreset, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
+		Tolerations:                   clusterAgentSpec.Tolerations,
+		TopologySpreadConstraints:     clusterAgentSpec.TopologySpreadConstraints,
+		PriorityClassName:             agentdeployment.Spec.ClusterAgent.PriorityClassName,
+		TerminationGracePeriodSeconds: clusterAgentSpec.TerminationGracePeriodSeconds,
+		Volumes:                       volumes,
 	}
 
 	newPodTemplate := corev1.PodTemplateSpec{
@@ -420,6 +464,10 @@ func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent,
 		newPodTemplate.Annotations[key] = val
 	}
 
+	if hash := getChecksHash(clusterChecks); hash != "" {
+		newPodTemplate.Annotations[datadoghqv1alpha1.ChecksHashAnnotationKey] = hash
+	}
+
 	for key, val := range agentdeployment.Spec.ClusterAgent.AdditionalLabels {
 		newPodTemplate.Labels[key] = val
 	}
@@ -456,7 +504,14 @@ func newClusterAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent,
 		container.Resources = *clusterAgentSpec.Config.Resources
 	}
 
-	return newPodTemplate
+	applyContainerOverrides(container, containerOverrides{
+		livenessProbe:  clusterAgentSpec.Config.LivenessProbe,
+		readinessProbe: clusterAgentSpec.Config.ReadinessProbe,
+		startupProbe:   clusterAgentSpec.Config.StartupProbe,
+		lifecycle:      clusterAgentSpec.Config.Lifecycle,
+	})
+
+	return newPodTemplate, nil
 }
 
 func getClusterAgentCustomConfigConfigMapName(dda *datadoghqv1alpha1.DatadogAgent) string {
@@ -595,8 +650,16 @@ func getEnvVarsForClusterAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.Env
 			Name:  datadoghqv1alpha1.DDAdmissionControllerServiceName,
 			Value: getAdmissionControllerServiceName(dda),
 		})
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerCertificateSecretName,
+			Value: getAdmissionControllerCertificateSecretName(dda),
+		})
+		envVars = append(envVars, getAdmissionControllerEnvVars(dda)...)
 	}
 
+	envVars = append(envVars, getProxyEnvVars(dda)...)
+	envVars = append(envVars, getAdditionalEndpointsEnvVars(dda, datadoghqv1alpha1.DDAdditionalEndpoints)...)
+
 	return append(envVars, spec.ClusterAgent.Config.Env...)
 }
 
@@ -780,6 +843,9 @@ func (r *Reconciler) createAgentClusterRole(logger logr.Logger, dda *datadoghqv1
 }
 
 func (r *Reconciler) updateIfNeededClusterAgentClusterRole(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, name, agentVersion string, clusterRole *rbacv1.ClusterRole) (reconcile.Result, error) {
+	if ownedByOtherDatadogAgent(clusterRole.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
 	newClusterRole := buildClusterAgentClusterRole(dda, name, agentVersion)
 	if !apiequality.Semantic.DeepEqual(newClusterRole.Rules, clusterRole.Rules) {
 		logger.V(1).Info("updateClusterAgentClusterRole", "clusterRole.name", clusterRole.Name)
@@ -806,6 +872,9 @@ func (r *Reconciler) updateIfNeededClusterAgentRole(logger logr.Logger, dda *dat
 }
 
 func (r *Reconciler) updateIfNeededAgentClusterRole(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, name, agentVersion string, clusterRole *rbacv1.ClusterRole) (reconcile.Result, error) {
+	if ownedByOtherDatadogAgent(clusterRole.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
 	newClusterRole := buildAgentClusterRole(dda, name, agentVersion)
 	if !apiequality.Semantic.DeepEqual(newClusterRole.Rules, clusterRole.Rules) {
 		logger.V(1).Info("updateAgentClusterRole", "clusterRole.name", clusterRole.Name)
@@ -819,6 +888,9 @@ func (r *Reconciler) updateIfNeededAgentClusterRole(logger logr.Logger, dda *dat
 }
 
 func (r *Reconciler) udpateIfNeededAgentClusterRoleBinding(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, name, serviceAccountName, agentVersion string, clusterRoleBinding *rbacv1.ClusterRoleBinding) (reconcile.Result, error) {
+	if ownedByOtherDatadogAgent(clusterRoleBinding.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
 	info := roleBindingInfo{
 		name:               name,
 		roleName:           name,
@@ -1242,50 +1314,33 @@ func getExternalMetricsEndpoint(dda *datadoghqv1alpha1.DatadogAgent) string {
 }
 
 func (r *Reconciler) manageClusterAgentNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	policyName := fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+	policyName := getNetworkPolicyName(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
 
-	spec := dda.Spec.ClusterAgent
-	if spec == nil || !datadoghqv1alpha1.BoolValue(spec.NetworkPolicy.Create) {
-		return r.cleanupNetworkPolicy(logger, dda, policyName)
+	var spec *datadoghqv1alpha1.NetworkPolicySpec
+	if dda.Spec.ClusterAgent != nil {
+		spec = &dda.Spec.ClusterAgent.NetworkPolicy
 	}
 
-	return r.ensureNetworkPolicy(logger, dda, policyName, buildClusterAgentNetworkPolicy)
+	return r.manageNetworkPolicy(logger, dda, policyName, spec, buildClusterAgentNetworkPolicy, buildClusterAgentCiliumNetworkPolicy)
 }
 
 func buildClusterAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *networkingv1.NetworkPolicy {
-	egressRules := []networkingv1.NetworkPolicyEgressRule{
-		// Egress to datadog intake and
-		// kubeapi server
-		{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: 443,
-					},
-				},
-			},
-		},
+	egressRules := getCommonEgressRules(dda)
+
+	// The cluster checks are dispatched to the node agents and the runners,
+	// the Cluster Agent only connects to the autodiscovered services when it is allowed.
+	if isAutodiscoveryEgressAllowed(dda.Spec.ClusterAgent.NetworkPolicy) {
+		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{})
 	}
 
 	ingressRules := []networkingv1.NetworkPolicyIngressRule{
 		// Ingress for the node agents
 		{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: datadoghqv1alpha1.DefaultClusterAgentServicePort,
-					},
-				},
-			},
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, datadoghqv1alpha1.DefaultClusterAgentServicePort),
 			From: []networkingv1.NetworkPolicyPeer{
 				{
 					PodSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							kubernetes.AppKubernetesInstanceLabelKey: datadoghqv1alpha1.DefaultAgentResourceSuffix,
-							kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
-						},
+						MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix),
 					},
 				},
 			},
@@ -1294,37 +1349,21 @@ func buildClusterAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name st
 
 	if datadoghqv1alpha1.BoolValue(dda.Spec.ClusterAgent.Config.ClusterChecksEnabled) {
 		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: datadoghqv1alpha1.DefaultClusterAgentServicePort,
-					},
-				},
-			},
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, datadoghqv1alpha1.DefaultClusterAgentServicePort),
 			From: []networkingv1.NetworkPolicyPeer{
 				{
 					PodSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							kubernetes.AppKubernetesInstanceLabelKey: datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix,
-							kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
-						},
+						MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix),
 					},
 				},
 			},
 		})
 	}
 
-	if dda.Spec.ClusterAgent.Config.ExternalMetrics != nil && dda.Spec.ClusterAgent.Config.ExternalMetrics.Enabled {
+	// The metrics provider and the admission controller are called by the kube API server
+	if ports := getClusterAgentAPIServerIngressPorts(dda); len(ports) > 0 {
 		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: int32(datadoghqv1alpha1.DefaultMetricsServerTargetPort),
-					},
-				},
-			},
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, ports...),
 		})
 	}
 
@@ -1336,10 +1375,7 @@ func buildClusterAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name st
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					kubernetes.AppKubernetesInstanceLabelKey: datadoghqv1alpha1.DefaultClusterAgentResourceSuffix,
-					kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
-				},
+				MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
 			},
 			Ingress: ingressRules,
 			Egress:  egressRules,
@@ -1352,3 +1388,57 @@ func buildClusterAgentNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name st
 
 	return policy
 }
+
+func buildClusterAgentCiliumNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *unstructured.Unstructured {
+	egressRules := getCiliumCommonEgressRules(dda, dda.Spec.ClusterAgent.NetworkPolicy)
+	if isAutodiscoveryEgressAllowed(dda.Spec.ClusterAgent.NetworkPolicy) {
+		egressRules = append(egressRules, getCiliumAutodiscoveryEgressRule())
+	}
+
+	agentsSelectors := []metav1.LabelSelector{
+		{
+			MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix),
+		},
+	}
+	if datadoghqv1alpha1.BoolValue(dda.Spec.ClusterAgent.Config.ClusterChecksEnabled) {
+		agentsSelectors = append(agentsSelectors, metav1.LabelSelector{
+			MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix),
+		})
+	}
+
+	ingressRules := []ciliumIngressRule{
+		// Ingress for the node agents and the runners
+		{
+			FromEndpoints: agentsSelectors,
+			ToPorts:       []ciliumPortRule{getCiliumPortRule("TCP", datadoghqv1alpha1.DefaultClusterAgentServicePort)},
+		},
+	}
+
+	if ports := getClusterAgentAPIServerIngressPorts(dda); len(ports) > 0 {
+		ingressRules = append(ingressRules, ciliumIngressRule{
+			FromEntities: []string{ciliumEntityKubeAPIServer},
+			ToPorts:      []ciliumPortRule{getCiliumPortRule("TCP", ports...)},
+		})
+	}
+
+	return newCiliumNetworkPolicy(dda, name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, getClusterAgentVersion(dda), ciliumNetworkPolicySpec{
+		Description: "Egress to the Datadog intake and the kube API server, ingress from the agents and the kube API server",
+		EndpointSelector: metav1.LabelSelector{
+			MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
+		},
+		Ingress: ingressRules,
+		Egress:  egressRules,
+	})
+}
+
+// getClusterAgentAPIServerIngressPorts returns the ports of the Cluster Agent called by the kube API server
+func getClusterAgentAPIServerIngressPorts(dda *datadoghqv1alpha1.DatadogAgent) []int32 {
+	var ports []int32
+	if isMetricsProviderEnabled(dda.Spec.ClusterAgent) {
+		ports = append(ports, getClusterAgentMetricsProviderPort(dda.Spec.ClusterAgent.Config))
+	}
+	if isAdmissionControllerEnabled(dda.Spec.ClusterAgent) {
+		ports = append(ports, datadoghqv1alpha1.DefaultAdmissionControllerTargetPort)
+	}
+	return ports
+}
@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+)
+
+const (
+	admissionEnabledLabelKey = "admission.datadoghq.com/enabled"
+
+	admissionConfigWebhookName    = "datadog.webhook.config"
+	admissionConfigWebhookPath    = "/injectconfig"
+	admissionTagsWebhookName      = "datadog.webhook.tags"
+	admissionTagsWebhookPath      = "/injecttags"
+	admissionLibWebhookName       = "datadog.webhook.auto.instrumentation"
+	admissionLibWebhookPath       = "/injectlib"
+	admissionWebhookReviewVersion = "v1beta1"
+
+	unixSocketPrefix = "unix://"
+)
+
+// manageAdmissionControllerWebhook creates, updates and deletes the MutatingWebhookConfiguration of the admission controller
+func (r *Reconciler) manageAdmissionControllerWebhook(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
+	if !isAdmissionControllerEnabled(dda.Spec.ClusterAgent) {
+		return r.cleanupAdmissionControllerWebhook(logger, dda)
+	}
+
+	caBundle, err := r.getClusterAgentCABundle(dda)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(caBundle) == 0 {
+		// The webhook can't be called until the serving certificate is issued
+		return reconcile.Result{}, nil
+	}
+
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.createAdmissionControllerWebhook(logger, dda, caBundle)
+		}
+		return reconcile.Result{}, err
+	}
+
+	return r.updateIfNeededAdmissionControllerWebhook(logger, dda, webhookConfig, caBundle)
+}
+
+func (r *Reconciler) createAdmissionControllerWebhook(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, caBundle []byte) (reconcile.Result, error) {
+	newWebhookConfig, err := newAdmissionControllerWebhookConfiguration(dda, caBundle)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = SetOwnerReference(dda, newWebhookConfig, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = r.client.Create(context.TODO(), newWebhookConfig); err != nil {
+		return reconcile.Result{}, err
+	}
+	logger.Info("Created MutatingWebhookConfiguration", "name", newWebhookConfig.Name)
+	event := buildEventInfo(newWebhookConfig.Name, newWebhookConfig.Namespace, mutatingWebhookConfigurationKind, datadog.CreationEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) updateIfNeededAdmissionControllerWebhook(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, currentWebhookConfig *admissionregistrationv1beta1.MutatingWebhookConfiguration, caBundle []byte) (reconcile.Result, error) {
+	if ownedByOtherDatadogAgent(currentWebhookConfig.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
+
+	newWebhookConfig, err := newAdmissionControllerWebhookConfiguration(dda, caBundle)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	hash := newWebhookConfig.Annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
+	if comparison.IsSameSpecMD5Hash(hash, currentWebhookConfig.GetAnnotations()) {
+		return reconcile.Result{}, nil
+	}
+
+	updatedWebhookConfig := currentWebhookConfig.DeepCopy()
+	updatedWebhookConfig.Labels = newWebhookConfig.Labels
+	updatedWebhookConfig.Annotations = newWebhookConfig.Annotations
+	updatedWebhookConfig.Webhooks = newWebhookConfig.Webhooks
+	// The webhook configuration may have been created by the Cluster Agent
+	if err = SetOwnerReference(dda, updatedWebhookConfig, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = r.client.Update(context.TODO(), updatedWebhookConfig); err != nil {
+		return reconcile.Result{}, err
+	}
+	logger.Info("Update MutatingWebhookConfiguration", "name", updatedWebhookConfig.Name)
+	event := buildEventInfo(updatedWebhookConfig.Name, updatedWebhookConfig.Namespace, mutatingWebhookConfigurationKind, datadog.UpdateEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, nil
+}
+
+// cleanupAdmissionControllerWebhook deletes the MutatingWebhookConfiguration so that a stopped admission controller doesn't block pod creations
+func (r *Reconciler) cleanupAdmissionControllerWebhook(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !ownedByDatadogOperator(webhookConfig.OwnerReferences) || ownedByOtherDatadogAgent(webhookConfig.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
+
+	logger.Info("Deleting MutatingWebhookConfiguration", "name", webhookConfig.Name)
+	if err = r.client.Delete(context.TODO(), webhookConfig); err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	event := buildEventInfo(webhookConfig.Name, webhookConfig.Namespace, mutatingWebhookConfigurationKind, datadog.DeletionEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, nil
+}
+
+func newAdmissionControllerWebhookConfiguration(dda *datadoghqv1alpha1.DatadogAgent, caBundle []byte) (*admissionregistrationv1beta1.MutatingWebhookConfiguration, error) {
+	config := dda.Spec.ClusterAgent.Config.AdmissionController
+	labels := getDefaultLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, getClusterAgentVersion(dda))
+
+	failurePolicy := admissionregistrationv1beta1.Ignore
+	if config.FailurePolicy != nil && *config.FailurePolicy == datadoghqv1alpha1.AdmissionControllerFailurePolicyFail {
+		failurePolicy = admissionregistrationv1beta1.Fail
+	}
+	sideEffects := admissionregistrationv1beta1.SideEffectClassNone
+	port := int32(datadoghqv1alpha1.DefaultAdmissionControllerServicePort)
+	objectSelector := getAdmissionControllerObjectSelector(config)
+
+	webhooks := []admissionregistrationv1beta1.MutatingWebhook{}
+	for _, hook := range []struct {
+		name    string
+		path    string
+		enabled bool
+	}{
+		{name: admissionConfigWebhookName, path: admissionConfigWebhookPath, enabled: isAdmissionControllerConfigInjectionEnabled(config)},
+		{name: admissionTagsWebhookName, path: admissionTagsWebhookPath, enabled: datadoghqv1alpha1.BoolValue(config.InjectTags)},
+		{name: admissionLibWebhookName, path: admissionLibWebhookPath, enabled: datadoghqv1alpha1.BoolValue(config.InjectAPMLibraries)},
+	} {
+		if !hook.enabled {
+			continue
+		}
+		path := hook.path
+		webhooks = append(webhooks, admissionregistrationv1beta1.MutatingWebhook{
+			Name: hook.name,
+			ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+				Service: &admissionregistrationv1beta1.ServiceReference{
+					Namespace: dda.Namespace,
+					Name:      getAdmissionControllerServiceName(dda),
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionregistrationv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create},
+					Rule: admissionregistrationv1beta1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   []string{"pods"},
+					},
+				},
+			},
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			NamespaceSelector:       config.NamespaceSelector,
+			ObjectSelector:          objectSelector,
+			AdmissionReviewVersions: []string{admissionWebhookReviewVersion},
+		})
+	}
+
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   admissionWebhookName,
+			Labels: labels,
+		},
+		Webhooks: webhooks,
+	}
+	_, err := comparison.SetMD5GenerationAnnotation(&webhookConfig.ObjectMeta, webhookConfig.Webhooks)
+	return webhookConfig, err
+}
+
+// getAdmissionControllerObjectSelector returns the pods selector of the webhooks, the label
+// admission.datadoghq.com/enabled is used unless a selector is provided
+func getAdmissionControllerObjectSelector(config *datadoghqv1alpha1.AdmissionControllerConfig) *metav1.LabelSelector {
+	if config.ObjectSelector != nil {
+		return config.ObjectSelector
+	}
+	if datadoghqv1alpha1.BoolValue(config.MutateUnlabelled) {
+		return &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      admissionEnabledLabelKey,
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"false"},
+				},
+			},
+		}
+	}
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{admissionEnabledLabelKey: "true"},
+	}
+}
+
+// isAdmissionControllerConfigInjectionEnabled returns true if the config webhook is needed: it injects both
+// the Agent configuration and the entity ID
+func isAdmissionControllerConfigInjectionEnabled(config *datadoghqv1alpha1.AdmissionControllerConfig) bool {
+	return datadoghqv1alpha1.BoolValue(config.InjectConfig) || datadoghqv1alpha1.BoolValue(config.InjectEntityID)
+}
+
+func getAdmissionControllerEnvVars(dda *datadoghqv1alpha1.DatadogAgent) []corev1.EnvVar {
+	config := dda.Spec.ClusterAgent.Config.AdmissionController
+	envVars := []corev1.EnvVar{
+		{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerInjectConfig,
+			Value: datadoghqv1alpha1.BoolToString(config.InjectConfig),
+		},
+		{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerInjectEntityID,
+			Value: datadoghqv1alpha1.BoolToString(config.InjectEntityID),
+		},
+		{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerInjectTags,
+			Value: datadoghqv1alpha1.BoolToString(config.InjectTags),
+		},
+		{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerAutoInstrumentation,
+			Value: datadoghqv1alpha1.BoolToString(config.InjectAPMLibraries),
+		},
+		{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerWebhookName,
+			Value: admissionWebhookName,
+		},
+	}
+	if config.InjectionMode != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerInjectConfigMode,
+			Value: string(*config.InjectionMode),
+		})
+		// In socket mode, the injected configuration points to the sockets exposed by the Agent
+		if *config.InjectionMode == datadoghqv1alpha1.AdmissionControllerInjectionModeSocket {
+			if isDogstatsdSocketEnabled(&dda.Spec) {
+				envVars = append(envVars, corev1.EnvVar{
+					Name:  datadoghqv1alpha1.DDAdmissionControllerDogstatsdSocket,
+					Value: unixSocketPrefix + getDogstatsdSocketPath(&dda.Spec),
+				})
+			}
+			if isAPMSocketEnabled(dda) {
+				envVars = append(envVars, corev1.EnvVar{
+					Name:  datadoghqv1alpha1.DDAdmissionControllerTraceAgentSocket,
+					Value: unixSocketPrefix + getAPMSocketPath(dda),
+				})
+			}
+		}
+	}
+	if config.FailurePolicy != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDAdmissionControllerFailurePolicy,
+			Value: string(*config.FailurePolicy),
+		})
+	}
+	return envVars
+}
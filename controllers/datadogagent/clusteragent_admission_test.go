@@ -0,0 +1,169 @@
+package datadogagent
+
+import (
+	"context"
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func TestReconcileDatadogAgent_manageAdmissionControllerWebhook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageAdmissionControllerWebhook")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, AdmissionControllerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	// The webhook isn't created until the serving certificate is issued
+	_, err := r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+	err = c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	assert.True(t, apierrors.IsNotFound(err))
+
+	caBundle := createClusterAgentCertificates(c, dda)
+	_, err = r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig))
+	assert.True(t, ownedByDatadogOperator(webhookConfig.OwnerReferences))
+	assert.Len(t, webhookConfig.Webhooks, 2)
+	for i, name := range []string{admissionConfigWebhookName, admissionTagsWebhookName} {
+		webhook := webhookConfig.Webhooks[i]
+		assert.Equal(t, name, webhook.Name)
+		assert.Equal(t, caBundle, webhook.ClientConfig.CABundle)
+		assert.Equal(t, "datadog-admission-controller", webhook.ClientConfig.Service.Name)
+		assert.Equal(t, "bar", webhook.ClientConfig.Service.Namespace)
+		assert.Equal(t, admissionregistrationv1beta1.Ignore, *webhook.FailurePolicy)
+		assert.Equal(t, map[string]string{"admission.datadoghq.com/enabled": "true"}, webhook.ObjectSelector.MatchLabels)
+		assert.Nil(t, webhook.NamespaceSelector)
+	}
+
+	// The webhooks follow the spec
+	config := dda.Spec.ClusterAgent.Config.AdmissionController
+	config.InjectConfig = datadoghqv1alpha1.NewBoolPointer(false)
+	config.InjectEntityID = datadoghqv1alpha1.NewBoolPointer(false)
+	config.InjectAPMLibraries = datadoghqv1alpha1.NewBoolPointer(true)
+	config.MutateUnlabelled = datadoghqv1alpha1.NewBoolPointer(true)
+	failurePolicy := datadoghqv1alpha1.AdmissionControllerFailurePolicyFail
+	config.FailurePolicy = &failurePolicy
+	config.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "foo"}}
+	_, err = r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig))
+	assert.Len(t, webhookConfig.Webhooks, 2)
+	for i, name := range []string{admissionTagsWebhookName, admissionLibWebhookName} {
+		webhook := webhookConfig.Webhooks[i]
+		assert.Equal(t, name, webhook.Name)
+		assert.Equal(t, admissionregistrationv1beta1.Fail, *webhook.FailurePolicy)
+		assert.Equal(t, metav1.LabelSelectorOpNotIn, webhook.ObjectSelector.MatchExpressions[0].Operator)
+		assert.Equal(t, config.NamespaceSelector, webhook.NamespaceSelector)
+	}
+
+	// The webhook is removed once the admission controller is disabled
+	config.Enabled = false
+	_, err = r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	err = c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReconcileDatadogAgent_manageAdmissionControllerWebhook_takeOver(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageAdmissionControllerWebhook_takeOver")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, AdmissionControllerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	createClusterAgentCertificates(c, dda)
+
+	// The webhook configuration previously created by the Cluster Agent is taken over
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: admissionWebhookName},
+		Webhooks:   []admissionregistrationv1beta1.MutatingWebhook{{Name: admissionConfigWebhookName}},
+	}
+	assert.NoError(t, c.Create(context.TODO(), webhookConfig))
+	_, err := r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig))
+	assert.True(t, ownedByDatadogOperator(webhookConfig.OwnerReferences))
+	assert.Len(t, webhookConfig.Webhooks, 2)
+
+	// Deleting the Cluster Agent deletes the webhook configuration
+	_, err = r.cleanupClusterAgent(logger, dda, &datadoghqv1alpha1.DatadogAgentStatus{})
+	assert.NoError(t, err)
+	err = c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReconcileDatadogAgent_handleFinalizer_admissionControllerWebhook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_handleFinalizer_admissionControllerWebhook")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, AdmissionControllerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	createClusterAgentCertificates(c, dda)
+	_, err := r.manageAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+
+	// The webhook configuration is deleted with the DatadogAgent
+	now := metav1.Now()
+	dda.DeletionTimestamp = &now
+	dda.Finalizers = []string{datadogAgentFinalizer}
+	assert.NoError(t, c.Create(context.TODO(), dda))
+	_, err = r.handleFinalizer(logger, dda)
+	assert.NoError(t, err)
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+	err = c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig)
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Empty(t, dda.Finalizers)
+}
+
+func TestReconcileDatadogAgent_cleanupAdmissionControllerWebhook_notOwned(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_cleanupAdmissionControllerWebhook_notOwned")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	webhookConfig := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: admissionWebhookName},
+	}
+	assert.NoError(t, c.Create(context.TODO(), webhookConfig))
+	_, err := r.cleanupAdmissionControllerWebhook(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: admissionWebhookName}, webhookConfig))
+}
+
+func TestGetAdmissionControllerEnvVars_socketMode(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, AdmissionControllerEnabled: true, APMEnabled: true})
+	mode := datadoghqv1alpha1.AdmissionControllerInjectionModeSocket
+	dda.Spec.ClusterAgent.Config.AdmissionController.InjectionMode = &mode
+	dda.Spec.Agent.Apm.UnixDomainSocket = &datadoghqv1alpha1.UnixDomainSocketConfig{Enabled: datadoghqv1alpha1.NewBoolPointer(true)}
+
+	// Only the sockets exposed by the Agent are injected
+	envVars := getAdmissionControllerEnvVars(dda)
+	assert.Contains(t, envVars, corev1.EnvVar{Name: datadoghqv1alpha1.DDAdmissionControllerTraceAgentSocket, Value: "unix:///var/run/datadog/apm.socket"})
+	for _, envVar := range envVars {
+		assert.NotEqual(t, datadoghqv1alpha1.DDAdmissionControllerDogstatsdSocket, envVar.Name)
+	}
+
+	dda.Spec.Agent.Config.Dogstatsd.UnixDomainSocket = &datadoghqv1alpha1.UnixDomainSocketConfig{
+		Enabled:      datadoghqv1alpha1.NewBoolPointer(true),
+		HostFilepath: datadoghqv1alpha1.NewStringPointer("/var/run/dsd/dsd.socket"),
+	}
+	envVars = getAdmissionControllerEnvVars(dda)
+	assert.Contains(t, envVars, corev1.EnvVar{Name: datadoghqv1alpha1.DDAdmissionControllerDogstatsdSocket, Value: "unix:///var/run/dsd/dsd.socket"})
+}
@@ -0,0 +1,500 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/certificate"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+)
+
+const (
+	defaultCertificatesValidity    = 365 * 24 * time.Hour
+	defaultCertificatesRenewBefore = 30 * 24 * time.Hour
+	clusterAgentCAValidity         = 10 * 365 * 24 * time.Hour
+	certManagerRequeuePeriod       = 5 * time.Second
+
+	caCertificateKey          = "ca.crt"
+	caPrivateKeyKey           = "ca.key"
+	webhookCertificateKey     = "cert"
+	webhookPrivateKeyKey      = "key"
+	apiServerCertificateFile  = "apiserver.crt"
+	apiServerPrivateKeyFile   = "apiserver.key"
+	admissionWebhookName      = "datadog-webhook"
+	certManagerCertificateKey = "cert-manager.io/certificate-name"
+)
+
+var (
+	certManagerIssuerGVK      = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}
+	certManagerCertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+)
+
+// manageClusterAgentCertificates creates and rotates the certificates served by the Cluster Agent
+// for the external metrics APIService and the admission controller webhook
+func (r *Reconciler) manageClusterAgentCertificates(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	if !isClusterAgentCertificatesEnabled(dda) {
+		return r.cleanupClusterAgentCertificates(logger, dda, newStatus)
+	}
+
+	provider := r.getClusterAgentCertificatesProvider(dda)
+	var result reconcile.Result
+	var err error
+	if provider == datadoghqv1alpha1.CertificatesProviderCertManager {
+		result, err = r.manageCertManagerCertificates(logger, dda)
+	} else {
+		result, err = r.manageOperatorCertificates(logger, dda)
+	}
+	if shouldReturn(result, err) {
+		return result, err
+	}
+
+	servingSecret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Namespace: dda.Namespace, Name: getClusterAgentCertificatesSecretName(dda)}, servingSecret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// cert-manager hasn't issued the certificate yet
+			logger.V(1).Info("Waiting for the Cluster Agent certificate", "secret", getClusterAgentCertificatesSecretName(dda))
+			return reconcile.Result{RequeueAfter: certManagerRequeuePeriod}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	updateClusterAgentCertificatesStatus(newStatus, provider, servingSecret)
+
+	if !isAdmissionControllerEnabled(dda.Spec.ClusterAgent) {
+		return r.cleanupCertificateSecret(dda, getAdmissionControllerCertificateSecretName(dda))
+	}
+	return r.manageAdmissionControllerCertificate(logger, dda, servingSecret)
+}
+
+// manageOperatorCertificates generates a self-signed CA and uses it to sign the serving certificate
+func (r *Reconciler) manageOperatorCertificates(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
+	now := time.Now()
+	validity := getClusterAgentCertificatesValidity(dda)
+
+	caSecret, err := r.getCertificateSecret(dda.Namespace, getClusterAgentCASecretName(dda))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The CA is renewed before it expires during the lifetime of a serving certificate
+	caRenewBefore := validity
+	if caRenewBefore > clusterAgentCAValidity/2 {
+		caRenewBefore = clusterAgentCAValidity / 2
+	}
+	caCert, err := parseSecretCertificate(caSecret, caCertificateKey)
+	if err != nil || len(caSecret.Data[caPrivateKeyKey]) == 0 || certificate.NeedsRenewal(caCert, caRenewBefore, now) {
+		ca, err := certificate.NewCA(getClusterAgentCASecretName(dda), clusterAgentCAValidity, now)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		var previousBundle []byte
+		if caSecret != nil {
+			previousBundle = caSecret.Data[caCertificateKey]
+		}
+		data := map[string][]byte{
+			// Keep trusting the previous CA until the serving certificate it signed is replaced everywhere
+			caCertificateKey: certificate.Bundle(now, ca.Certificate, previousBundle),
+			caPrivateKeyKey:  ca.PrivateKey,
+		}
+		if caSecret, err = r.writeCertificateSecret(logger, dda, caSecret, getClusterAgentCASecretName(dda), corev1.SecretTypeOpaque, data); err != nil {
+			return reconcile.Result{}, err
+		}
+		if caCert, err = certificate.ParseCertificate(ca.Certificate); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	servingSecret, err := r.getCertificateSecret(dda.Namespace, getClusterAgentCertificatesSecretName(dda))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	dnsNames := getClusterAgentCertificatesDNSNames(dda)
+	if !needServingCertificateRenewal(servingSecret, caSecret, caCert, dnsNames, getClusterAgentCertificatesRenewBefore(dda), now) {
+		return reconcile.Result{}, nil
+	}
+
+	ca := &certificate.KeyPair{Certificate: caSecret.Data[caCertificateKey], PrivateKey: caSecret.Data[caPrivateKeyKey]}
+	serving, err := certificate.NewServingCertificate(ca, dnsNames, validity, now)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       serving.Certificate,
+		corev1.TLSPrivateKeyKey: serving.PrivateKey,
+		caCertificateKey:        caSecret.Data[caCertificateKey],
+	}
+	_, err = r.writeCertificateSecret(logger, dda, servingSecret, getClusterAgentCertificatesSecretName(dda), corev1.SecretTypeTLS, data)
+	return reconcile.Result{}, err
+}
+
+// manageCertManagerCertificates creates the cert-manager issuers and certificates.
+// cert-manager issues and renews the serving certificate in the same Secret as the operator provider.
+func (r *Reconciler) manageCertManagerCertificates(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
+	for _, obj := range newCertManagerObjects(dda) {
+		result, err := r.manageCertManagerObject(logger, dda, obj)
+		if shouldReturn(result, err) {
+			return result, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) manageCertManagerObject(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newObj *unstructured.Unstructured) (reconcile.Result, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(newObj.GroupVersionKind())
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: newObj.GetNamespace(), Name: newObj.GetName()}, current)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		if err = controllerutil.SetControllerReference(dda, newObj, r.scheme); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err = r.client.Create(context.TODO(), newObj); err != nil {
+			return reconcile.Result{}, err
+		}
+		logger.Info("Create cert-manager object", "kind", newObj.GetKind(), "name", newObj.GetName())
+		event := buildEventInfo(newObj.GetName(), newObj.GetNamespace(), newObj.GetKind(), datadog.CreationEvent)
+		r.recordEvent(dda, event)
+		return reconcile.Result{}, nil
+	}
+
+	hash := newObj.GetAnnotations()[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
+	if comparison.IsSameSpecMD5Hash(hash, current.GetAnnotations()) {
+		return reconcile.Result{}, nil
+	}
+	updated := current.DeepCopy()
+	updated.SetLabels(newObj.GetLabels())
+	updated.SetAnnotations(newObj.GetAnnotations())
+	updated.Object["spec"] = newObj.Object["spec"]
+	if err = r.client.Update(context.TODO(), updated); err != nil {
+		return reconcile.Result{}, err
+	}
+	logger.Info("Update cert-manager object", "kind", newObj.GetKind(), "name", newObj.GetName())
+	event := buildEventInfo(newObj.GetName(), newObj.GetNamespace(), newObj.GetKind(), datadog.UpdateEvent)
+	r.recordEvent(dda, event)
+	return reconcile.Result{}, nil
+}
+
+// manageAdmissionControllerCertificate provides the serving certificate to the admission controller
+// in the format of the Cluster Agent webhook Secret
+func (r *Reconciler) manageAdmissionControllerCertificate(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, servingSecret *corev1.Secret) (reconcile.Result, error) {
+	cert := append(append([]byte{}, servingSecret.Data[corev1.TLSCertKey]...), servingSecret.Data[caCertificateKey]...)
+	data := map[string][]byte{
+		webhookCertificateKey: cert,
+		webhookPrivateKeyKey:  servingSecret.Data[corev1.TLSPrivateKeyKey],
+	}
+
+	secretName := getAdmissionControllerCertificateSecretName(dda)
+	webhookSecret, err := r.getCertificateSecret(dda.Namespace, secretName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if webhookSecret == nil ||
+		!bytes.Equal(webhookSecret.Data[webhookCertificateKey], data[webhookCertificateKey]) ||
+		!bytes.Equal(webhookSecret.Data[webhookPrivateKeyKey], data[webhookPrivateKeyKey]) {
+		if _, err = r.writeCertificateSecret(logger, dda, webhookSecret, secretName, corev1.SecretTypeOpaque, data); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) cleanupClusterAgentCertificates(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	newStatus.ClusterAgentCertificates = nil
+
+	if r.options.SupportCertManager {
+		for _, obj := range newCertManagerObjects(dda) {
+			current := &unstructured.Unstructured{}
+			current.SetGroupVersionKind(obj.GroupVersionKind())
+			err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return reconcile.Result{}, err
+			}
+			if !ownedByDatadogOperator(current.GetOwnerReferences()) {
+				continue
+			}
+			logger.Info("Delete cert-manager object", "kind", obj.GetKind(), "name", obj.GetName())
+			if err = r.client.Delete(context.TODO(), current); err != nil && !apierrors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+			event := buildEventInfo(obj.GetName(), obj.GetNamespace(), obj.GetKind(), datadog.DeletionEvent)
+			r.recordEvent(dda, event)
+		}
+	}
+
+	for _, name := range []string{getAdmissionControllerCertificateSecretName(dda), getClusterAgentCertificatesSecretName(dda), getClusterAgentCASecretName(dda)} {
+		if result, err := r.cleanupCertificateSecret(dda, name); shouldReturn(result, err) {
+			return result, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// cleanupCertificateSecret deletes a certificate Secret created by the operator or by cert-manager for the operator
+func (r *Reconciler) cleanupCertificateSecret(dda *datadoghqv1alpha1.DatadogAgent, name string) (reconcile.Result, error) {
+	secret, err := r.getCertificateSecret(dda.Namespace, name)
+	if err != nil || secret == nil {
+		return reconcile.Result{}, err
+	}
+	if !ownedByDatadogOperator(secret.OwnerReferences) && !isCertManagerSecretOf(secret, dda) {
+		return reconcile.Result{}, nil
+	}
+	if err = r.client.Delete(context.TODO(), secret); err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	event := buildEventInfo(secret.Name, secret.Namespace, secretKind, datadog.DeletionEvent)
+	r.recordEvent(dda, event)
+	return reconcile.Result{}, nil
+}
+
+// getClusterAgentCABundle returns the CA bundle of the Cluster Agent serving certificate, nil if it isn't available yet
+func (r *Reconciler) getClusterAgentCABundle(dda *datadoghqv1alpha1.DatadogAgent) ([]byte, error) {
+	if !isClusterAgentCertificatesEnabled(dda) {
+		return nil, nil
+	}
+	secret, err := r.getCertificateSecret(dda.Namespace, getClusterAgentCertificatesSecretName(dda))
+	if err != nil || secret == nil {
+		return nil, err
+	}
+	return secret.Data[caCertificateKey], nil
+}
+
+func (r *Reconciler) getCertificateSecret(namespace, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+// writeCertificateSecret creates the Secret if current is nil, otherwise it replaces its data
+func (r *Reconciler) writeCertificateSecret(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, current *corev1.Secret, name string, secretType corev1.SecretType, data map[string][]byte) (*corev1.Secret, error) {
+	if current == nil {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   dda.Namespace,
+				Labels:      getDefaultLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, getClusterAgentVersion(dda)),
+				Annotations: getDefaultAnnotations(dda),
+			},
+			Type: secretType,
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(dda, secret, r.scheme); err != nil {
+			return nil, err
+		}
+		if err := r.client.Create(context.TODO(), secret); err != nil {
+			return nil, err
+		}
+		logger.Info("Create certificate Secret", "name", name)
+		event := buildEventInfo(name, dda.Namespace, secretKind, datadog.CreationEvent)
+		r.recordEvent(dda, event)
+		return secret, nil
+	}
+
+	if !ownedByDatadogOperator(current.OwnerReferences) {
+		return nil, fmt.Errorf("secret %s/%s is not managed by the operator", current.Namespace, current.Name)
+	}
+	updated := current.DeepCopy()
+	updated.Data = data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return nil, err
+	}
+	logger.Info("Rotate certificate Secret", "name", name)
+	event := buildEventInfo(name, dda.Namespace, secretKind, datadog.UpdateEvent)
+	r.recordEvent(dda, event)
+	return updated, nil
+}
+
+func updateClusterAgentCertificatesStatus(newStatus *datadoghqv1alpha1.DatadogAgentStatus, provider datadoghqv1alpha1.CertificatesProvider, servingSecret *corev1.Secret) {
+	status := &datadoghqv1alpha1.CertificatesStatus{
+		Provider:   string(provider),
+		SecretName: servingSecret.Name,
+	}
+	if cert, err := parseSecretCertificate(servingSecret, corev1.TLSCertKey); err == nil {
+		notAfter := metav1.NewTime(cert.NotAfter)
+		lastRotation := metav1.NewTime(cert.NotBefore)
+		status.NotAfter = &notAfter
+		status.LastRotation = &lastRotation
+	}
+	if caCert, err := parseSecretCertificate(servingSecret, caCertificateKey); err == nil {
+		caNotAfter := metav1.NewTime(caCert.NotAfter)
+		status.CANotAfter = &caNotAfter
+	}
+	newStatus.ClusterAgentCertificates = status
+}
+
+// needServingCertificateRenewal returns true if the serving certificate is missing, about to expire,
+// doesn't match the expected DNS names or hasn't been signed by the current CA
+func needServingCertificateRenewal(servingSecret, caSecret *corev1.Secret, caCert *x509.Certificate, dnsNames []string, renewBefore time.Duration, now time.Time) bool {
+	cert, err := parseSecretCertificate(servingSecret, corev1.TLSCertKey)
+	if err != nil || len(servingSecret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return true
+	}
+	return certificate.NeedsRenewal(cert, renewBefore, now) ||
+		!certificate.HasDNSNames(cert, dnsNames) ||
+		!certificate.IsSignedBy(cert, caCert) ||
+		!bytes.Equal(servingSecret.Data[caCertificateKey], caSecret.Data[caCertificateKey])
+}
+
+func parseSecretCertificate(secret *corev1.Secret, key string) (*x509.Certificate, error) {
+	if secret == nil {
+		return nil, fmt.Errorf("secret not found")
+	}
+	return certificate.ParseCertificate(secret.Data[key])
+}
+
+func newCertManagerObjects(dda *datadoghqv1alpha1.DatadogAgent) []*unstructured.Unstructured {
+	privateKey := map[string]interface{}{
+		"algorithm": "ECDSA",
+		"size":      int64(256),
+	}
+	dnsNames := []interface{}{}
+	for _, name := range getClusterAgentCertificatesDNSNames(dda) {
+		dnsNames = append(dnsNames, name)
+	}
+
+	return []*unstructured.Unstructured{
+		newCertManagerObject(dda, certManagerIssuerGVK, getCertManagerSelfSignedIssuerName(dda), map[string]interface{}{
+			"selfSigned": map[string]interface{}{},
+		}),
+		newCertManagerObject(dda, certManagerCertificateGVK, getClusterAgentCASecretName(dda), map[string]interface{}{
+			"isCA":       true,
+			"commonName": getClusterAgentCASecretName(dda),
+			"secretName": getClusterAgentCASecretName(dda),
+			"duration":   clusterAgentCAValidity.String(),
+			"privateKey": privateKey,
+			"issuerRef": map[string]interface{}{
+				"kind": certManagerIssuerGVK.Kind,
+				"name": getCertManagerSelfSignedIssuerName(dda),
+			},
+		}),
+		newCertManagerObject(dda, certManagerIssuerGVK, getClusterAgentCASecretName(dda), map[string]interface{}{
+			"ca": map[string]interface{}{
+				"secretName": getClusterAgentCASecretName(dda),
+			},
+		}),
+		newCertManagerObject(dda, certManagerCertificateGVK, getCertManagerServingCertificateName(dda), map[string]interface{}{
+			"secretName":  getClusterAgentCertificatesSecretName(dda),
+			"dnsNames":    dnsNames,
+			"duration":    getClusterAgentCertificatesValidity(dda).String(),
+			"renewBefore": getClusterAgentCertificatesRenewBefore(dda).String(),
+			"privateKey":  privateKey,
+			"issuerRef": map[string]interface{}{
+				"kind": certManagerIssuerGVK.Kind,
+				"name": getClusterAgentCASecretName(dda),
+			},
+		}),
+	}
+}
+
+func newCertManagerObject(dda *datadoghqv1alpha1.DatadogAgent, gvk schema.GroupVersionKind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(dda.Namespace)
+	obj.SetLabels(getDefaultLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, getClusterAgentVersion(dda)))
+
+	annotations := getDefaultAnnotations(dda)
+	if hash, err := comparison.GenerateMD5ForSpec(spec); err == nil {
+		annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey] = hash
+	}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func isCertManagerSecretOf(secret *corev1.Secret, dda *datadoghqv1alpha1.DatadogAgent) bool {
+	name := secret.Annotations[certManagerCertificateKey]
+	return name != "" && (name == getCertManagerServingCertificateName(dda) || name == getClusterAgentCASecretName(dda))
+}
+
+func isClusterAgentCertificatesEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	return isMetricsProviderEnabled(dda.Spec.ClusterAgent) || isAdmissionControllerEnabled(dda.Spec.ClusterAgent)
+}
+
+func (r *Reconciler) getClusterAgentCertificatesProvider(dda *datadoghqv1alpha1.DatadogAgent) datadoghqv1alpha1.CertificatesProvider {
+	provider := datadoghqv1alpha1.CertificatesProviderAuto
+	if config := dda.Spec.ClusterAgent.Config.Certificates; config != nil && config.Provider != nil {
+		provider = *config.Provider
+	}
+	if provider == datadoghqv1alpha1.CertificatesProviderAuto {
+		if r.options.SupportCertManager {
+			return datadoghqv1alpha1.CertificatesProviderCertManager
+		}
+		return datadoghqv1alpha1.CertificatesProviderOperator
+	}
+	return provider
+}
+
+func getClusterAgentCertificatesValidity(dda *datadoghqv1alpha1.DatadogAgent) time.Duration {
+	if config := dda.Spec.ClusterAgent.Config.Certificates; config != nil && config.Validity != nil && config.Validity.Duration > 0 {
+		return config.Validity.Duration
+	}
+	return defaultCertificatesValidity
+}
+
+func getClusterAgentCertificatesRenewBefore(dda *datadoghqv1alpha1.DatadogAgent) time.Duration {
+	if config := dda.Spec.ClusterAgent.Config.Certificates; config != nil && config.RenewBefore != nil && config.RenewBefore.Duration > 0 {
+		return config.RenewBefore.Duration
+	}
+	return defaultCertificatesRenewBefore
+}
+
+func getClusterAgentCertificatesDNSNames(dda *datadoghqv1alpha1.DatadogAgent) []string {
+	var dnsNames []string
+	if isMetricsProviderEnabled(dda.Spec.ClusterAgent) {
+		dnsNames = append(dnsNames, fmt.Sprintf("%s.%s.svc", getMetricsServerServiceName(dda), dda.Namespace))
+	}
+	if isAdmissionControllerEnabled(dda.Spec.ClusterAgent) {
+		dnsNames = append(dnsNames, fmt.Sprintf("%s.%s.svc", getAdmissionControllerServiceName(dda), dda.Namespace))
+	}
+	return dnsNames
+}
+
+func getClusterAgentCASecretName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-%s-ca", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+}
+
+func getClusterAgentCertificatesSecretName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-%s-certificates", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+}
+
+func getAdmissionControllerCertificateSecretName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-%s-webhook-certificate", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+}
+
+func getCertManagerSelfSignedIssuerName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-%s-selfsigned", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+}
+
+func getCertManagerServingCertificateName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
+}
@@ -0,0 +1,195 @@
+package datadogagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/certificate"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+// createClusterAgentCertificates creates valid operator certificates and returns the CA bundle
+func createClusterAgentCertificates(c client.Client, dda *datadoghqv1alpha1.DatadogAgent) []byte {
+	now := time.Now()
+	ca, _ := certificate.NewCA(getClusterAgentCASecretName(dda), clusterAgentCAValidity, now)
+	serving, _ := certificate.NewServingCertificate(ca, getClusterAgentCertificatesDNSNames(dda), defaultCertificatesValidity, now)
+
+	_ = c.Create(context.TODO(), test.NewSecret(dda.Namespace, getClusterAgentCASecretName(dda), &test.NewSecretOptions{Data: map[string][]byte{
+		caCertificateKey: ca.Certificate,
+		caPrivateKeyKey:  ca.PrivateKey,
+	}}))
+	_ = c.Create(context.TODO(), test.NewSecret(dda.Namespace, getClusterAgentCertificatesSecretName(dda), &test.NewSecretOptions{Data: map[string][]byte{
+		corev1.TLSCertKey:       serving.Certificate,
+		corev1.TLSPrivateKeyKey: serving.PrivateKey,
+		caCertificateKey:        ca.Certificate,
+	}}))
+	return ca.Certificate
+}
+
+func newCertificatesTestReconciler(c client.Client, options ReconcilerOptions) *Reconciler {
+	eventBroadcaster := record.NewBroadcaster()
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "TestReconcileDatadogAgent_manageClusterAgentCertificates"})
+
+	s := scheme.Scheme
+	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{}, &datadoghqv1alpha1.DatadogAgentList{})
+
+	return &Reconciler{
+		options:    options,
+		client:     c,
+		scheme:     s,
+		recorder:   recorder,
+		forwarders: dummyManager{},
+	}
+}
+
+func getTestSecret(t *testing.T, c client.Client, name string) *corev1.Secret {
+	secret := &corev1.Secret{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: name}, secret))
+	return secret
+}
+
+func TestReconcileDatadogAgent_manageClusterAgentCertificates_operator(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageClusterAgentCertificates_operator")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, MetricsServerEnabled: true, AdmissionControllerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	// The CA, the serving certificate and the admission controller Secret are created at once
+	newStatus := &datadoghqv1alpha1.DatadogAgentStatus{}
+	result, err := r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.False(t, shouldReturn(result, err))
+
+	caSecret := getTestSecret(t, c, "foo-cluster-agent-ca")
+	servingSecret := getTestSecret(t, c, "foo-cluster-agent-certificates")
+	webhookSecret := getTestSecret(t, c, "foo-cluster-agent-webhook-certificate")
+	assert.Equal(t, corev1.SecretTypeTLS, servingSecret.Type)
+	assert.True(t, ownedByDatadogOperator(servingSecret.OwnerReferences))
+
+	caCert, err := certificate.ParseCertificate(caSecret.Data[caCertificateKey])
+	assert.NoError(t, err)
+	cert, err := certificate.ParseCertificate(servingSecret.Data[corev1.TLSCertKey])
+	assert.NoError(t, err)
+	assert.True(t, certificate.IsSignedBy(cert, caCert))
+	assert.True(t, certificate.HasDNSNames(cert, []string{"foo-cluster-agent-metrics-server.bar.svc", "datadog-admission-controller.bar.svc"}))
+	assert.Equal(t, caSecret.Data[caCertificateKey], servingSecret.Data[caCertificateKey])
+	assert.Equal(t, servingSecret.Data[corev1.TLSPrivateKeyKey], webhookSecret.Data[webhookPrivateKeyKey])
+
+	assert.NotNil(t, newStatus.ClusterAgentCertificates)
+	assert.Equal(t, "operator", newStatus.ClusterAgentCertificates.Provider)
+	assert.Equal(t, "foo-cluster-agent-certificates", newStatus.ClusterAgentCertificates.SecretName)
+	assert.True(t, newStatus.ClusterAgentCertificates.NotAfter.Time.Equal(cert.NotAfter))
+	assert.True(t, newStatus.ClusterAgentCertificates.CANotAfter.Time.Equal(caCert.NotAfter))
+
+	// Nothing changes when the certificate is valid
+	_, err = r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, servingSecret.Data, getTestSecret(t, c, "foo-cluster-agent-certificates").Data)
+
+	// The serving certificate is renewed when it's about to expire
+	dda.Spec.ClusterAgent.Config.Certificates = &datadoghqv1alpha1.ClusterAgentCertificatesConfig{
+		RenewBefore: &metav1.Duration{Duration: 2 * defaultCertificatesValidity},
+	}
+	_, err = r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	rotatedSecret := getTestSecret(t, c, "foo-cluster-agent-certificates")
+	assert.NotEqual(t, servingSecret.Data[corev1.TLSCertKey], rotatedSecret.Data[corev1.TLSCertKey])
+	assert.Equal(t, caSecret.Data, getTestSecret(t, c, "foo-cluster-agent-ca").Data)
+
+	// Everything is removed once the features using the certificates are disabled
+	dda.Spec.ClusterAgent.Config.ExternalMetrics.Enabled = false
+	dda.Spec.ClusterAgent.Config.AdmissionController.Enabled = false
+	_, err = r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.Nil(t, newStatus.ClusterAgentCertificates)
+	for _, name := range []string{"foo-cluster-agent-ca", "foo-cluster-agent-certificates", "foo-cluster-agent-webhook-certificate"} {
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: name}, &corev1.Secret{})
+		assert.True(t, apierrors.IsNotFound(err), "secret %s should be deleted", name)
+	}
+}
+
+func TestReconcileDatadogAgent_manageClusterAgentCertificates_certManager(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageClusterAgentCertificates_certManager")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, MetricsServerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{SupportCertManager: true})
+
+	// The certificate isn't issued yet
+	newStatus := &datadoghqv1alpha1.DatadogAgentStatus{}
+	result, err := r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, certManagerRequeuePeriod, result.RequeueAfter)
+	assert.Nil(t, newStatus.ClusterAgentCertificates)
+
+	servingCertificate := &unstructured.Unstructured{}
+	servingCertificate.SetGroupVersionKind(certManagerCertificateGVK)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-cluster-agent"}, servingCertificate))
+	secretName, _, _ := unstructured.NestedString(servingCertificate.Object, "spec", "secretName")
+	assert.Equal(t, "foo-cluster-agent-certificates", secretName)
+	dnsNames, _, _ := unstructured.NestedStringSlice(servingCertificate.Object, "spec", "dnsNames")
+	assert.Equal(t, []string{"foo-cluster-agent-metrics-server.bar.svc"}, dnsNames)
+	issuerName, _, _ := unstructured.NestedString(servingCertificate.Object, "spec", "issuerRef", "name")
+	assert.Equal(t, "foo-cluster-agent-ca", issuerName)
+
+	caIssuer := &unstructured.Unstructured{}
+	caIssuer.SetGroupVersionKind(certManagerIssuerGVK)
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-cluster-agent-ca"}, caIssuer))
+
+	// cert-manager issued the certificate
+	caBundle := createClusterAgentCertificates(c, dda)
+	result, err = r.manageClusterAgentCertificates(logger, dda, newStatus)
+	assert.NoError(t, err)
+	assert.False(t, shouldReturn(result, err))
+	assert.Equal(t, "cert-manager", newStatus.ClusterAgentCertificates.Provider)
+
+	bundle, err := r.getClusterAgentCABundle(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, caBundle, bundle)
+
+	// The operator provider can be forced
+	provider := datadoghqv1alpha1.CertificatesProviderOperator
+	dda.Spec.ClusterAgent.Config.Certificates = &datadoghqv1alpha1.ClusterAgentCertificatesConfig{Provider: &provider}
+	assert.Equal(t, datadoghqv1alpha1.CertificatesProviderOperator, r.getClusterAgentCertificatesProvider(dda))
+}
+
+func TestReconcileDatadogAgent_manageClusterAgentCertificates_notOwned(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageClusterAgentCertificates_notOwned")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, MetricsServerEnabled: true})
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	// A Secret created by the operator is rotated, a Secret created by someone else is left untouched
+	_ = c.Create(context.TODO(), test.NewSecret("bar", "foo-cluster-agent-ca", &test.NewSecretOptions{Data: map[string][]byte{caCertificateKey: []byte("invalid")}}))
+	_, err := r.manageClusterAgentCertificates(logger, dda, &datadoghqv1alpha1.DatadogAgentStatus{})
+	assert.Error(t, err)
+	assert.Equal(t, []byte("invalid"), getTestSecret(t, c, "foo-cluster-agent-ca").Data[caCertificateKey])
+
+	caSecret := getTestSecret(t, c, "foo-cluster-agent-ca")
+	assert.NoError(t, controllerutil.SetControllerReference(dda, caSecret, r.scheme))
+	assert.NoError(t, c.Update(context.TODO(), caSecret))
+	_, err = r.manageClusterAgentCertificates(logger, dda, &datadoghqv1alpha1.DatadogAgentStatus{})
+	assert.NoError(t, err)
+	_, err = certificate.ParseCertificate(getTestSecret(t, c, "foo-cluster-agent-ca").Data[caCertificateKey])
+	assert.NoError(t, err)
+}
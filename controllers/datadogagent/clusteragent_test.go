@@ -512,6 +512,7 @@ func Test_newClusterAgentDeploymentFromInstance_MetricsServer(t *testing.T) {
 
 	metricsServerPodSpec.Containers[0].LivenessProbe = probe
 	metricsServerPodSpec.Containers[0].ReadinessProbe = probe
+	addClusterAgentCertificatesVolume(&metricsServerPodSpec)
 
 	metricsServerAgentDeployment := test.NewDefaultedDatadogAgent("bar", "foo",
 		&test.NewDatadogAgentOptions{
@@ -557,6 +558,7 @@ func Test_newClusterAgentDeploymentFromInstance_MetricsServer(t *testing.T) {
 	)
 	metricsServerWithSitePodSpec.Containers[0].LivenessProbe = probe
 	metricsServerWithSitePodSpec.Containers[0].ReadinessProbe = probe
+	addClusterAgentCertificatesVolume(&metricsServerWithSitePodSpec)
 
 	for index := range metricsServerWithSitePodSpec.Containers[0].Env {
 		if metricsServerWithSitePodSpec.Containers[0].Env[index].Name == "DD_SITE" {
@@ -678,6 +680,26 @@ func Test_newClusterAgentDeploymentFromInstance_MetricsServer(t *testing.T) {
 	tests.Run(t)
 }
 
+func addClusterAgentCertificatesVolume(podSpec *corev1.PodSpec) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "certificates",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: "foo-cluster-agent-certificates",
+				Items: []corev1.KeyToPath{
+					{Key: "tls.crt", Path: "apiserver.crt"},
+					{Key: "tls.key", Path: "apiserver.key"},
+				},
+			},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "certificates",
+		MountPath: "/etc/datadog-agent/certificates",
+		ReadOnly:  true,
+	})
+}
+
 func Test_newClusterAgentDeploymentFromInstance_AdmissionController(t *testing.T) {
 	commonLabels := map[string]string{
 		"agent.datadoghq.com/name":      "foo",
@@ -705,6 +727,38 @@ func Test_newClusterAgentDeploymentFromInstance_AdmissionController(t *testing.T
 				Name:  "DD_ADMISSION_CONTROLLER_SERVICE_NAME",
 				Value: "datadog-admission-controller",
 			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_CERTIFICATE_SECRET_NAME",
+				Value: "foo-cluster-agent-webhook-certificate",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_ENTITY_ID_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_TAGS_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_AUTO_INSTRUMENTATION_ENABLED",
+				Value: "false",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_WEBHOOK_NAME",
+				Value: "datadog-webhook",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_MODE",
+				Value: "hostip",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_FAILURE_POLICY",
+				Value: "Ignore",
+			},
 		}...,
 	)
 
@@ -730,6 +784,38 @@ func Test_newClusterAgentDeploymentFromInstance_AdmissionController(t *testing.T
 				Name:  "DD_ADMISSION_CONTROLLER_SERVICE_NAME",
 				Value: "custom-service-name",
 			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_CERTIFICATE_SECRET_NAME",
+				Value: "foo-cluster-agent-webhook-certificate",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_ENTITY_ID_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_TAGS_ENABLED",
+				Value: "false",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_AUTO_INSTRUMENTATION_ENABLED",
+				Value: "true",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_WEBHOOK_NAME",
+				Value: "datadog-webhook",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_INJECT_CONFIG_MODE",
+				Value: "service",
+			},
+			{
+				Name:  "DD_ADMISSION_CONTROLLER_FAILURE_POLICY",
+				Value: "Fail",
+			},
 		}...,
 	)
 
@@ -741,6 +827,13 @@ func Test_newClusterAgentDeploymentFromInstance_AdmissionController(t *testing.T
 			AdmissionMutateUnlabelled:  true,
 			AdmissionServiceName:       "custom-service-name",
 		})
+	admissionControllerConfigCustom := admissionControllerDatadogAgentCustom.Spec.ClusterAgent.Config.AdmissionController
+	admissionControllerConfigCustom.InjectTags = datadoghqv1alpha1.NewBoolPointer(false)
+	admissionControllerConfigCustom.InjectAPMLibraries = datadoghqv1alpha1.NewBoolPointer(true)
+	injectionModeCustom := datadoghqv1alpha1.AdmissionControllerInjectionModeService
+	admissionControllerConfigCustom.InjectionMode = &injectionModeCustom
+	failurePolicyCustom := datadoghqv1alpha1.AdmissionControllerFailurePolicyFail
+	admissionControllerConfigCustom.FailurePolicy = &failurePolicyCustom
 
 	tests := clusterAgentDeploymentFromInstanceTestSuite{
 		{
@@ -996,7 +1089,7 @@ func TestReconcileDatadogAgent_createNewClusterAgentDeployment(t *testing.T) {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{})
+	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{}, &datadoghqv1alpha1.DatadogAgentList{})
 
 	type fields struct {
 		client   client.Client
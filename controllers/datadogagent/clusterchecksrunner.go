@@ -17,15 +17,14 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
-	"github.com/DataDog/datadog-operator/pkg/kubernetes"
 )
 
 func (r *Reconciler) reconcileClusterChecksRunner(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
@@ -82,6 +81,9 @@ func (r *Reconciler) createNewClusterChecksRunnerDeployment(logger logr.Logger,
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if hash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix, &newDCAW.ObjectMeta, &newDCAW.Spec.Template, &newDCAW.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	// Set ClusterChecksRunner Deployment instance as the owner and controller
 	if err = controllerutil.SetControllerReference(dda, newDCAW, r.scheme); err != nil {
@@ -111,6 +113,9 @@ func (r *Reconciler) updateClusterChecksRunnerDeployment(logger logr.Logger, dda
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if hash, err = r.setReferencesHash(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix, &newDCAW.ObjectMeta, &newDCAW.Spec.Template, &newDCAW.Spec); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	var needUpdate bool
 	if !comparison.IsSameSpecMD5Hash(hash, dep.GetAnnotations()) {
@@ -120,6 +125,7 @@ func (r *Reconciler) updateClusterChecksRunnerDeployment(logger logr.Logger, dda
 	updateStatusWithClusterChecksRunner(dep, newStatus, nil)
 
 	if !needUpdate {
+		r.observeWorkload(dda, newDeploymentWorkload(dep))
 		return reconcile.Result{}, nil
 	}
 
@@ -148,6 +154,7 @@ func (r *Reconciler) updateClusterChecksRunnerDeployment(logger logr.Logger, dda
 	}
 	event := buildEventInfo(updateDca.Name, updateDca.Namespace, deploymentKind, datadog.UpdateEvent)
 	r.recordEvent(dda, event)
+	r.startRollout(dda, &dep.Spec.Template, &newDCAW.Spec.Template, newDeploymentWorkload(updateDca))
 	updateStatusWithClusterChecksRunner(updateDca, newStatus, &now)
 	return reconcile.Result{}, nil
 }
@@ -185,6 +192,14 @@ func newClusterChecksRunnerDeploymentFromInstance(
 		annotations[key] = val
 	}
 
+	template, err := newClusterChecksRunnerPodTemplate(dda, labels, annotations)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = applyPodTemplatePatches(&template, dda.Spec.ClusterChecksRunner.PodTemplatePatches); err != nil {
+		return nil, "", err
+	}
+
 	dca := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        getClusterChecksRunnerName(dda),
@@ -193,7 +208,7 @@ func newClusterChecksRunnerDeploymentFromInstance(
 			Annotations: annotations,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Template: newClusterChecksRunnerPodTemplate(dda, labels, annotations),
+			Template: template,
 			Replicas: dda.Spec.ClusterChecksRunner.Replicas,
 			Selector: selector,
 		},
@@ -262,7 +277,7 @@ func (r *Reconciler) cleanupClusterChecksRunner(logger logr.Logger, dda *datadog
 }
 
 // newClusterChecksRunnerPodTemplate generates a PodTemplate from a DatadogClusterChecksRunnerDeployment spec
-func newClusterChecksRunnerPodTemplate(dda *datadoghqv1alpha1.DatadogAgent, labels, annotations map[string]string) corev1.PodTemplateSpec {
+func newClusterChecksRunnerPodTemplate(dda *datadoghqv1alpha1.DatadogAgent, labels, annotations map[string]string) (corev1.PodTemplateSpec, error) {
 	// copy Spec to configure the Cluster Checks Runner Pod Template
 	clusterChecksRunnerSpec := dda.Spec.ClusterChecksRunner.DeepCopy()
 
@@ -290,10 +305,12 @@ func newClusterChecksRunnerPodTemplate(dda *datadoghqv1alpha1.DatadogAgent, labe
 					ReadinessProbe:  getDefaultReadinessProbe(),
 				},
 			},
-			Volumes:           getVolumesForClusterChecksRunner(dda),
-			Affinity:          getPodAffinity(clusterChecksRunnerSpec.Affinity),
-			Tolerations:       clusterChecksRunnerSpec.Tolerations,
-			PriorityClassName: clusterChecksRunnerSpec.PriorityClassName,
+			Volumes:                       getVolumesForClusterChecksRunner(dda),
+			Affinity:                      getPodAffinity(clusterChecksRunnerSpec.Affinity, clusterChecksRunnerSpec.AntiAffinityPreset),
+			Tolerations:                   clusterChecksRunnerSpec.Tolerations,
+			PriorityClassName:             clusterChecksRunnerSpec.PriorityClassName,
+			TerminationGracePeriodSeconds: clusterChecksRunnerSpec.TerminationGracePeriodSeconds,
+			TopologySpreadConstraints:     clusterChecksRunnerSpec.TopologySpreadConstraints,
 		},
 	}
 
@@ -309,7 +326,14 @@ func newClusterChecksRunnerPodTemplate(dda *datadoghqv1alpha1.DatadogAgent, labe
 		newPodTemplate.Spec.Containers[0].Resources = *clusterChecksRunnerSpec.Config.Resources
 	}
 
-	return newPodTemplate
+	applyContainerOverrides(&newPodTemplate.Spec.Containers[0], containerOverrides{
+		livenessProbe:  clusterChecksRunnerSpec.Config.LivenessProbe,
+		readinessProbe: clusterChecksRunnerSpec.Config.ReadinessProbe,
+		startupProbe:   clusterChecksRunnerSpec.Config.StartupProbe,
+		lifecycle:      clusterChecksRunnerSpec.Config.Lifecycle,
+	})
+
+	return newPodTemplate, nil
 }
 
 func buildClusterChecksRunnerConfigurationConfigMap(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.ConfigMap, error) {
@@ -411,6 +435,9 @@ func getEnvVarsForClusterChecksRunner(dda *datadoghqv1alpha1.DatadogAgent) []cor
 		})
 	}
 
+	envVars = append(envVars, getProxyEnvVars(dda)...)
+	envVars = append(envVars, getAdditionalEndpointsEnvVars(dda, datadoghqv1alpha1.DDAdditionalEndpoints)...)
+
 	return append(envVars, spec.ClusterChecksRunner.Config.Env...)
 }
 
@@ -496,84 +523,46 @@ func getClusterChecksRunnerCustomConfigConfigMapName(dda *datadoghqv1alpha1.Data
 
 // getPodAffinity returns the pod anti affinity of the cluster check runner pods
 // the default anti affinity ensures we don't schedule multiple cluster check runners on the same node
-func getPodAffinity(affinity *corev1.Affinity) *corev1.Affinity {
-	if affinity != nil {
-		return affinity
+func getPodAffinity(affinity *corev1.Affinity, preset *datadoghqv1alpha1.PodAntiAffinityPreset) *corev1.Affinity {
+	if affinity == nil && preset == nil {
+		preset = &datadoghqv1alpha1.PodAntiAffinityPreset{
+			Type:     datadoghqv1alpha1.PodAntiAffinityTypeHard,
+			Topology: datadoghqv1alpha1.PodAntiAffinityTopologyHost,
+		}
 	}
 
-	return &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-				{
-					LabelSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix,
-						},
-					},
-					TopologyKey: "kubernetes.io/hostname",
-				},
-			},
-		},
-	}
+	return getAffinity(affinity, preset, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix)
 }
 
 func (r *Reconciler) manageClusterChecksRunnerNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	policyName := fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix)
+	policyName := getNetworkPolicyName(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix)
 
-	spec := dda.Spec.ClusterChecksRunner
-	if spec == nil || !datadoghqv1alpha1.BoolValue(spec.NetworkPolicy.Create) {
-		return r.cleanupNetworkPolicy(logger, dda, policyName)
+	var spec *datadoghqv1alpha1.NetworkPolicySpec
+	if dda.Spec.ClusterChecksRunner != nil {
+		spec = &dda.Spec.ClusterChecksRunner.NetworkPolicy
 	}
 
-	return r.ensureNetworkPolicy(logger, dda, policyName, buildClusterChecksRunnerNetworkPolicy)
+	return r.manageNetworkPolicy(logger, dda, policyName, spec, buildClusterChecksRunnerNetworkPolicy, buildClusterChecksRunnerCiliumNetworkPolicy)
 }
 
 func buildClusterChecksRunnerNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *networkingv1.NetworkPolicy {
-	egressRules := []networkingv1.NetworkPolicyEgressRule{
-		// Egress to datadog intake and kubeapi server
-		{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: 443,
-					},
-				},
-			},
-		},
-	}
+	egressRules := getCommonEgressRules(dda)
 
 	if dda.Spec.ClusterAgent != nil {
-		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Port: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: datadoghqv1alpha1.DefaultClusterAgentServicePort,
-					},
-				},
-			},
-			To: []networkingv1.NetworkPolicyPeer{
-				{
-					PodSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"app": fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
-						},
-					},
-				},
-			},
-		})
+		egressRules = append(egressRules, getClusterAgentEgressRule(dda))
 	}
 
 	// The cluster check runners are susceptible to connect to any service
 	// that would be annotated with auto-discovery annotations.
 	//
-	// When a user wants to add a check on one of its service, he needs to
+	// When a user wants to add a check on one of its service, they need to
 	// * annotate its service
 	// * add an ingress policy from the CLC on its own pod
 	// In order to not ask end-users to inject NetworkPolicy on the agent in
-	// the agent namespace, the agent must be allowed to probe any service.
-	egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{})
+	// the agent namespace, the runners can be allowed to probe any service.
+	if isAutodiscoveryEgressAllowed(dda.Spec.ClusterChecksRunner.NetworkPolicy) {
+		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{})
+	}
 
 	policy := &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -583,10 +572,7 @@ func buildClusterChecksRunnerNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent,
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					kubernetes.AppKubernetesInstanceLabelKey: datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix,
-					kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
-				},
+				MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix),
 			},
 			Egress: egressRules,
 			PolicyTypes: []networkingv1.PolicyType{
@@ -598,3 +584,25 @@ func buildClusterChecksRunnerNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent,
 
 	return policy
 }
+
+func buildClusterChecksRunnerCiliumNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name string) *unstructured.Unstructured {
+	egressRules := getCiliumCommonEgressRules(dda, dda.Spec.ClusterChecksRunner.NetworkPolicy)
+
+	if dda.Spec.ClusterAgent != nil {
+		egressRules = append(egressRules, getCiliumClusterAgentEgressRule(dda))
+	}
+
+	if isAutodiscoveryEgressAllowed(dda.Spec.ClusterChecksRunner.NetworkPolicy) {
+		egressRules = append(egressRules, getCiliumAutodiscoveryEgressRule())
+	}
+
+	// A single empty ingress rule enforces the default deny of the ingress traffic
+	return newCiliumNetworkPolicy(dda, name, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix, getClusterChecksRunnerVersion(dda), ciliumNetworkPolicySpec{
+		Description: "Egress to the Datadog intake, the kube API server and the Cluster Agent",
+		EndpointSelector: metav1.LabelSelector{
+			MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix),
+		},
+		Ingress: []ciliumIngressRule{{}},
+		Egress:  egressRules,
+	})
+}
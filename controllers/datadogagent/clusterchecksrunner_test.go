@@ -22,7 +22,7 @@ var testClusterChecksRunnerReplicas int32 = 2
 
 func clusterChecksRunnerDefaultPodSpec() corev1.PodSpec {
 	return corev1.PodSpec{
-		Affinity:           getPodAffinity(nil),
+		Affinity:           getPodAffinity(nil, nil),
 		ServiceAccountName: "foo-cluster-checks-runner",
 		InitContainers: []corev1.Container{
 			{
@@ -460,7 +460,7 @@ func Test_getPodAffinity(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getPodAffinity(tt.affinity); !reflect.DeepEqual(got, tt.want) {
+			if got := getPodAffinity(tt.affinity, nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getPodAffinity() = %v, want %v", got, tt.want)
 			}
 		})
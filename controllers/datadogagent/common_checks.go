@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+)
+
+func getChecksConfigMapName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	return fmt.Sprintf("%s-checks", dda.Name)
+}
+
+func getCheckFileName(check *datadoghqv1alpha1.CheckConfig) string {
+	return fmt.Sprintf("%s.yaml", check.Name)
+}
+
+// reconcileChecks manages the ConfigMap of the checks, shared by the Agents and the Cluster Agent.
+// It is managed once for the DatadogAgent, whichever component is enabled, and deleted when there is no check.
+func (r *Reconciler) reconcileChecks(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) (reconcile.Result, error) {
+	return r.manageConfigMap(logger, dda, getChecksConfigMapName(dda), buildChecksConfigMap)
+}
+
+// buildChecksConfigMap renders the checks of the spec in a ConfigMap, each check in its conf.d file
+func buildChecksConfigMap(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.ConfigMap, error) {
+	if len(dda.Spec.Checks) == 0 {
+		return nil, nil
+	}
+
+	data := make(map[string]string, len(dda.Spec.Checks))
+	for i := range dda.Spec.Checks {
+		check := &dda.Spec.Checks[i]
+		config, err := renderCheckConfig(check)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render the check %q: %v", check.Name, err)
+		}
+		data[getCheckFileName(check)] = config
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getChecksConfigMapName(dda),
+			Namespace:   dda.Namespace,
+			Labels:      getDefaultLabels(dda, dda.Name, getAgentVersion(dda)),
+			Annotations: getDefaultAnnotations(dda),
+		},
+		Data: data,
+	}
+	return configMap, nil
+}
+
+// renderCheckConfig returns the content of the conf.d file of a check
+func renderCheckConfig(check *datadoghqv1alpha1.CheckConfig) (string, error) {
+	config := map[string]interface{}{
+		"init_config": map[string]interface{}{},
+	}
+	if check.InitConfig != nil {
+		initConfig := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(*check.InitConfig), &initConfig); err != nil {
+			return "", fmt.Errorf("unable to parse YAML from 'initConfig' field: %v", err)
+		}
+		config["init_config"] = initConfig
+	}
+	if check.Instances != nil {
+		var instances []interface{}
+		if err := yaml.Unmarshal([]byte(*check.Instances), &instances); err != nil {
+			return "", fmt.Errorf("unable to parse YAML from 'instances' field: %v", err)
+		}
+		config["instances"] = instances
+	}
+	if check.Logs != nil {
+		var logs []interface{}
+		if err := yaml.Unmarshal([]byte(*check.Logs), &logs); err != nil {
+			return "", fmt.Errorf("unable to parse YAML from 'logs' field: %v", err)
+		}
+		config["logs"] = logs
+	}
+	if datadoghqv1alpha1.BoolValue(check.ClusterCheck) {
+		config["cluster_check"] = true
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// getChecks returns the cluster checks run by the Cluster Agent if clusterChecks is true,
+// otherwise the checks run by every Agent
+func getChecks(dda *datadoghqv1alpha1.DatadogAgent, clusterChecks bool) []datadoghqv1alpha1.CheckConfig {
+	var checks []datadoghqv1alpha1.CheckConfig
+	for _, check := range dda.Spec.Checks {
+		if datadoghqv1alpha1.BoolValue(check.ClusterCheck) == clusterChecks {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// getChecksHash returns the hash of the checks, set on the pod templates to roll the pods when the checks change
+func getChecksHash(checks []datadoghqv1alpha1.CheckConfig) string {
+	if len(checks) == 0 {
+		return ""
+	}
+	// Marshalling the checks can't fail
+	hash, _ := comparison.GenerateMD5ForSpec(checks)
+	return hash
+}
+
+// getConfdVolumeSource returns the source of the Confd volume: the Confd ConfigMap of the user and the managed checks,
+// projected in the same directory
+func getConfdVolumeSource(dda *datadoghqv1alpha1.DatadogAgent, confd *datadoghqv1alpha1.ConfigDirSpec, checks []datadoghqv1alpha1.CheckConfig) corev1.VolumeSource {
+	if len(checks) == 0 {
+		if confd == nil {
+			return corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			}
+		}
+		return corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: confd.ConfigMapName,
+				},
+			},
+		}
+	}
+
+	var sources []corev1.VolumeProjection
+	if confd != nil {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: confd.ConfigMapName,
+				},
+			},
+		})
+	}
+	items := make([]corev1.KeyToPath, 0, len(checks))
+	for i := range checks {
+		fileName := getCheckFileName(&checks[i])
+		items = append(items, corev1.KeyToPath{
+			Key:  fileName,
+			Path: fileName,
+		})
+	}
+	sources = append(sources, corev1.VolumeProjection{
+		ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: getChecksConfigMapName(dda),
+			},
+			Items: items,
+		},
+	})
+	return corev1.VolumeSource{
+		Projected: &corev1.ProjectedVolumeSource{
+			Sources: sources,
+		},
+	}
+}
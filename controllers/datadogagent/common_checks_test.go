@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func newTestChecksDatadogAgent() *datadoghqv1alpha1.DatadogAgent {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, ClusterChecksEnabled: true})
+	dda.Spec.Checks = []datadoghqv1alpha1.CheckConfig{
+		{
+			Name:       "redisdb",
+			InitConfig: datadoghqv1alpha1.NewStringPointer("service: redis"),
+			Instances:  datadoghqv1alpha1.NewStringPointer("- host: localhost\n  port: 6379"),
+			Logs:       datadoghqv1alpha1.NewStringPointer("- type: file\n  path: /var/log/redis.log"),
+		},
+		{
+			Name:         "http_check",
+			Instances:    datadoghqv1alpha1.NewStringPointer("- name: example\n  url: https://example.com"),
+			ClusterCheck: datadoghqv1alpha1.NewBoolPointer(true),
+		},
+	}
+	return dda
+}
+
+func TestBuildChecksConfigMap(t *testing.T) {
+	dda := newTestChecksDatadogAgent()
+
+	configMap, err := buildChecksConfigMap(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-checks", configMap.Name)
+	assert.Equal(t, map[string]string{
+		"redisdb.yaml": `init_config:
+  service: redis
+instances:
+- host: localhost
+  port: 6379
+logs:
+- path: /var/log/redis.log
+  type: file
+`,
+		"http_check.yaml": `cluster_check: true
+init_config: {}
+instances:
+- name: example
+  url: https://example.com
+`,
+	}, configMap.Data)
+
+	// Invalid YAML is reported
+	dda.Spec.Checks[0].Instances = datadoghqv1alpha1.NewStringPointer("host: [")
+	_, err = buildChecksConfigMap(dda)
+	assert.Error(t, err)
+
+	// Without checks, the ConfigMap is deleted
+	dda.Spec.Checks = nil
+	configMap, err = buildChecksConfigMap(dda)
+	assert.NoError(t, err)
+	assert.Nil(t, configMap)
+}
+
+func TestReconcileChecks(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileChecks")
+
+	dda := newTestChecksDatadogAgent()
+	c := fake.NewFakeClient()
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	// The ConfigMap is managed once for the Agents and the Cluster Agent
+	_, err := r.reconcileChecks(logger, dda, &datadoghqv1alpha1.DatadogAgentStatus{})
+	assert.NoError(t, err)
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-checks"}, configMap))
+	assert.Len(t, configMap.Data, 2)
+
+	dda.Spec.Checks = nil
+	_, err = r.reconcileChecks(logger, dda, &datadoghqv1alpha1.DatadogAgentStatus{})
+	assert.NoError(t, err)
+	err = c.Get(context.TODO(), types.NamespacedName{Namespace: "bar", Name: "foo-checks"}, configMap)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestGetConfdVolumeSource(t *testing.T) {
+	dda := newTestChecksDatadogAgent()
+	confd := &datadoghqv1alpha1.ConfigDirSpec{ConfigMapName: "user-confd"}
+
+	// Without checks the volume isn't changed
+	assert.Equal(t, &corev1.EmptyDirVolumeSource{}, getConfdVolumeSource(dda, nil, nil).EmptyDir)
+	assert.Equal(t, "user-confd", getConfdVolumeSource(dda, confd, nil).ConfigMap.Name)
+
+	// The checks are projected with the Confd ConfigMap, the Agents and the Cluster Agent get their own checks
+	source := getConfdVolumeSource(dda, confd, getChecks(dda, false))
+	assert.Equal(t, []corev1.VolumeProjection{
+		{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "user-confd"}}},
+		{ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "foo-checks"},
+			Items:                []corev1.KeyToPath{{Key: "redisdb.yaml", Path: "redisdb.yaml"}},
+		}},
+	}, source.Projected.Sources)
+
+	source = getConfdVolumeSource(dda, nil, getChecks(dda, true))
+	assert.Equal(t, []corev1.VolumeProjection{
+		{ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "foo-checks"},
+			Items:                []corev1.KeyToPath{{Key: "http_check.yaml", Path: "http_check.yaml"}},
+		}},
+	}, source.Projected.Sources)
+}
+
+func TestChecksHashAnnotation(t *testing.T) {
+	dda := newTestChecksDatadogAgent()
+
+	template, err := newAgentPodTemplate(dda, nil)
+	assert.NoError(t, err)
+	agentHash := template.Annotations[datadoghqv1alpha1.ChecksHashAnnotationKey]
+	assert.NotEmpty(t, agentHash)
+	dcaTemplate, err := newClusterAgentPodTemplate(dda, nil, nil)
+	assert.NoError(t, err)
+	dcaHash := dcaTemplate.Annotations[datadoghqv1alpha1.ChecksHashAnnotationKey]
+	assert.NotEmpty(t, dcaHash)
+
+	// Only the pods running the updated check are rolled
+	dda.Spec.Checks[1].Instances = datadoghqv1alpha1.NewStringPointer("- name: example\n  url: https://example.org")
+	template, err = newAgentPodTemplate(dda, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, agentHash, template.Annotations[datadoghqv1alpha1.ChecksHashAnnotationKey])
+	dcaTemplate, err = newClusterAgentPodTemplate(dda, nil, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, dcaHash, dcaTemplate.Annotations[datadoghqv1alpha1.ChecksHashAnnotationKey])
+}
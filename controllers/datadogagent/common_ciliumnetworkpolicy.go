@@ -0,0 +1,292 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+)
+
+const (
+	ciliumEntityKubeAPIServer = "kube-apiserver"
+	ciliumEntityHost          = "host"
+	ciliumEntityRemoteNode    = "remote-node"
+	ciliumEntityAll           = "all"
+
+	ciliumNamespaceLabelKey = "k8s:io.kubernetes.pod.namespace"
+)
+
+var ciliumNetworkPolicyGVK = schema.GroupVersionKind{Group: "cilium.io", Version: "v2", Kind: ciliumNetworkPolicyKind}
+
+// defaultDNSSelectorEndpoints selects the kube-dns pods, used when no DNS endpoint is configured
+var defaultDNSSelectorEndpoints = []metav1.LabelSelector{
+	{
+		MatchLabels: map[string]string{
+			ciliumNamespaceLabelKey: "kube-system",
+			"k8s:k8s-app":           "kube-dns",
+		},
+	},
+}
+
+type ciliumNetworkPolicyBuilder func(dda *datadoghqv1alpha1.DatadogAgent, name string) *unstructured.Unstructured
+
+// The following types describe the subset of the CiliumNetworkPolicy spec used by the operator
+
+type ciliumNetworkPolicySpec struct {
+	Description      string               `json:"description,omitempty"`
+	EndpointSelector metav1.LabelSelector `json:"endpointSelector"`
+	Ingress          []ciliumIngressRule  `json:"ingress,omitempty"`
+	Egress           []ciliumEgressRule   `json:"egress,omitempty"`
+}
+
+type ciliumIngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromEntities  []string               `json:"fromEntities,omitempty"`
+	ToPorts       []ciliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type ciliumEgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToEntities  []string               `json:"toEntities,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToFQDNs     []ciliumFQDNSelector   `json:"toFQDNs,omitempty"`
+	ToPorts     []ciliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type ciliumFQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+type ciliumPortRule struct {
+	Ports []ciliumPortProtocol `json:"ports,omitempty"`
+	Rules *ciliumL7Rules       `json:"rules,omitempty"`
+}
+
+type ciliumPortProtocol struct {
+	Port     string `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+type ciliumL7Rules struct {
+	DNS []ciliumFQDNSelector `json:"dns,omitempty"`
+}
+
+func (r *Reconciler) ensureCiliumNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, policyName string, builder ciliumNetworkPolicyBuilder) (reconcile.Result, error) {
+	if !r.options.SupportCilium {
+		return reconcile.Result{}, fmt.Errorf("unable to create %s %s: the cilium.io/v2 API isn't available", ciliumNetworkPolicyKind, policyName)
+	}
+
+	newPolicy := builder(dda, policyName)
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ciliumNetworkPolicyGVK)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: policyName, Namespace: dda.Namespace}, policy)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.createCiliumNetworkPolicy(logger, dda, newPolicy)
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	return r.updateCiliumNetworkPolicy(logger, dda, policy, newPolicy)
+}
+
+func (r *Reconciler) cleanupCiliumNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, name string) (reconcile.Result, error) {
+	if !r.options.SupportCilium {
+		return reconcile.Result{}, nil
+	}
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ciliumNetworkPolicyGVK)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: dda.Namespace}, policy)
+	if err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	if !ownedByDatadogOperator(policy.GetOwnerReferences()) {
+		return reconcile.Result{}, nil
+	}
+
+	logger.V(1).Info("deleteCiliumNetworkPolicy", "ciliumNetworkPolicy.name", policy.GetName(), "ciliumNetworkPolicy.Namespace", policy.GetNamespace())
+	event := buildEventInfo(policy.GetName(), policy.GetNamespace(), ciliumNetworkPolicyKind, datadog.DeletionEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, r.client.Delete(context.TODO(), policy)
+}
+
+func (r *Reconciler) createCiliumNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, policy *unstructured.Unstructured) (reconcile.Result, error) {
+	if err := controllerutil.SetControllerReference(dda, policy, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.V(1).Info("createCiliumNetworkPolicy", "ciliumNetworkPolicy.name", policy.GetName(), "ciliumNetworkPolicy.Namespace", policy.GetNamespace())
+	event := buildEventInfo(policy.GetName(), policy.GetNamespace(), ciliumNetworkPolicyKind, datadog.CreationEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, r.client.Create(context.TODO(), policy)
+}
+
+func (r *Reconciler) updateCiliumNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, policy, newPolicy *unstructured.Unstructured) (reconcile.Result, error) {
+	hash := newPolicy.GetAnnotations()[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
+	if comparison.IsSameSpecMD5Hash(hash, policy.GetAnnotations()) {
+		return reconcile.Result{}, nil
+	}
+
+	updated := policy.DeepCopy()
+	updated.SetLabels(newPolicy.GetLabels())
+	updated.SetAnnotations(newPolicy.GetAnnotations())
+	updated.Object["spec"] = newPolicy.Object["spec"]
+
+	logger.V(1).Info("updateCiliumNetworkPolicy", "ciliumNetworkPolicy.name", policy.GetName(), "ciliumNetworkPolicy.Namespace", policy.GetNamespace())
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	event := buildEventInfo(policy.GetName(), policy.GetNamespace(), ciliumNetworkPolicyKind, datadog.UpdateEvent)
+	r.recordEvent(dda, event)
+
+	return reconcile.Result{}, nil
+}
+
+// newCiliumNetworkPolicy converts the spec into an unstructured CiliumNetworkPolicy
+func newCiliumNetworkPolicy(dda *datadoghqv1alpha1.DatadogAgent, name, component, version string, spec ciliumNetworkPolicySpec) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		// The spec only contains serializable fields
+		content = map[string]interface{}{}
+	}
+
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content}}
+	policy.SetGroupVersionKind(ciliumNetworkPolicyGVK)
+	policy.SetName(name)
+	policy.SetNamespace(dda.Namespace)
+	policy.SetLabels(getDefaultLabels(dda, component, version))
+
+	annotations := getDefaultAnnotations(dda)
+	if hash, err := comparison.GenerateMD5ForSpec(content); err == nil {
+		annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey] = hash
+	}
+	policy.SetAnnotations(annotations)
+	return policy
+}
+
+func getCiliumPortRule(protocol string, ports ...int32) ciliumPortRule {
+	rule := ciliumPortRule{}
+	for _, port := range ports {
+		rule.Ports = append(rule.Ports, ciliumPortProtocol{
+			Port:     strconv.Itoa(int(port)),
+			Protocol: protocol,
+		})
+	}
+	return rule
+}
+
+// getCiliumCommonEgressRules returns the egress rules shared by all the agents:
+// the Datadog intake filtered by FQDN, the kube API server and the DNS
+func getCiliumCommonEgressRules(dda *datadoghqv1alpha1.DatadogAgent, spec datadoghqv1alpha1.NetworkPolicySpec) []ciliumEgressRule {
+	var fqdns []ciliumFQDNSelector
+	for _, site := range getIntakeSites(dda) {
+		fqdns = append(fqdns, ciliumFQDNSelector{MatchPattern: fmt.Sprintf("*.%s", site)})
+	}
+	var cidrs []string
+	for _, u := range getCustomIntakeURLs(dda) {
+		// FQDN selectors only match names, the endpoints configured by IP are selected by CIDR
+		if ip := net.ParseIP(u.Hostname()); ip != nil {
+			cidrs = append(cidrs, getSingleIPCIDR(ip))
+			continue
+		}
+		fqdns = append(fqdns, ciliumFQDNSelector{MatchName: u.Hostname()})
+	}
+
+	dnsSelectorEndpoints := spec.DNSSelectorEndpoints
+	if len(dnsSelectorEndpoints) == 0 {
+		dnsSelectorEndpoints = defaultDNSSelectorEndpoints
+	}
+
+	dnsPortRule := getCiliumPortRule("ANY", dnsPort)
+	dnsPortRule.Rules = &ciliumL7Rules{
+		DNS: []ciliumFQDNSelector{{MatchPattern: "*"}},
+	}
+
+	rules := []ciliumEgressRule{
+		{
+			ToFQDNs: fqdns,
+			ToPorts: []ciliumPortRule{getCiliumPortRule("TCP", getIntakePorts(dda)...)},
+		},
+		{
+			ToEntities: []string{ciliumEntityKubeAPIServer},
+		},
+		{
+			ToEndpoints: dnsSelectorEndpoints,
+			ToPorts:     []ciliumPortRule{dnsPortRule},
+		},
+	}
+	if len(cidrs) > 0 {
+		rules = append(rules, ciliumEgressRule{
+			ToCIDR:  cidrs,
+			ToPorts: []ciliumPortRule{getCiliumPortRule("TCP", getIntakePorts(dda)...)},
+		})
+	}
+	return rules
+}
+
+func getSingleIPCIDR(ip net.IP) string {
+	if ip.To4() != nil {
+		return fmt.Sprintf("%s/32", ip.String())
+	}
+	return fmt.Sprintf("%s/128", ip.String())
+}
+
+// getCiliumClusterAgentEgressRule returns the egress rule to the Cluster Agent of the DatadogAgent
+func getCiliumClusterAgentEgressRule(dda *datadoghqv1alpha1.DatadogAgent) ciliumEgressRule {
+	return ciliumEgressRule{
+		ToEndpoints: []metav1.LabelSelector{
+			{
+				MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
+			},
+		},
+		ToPorts: []ciliumPortRule{getCiliumPortRule("TCP", datadoghqv1alpha1.DefaultClusterAgentServicePort)},
+	}
+}
+
+// getCiliumAutodiscoveryEgressRule returns the egress rule to the pods and nodes of the cluster, targeted by the autodiscovered checks
+func getCiliumAutodiscoveryEgressRule() ciliumEgressRule {
+	return ciliumEgressRule{
+		ToEndpoints: []metav1.LabelSelector{
+			{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      ciliumNamespaceLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			},
+		},
+		ToEntities: []string{ciliumEntityHost, ciliumEntityRemoteNode},
+	}
+}
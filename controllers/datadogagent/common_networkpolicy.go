@@ -2,21 +2,65 @@ package datadogagent
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+	"github.com/DataDog/datadog-operator/pkg/kubernetes"
 )
 
+const (
+	defaultSite       = "datadoghq.com"
+	defaultIntakePort = 443
+	dnsPort           = 53
+	kubeletPort       = 10250
+	// logsTCPIntakePort is the port of the logs intake when the logs are sent over TCP
+	logsTCPIntakePort = 10516
+)
+
+// apiServerPorts are the usual ports of the kube API server: NetworkPolicies can't select it by name
+var apiServerPorts = []int32{443, 6443}
+
 type networkPolicyBuilder func(dda *datadoghqv1alpha1.DatadogAgent, name string) *networkingv1.NetworkPolicy
 
+// manageNetworkPolicy creates the network policy of the flavor selected in the spec and deletes the other one
+func (r *Reconciler) manageNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, policyName string, spec *datadoghqv1alpha1.NetworkPolicySpec, builder networkPolicyBuilder, ciliumBuilder ciliumNetworkPolicyBuilder) (reconcile.Result, error) {
+	if spec == nil || !datadoghqv1alpha1.BoolValue(spec.Create) {
+		result, err := r.cleanupNetworkPolicy(logger, dda, policyName)
+		if shouldReturn(result, err) {
+			return result, err
+		}
+		return r.cleanupCiliumNetworkPolicy(logger, dda, policyName)
+	}
+
+	if spec.Flavor == datadoghqv1alpha1.NetworkPolicyFlavorCilium {
+		result, err := r.cleanupNetworkPolicy(logger, dda, policyName)
+		if shouldReturn(result, err) {
+			return result, err
+		}
+		return r.ensureCiliumNetworkPolicy(logger, dda, policyName, ciliumBuilder)
+	}
+
+	result, err := r.cleanupCiliumNetworkPolicy(logger, dda, policyName)
+	if shouldReturn(result, err) {
+		return result, err
+	}
+	return r.ensureNetworkPolicy(logger, dda, policyName, builder)
+}
+
 func (r *Reconciler) ensureNetworkPolicy(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, policyName string, builder networkPolicyBuilder) (reconcile.Result, error) {
 	policy := &networkingv1.NetworkPolicy{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: policyName, Namespace: dda.Namespace}, policy)
@@ -90,3 +134,147 @@ func (r *Reconciler) updateNetworkPolicy(logger logr.Logger, dda *datadoghqv1alp
 
 	return reconcile.Result{}, nil
 }
+
+// getIntakeSite returns the Datadog site the agents send their data to
+func getIntakeSite(dda *datadoghqv1alpha1.DatadogAgent) string {
+	if dda.Spec.Site != "" {
+		return dda.Spec.Site
+	}
+	return defaultSite
+}
+
+// getIntakeSites returns the Datadog site the agents send their data to and the sites of the additional endpoints
+func getIntakeSites(dda *datadoghqv1alpha1.DatadogAgent) []string {
+	site := getIntakeSite(dda)
+	sites := []string{site}
+	seen := map[string]bool{site: true}
+	for _, endpoint := range dda.Spec.AdditionalEndpoints {
+		if endpoint.Site == "" || seen[endpoint.Site] {
+			continue
+		}
+		seen[endpoint.Site] = true
+		sites = append(sites, endpoint.Site)
+	}
+	return sites
+}
+
+// getCustomIntakeURLs returns the endpoints and proxies configured in the spec that may be outside of the Datadog site
+func getCustomIntakeURLs(dda *datadoghqv1alpha1.DatadogAgent) []*url.URL {
+	var endpoints []string
+	if proxy := dda.Spec.Proxy; proxy != nil {
+		for _, proxyURL := range []*string{proxy.HTTP, proxy.HTTPS} {
+			if proxyURL != nil {
+				endpoints = append(endpoints, *proxyURL)
+			}
+		}
+	}
+	if dda.Spec.Agent != nil && dda.Spec.Agent.Config.DDUrl != nil {
+		endpoints = append(endpoints, *dda.Spec.Agent.Config.DDUrl)
+	}
+	if dda.Spec.Agent != nil && dda.Spec.Agent.Log.DDUrl != nil {
+		// The logs endpoint is configured as <host>:<port>, without scheme
+		endpoints = append(endpoints, "//"+*dda.Spec.Agent.Log.DDUrl)
+	}
+	if dda.Spec.ClusterAgent != nil && dda.Spec.ClusterAgent.Config.ExternalMetrics != nil && dda.Spec.ClusterAgent.Config.ExternalMetrics.Endpoint != nil {
+		endpoints = append(endpoints, *dda.Spec.ClusterAgent.Config.ExternalMetrics.Endpoint)
+	}
+	for _, endpoint := range dda.Spec.AdditionalEndpoints {
+		if endpoint.URL != nil {
+			endpoints = append(endpoints, *endpoint.URL)
+		}
+	}
+
+	var urls []*url.URL
+	for _, endpoint := range endpoints {
+		if u, err := url.Parse(endpoint); err == nil && u.Hostname() != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// getIntakePorts returns the ports of the Datadog intake and of the custom endpoints
+func getIntakePorts(dda *datadoghqv1alpha1.DatadogAgent) []int32 {
+	ports := []int32{defaultIntakePort}
+	seen := map[int32]bool{defaultIntakePort: true}
+	if dda.Spec.Agent != nil && dda.Spec.Agent.Log.Transport != nil && dda.Spec.Agent.Log.Transport.Protocol == datadoghqv1alpha1.LogTransportProtocolTCP {
+		ports = append(ports, logsTCPIntakePort)
+		seen[logsTCPIntakePort] = true
+	}
+	for _, u := range getCustomIntakeURLs(dda) {
+		port, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil || seen[int32(port)] {
+			continue
+		}
+		seen[int32(port)] = true
+		ports = append(ports, int32(port))
+	}
+	return ports
+}
+
+func getNetworkPolicyPorts(protocol corev1.Protocol, ports ...int32) []networkingv1.NetworkPolicyPort {
+	policyPorts := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+	for _, port := range ports {
+		policyPort := networkingv1.NetworkPolicyPort{
+			Port: &intstr.IntOrString{
+				Type:   intstr.Int,
+				IntVal: port,
+			},
+		}
+		if protocol != "" {
+			protocol := protocol
+			policyPort.Protocol = &protocol
+		}
+		policyPorts = append(policyPorts, policyPort)
+	}
+	return policyPorts
+}
+
+// getCommonEgressRules returns the egress rules shared by all the agents:
+// the Datadog intake, the kube API server and the DNS
+func getCommonEgressRules(dda *datadoghqv1alpha1.DatadogAgent) []networkingv1.NetworkPolicyEgressRule {
+	return []networkingv1.NetworkPolicyEgressRule{
+		// Egress to the Datadog intake, NetworkPolicies can't filter FQDNs
+		{
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, getIntakePorts(dda)...),
+		},
+		// Egress to the kube API server
+		{
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, apiServerPorts...),
+		},
+		// Egress to the DNS
+		{
+			Ports: append(getNetworkPolicyPorts(corev1.ProtocolUDP, dnsPort), getNetworkPolicyPorts(corev1.ProtocolTCP, dnsPort)...),
+		},
+	}
+}
+
+// getClusterAgentEgressRule returns the egress rule to the Cluster Agent of the DatadogAgent
+func getClusterAgentEgressRule(dda *datadoghqv1alpha1.DatadogAgent) networkingv1.NetworkPolicyEgressRule {
+	return networkingv1.NetworkPolicyEgressRule{
+		Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, datadoghqv1alpha1.DefaultClusterAgentServicePort),
+		To: []networkingv1.NetworkPolicyPeer{
+			{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: getNetworkPolicyPodLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix),
+				},
+			},
+		},
+	}
+}
+
+// getNetworkPolicyPodLabels returns the labels selecting the pods of a component of the DatadogAgent
+func getNetworkPolicyPodLabels(dda *datadoghqv1alpha1.DatadogAgent, component string) map[string]string {
+	return map[string]string{
+		kubernetes.AppKubernetesInstanceLabelKey: component,
+		kubernetes.AppKubernetesPartOfLabelKey:   dda.Name,
+	}
+}
+
+func isAutodiscoveryEgressAllowed(spec datadoghqv1alpha1.NetworkPolicySpec) bool {
+	return datadoghqv1alpha1.BoolValue(spec.AllowAutodiscovery)
+}
+
+func getNetworkPolicyName(dda *datadoghqv1alpha1.DatadogAgent, component string) string {
+	return fmt.Sprintf("%s-%s", dda.Name, component)
+}
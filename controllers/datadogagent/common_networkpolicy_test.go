@@ -0,0 +1,106 @@
+package datadogagent
+
+import (
+	"context"
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func TestBuildAgentNetworkPolicy_egress(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, CreateNetworkPolicy: true})
+	dda.Spec.Agent.Config.DDUrl = datadoghqv1alpha1.NewStringPointer("https://intake.example.com:8443")
+
+	policy := buildAgentNetworkPolicy(dda, "foo-agent")
+	assert.Equal(t, []networkingv1.NetworkPolicyEgressRule{
+		{Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, 443, 8443)},
+		{Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, 443, 6443)},
+		{Ports: append(getNetworkPolicyPorts(corev1.ProtocolUDP, 53), getNetworkPolicyPorts(corev1.ProtocolTCP, 53)...)},
+		{Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, 10250)},
+		{
+			Ports: getNetworkPolicyPorts(corev1.ProtocolTCP, 5005),
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/instance": "cluster-agent",
+							"app.kubernetes.io/part-of":  "foo",
+						},
+					},
+				},
+			},
+		},
+	}, policy.Spec.Egress)
+
+	// The autodiscovered check targets are opt-in
+	dda.Spec.Agent.NetworkPolicy.AllowAutodiscovery = datadoghqv1alpha1.NewBoolPointer(true)
+	policy = buildAgentNetworkPolicy(dda, "foo-agent")
+	assert.Equal(t, networkingv1.NetworkPolicyEgressRule{}, policy.Spec.Egress[len(policy.Spec.Egress)-1])
+}
+
+func TestReconcileDatadogAgent_manageNetworkPolicy_cilium(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_manageNetworkPolicy_cilium")
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true, CreateNetworkPolicy: true})
+	dda.Spec.Site = "datadoghq.eu"
+	c := fake.NewFakeClient()
+	policyKey := types.NamespacedName{Namespace: "bar", Name: "foo-agent"}
+
+	// The kubernetes flavor creates a NetworkPolicy
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	_, err := r.manageAgentNetworkPolicy(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), policyKey, &networkingv1.NetworkPolicy{}))
+
+	// The cilium flavor requires the CiliumNetworkPolicy API
+	dda.Spec.Agent.NetworkPolicy.Flavor = datadoghqv1alpha1.NetworkPolicyFlavorCilium
+	_, err = r.manageAgentNetworkPolicy(logger, dda)
+	assert.Error(t, err)
+
+	r = newCertificatesTestReconciler(c, ReconcilerOptions{SupportCilium: true})
+	_, err = r.manageAgentNetworkPolicy(logger, dda)
+	assert.NoError(t, err)
+	err = c.Get(context.TODO(), policyKey, &networkingv1.NetworkPolicy{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	ciliumPolicy := &unstructured.Unstructured{}
+	ciliumPolicy.SetGroupVersionKind(ciliumNetworkPolicyGVK)
+	assert.NoError(t, c.Get(context.TODO(), policyKey, ciliumPolicy))
+	assert.True(t, ownedByDatadogOperator(ciliumPolicy.GetOwnerReferences()))
+	egress, _, _ := unstructured.NestedSlice(ciliumPolicy.Object, "spec", "egress")
+	fqdns, _, _ := unstructured.NestedSlice(egress[0].(map[string]interface{}), "toFQDNs")
+	assert.Equal(t, []interface{}{map[string]interface{}{"matchPattern": "*.datadoghq.eu"}}, fqdns)
+	dnsEndpoints, _, _ := unstructured.NestedSlice(egress[2].(map[string]interface{}), "toEndpoints")
+	assert.Equal(t, []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{
+		"k8s:io.kubernetes.pod.namespace": "kube-system",
+		"k8s:k8s-app":                     "kube-dns",
+	}}}, dnsEndpoints)
+
+	// The CiliumNetworkPolicy follows the spec
+	dda.Spec.Agent.NetworkPolicy.DNSSelectorEndpoints = []metav1.LabelSelector{{MatchLabels: map[string]string{"k8s:app": "coredns"}}}
+	_, err = r.manageAgentNetworkPolicy(logger, dda)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.TODO(), policyKey, ciliumPolicy))
+	egress, _, _ = unstructured.NestedSlice(ciliumPolicy.Object, "spec", "egress")
+	dnsEndpoints, _, _ = unstructured.NestedSlice(egress[2].(map[string]interface{}), "toEndpoints")
+	assert.Equal(t, []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"k8s:app": "coredns"}}}, dnsEndpoints)
+
+	// and is deleted once the network policy is disabled
+	dda.Spec.Agent.NetworkPolicy.Create = datadoghqv1alpha1.NewBoolPointer(false)
+	_, err = r.manageAgentNetworkPolicy(logger, dda)
+	assert.NoError(t, err)
+	err = c.Get(context.TODO(), policyKey, ciliumPolicy)
+	assert.True(t, apierrors.IsNotFound(err))
+}
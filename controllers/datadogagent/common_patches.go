@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+// applyPodTemplatePatches applies in order the strategic merge patches of the spec to the pod template rendered by the operator,
+// and checks that the result is a valid pod template keeping the labels set by the operator
+func applyPodTemplatePatches(template *corev1.PodTemplateSpec, patches []datadoghqv1alpha1.PodTemplatePatch) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	current, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	for _, patch := range patches {
+		patchJSON, err := yaml.YAMLToJSON([]byte(patch.Patch))
+		if err != nil {
+			return fmt.Errorf("unable to parse the pod template patch %q: %v", patch.Name, err)
+		}
+		current, err = strategicpatch.StrategicMergePatch(current, patchJSON, corev1.PodTemplateSpec{})
+		if err != nil {
+			return fmt.Errorf("unable to apply the pod template patch %q: %v", patch.Name, err)
+		}
+	}
+
+	patched := corev1.PodTemplateSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(current))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&patched); err != nil {
+		return fmt.Errorf("invalid pod template after the patches: %v", err)
+	}
+	if err = isValidPatchedPodTemplate(template, &patched); err != nil {
+		return fmt.Errorf("invalid pod template after the patches: %v", err)
+	}
+
+	*template = patched
+	return nil
+}
+
+// isValidPatchedPodTemplate checks that the patched pod template can still be deployed by the operator
+func isValidPatchedPodTemplate(original, patched *corev1.PodTemplateSpec) error {
+	// The labels select the pods of the workload and can't be changed once it's created
+	for key, value := range original.Labels {
+		if patched.Labels[key] != value {
+			return fmt.Errorf("the label %s set by the operator can't be changed", key)
+		}
+	}
+
+	if len(patched.Spec.Containers) == 0 {
+		return fmt.Errorf("the pod must have at least one container")
+	}
+	volumes := map[string]bool{}
+	for _, volume := range patched.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	names := map[string]bool{}
+	for _, containers := range [][]corev1.Container{patched.Spec.InitContainers, patched.Spec.Containers} {
+		for _, container := range containers {
+			if container.Name == "" || container.Image == "" {
+				return fmt.Errorf("the containers must have a name and an image")
+			}
+			if names[container.Name] {
+				return fmt.Errorf("duplicated container %s", container.Name)
+			}
+			names[container.Name] = true
+			for _, mount := range container.VolumeMounts {
+				if !volumes[mount.Name] {
+					return fmt.Errorf("the volume %s mounted by the container %s doesn't exist", mount.Name, container.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
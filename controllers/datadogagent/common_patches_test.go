@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyPodTemplatePatches(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	template, err := newAgentPodTemplate(dda, nil)
+	assert.NoError(t, err)
+	original := template.DeepCopy()
+
+	// Without patches, the template is unchanged
+	assert.NoError(t, applyPodTemplatePatches(template, nil))
+	assert.Equal(t, original, template)
+
+	// The patches are applied in order, the containers are merged by name
+	assert.NoError(t, applyPodTemplatePatches(template, []datadoghqv1alpha1.PodTemplatePatch{
+		{Name: "runtime-class", Patch: "spec: {runtimeClassName: gvisor, shareProcessNamespace: true}"},
+		{Name: "host-aliases", Patch: `{"spec": {"hostAliases": [{"ip": "10.0.0.1", "hostnames": ["intake"]}]}}`},
+		{Name: "agent-security-context", Patch: `
+spec:
+  containers:
+  - name: agent
+    securityContext:
+      readOnlyRootFilesystem: true
+`},
+		{Name: "runtime-class-override", Patch: "spec: {runtimeClassName: kata}"},
+	}))
+	assert.Equal(t, "kata", *template.Spec.RuntimeClassName)
+	assert.True(t, *template.Spec.ShareProcessNamespace)
+	assert.Equal(t, []corev1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"intake"}}}, template.Spec.HostAliases)
+	assert.Len(t, template.Spec.Containers, len(original.Spec.Containers))
+	assert.Equal(t, "agent", template.Spec.Containers[0].Name)
+	assert.Equal(t, original.Spec.Containers[0].Image, template.Spec.Containers[0].Image)
+	assert.True(t, *template.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, original.Labels, template.Labels)
+}
+
+func TestApplyPodTemplatePatchesValidation(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	tests := map[string]string{
+		"unknown field":   "spec: {runtimeClass: gvisor}",
+		"operator label":  "metadata: {labels: {agent.datadoghq.com/component: other}}",
+		"no container":    "spec: {containers: [{name: agent, $patch: delete}]}",
+		"missing volume":  "spec: {volumes: [{name: config, $patch: delete}]}",
+		"container image": "spec: {containers: [{name: sidecar}]}",
+		"invalid YAML":    "spec: [",
+	}
+	for name, patch := range tests {
+		template, err := newAgentPodTemplate(dda, nil)
+		assert.NoError(t, err)
+		original := template.DeepCopy()
+		err = applyPodTemplatePatches(template, []datadoghqv1alpha1.PodTemplatePatch{{Name: "test", Patch: patch}})
+		assert.Error(t, err, name)
+		assert.Equal(t, original, template, name)
+	}
+}
+
+func TestNewDaemonSetFromInstancePatches(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	_, hash, err := newDaemonSetFromInstance(dda, nil)
+	assert.NoError(t, err)
+
+	// The patches are part of the spec hash
+	dda.Spec.Agent.PodTemplatePatches = []datadoghqv1alpha1.PodTemplatePatch{{Name: "runtime-class", Patch: "spec: {runtimeClassName: gvisor}"}}
+	ds, newHash, err := newDaemonSetFromInstance(dda, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "gvisor", *ds.Spec.Template.Spec.RuntimeClassName)
+	assert.NotEqual(t, hash, newHash)
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// containerOverrides contains the probes and the lifecycle hooks of a container set in the spec
+type containerOverrides struct {
+	livenessProbe  *corev1.Probe
+	readinessProbe *corev1.Probe
+	startupProbe   *corev1.Probe
+	lifecycle      *corev1.Lifecycle
+}
+
+// applyContainerOverrides merges the probes and the lifecycle hooks of the spec over the defaults of the container.
+// The overrides are validated with the spec: the probes without default have a handler, and the ports exist on the container.
+func applyContainerOverrides(container *corev1.Container, overrides containerOverrides) {
+	container.LivenessProbe = mergeProbe(container.LivenessProbe, overrides.livenessProbe)
+	container.ReadinessProbe = mergeProbe(container.ReadinessProbe, overrides.readinessProbe)
+	if overrides.startupProbe != nil {
+		// The startup probe checks the container like the liveness probe by default, with its own thresholds
+		container.StartupProbe = mergeProbe(container.LivenessProbe, overrides.startupProbe)
+	}
+	if overrides.lifecycle != nil {
+		lifecycle := &corev1.Lifecycle{}
+		if container.Lifecycle != nil {
+			lifecycle = container.Lifecycle.DeepCopy()
+		}
+		if overrides.lifecycle.PostStart != nil {
+			lifecycle.PostStart = overrides.lifecycle.PostStart.DeepCopy()
+		}
+		if overrides.lifecycle.PreStop != nil {
+			lifecycle.PreStop = overrides.lifecycle.PreStop.DeepCopy()
+		}
+		container.Lifecycle = lifecycle
+	}
+}
+
+// mergeProbe returns the default probe with the fields of the override that are set
+func mergeProbe(defaultProbe, override *corev1.Probe) *corev1.Probe {
+	if override == nil {
+		return defaultProbe
+	}
+	probe := override.DeepCopy()
+	if defaultProbe == nil {
+		return probe
+	}
+	if probe.Exec == nil && probe.HTTPGet == nil && probe.TCPSocket == nil {
+		probe.Handler = *defaultProbe.Handler.DeepCopy()
+	}
+	if probe.InitialDelaySeconds == 0 {
+		probe.InitialDelaySeconds = defaultProbe.InitialDelaySeconds
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = defaultProbe.TimeoutSeconds
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = defaultProbe.PeriodSeconds
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = defaultProbe.SuccessThreshold
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = defaultProbe.FailureThreshold
+	}
+	return probe
+}
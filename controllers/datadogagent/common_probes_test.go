@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newTestProbesContainer() *corev1.Container {
+	return &corev1.Container{
+		Name:           "agent",
+		Ports:          []corev1.ContainerPort{{Name: "dogstatsdport", ContainerPort: 8125, Protocol: corev1.ProtocolUDP}},
+		LivenessProbe:  getDefaultLivenessProbe(),
+		ReadinessProbe: getDefaultReadinessProbe(),
+	}
+}
+
+func TestApplyContainerOverrides(t *testing.T) {
+	// Without overrides, the defaults are kept
+	container := newTestProbesContainer()
+	applyContainerOverrides(container, containerOverrides{})
+	assert.Equal(t, newTestProbesContainer(), container)
+
+	// The fields that are set replace the ones of the default probes
+	container = newTestProbesContainer()
+	applyContainerOverrides(container, containerOverrides{
+		livenessProbe: &corev1.Probe{FailureThreshold: 12},
+		startupProbe:  &corev1.Probe{PeriodSeconds: 5, FailureThreshold: 60},
+		lifecycle: &corev1.Lifecycle{
+			PreStop: &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"sleep", "10"}}},
+		},
+	})
+	expectedLiveness := getDefaultLivenessProbe()
+	expectedLiveness.FailureThreshold = 12
+	assert.Equal(t, expectedLiveness, container.LivenessProbe)
+	assert.Equal(t, getDefaultReadinessProbe(), container.ReadinessProbe)
+
+	// The startup probe checks the container like the liveness probe
+	assert.Equal(t, expectedLiveness.HTTPGet, container.StartupProbe.HTTPGet)
+	assert.Equal(t, int32(5), container.StartupProbe.PeriodSeconds)
+	assert.Equal(t, int32(60), container.StartupProbe.FailureThreshold)
+	assert.Equal(t, expectedLiveness.TimeoutSeconds, container.StartupProbe.TimeoutSeconds)
+	assert.Nil(t, container.Lifecycle.PostStart)
+	assert.Equal(t, []string{"sleep", "10"}, container.Lifecycle.PreStop.Exec.Command)
+
+	// A handler replaces the default one
+	container = newTestProbesContainer()
+	applyContainerOverrides(container, containerOverrides{
+		readinessProbe: &corev1.Probe{Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("dogstatsdport")}}},
+	})
+	assert.Nil(t, container.ReadinessProbe.HTTPGet)
+	assert.Equal(t, intstr.FromString("dogstatsdport"), container.ReadinessProbe.TCPSocket.Port)
+	assert.Equal(t, getDefaultReadinessProbe().PeriodSeconds, container.ReadinessProbe.PeriodSeconds)
+}
+
+func TestNewAgentPodTemplateOverrides(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true})
+	dda.Spec.Agent.TerminationGracePeriodSeconds = datadoghqv1alpha1.NewInt64Pointer(60)
+	dda.Spec.Agent.Config.StartupProbe = &corev1.Probe{FailureThreshold: 30}
+	dda.Spec.ClusterAgent.TerminationGracePeriodSeconds = datadoghqv1alpha1.NewInt64Pointer(10)
+	dda.Spec.ClusterAgent.Config.ReadinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("agentport")}},
+	}
+
+	template, err := newAgentPodTemplate(dda, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), *template.Spec.TerminationGracePeriodSeconds)
+	assert.Equal(t, int32(30), template.Spec.Containers[0].StartupProbe.FailureThreshold)
+
+	dcaTemplate, err := newClusterAgentPodTemplate(dda, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), *dcaTemplate.Spec.TerminationGracePeriodSeconds)
+	assert.Equal(t, intstr.FromString("agentport"), dcaTemplate.Spec.Containers[0].ReadinessProbe.TCPSocket.Port)
+}
@@ -116,7 +116,7 @@ func (r *Reconciler) cleanupClusterRole(logger logr.Logger, client client.Client
 		}
 		return reconcile.Result{}, err
 	}
-	if !ownedByDatadogOperator(clusterRole.OwnerReferences) {
+	if !ownedByDatadogOperator(clusterRole.OwnerReferences) || ownedByOtherDatadogAgent(clusterRole.OwnerReferences, dda) {
 		return reconcile.Result{}, nil
 	}
 	logger.V(1).Info("deleteClusterRole", "clusterRole.name", clusterRole.Name, "clusterRole.Namespace", clusterRole.Namespace)
@@ -134,7 +134,7 @@ func (r *Reconciler) cleanupClusterRoleBinding(logger logr.Logger, client client
 		}
 		return reconcile.Result{}, err
 	}
-	if !ownedByDatadogOperator(clusterRoleBinding.OwnerReferences) {
+	if !ownedByDatadogOperator(clusterRoleBinding.OwnerReferences) || ownedByOtherDatadogAgent(clusterRoleBinding.OwnerReferences, dda) {
 		return reconcile.Result{}, nil
 	}
 	logger.V(1).Info("deleteClusterRoleBinding", "clusterRoleBinding.name", clusterRoleBinding.Name, "clusterRoleBinding.Namespace", clusterRoleBinding.Namespace)
@@ -0,0 +1,282 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+)
+
+// reference identifies a ConfigMap or a Secret used by a pod template, and the keys it uses (nil: all the keys)
+type reference struct {
+	kind string
+	name string
+	keys []string
+}
+
+// referenceTracker records the ConfigMaps and Secrets used by the components of each DatadogAgent,
+// in order to reconcile them when they change
+type referenceTracker struct {
+	mutex      sync.RWMutex
+	references map[types.NamespacedName]map[string][]reference
+}
+
+func (t *referenceTracker) set(dda types.NamespacedName, component string, references []reference) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.references == nil {
+		t.references = map[types.NamespacedName]map[string][]reference{}
+	}
+	if t.references[dda] == nil {
+		t.references[dda] = map[string][]reference{}
+	}
+	t.references[dda][component] = references
+}
+
+func (t *referenceTracker) delete(dda types.NamespacedName) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.references, dda)
+}
+
+func (t *referenceTracker) getReferencing(kind, namespace, name string) []types.NamespacedName {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	var ddas []types.NamespacedName
+	for dda, components := range t.references {
+		if dda.Namespace != namespace {
+			continue
+		}
+	componentsLoop:
+		for _, references := range components {
+			for _, ref := range references {
+				if ref.kind == kind && ref.name == name {
+					ddas = append(ddas, dda)
+					break componentsLoop
+				}
+			}
+		}
+	}
+	return ddas
+}
+
+// GetReferencingDatadogAgents returns the requests of the DatadogAgents whose pods use the ConfigMap or the Secret
+func (r *Reconciler) GetReferencingDatadogAgents(obj runtime.Object, meta metav1.Object) []reconcile.Request {
+	var kind string
+	switch obj.(type) {
+	case *corev1.ConfigMap:
+		kind = configMapKind
+	case *corev1.Secret:
+		kind = secretKind
+	default:
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, dda := range r.references.getReferencing(kind, meta.GetNamespace(), meta.GetName()) {
+		requests = append(requests, reconcile.Request{NamespacedName: dda})
+	}
+	return requests
+}
+
+// setReferencesHash folds the hash of the content of the ConfigMaps and Secrets used by the pod template in its annotations,
+// so that their changes roll the pods, then updates the hash of the workload spec.
+// A ConfigMap or a Secret is ignored if its "agent.datadoghq.com/rollout-on-change" annotation is "false".
+func (r *Reconciler) setReferencesHash(dda *datadoghqv1alpha1.DatadogAgent, component string, obj *metav1.ObjectMeta, template *corev1.PodTemplateSpec, spec interface{}) (string, error) {
+	references := getPodTemplateReferences(template)
+	r.references.set(types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}, component, references)
+
+	hash, err := r.getReferencesHash(dda.Namespace, references)
+	if err != nil {
+		return "", err
+	}
+	if hash != "" {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[datadoghqv1alpha1.ReferencesHashAnnotationKey] = hash
+	}
+	return comparison.SetMD5GenerationAnnotation(obj, spec)
+}
+
+func (r *Reconciler) getReferencesHash(namespace string, references []reference) (string, error) {
+	contents := map[string]map[string]string{}
+	for _, ref := range references {
+		var annotations map[string]string
+		data := map[string]string{}
+		key := types.NamespacedName{Namespace: namespace, Name: ref.name}
+		switch ref.kind {
+		case configMapKind:
+			configMap := &corev1.ConfigMap{}
+			if err := r.client.Get(context.TODO(), key, configMap); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return "", err
+			}
+			annotations = configMap.Annotations
+			for k, v := range configMap.Data {
+				data[k] = v
+			}
+			for k, v := range configMap.BinaryData {
+				data[k] = string(v)
+			}
+		case secretKind:
+			secret := &corev1.Secret{}
+			if err := r.client.Get(context.TODO(), key, secret); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return "", err
+			}
+			annotations = secret.Annotations
+			for k, v := range secret.Data {
+				data[k] = string(v)
+			}
+		}
+
+		if annotations[datadoghqv1alpha1.RolloutOnChangeAnnotationKey] == "false" {
+			continue
+		}
+		if ref.keys != nil {
+			usedData := map[string]string{}
+			for _, k := range ref.keys {
+				if v, found := data[k]; found {
+					usedData[k] = v
+				}
+			}
+			data = usedData
+		}
+		contents[ref.kind+"/"+ref.name] = data
+	}
+
+	if len(contents) == 0 {
+		return "", nil
+	}
+	return comparison.GenerateMD5ForSpec(contents)
+}
+
+// getPodTemplateReferences returns the ConfigMaps and Secrets used by the volumes and the env vars of the pod template
+func getPodTemplateReferences(template *corev1.PodTemplateSpec) []reference {
+	refs := referencesBuilder{}
+	for _, volume := range template.Spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			refs.add(configMapKind, volume.ConfigMap.Name, keysOf(volume.ConfigMap.Items))
+		case volume.Secret != nil:
+			refs.add(secretKind, volume.Secret.SecretName, keysOf(volume.Secret.Items))
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					refs.add(configMapKind, source.ConfigMap.Name, keysOf(source.ConfigMap.Items))
+				}
+				if source.Secret != nil {
+					refs.add(secretKind, source.Secret.Name, keysOf(source.Secret.Items))
+				}
+			}
+		}
+	}
+
+	containers := append([]corev1.Container{}, template.Spec.InitContainers...)
+	containers = append(containers, template.Spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				refs.add(configMapKind, envFrom.ConfigMapRef.Name, nil)
+			}
+			if envFrom.SecretRef != nil {
+				refs.add(secretKind, envFrom.SecretRef.Name, nil)
+			}
+		}
+		for _, envVar := range container.Env {
+			if envVar.ValueFrom == nil {
+				continue
+			}
+			if ref := envVar.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs.add(configMapKind, ref.Name, []string{ref.Key})
+			}
+			if ref := envVar.ValueFrom.SecretKeyRef; ref != nil {
+				refs.add(secretKind, ref.Name, []string{ref.Key})
+			}
+		}
+	}
+	return refs.build()
+}
+
+func keysOf(items []corev1.KeyToPath) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+type referenceID struct {
+	kind string
+	name string
+}
+
+// referencesBuilder merges the keys used by the different references to the same object
+type referencesBuilder map[referenceID]map[string]bool
+
+func (b referencesBuilder) add(kind, name string, keys []string) {
+	if name == "" {
+		return
+	}
+	id := referenceID{kind: kind, name: name}
+	usedKeys, found := b[id]
+	if found && usedKeys == nil {
+		// all the keys are already used
+		return
+	}
+	if keys == nil {
+		b[id] = nil
+		return
+	}
+	if usedKeys == nil {
+		usedKeys = map[string]bool{}
+		b[id] = usedKeys
+	}
+	for _, key := range keys {
+		usedKeys[key] = true
+	}
+}
+
+func (b referencesBuilder) build() []reference {
+	references := make([]reference, 0, len(b))
+	for id, usedKeys := range b {
+		ref := reference{kind: id.kind, name: id.name}
+		if usedKeys != nil {
+			ref.keys = make([]string, 0, len(usedKeys))
+			for key := range usedKeys {
+				ref.keys = append(ref.keys, key)
+			}
+			sort.Strings(ref.keys)
+		}
+		references = append(references, ref)
+	}
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].kind != references[j].kind {
+			return references[i].kind < references[j].kind
+		}
+		return references[i].name < references[j].name
+	})
+	return references
+}
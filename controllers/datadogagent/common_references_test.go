@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newTestReferencesPodTemplate() *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "confd",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "confd"}},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name: "agent",
+					Env: []corev1.EnvVar{
+						getSecretEnvVar("DD_API_KEY", "keys", "api_key"),
+						getSecretEnvVar("DD_APP_KEY", "keys", "app_key"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetPodTemplateReferences(t *testing.T) {
+	template := newTestReferencesPodTemplate()
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: "checks",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{ConfigMap: &corev1.ConfigMapProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "confd"},
+						Items:                []corev1.KeyToPath{{Key: "redisdb.yaml", Path: "redisdb.yaml"}},
+					}},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, []reference{
+		{kind: configMapKind, name: "confd"},
+		{kind: secretKind, name: "keys", keys: []string{"api_key", "app_key"}},
+	}, getPodTemplateReferences(template))
+}
+
+func TestReconcileDatadogAgent_setReferencesHash(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "confd"},
+		Data:       map[string]string{"redisdb.yaml": "instances: [{}]"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "keys"},
+		Data:       map[string][]byte{"api_key": []byte("foo"), "app_key": []byte("bar"), "unused": []byte("baz")},
+	}
+	c := fake.NewFakeClient(configMap, secret)
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+
+	getHashes := func() (string, string) {
+		template := newTestReferencesPodTemplate()
+		meta := &metav1.ObjectMeta{}
+		specHash, err := r.setReferencesHash(dda, datadoghqv1alpha1.DefaultAgentResourceSuffix, meta, template, template)
+		assert.NoError(t, err)
+		assert.Equal(t, specHash, meta.Annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey])
+		return template.Annotations[datadoghqv1alpha1.ReferencesHashAnnotationKey], specHash
+	}
+	referencesHash, specHash := getHashes()
+	assert.NotEmpty(t, referencesHash)
+
+	// The changes of the keys that aren't used don't roll the pods
+	secret.Data["unused"] = []byte("qux")
+	assert.NoError(t, c.Update(context.TODO(), secret))
+	newReferencesHash, newSpecHash := getHashes()
+	assert.Equal(t, referencesHash, newReferencesHash)
+	assert.Equal(t, specHash, newSpecHash)
+
+	// The changes of the used keys do
+	secret.Data["api_key"] = []byte("qux")
+	assert.NoError(t, c.Update(context.TODO(), secret))
+	newReferencesHash, newSpecHash = getHashes()
+	assert.NotEqual(t, referencesHash, newReferencesHash)
+	assert.NotEqual(t, specHash, newSpecHash)
+	referencesHash = newReferencesHash
+
+	// unless the object opts out
+	configMap.Annotations = map[string]string{datadoghqv1alpha1.RolloutOnChangeAnnotationKey: "false"}
+	configMap.Data["redisdb.yaml"] = "instances: [{}, {}]"
+	assert.NoError(t, c.Update(context.TODO(), configMap))
+	newReferencesHash, _ = getHashes()
+	assert.NotEqual(t, referencesHash, newReferencesHash)
+	configMap.Data["redisdb.yaml"] = "instances: [{}]"
+	assert.NoError(t, c.Update(context.TODO(), configMap))
+	referencesHash, _ = getHashes()
+	assert.Equal(t, newReferencesHash, referencesHash)
+
+	// The DatadogAgent using the objects is reconciled when they change
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "bar", Name: "foo"}}
+	assert.Equal(t, []reconcile.Request{request}, r.GetReferencingDatadogAgents(configMap, configMap))
+	assert.Equal(t, []reconcile.Request{request}, r.GetReferencingDatadogAgents(secret, secret))
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "other"}}
+	assert.Empty(t, r.GetReferencingDatadogAgents(other, other))
+
+	r.references.delete(request.NamespacedName)
+	assert.Empty(t, r.GetReferencingDatadogAgents(secret, secret))
+}
@@ -0,0 +1,269 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	edsdatadoghqv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/comparison"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
+)
+
+const (
+	// rolloutDeadline is the duration after which a rollout that isn't completed is reported as failed
+	rolloutDeadline = 30 * time.Minute
+	// progressDeadlineExceededReason is the reason of the Progressing condition of a Deployment whose rollout is stuck
+	progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// workloadKey identifies a DaemonSet, ExtendedDaemonSet or Deployment managed by a DatadogAgent
+type workloadKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// workload is the state of a DaemonSet, ExtendedDaemonSet or Deployment used to follow its rollouts
+type workload struct {
+	workloadKey
+	// specHash is the hash of the spec set by the operator in the annotations
+	specHash string
+	template *corev1.PodTemplateSpec
+	// complete is true when all the pods run the current pod template
+	complete bool
+	// failure is set when the rollout of the current pod template failed
+	failure string
+	// replicaSet is the active ReplicaSet of an ExtendedDaemonSet
+	replicaSet string
+	// canary is the canary ReplicaSet of an ExtendedDaemonSet
+	canary string
+	paused bool
+	// pauseReason explains why the canary of an ExtendedDaemonSet is paused
+	pauseReason string
+}
+
+func newDaemonSetWorkload(ds *appsv1.DaemonSet) workload {
+	return workload{
+		workloadKey: workloadKey{kind: daemonSetKind, namespace: ds.Namespace, name: ds.Name},
+		specHash:    getHashAnnotation(ds.Annotations),
+		template:    &ds.Spec.Template,
+		complete: ds.Status.ObservedGeneration >= ds.Generation &&
+			ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+			ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled,
+	}
+}
+
+func newDeploymentWorkload(dep *appsv1.Deployment) workload {
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	w := workload{
+		workloadKey: workloadKey{kind: deploymentKind, namespace: dep.Namespace, name: dep.Name},
+		specHash:    getHashAnnotation(dep.Annotations),
+		template:    &dep.Spec.Template,
+		complete: dep.Status.ObservedGeneration >= dep.Generation &&
+			dep.Status.Replicas == replicas &&
+			dep.Status.UpdatedReplicas == replicas &&
+			dep.Status.AvailableReplicas == replicas,
+	}
+	for _, condition := range dep.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == progressDeadlineExceededReason {
+			w.failure = condition.Message
+		}
+	}
+	return w
+}
+
+func newExtendedDaemonSetWorkload(eds *edsdatadoghqv1alpha1.ExtendedDaemonSet) workload {
+	w := workload{
+		workloadKey: workloadKey{kind: extendedDaemonSetKind, namespace: eds.Namespace, name: eds.Name},
+		specHash:    getHashAnnotation(eds.Annotations),
+		template:    &eds.Spec.Template,
+		complete: eds.Status.State == edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning &&
+			eds.Status.Canary == nil &&
+			eds.Status.UpToDate == eds.Status.Desired,
+		replicaSet: eds.Status.ActiveReplicaSet,
+	}
+	if eds.Status.Canary != nil {
+		w.canary = eds.Status.Canary.ReplicaSet
+	}
+	switch eds.Status.State {
+	case edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused:
+		w.paused = true
+		w.pauseReason = eds.Annotations[edsdatadoghqv1alpha1.ExtendedDaemonSetCanaryPausedReasonAnnotationKey]
+		if w.pauseReason == "" {
+			w.pauseReason = string(eds.Status.Reason)
+		}
+	case edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryFailed:
+		w.failure = fmt.Sprintf("canary failed: %s", eds.Status.Reason)
+	}
+	return w
+}
+
+// rolloutState is the progress of a rollout started by the operator
+type rolloutState struct {
+	start time.Time
+	// replicaSet is the active ReplicaSet of an ExtendedDaemonSet when the rollout started
+	replicaSet string
+	// canary is the canary ReplicaSet of an ExtendedDaemonSet seen during the rollout
+	canary string
+	paused bool
+}
+
+// workloadState is what the operator knows of a workload it manages
+type workloadState struct {
+	// specHash and templateHash are the spec hash annotation and the hash of the pod template
+	// observed after the last update of the operator, in order to detect the changes made by other clients
+	specHash     string
+	templateHash string
+	rollout      *rolloutState
+}
+
+// workloadTracker records the state of the workloads of each DatadogAgent,
+// in order to report their rollouts and the changes made outside of the operator
+type workloadTracker struct {
+	mutex     sync.Mutex
+	workloads map[types.NamespacedName]map[workloadKey]workloadState
+}
+
+func (t *workloadTracker) get(dda types.NamespacedName, key workloadKey) (workloadState, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state, found := t.workloads[dda][key]
+	return state, found
+}
+
+func (t *workloadTracker) set(dda types.NamespacedName, key workloadKey, state workloadState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.workloads == nil {
+		t.workloads = map[types.NamespacedName]map[workloadKey]workloadState{}
+	}
+	if t.workloads[dda] == nil {
+		t.workloads[dda] = map[workloadKey]workloadState{}
+	}
+	t.workloads[dda][key] = state
+}
+
+func (t *workloadTracker) delete(dda types.NamespacedName) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.workloads, dda)
+}
+
+// startRollout records the workload updated by the operator,
+// and reports the start of a rollout and the image changes when the pod template changed
+func (r *Reconciler) startRollout(dda *datadoghqv1alpha1.DatadogAgent, oldTemplate, newTemplate *corev1.PodTemplateSpec, updated workload) {
+	ddaName := types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}
+	state, _ := r.workloads.get(ddaName, updated.workloadKey)
+	state.specHash = updated.specHash
+	state.templateHash, _ = comparison.GenerateMD5ForSpec(updated.template)
+
+	// The live template contains the fields defaulted by the API server: only the fields set by the operator are compared
+	if !apiequality.Semantic.DeepDerivative(newTemplate, oldTemplate) {
+		state.rollout = &rolloutState{
+			start:      time.Now(),
+			replicaSet: updated.replicaSet,
+		}
+		r.recordEvent(dda, buildEventInfo(updated.name, updated.namespace, updated.kind, datadog.RolloutStartedEvent))
+		for _, change := range getImageChanges(oldTemplate, newTemplate) {
+			r.recordEvent(dda, buildEventInfoWithDetails(updated.name, updated.namespace, updated.kind, datadog.ImageChangeEvent, change))
+		}
+	}
+	r.workloads.set(ddaName, updated.workloadKey, state)
+}
+
+// observeWorkload follows the rollout of a workload that doesn't need to be updated by the operator.
+// The changes of the pod template made by other clients (kubectl rollout restart, admission webhooks...)
+// are reported, but not reverted: the template is restored at the next change of the spec.
+func (r *Reconciler) observeWorkload(dda *datadoghqv1alpha1.DatadogAgent, current workload) {
+	ddaName := types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}
+	templateHash, err := comparison.GenerateMD5ForSpec(current.template)
+	if err != nil {
+		return
+	}
+	state, found := r.workloads.get(ddaName, current.workloadKey)
+	if found && state.specHash == current.specHash && state.templateHash != templateHash {
+		details := "the pod template was modified outside of the operator"
+		r.recordEvent(dda, buildEventInfoWithDetails(current.name, current.namespace, current.kind, datadog.DriftDetectionEvent, details))
+	}
+
+	state.specHash = current.specHash
+	state.templateHash = templateHash
+	if state.rollout != nil {
+		state.rollout = r.followRollout(dda, current, state.rollout)
+	}
+	r.workloads.set(ddaName, current.workloadKey, state)
+}
+
+// followRollout reports the progress of a rollout and returns its new state, nil once it's finished
+func (r *Reconciler) followRollout(dda *datadoghqv1alpha1.DatadogAgent, current workload, rollout *rolloutState) *rolloutState {
+	duration := time.Since(rollout.start).Round(time.Second)
+	record := func(eventType datadog.EventType, details string) {
+		r.recordEvent(dda, buildEventInfoWithDetails(current.name, current.namespace, current.kind, eventType, details))
+	}
+
+	if current.failure != "" {
+		record(datadog.RolloutFailedEvent, fmt.Sprintf("failed after %s: %s", duration, current.failure))
+		return nil
+	}
+
+	// ExtendedDaemonSet canary
+	if current.canary != "" {
+		rollout.canary = current.canary
+	}
+	if current.paused && !rollout.paused {
+		record(datadog.CanaryPausedEvent, current.pauseReason)
+	}
+	rollout.paused = current.paused
+	if rollout.canary != "" && current.canary == "" && current.replicaSet == rollout.canary {
+		record(datadog.CanaryPromotedEvent, fmt.Sprintf("canary %s promoted after %s", rollout.canary, duration))
+		rollout.canary = ""
+	}
+
+	// The active ReplicaSet of an ExtendedDaemonSet changes once the new pod template is deployed
+	if current.complete && (current.kind != extendedDaemonSetKind || current.replicaSet != rollout.replicaSet) {
+		record(datadog.RolloutCompletedEvent, fmt.Sprintf("completed in %s", duration))
+		return nil
+	}
+
+	// A paused canary waits for an action of the user
+	if !rollout.paused && duration > rolloutDeadline {
+		record(datadog.RolloutFailedEvent, fmt.Sprintf("not completed after %s", duration))
+		return nil
+	}
+	return rollout
+}
+
+// getImageChanges returns the containers whose image changed, with their old and new images
+func getImageChanges(oldTemplate, newTemplate *corev1.PodTemplateSpec) []string {
+	oldImages := map[string]string{}
+	for _, containers := range [][]corev1.Container{oldTemplate.Spec.InitContainers, oldTemplate.Spec.Containers} {
+		for _, container := range containers {
+			oldImages[container.Name] = container.Image
+		}
+	}
+
+	var changes []string
+	for _, containers := range [][]corev1.Container{newTemplate.Spec.InitContainers, newTemplate.Spec.Containers} {
+		for _, container := range containers {
+			if oldImage, found := oldImages[container.Name]; found && oldImage != container.Image {
+				changes = append(changes, fmt.Sprintf("%s: %s → %s", container.Name, oldImage, container.Image))
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"fmt"
+	"testing"
+
+	edsdatadoghqv1alpha1 "github.com/DataDog/extendeddaemonset/api/v1alpha1"
+	assert "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+)
+
+func newRolloutTestReconciler() (*Reconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(20)
+	return &Reconciler{recorder: recorder, forwarders: dummyManager{}}, recorder
+}
+
+func getRecordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func newRolloutTestTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "agent", Image: image},
+				{Name: "trace-agent", Image: image},
+			},
+		},
+	}
+}
+
+func TestReconciler_DaemonSetRollout(t *testing.T) {
+	r, recorder := newRolloutTestReconciler()
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "bar",
+			Name:        "foo-agent",
+			Generation:  1,
+			Annotations: map[string]string{datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey: "hash1"},
+		},
+		Spec: appsv1.DaemonSetSpec{Template: newRolloutTestTemplate("datadog/agent:7.21.0")},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration:     1,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberAvailable:        3,
+		},
+	}
+
+	// The first observation records the workload
+	r.observeWorkload(dda, newDaemonSetWorkload(ds))
+	assert.Empty(t, getRecordedEvents(recorder))
+
+	// Update of the image
+	newTemplate := newRolloutTestTemplate("datadog/agent:7.22.0")
+	updated := ds.DeepCopy()
+	updated.Generation = 2
+	updated.Annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey] = "hash2"
+	updated.Spec.Template = newTemplate
+	r.startRollout(dda, &ds.Spec.Template, &newTemplate, newDaemonSetWorkload(updated))
+	assert.Equal(t, []string{
+		"Normal RolloutStarted DaemonSet bar/foo-agent",
+		"Normal ImageChange DaemonSet bar/foo-agent: agent: datadog/agent:7.21.0 → datadog/agent:7.22.0",
+		"Normal ImageChange DaemonSet bar/foo-agent: trace-agent: datadog/agent:7.21.0 → datadog/agent:7.22.0",
+	}, getRecordedEvents(recorder))
+
+	// The rollout is in progress
+	updated.Status.ObservedGeneration = 2
+	updated.Status.UpdatedNumberScheduled = 1
+	r.observeWorkload(dda, newDaemonSetWorkload(updated))
+	assert.Empty(t, getRecordedEvents(recorder))
+
+	// The rollout is completed
+	updated.Status.UpdatedNumberScheduled = 3
+	r.observeWorkload(dda, newDaemonSetWorkload(updated))
+	events := getRecordedEvents(recorder)
+	assert.Len(t, events, 1)
+	assert.Contains(t, events[0], "Normal RolloutCompleted DaemonSet bar/foo-agent: completed in ")
+	r.observeWorkload(dda, newDaemonSetWorkload(updated))
+	assert.Empty(t, getRecordedEvents(recorder))
+
+	// The pod template is modified without changing the spec hash: the drift is reported once
+	updated.Spec.Template.Spec.Containers[0].Image = "datadog/agent:latest"
+	r.observeWorkload(dda, newDaemonSetWorkload(updated))
+	assert.Equal(t, []string{
+		"Warning DriftDetected DaemonSet bar/foo-agent: the pod template was modified outside of the operator",
+	}, getRecordedEvents(recorder))
+	r.observeWorkload(dda, newDaemonSetWorkload(updated))
+	assert.Empty(t, getRecordedEvents(recorder))
+
+	// An update that doesn't change the pod template doesn't start a rollout
+	unchanged := updated.DeepCopy()
+	unchanged.Spec.Template = newTemplate
+	r.startRollout(dda, &newTemplate, &newTemplate, newDaemonSetWorkload(unchanged))
+	assert.Empty(t, getRecordedEvents(recorder))
+	r.observeWorkload(dda, newDaemonSetWorkload(unchanged))
+	assert.Empty(t, getRecordedEvents(recorder))
+}
+
+func TestReconciler_ExtendedDaemonSetRollout(t *testing.T) {
+	r, recorder := newRolloutTestReconciler()
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	oldTemplate := newRolloutTestTemplate("datadog/agent:7.21.0")
+	eds := &edsdatadoghqv1alpha1.ExtendedDaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "bar",
+			Name:        "foo-agent",
+			Annotations: map[string]string{datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey: "hash2"},
+		},
+		Spec: edsdatadoghqv1alpha1.ExtendedDaemonSetSpec{Template: newRolloutTestTemplate("datadog/agent:7.22.0")},
+		Status: edsdatadoghqv1alpha1.ExtendedDaemonSetStatus{
+			State:            edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning,
+			ActiveReplicaSet: "foo-agent-rs1",
+			Desired:          3,
+			UpToDate:         3,
+		},
+	}
+	r.startRollout(dda, &oldTemplate, &eds.Spec.Template, newExtendedDaemonSetWorkload(eds))
+	assert.Len(t, getRecordedEvents(recorder), 3)
+
+	// The status of the ExtendedDaemonSet isn't updated yet
+	r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
+	assert.Empty(t, getRecordedEvents(recorder))
+
+	// The canary is paused
+	eds.Status.State = edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateCanaryPaused
+	eds.Status.Canary = &edsdatadoghqv1alpha1.ExtendedDaemonSetStatusCanary{ReplicaSet: "foo-agent-rs2"}
+	eds.Status.UpToDate = 1
+	eds.Annotations[edsdatadoghqv1alpha1.ExtendedDaemonSetCanaryPausedReasonAnnotationKey] = "CrashLoopBackOff"
+	for i := 0; i < 2; i++ {
+		r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
+	}
+	assert.Equal(t, []string{
+		"Warning CanaryPaused ExtendedDaemonSet bar/foo-agent: CrashLoopBackOff",
+	}, getRecordedEvents(recorder))
+
+	// The canary is resumed then promoted
+	eds.Status.State = edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateCanary
+	r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
+	eds.Status.State = edsdatadoghqv1alpha1.ExtendedDaemonSetStatusStateRunning
+	eds.Status.Canary = nil
+	eds.Status.ActiveReplicaSet = "foo-agent-rs2"
+	r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
+	eds.Status.UpToDate = 3
+	r.observeWorkload(dda, newExtendedDaemonSetWorkload(eds))
+	events := getRecordedEvents(recorder)
+	assert.Len(t, events, 2)
+	assert.Contains(t, events[0], "Normal CanaryPromoted ExtendedDaemonSet bar/foo-agent: canary foo-agent-rs2 promoted after ")
+	assert.Contains(t, events[1], "Normal RolloutCompleted ExtendedDaemonSet bar/foo-agent: completed in ")
+}
+
+func TestReconciler_DeploymentRolloutFailure(t *testing.T) {
+	r, recorder := newRolloutTestReconciler()
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	oldTemplate := newRolloutTestTemplate("datadog/cluster-agent:1.8.0")
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo-cluster-agent", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Template: newRolloutTestTemplate("datadog/cluster-agent:1.9.0")},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 0, AvailableReplicas: 1},
+	}
+	r.startRollout(dda, &oldTemplate, &dep.Spec.Template, newDeploymentWorkload(dep))
+	assert.Len(t, getRecordedEvents(recorder), 3)
+
+	dep.Status.ObservedGeneration = 2
+	dep.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  progressDeadlineExceededReason,
+		Message: `ReplicaSet "foo-cluster-agent-5d4f" has timed out progressing.`,
+	}}
+	for i := 0; i < 2; i++ {
+		r.observeWorkload(dda, newDeploymentWorkload(dep))
+	}
+	events := getRecordedEvents(recorder)
+	assert.Len(t, events, 1)
+	assert.Contains(t, events[0], "Warning RolloutFailed Deployment bar/foo-cluster-agent: failed after ")
+	assert.Contains(t, events[0], `ReplicaSet "foo-cluster-agent-5d4f" has timed out progressing.`)
+}
+
+func TestReconciler_recordReconcileError(t *testing.T) {
+	r, recorder := newRolloutTestReconciler()
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+
+	// The event is sent once when the threshold is reached
+	for i := 0; i < 2*reconcileErrorEventThreshold; i++ {
+		r.recordReconcileError(dda, fmt.Errorf("error %d", i))
+	}
+	assert.Equal(t, []string{
+		"Warning ReconcileError DatadogAgent bar/foo: 5 consecutive reconcile errors, last error: error 4",
+	}, getRecordedEvents(recorder))
+
+	// A successful reconciliation resets the counter
+	r.recordReconcileError(dda, nil)
+	for i := 0; i < reconcileErrorEventThreshold-1; i++ {
+		r.recordReconcileError(dda, fmt.Errorf("error %d", i))
+	}
+	assert.Empty(t, getRecordedEvents(recorder))
+}
@@ -0,0 +1,223 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/condition"
+)
+
+// sharedResource is a resource that a single DatadogAgent of the cluster can manage:
+// cluster-scoped objects with a fixed name, or host ports opened on every node
+type sharedResource struct {
+	name string
+	// release disables the usage of the resource, nil if it can't be disabled
+	release func(dda *datadoghqv1alpha1.DatadogAgent)
+}
+
+// sharedResourceConflict is a shared resource used by a DatadogAgent and owned by another one
+type sharedResourceConflict struct {
+	sharedResource
+	owner types.NamespacedName
+}
+
+// detectConflicts returns the shared resources used by the DatadogAgent that are owned by another DatadogAgent.
+// The owner of a shared resource is the oldest DatadogAgent using it.
+func (r *Reconciler) detectConflicts(dda *datadoghqv1alpha1.DatadogAgent) ([]sharedResourceConflict, error) {
+	list := &datadoghqv1alpha1.DatadogAgentList{}
+	if err := r.client.List(context.TODO(), list); err != nil {
+		return nil, err
+	}
+
+	others := make([]*datadoghqv1alpha1.DatadogAgent, 0, len(list.Items))
+	for i := range list.Items {
+		other := &list.Items[i]
+		if (other.Namespace == dda.Namespace && other.Name == dda.Name) || other.DeletionTimestamp != nil {
+			continue
+		}
+		if isElectedBefore(other, dda) {
+			others = append(others, other)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return isElectedBefore(others[i], others[j]) })
+
+	var conflicts []sharedResourceConflict
+	for _, resource := range getSharedResources(dda) {
+		for _, other := range others {
+			if hasSharedResource(other, resource.name) {
+				conflicts = append(conflicts, sharedResourceConflict{
+					sharedResource: resource,
+					owner:          types.NamespacedName{Namespace: other.Namespace, Name: other.Name},
+				})
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// resolveConflicts returns a copy of the DatadogAgent not using the shared resources owned by other DatadogAgents
+func resolveConflicts(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, conflicts []sharedResourceConflict) *datadoghqv1alpha1.DatadogAgent {
+	if len(conflicts) == 0 {
+		return dda
+	}
+	resolved := dda.DeepCopy()
+	for _, conflict := range conflicts {
+		logger.Info("Shared resource managed by another DatadogAgent", "resource", conflict.name, "owner", conflict.owner.String())
+		if conflict.release != nil {
+			conflict.release(resolved)
+		}
+	}
+	return resolved
+}
+
+func updateConflictCondition(newStatus *datadoghqv1alpha1.DatadogAgentStatus, conflicts []sharedResourceConflict) {
+	now := metav1.NewTime(time.Now())
+	if len(conflicts) == 0 {
+		condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeConflict, corev1.ConditionFalse, "No conflict with other DatadogAgents", false)
+		return
+	}
+
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		messages = append(messages, fmt.Sprintf("%s is managed by DatadogAgent %s", conflict.name, conflict.owner.String()))
+	}
+	condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeConflict, corev1.ConditionTrue, strings.Join(messages, "; "), false)
+}
+
+// isElectedBefore returns true if a has priority over b to own the shared resources:
+// the oldest DatadogAgent wins, the namespace and the name break ties
+func isElectedBefore(a, b *datadoghqv1alpha1.DatadogAgent) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+func hasSharedResource(dda *datadoghqv1alpha1.DatadogAgent, name string) bool {
+	for _, resource := range getSharedResources(dda) {
+		if resource.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getSharedResources returns the shared resources used by a DatadogAgent
+func getSharedResources(dda *datadoghqv1alpha1.DatadogAgent) []sharedResource {
+	var resources []sharedResource
+
+	if isMetricsProviderEnabled(dda.Spec.ClusterAgent) {
+		resources = append(resources,
+			sharedResource{name: fmt.Sprintf("%s %s", apiServiceKind, getMetricsServerAPIServiceName()), release: disableMetricsProvider},
+			sharedResource{name: fmt.Sprintf("%s %s", clusterRoleBindingKind, getHPAClusterRoleBindingName(dda)), release: disableMetricsProvider},
+		)
+	}
+	if isAdmissionControllerEnabled(dda.Spec.ClusterAgent) {
+		resources = append(resources, sharedResource{name: fmt.Sprintf("%s %s", mutatingWebhookConfigurationKind, admissionWebhookName), release: disableAdmissionController})
+	}
+
+	// The cluster-scoped RBAC are named after the DatadogAgent, they can't be released but
+	// they are only updated by their owner
+	if dda.Spec.Agent != nil && isCreateRBACEnabled(dda.Spec.Agent.Rbac) {
+		resources = append(resources, sharedResource{name: fmt.Sprintf("%s %s", clusterRoleKind, getAgentRbacResourcesName(dda))})
+	}
+	if dda.Spec.ClusterAgent != nil && isCreateRBACEnabled(dda.Spec.ClusterAgent.Rbac) {
+		resources = append(resources, sharedResource{name: fmt.Sprintf("%s %s", clusterRoleKind, getClusterAgentRbacResourcesName(dda))})
+	}
+	if dda.Spec.ClusterChecksRunner != nil && isCreateRBACEnabled(dda.Spec.ClusterChecksRunner.Rbac) {
+		resources = append(resources, sharedResource{name: fmt.Sprintf("%s %s", clusterRoleKind, getClusterChecksRunnerRbacResourcesName(dda))})
+	}
+
+	return append(resources, getAgentHostPorts(dda)...)
+}
+
+// getAgentHostPorts returns the ports opened by the Agent on every node
+func getAgentHostPorts(dda *datadoghqv1alpha1.DatadogAgent) []sharedResource {
+	if dda.Spec.Agent == nil {
+		return nil
+	}
+	var resources []sharedResource
+
+	// The sockets are written on the host, they can't be released but are reported
+	if isDogstatsdSocketEnabled(&dda.Spec) {
+		resources = append(resources, sharedResource{name: hostSocketResourceName(getDogstatsdSocketPath(&dda.Spec))})
+	}
+	if isAPMSocketEnabled(dda) {
+		resources = append(resources, sharedResource{name: hostSocketResourceName(getAPMSocketPath(dda))})
+	}
+
+	if dda.Spec.Agent.Config.HostPort != nil && !isDogstatsdUDPDisabled(&dda.Spec) {
+		resources = append(resources, sharedResource{
+			name: hostPortResourceName(*dda.Spec.Agent.Config.HostPort, corev1.ProtocolUDP),
+			release: func(dda *datadoghqv1alpha1.DatadogAgent) {
+				dda.Spec.Agent.Config.HostPort = nil
+			},
+		})
+	} else if dda.Spec.Agent.HostNetwork {
+		resources = append(resources, sharedResource{name: hostPortResourceName(datadoghqv1alpha1.DefaultDogstatsdPort, corev1.ProtocolUDP)})
+	}
+
+	if isAPMEnabled(dda) {
+		if dda.Spec.Agent.Apm.HostPort != nil && !isAPMSocketEnabled(dda) {
+			resources = append(resources, sharedResource{
+				name: hostPortResourceName(*dda.Spec.Agent.Apm.HostPort, corev1.ProtocolTCP),
+				release: func(dda *datadoghqv1alpha1.DatadogAgent) {
+					dda.Spec.Agent.Apm.HostPort = nil
+				},
+			})
+		} else if dda.Spec.Agent.HostNetwork {
+			resources = append(resources, sharedResource{name: hostPortResourceName(datadoghqv1alpha1.DefaultAPMAgentTCPPort, corev1.ProtocolTCP)})
+		}
+	}
+
+	return resources
+}
+
+func hostPortResourceName(port int32, protocol corev1.Protocol) string {
+	return fmt.Sprintf("hostPort %d/%s", port, protocol)
+}
+
+func hostSocketResourceName(path string) string {
+	return fmt.Sprintf("hostPath socket %s", path)
+}
+
+func disableMetricsProvider(dda *datadoghqv1alpha1.DatadogAgent) {
+	dda.Spec.ClusterAgent.Config.ExternalMetrics.Enabled = false
+}
+
+func disableAdmissionController(dda *datadoghqv1alpha1.DatadogAgent) {
+	dda.Spec.ClusterAgent.Config.AdmissionController.Enabled = false
+}
+
+// ownedByOtherDatadogAgent returns true if the object is owned by DatadogAgents other than dda
+func ownedByOtherDatadogAgent(owners []metav1.OwnerReference, dda *datadoghqv1alpha1.DatadogAgent) bool {
+	ownedByOther := false
+	for _, owner := range owners {
+		if owner.Kind != datadogOperatorName {
+			continue
+		}
+		if owner.UID == dda.UID {
+			return false
+		}
+		ownedByOther = true
+	}
+	return ownedByOther
+}
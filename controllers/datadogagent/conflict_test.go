@@ -0,0 +1,144 @@
+package datadogagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+func newConflictTestDatadogAgent(ns, name string, creationTime time.Time) *datadoghqv1alpha1.DatadogAgent {
+	dda := test.NewDefaultedDatadogAgent(ns, name, &test.NewDatadogAgentOptions{
+		ClusterAgentEnabled:  true,
+		MetricsServerEnabled: true,
+		APMEnabled:           true,
+		HostPort:             datadoghqv1alpha1.DefaultDogstatsdPort,
+	})
+	dda.UID = types.UID(ns + "/" + name)
+	dda.CreationTimestamp = metav1.NewTime(creationTime)
+	return dda
+}
+
+func TestReconcileDatadogAgent_detectConflicts(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	logger := logf.Log.WithName("TestReconcileDatadogAgent_detectConflicts")
+
+	now := time.Now()
+	older := newConflictTestDatadogAgent("bar", "foo", now.Add(-time.Hour))
+	newer := newConflictTestDatadogAgent("baz", "foo", now)
+	r := newCertificatesTestReconciler(nil, ReconcilerOptions{})
+	c := fake.NewFakeClient(older, newer)
+	r.client = c
+
+	// The oldest DatadogAgent owns the shared resources
+	conflicts, err := r.detectConflicts(older)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, older, resolveConflicts(logger, older, conflicts))
+
+	conflicts, err = r.detectConflicts(newer)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, conflict := range conflicts {
+		assert.Equal(t, types.NamespacedName{Namespace: "bar", Name: "foo"}, conflict.owner)
+		names = append(names, conflict.name)
+	}
+	assert.Equal(t, []string{
+		"APIService v1beta1.external.metrics.k8s.io",
+		// Cluster-scoped RBAC are named after the DatadogAgent, regardless of its namespace
+		"ClusterRoleBinding foo-cluster-agent-auth-delegator",
+		"ClusterRole foo-agent",
+		"ClusterRole foo-cluster-agent",
+		hostPortResourceName(datadoghqv1alpha1.DefaultDogstatsdPort, corev1.ProtocolUDP),
+	}, names)
+
+	resolved := resolveConflicts(logger, newer, conflicts)
+	assert.False(t, isMetricsProviderEnabled(resolved.Spec.ClusterAgent))
+	assert.Nil(t, resolved.Spec.Agent.Config.HostPort)
+	assert.True(t, isAPMEnabled(resolved))
+	// The DatadogAgent itself isn't modified
+	assert.True(t, isMetricsProviderEnabled(newer.Spec.ClusterAgent))
+	assert.NotNil(t, newer.Spec.Agent.Config.HostPort)
+
+	status := &datadoghqv1alpha1.DatadogAgentStatus{}
+	updateConflictCondition(status, conflicts)
+	cond := getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeConflict)
+	assert.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "APIService v1beta1.external.metrics.k8s.io is managed by DatadogAgent bar/foo")
+
+	// The conflict is cleared once the owner is deleted
+	assert.NoError(t, c.Delete(context.TODO(), older))
+	conflicts, err = r.detectConflicts(newer)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	updateConflictCondition(status, conflicts)
+	cond = getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeConflict)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
+
+func TestIsElectedBefore(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		a    *datadoghqv1alpha1.DatadogAgent
+		b    *datadoghqv1alpha1.DatadogAgent
+		want bool
+	}{
+		{
+			name: "older wins",
+			a:    newConflictTestDatadogAgent("b", "b", now.Add(-time.Second)),
+			b:    newConflictTestDatadogAgent("a", "a", now),
+			want: true,
+		},
+		{
+			name: "same creation time, namespace breaks the tie",
+			a:    newConflictTestDatadogAgent("b", "a", now),
+			b:    newConflictTestDatadogAgent("a", "b", now),
+			want: false,
+		},
+		{
+			name: "same creation time and namespace, name breaks the tie",
+			a:    newConflictTestDatadogAgent("a", "a", now),
+			b:    newConflictTestDatadogAgent("a", "b", now),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isElectedBefore(tt.a, tt.b))
+			assert.Equal(t, !tt.want, isElectedBefore(tt.b, tt.a))
+		})
+	}
+}
+
+func TestOwnedByOtherDatadogAgent(t *testing.T) {
+	dda := newConflictTestDatadogAgent("bar", "foo", time.Now())
+	ownerRef := func(kind, uid string) metav1.OwnerReference {
+		return metav1.OwnerReference{Kind: kind, UID: types.UID(uid)}
+	}
+
+	assert.False(t, ownedByOtherDatadogAgent(nil, dda))
+	assert.False(t, ownedByOtherDatadogAgent([]metav1.OwnerReference{ownerRef("Deployment", "other")}, dda))
+	assert.False(t, ownedByOtherDatadogAgent([]metav1.OwnerReference{ownerRef(datadogOperatorName, "bar/foo")}, dda))
+	assert.False(t, ownedByOtherDatadogAgent([]metav1.OwnerReference{ownerRef(datadogOperatorName, "other"), ownerRef(datadogOperatorName, "bar/foo")}, dda))
+	assert.True(t, ownedByOtherDatadogAgent([]metav1.OwnerReference{ownerRef(datadogOperatorName, "other")}, dda))
+}
+
+func getTestStatusCondition(status *datadoghqv1alpha1.DatadogAgentStatus, t datadoghqv1alpha1.DatadogAgentConditionType) *datadoghqv1alpha1.DatadogAgentCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == t {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ const (
 	FieldPathStatusPodIP = "status.podIP"
 
 	// kind names definition
+	datadogAgentKind        = "DatadogAgent"
 	extendedDaemonSetKind   = "ExtendedDaemonSet"
 	daemonSetKind           = "DaemonSet"
 	deploymentKind          = "Deployment"
@@ -30,4 +31,12 @@ const (
 	serviceKind             = "Service"
 	apiServiceKind          = "APIService"
 	networkPolicyKind       = "NetworkPolicy"
+	ciliumNetworkPolicyKind = "CiliumNetworkPolicy"
+
+	mutatingWebhookConfigurationKind = "MutatingWebhookConfiguration"
+	verticalPodAutoscalerKind        = "VerticalPodAutoscaler"
+
+	// socketDirectoryMode lets the application pods reach the Unix Domain Sockets shared on the host
+	socketDirectoryMode  = "755"
+	socketsInitMountPath = "/host/sockets"
 )
@@ -33,25 +33,39 @@ const (
 // ReconcilerOptions provides options read from command line
 type ReconcilerOptions struct {
 	SupportExtendedDaemonset bool
+	SupportCertManager       bool
+	SupportCilium            bool
+	SupportMetricsAPI        bool
+	SupportVPA               bool
+	// Platform is the platform detected from the APIs of the cluster, empty if it isn't recognized
+	Platform datadoghqv1alpha1.PlatformName
 }
 
 // Reconciler is the internal reconciler for Datadog Agent
 type Reconciler struct {
 	options     ReconcilerOptions
 	client      client.Client
+	apiReader   client.Reader
 	versionInfo *version.Info
 	scheme      *runtime.Scheme
 	log         logr.Logger
 	recorder    record.EventRecorder
 	forwarders  datadog.MetricForwardersManager
+	references  referenceTracker
+	workloads   workloadTracker
+	// reconcileErrors counts the consecutive reconcile errors to report them with an event
+	reconcileErrors reconcileErrorCounter
+	// usage records the resource usage of the Agent containers to recommend their resources
+	usage usageTracker
 }
 
 // NewReconciler returns a reconciler for DatadogAgent
-func NewReconciler(options ReconcilerOptions, client client.Client, versionInfo *version.Info,
+func NewReconciler(options ReconcilerOptions, client client.Client, apiReader client.Reader, versionInfo *version.Info,
 	scheme *runtime.Scheme, log logr.Logger, recorder record.EventRecorder, metricForwarder datadog.MetricForwardersManager) (*Reconciler, error) {
 	return &Reconciler{
 		options:     options,
 		client:      client,
+		apiReader:   apiReader,
 		versionInfo: versionInfo,
 		scheme:      scheme,
 		log:         log,
@@ -80,6 +94,10 @@ func (r *Reconciler) internalReconcile(ctx context.Context, request reconcile.Re
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.references.delete(request.NamespacedName)
+			r.workloads.delete(request.NamespacedName)
+			r.reconcileErrors.delete(request.NamespacedName)
+			r.usage.delete(request.NamespacedName)
 			return result, nil
 		}
 		// Error reading the object - requeue the request.
@@ -109,14 +127,42 @@ func (r *Reconciler) internalReconcile(ctx context.Context, request reconcile.Re
 		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
 	}
 
+	platform, err := r.getPlatform(instance)
+	if err != nil {
+		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
+	}
+	newStatus.Platform = platform
+	if err = datadoghqv1alpha1.IsValidPlatformProfile(&instance.Spec, platform); err != nil {
+		reqLogger.Info("Spec not allowed on the platform", "platform", platform)
+		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
+	}
+
+	// Shared resources owned by other DatadogAgents aren't managed
+	conflicts, err := r.detectConflicts(instance)
+	if err != nil {
+		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
+	}
+	updateConflictCondition(newStatus, conflicts)
+	resolvedInstance := resolveConflicts(reqLogger, instance, conflicts)
+	resolvedInstance = datadoghqv1alpha1.ApplyPlatformProfile(resolvedInstance, platform)
+	if resolvedInstance, err = r.applyAutoTolerations(resolvedInstance); err != nil {
+		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
+	}
+	resolvedInstance = applyResourceRecommendations(resolvedInstance)
+
+	if err = r.updateSchedulingCondition(resolvedInstance, newStatus); err != nil {
+		return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
+	}
+
 	reconcileFuncs :=
 		[]reconcileFuncInterface{
+			r.reconcileChecks,
 			r.reconcileClusterAgent,
 			r.reconcileClusterChecksRunner,
 			r.reconcileAgent,
 		}
 	for _, reconcileFunc := range reconcileFuncs {
-		result, err = reconcileFunc(reqLogger, instance, newStatus)
+		result, err = reconcileFunc(reqLogger, resolvedInstance, newStatus)
 		if shouldReturn(result, err) {
 			return r.updateStatusIfNeeded(reqLogger, instance, newStatus, result, err)
 		}
@@ -143,8 +189,10 @@ func (r *Reconciler) updateStatusIfNeeded(logger logr.Logger, agentdeployment *d
 	// get metrics forwarder status
 	if metricsCondition := r.forwarders.MetricsForwarderStatusForObj(agentdeployment); metricsCondition != nil {
 		logger.V(1).Info("metrics conditions status not available")
+		r.recordCredentialsError(agentdeployment, condition.GetDatadogAgentStatusCondition(&agentdeployment.Status, datadoghqv1alpha1.ConditionTypeActiveDatadogMetrics), metricsCondition)
 		condition.SetDatadogAgentStatusCondition(newStatus, metricsCondition)
 	}
+	r.recordReconcileError(agentdeployment, currentError)
 
 	if !apiequality.Semantic.DeepEqual(&agentdeployment.Status, newStatus) {
 		updateAgentDeployment := agentdeployment.DeepCopy()
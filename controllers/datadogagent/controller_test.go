@@ -70,7 +70,7 @@ func TestReconcileDatadogAgent_createNewExtendedDaemonSet(t *testing.T) {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{})
+	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{}, &datadoghqv1alpha1.DatadogAgentList{})
 	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &edsdatadoghqv1alpha1.ExtendedDaemonSet{})
 	s.AddKnownTypes(appsv1.SchemeGroupVersion, &appsv1.DaemonSet{})
 
@@ -118,7 +118,7 @@ func TestReconcileDatadogAgent_createNewExtendedDaemonSet(t *testing.T) {
 					SupportExtendedDaemonset: true,
 				},
 			}
-			got, err := r.createNewExtendedDaemonSet(tt.args.logger, tt.args.agentdeployment, tt.args.newStatus)
+			got, err := r.createNewExtendedDaemonSet(tt.args.logger, tt.args.agentdeployment, nil, tt.args.newStatus)
 			if tt.wantErr {
 				assert.Error(t, err, "ReconcileDatadogAgent.createNewExtendedDaemonSet() expected an error")
 			} else {
@@ -145,7 +145,7 @@ func TestReconcileDatadogAgent_Reconcile(t *testing.T) {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{})
+	s.AddKnownTypes(datadoghqv1alpha1.GroupVersion, &datadoghqv1alpha1.DatadogAgent{}, &datadoghqv1alpha1.DatadogAgentList{})
 	s.AddKnownTypes(edsdatadoghqv1alpha1.GroupVersion, &edsdatadoghqv1alpha1.ExtendedDaemonSet{})
 	s.AddKnownTypes(appsv1.SchemeGroupVersion, &appsv1.DaemonSet{})
 	s.AddKnownTypes(appsv1.SchemeGroupVersion, &appsv1.Deployment{})
@@ -1389,6 +1389,7 @@ func TestReconcileDatadogAgent_Reconcile(t *testing.T) {
 					}}))
 
 					createClusterAgentDependencies(c, dda)
+					caBundle := createClusterAgentCertificates(c, dda)
 
 					dcaExternalMetricsService := test.NewService(resourcesNamespace, "foo-cluster-agent-metrics-server", &test.NewServiceOptions{Spec: &corev1.ServiceSpec{
 						Type: corev1.ServiceTypeClusterIP,
@@ -1418,11 +1419,11 @@ func TestReconcileDatadogAgent_Reconcile(t *testing.T) {
 								Name:      "foo-cluster-agent-metrics-server",
 								Port:      &port,
 							},
-							Version:               "v1beta1",
-							InsecureSkipTLSVerify: true,
-							Group:                 "external.metrics.k8s.io",
-							GroupPriorityMinimum:  100,
-							VersionPriority:       100,
+							Version:              "v1beta1",
+							CABundle:             caBundle,
+							Group:                "external.metrics.k8s.io",
+							GroupPriorityMinimum: 100,
+							VersionPriority:      100,
 						},
 					})
 					_, _ = comparison.SetMD5GenerationAnnotation(&dcaExternalMetricsAPIService.ObjectMeta, dcaExternalMetricsAPIService.Spec)
@@ -2263,6 +2264,7 @@ func TestReconcileDatadogAgent_Reconcile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &Reconciler{
 				client:     tt.fields.client,
+				apiReader:  tt.fields.client,
 				scheme:     tt.fields.scheme,
 				recorder:   recorder,
 				log:        logf.Log.WithName(tt.name),
@@ -7,10 +7,17 @@ package datadogagent
 
 import (
 	"fmt"
+	"sync"
 
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/pkg/controller/utils/datadog"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// reconcileErrorEventThreshold is the number of consecutive reconcile errors reported by an event
+	reconcileErrorEventThreshold = 5
 )
 
 // eventInfo contains the required information
@@ -20,6 +27,8 @@ type eventInfo struct {
 	objNamespace string
 	objKind      string
 	eventType    datadog.EventType
+	// details are added to the message of the event, e.g. the duration of a rollout
+	details string
 }
 
 // buildEventInfo creates a new eventInfo instance
@@ -32,6 +41,13 @@ func buildEventInfo(name, ns, kind string, eventType datadog.EventType) eventInf
 	}
 }
 
+// buildEventInfoWithDetails creates a new eventInfo instance with details
+func buildEventInfoWithDetails(name, ns, kind string, eventType datadog.EventType, details string) eventInfo {
+	info := buildEventInfo(name, ns, kind, eventType)
+	info.details = details
+	return info
+}
+
 // getReason returns the event reason
 func (ei *eventInfo) getReason() string {
 	return fmt.Sprintf("%s %s", ei.eventType, ei.objKind)
@@ -39,22 +55,84 @@ func (ei *eventInfo) getReason() string {
 
 // getMessage returns the event message
 func (ei *eventInfo) getMessage() string {
+	if ei.details != "" {
+		return fmt.Sprintf("%s/%s: %s", ei.objNamespace, ei.objName, ei.details)
+	}
 	return fmt.Sprintf("%s/%s", ei.objNamespace, ei.objName)
 }
 
+// getType returns the Kubernetes event type: the events reporting an issue are warnings
+func (ei *eventInfo) getType() string {
+	switch ei.eventType.AlertType() {
+	case datadog.WarningAlertType, datadog.ErrorAlertType:
+		return corev1.EventTypeWarning
+	default:
+		return corev1.EventTypeNormal
+	}
+}
+
 // getDDEvent builds and returns a Datadog event
 func (ei *eventInfo) getDDEvent() datadog.Event {
 	reason := ei.getReason()
-	message := ei.getMessage()
 	return datadog.Event{
-		Title: fmt.Sprintf("%s %s", reason, message),
-		Type:  ei.eventType,
+		Title:          fmt.Sprintf("%s %s/%s", reason, ei.objNamespace, ei.objName),
+		Text:           ei.details,
+		Type:           ei.eventType,
+		AggregationKey: fmt.Sprintf("%s %s/%s", ei.objKind, ei.objNamespace, ei.objName),
 	}
 }
 
 // recordEvent wraps the manager event recorder
 // recordEvent calls the metric forwarders to send Datadog events
 func (r *Reconciler) recordEvent(dda *datadoghqv1alpha1.DatadogAgent, info eventInfo) {
-	r.recorder.Event(dda, corev1.EventTypeNormal, info.getReason(), info.getMessage())
+	r.recorder.Event(dda, info.getType(), info.getReason(), info.getMessage())
 	r.forwarders.ProcessEvent(dda, info.getDDEvent())
 }
+
+// reconcileErrorCounter counts the consecutive reconcile errors of each DatadogAgent
+type reconcileErrorCounter struct {
+	mutex  sync.Mutex
+	errors map[types.NamespacedName]int
+}
+
+// add records the result of a reconciliation and returns the number of consecutive errors
+func (c *reconcileErrorCounter) add(dda types.NamespacedName, err error) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err == nil {
+		delete(c.errors, dda)
+		return 0
+	}
+	if c.errors == nil {
+		c.errors = map[types.NamespacedName]int{}
+	}
+	c.errors[dda]++
+	return c.errors[dda]
+}
+
+func (c *reconcileErrorCounter) delete(dda types.NamespacedName) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.errors, dda)
+}
+
+// recordReconcileError sends an event when the reconciliation of the DatadogAgent fails several times in a row
+func (r *Reconciler) recordReconcileError(dda *datadoghqv1alpha1.DatadogAgent, err error) {
+	errors := r.reconcileErrors.add(types.NamespacedName{Namespace: dda.Namespace, Name: dda.Name}, err)
+	if errors != reconcileErrorEventThreshold {
+		return
+	}
+	details := fmt.Sprintf("%d consecutive reconcile errors, last error: %v", errors, err)
+	r.recordEvent(dda, buildEventInfoWithDetails(dda.Name, dda.Namespace, datadogAgentKind, datadog.ReconcileErrorEvent, details))
+}
+
+// recordCredentialsError sends an event when the metrics forwarder starts reporting invalid credentials
+func (r *Reconciler) recordCredentialsError(dda *datadoghqv1alpha1.DatadogAgent, oldCondition, newCondition *datadoghqv1alpha1.DatadogAgentCondition) {
+	if newCondition == nil || newCondition.Reason != datadog.InvalidCredentialsReason {
+		return
+	}
+	if oldCondition != nil && oldCondition.Reason == datadog.InvalidCredentialsReason {
+		return
+	}
+	r.recordEvent(dda, buildEventInfoWithDetails(dda.Name, dda.Namespace, datadogAgentKind, datadog.InvalidCredentialsEvent, newCondition.Message))
+}
@@ -66,6 +66,7 @@ func Test_eventInfo_getMessage(t *testing.T) {
 		objNamespace string
 		objKind      string
 		eventType    datadog.EventType
+		details      string
 	}
 	tests := []struct {
 		name   string
@@ -92,6 +93,17 @@ func Test_eventInfo_getMessage(t *testing.T) {
 			},
 			want: "/foo",
 		},
+		{
+			name: "with details",
+			fields: fields{
+				objName:      "foo",
+				objNamespace: "bar",
+				objKind:      "DaemonSet",
+				eventType:    datadog.RolloutCompletedEvent,
+				details:      "completed in 1m30s",
+			},
+			want: "bar/foo: completed in 1m30s",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -100,6 +112,7 @@ func Test_eventInfo_getMessage(t *testing.T) {
 				objNamespace: tt.fields.objNamespace,
 				objKind:      tt.fields.objKind,
 				eventType:    tt.fields.eventType,
+				details:      tt.fields.details,
 			}
 			if got := ei.getMessage(); got != tt.want {
 				t.Errorf("eventInfo.getMessage() = %v, want %v", got, tt.want)
@@ -114,6 +127,7 @@ func Test_eventInfo_getDDEvent(t *testing.T) {
 		objNamespace string
 		objKind      string
 		eventType    datadog.EventType
+		details      string
 	}
 	tests := []struct {
 		name   string
@@ -129,8 +143,9 @@ func Test_eventInfo_getDDEvent(t *testing.T) {
 				eventType:    datadog.CreationEvent,
 			},
 			want: datadog.Event{
-				Title: "Create DaemonSet bar/foo",
-				Type:  datadog.CreationEvent,
+				Title:          "Create DaemonSet bar/foo",
+				Type:           datadog.CreationEvent,
+				AggregationKey: "DaemonSet bar/foo",
 			},
 		},
 		{
@@ -142,8 +157,25 @@ func Test_eventInfo_getDDEvent(t *testing.T) {
 				eventType:    datadog.DeletionEvent,
 			},
 			want: datadog.Event{
-				Title: "Delete Service bar/foo",
-				Type:  datadog.DeletionEvent,
+				Title:          "Delete Service bar/foo",
+				Type:           datadog.DeletionEvent,
+				AggregationKey: "Service bar/foo",
+			},
+		},
+		{
+			name: "Deployment image change",
+			fields: fields{
+				objName:      "foo",
+				objNamespace: "bar",
+				objKind:      "Deployment",
+				eventType:    datadog.ImageChangeEvent,
+				details:      "agent: datadog/agent:7.21.0 → datadog/agent:7.22.0",
+			},
+			want: datadog.Event{
+				Title:          "ImageChange Deployment bar/foo",
+				Text:           "agent: datadog/agent:7.21.0 → datadog/agent:7.22.0",
+				Type:           datadog.ImageChangeEvent,
+				AggregationKey: "Deployment bar/foo",
 			},
 		},
 	}
@@ -154,6 +186,7 @@ func Test_eventInfo_getDDEvent(t *testing.T) {
 				objNamespace: tt.fields.objNamespace,
 				objKind:      tt.fields.objKind,
 				eventType:    tt.fields.eventType,
+				details:      tt.fields.details,
 			}
 			if got := ei.getDDEvent(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("eventInfo.getDDEvent() = %v, want %v", got, tt.want)
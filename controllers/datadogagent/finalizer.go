@@ -28,7 +28,9 @@ func (r *Reconciler) handleFinalizer(reqLogger logr.Logger, dda *datadoghqv1alph
 			// Run finalization logic for datadogAgentFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			r.finalizeDad(reqLogger, dda)
+			if err := r.finalizeDad(reqLogger, dda); err != nil {
+				return reconcile.Result{}, err
+			}
 
 			// Remove datadogAgentFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
@@ -52,9 +54,16 @@ func (r *Reconciler) handleFinalizer(reqLogger logr.Logger, dda *datadoghqv1alph
 	return reconcile.Result{}, nil
 }
 
-func (r *Reconciler) finalizeDad(reqLogger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) {
+func (r *Reconciler) finalizeDad(reqLogger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) error {
+	// The webhook configuration is cluster-scoped, it isn't garbage collected with the DatadogAgent
+	if _, err := r.cleanupAdmissionControllerWebhook(reqLogger, dda); err != nil {
+		reqLogger.Error(err, "Failed to delete the MutatingWebhookConfiguration")
+		return err
+	}
+
 	r.forwarders.Unregister(dda)
 	reqLogger.Info("Successfully finalized DatadogAgent")
+	return nil
 }
 
 func (r *Reconciler) addFinalizer(reqLogger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) error {
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+const (
+	eksNodeLabelPrefix    = "eks.amazonaws.com/"
+	aksNodeLabelKey       = "kubernetes.azure.com/cluster"
+	openShiftNodeLabelKey = "node.openshift.io/os_id"
+	kindProviderIDPrefix  = "kind://"
+)
+
+// getPlatform returns the platform whose profile applies to the DatadogAgent, empty if there is none
+func (r *Reconciler) getPlatform(dda *datadoghqv1alpha1.DatadogAgent) (datadoghqv1alpha1.PlatformName, error) {
+	if dda.Spec.Platform != datadoghqv1alpha1.PlatformAuto {
+		return dda.Spec.Platform, nil
+	}
+	if r.options.Platform != "" {
+		return r.options.Platform, nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodeList); err != nil {
+		return "", err
+	}
+	for i := range nodeList.Items {
+		if platform := getNodePlatform(&nodeList.Items[i]); platform != "" {
+			return platform, nil
+		}
+	}
+	return "", nil
+}
+
+// getNodePlatform returns the platform of a node from its labels and provider ID, empty if it isn't recognized
+func getNodePlatform(node *corev1.Node) datadoghqv1alpha1.PlatformName {
+	if strings.HasPrefix(node.Spec.ProviderID, kindProviderIDPrefix) {
+		return datadoghqv1alpha1.PlatformKind
+	}
+	if _, found := node.Labels[aksNodeLabelKey]; found {
+		return datadoghqv1alpha1.PlatformAKS
+	}
+	if _, found := node.Labels[openShiftNodeLabelKey]; found {
+		return datadoghqv1alpha1.PlatformOpenShift
+	}
+	for label := range node.Labels {
+		if strings.HasPrefix(label, eksNodeLabelPrefix) {
+			return datadoghqv1alpha1.PlatformEKS
+		}
+	}
+	return ""
+}
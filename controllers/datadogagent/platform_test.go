@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+)
+
+func TestGetNodePlatform(t *testing.T) {
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		providerID string
+		want       datadoghqv1alpha1.PlatformName
+	}{
+		{name: "unknown", labels: map[string]string{corev1.LabelOSStable: linuxOS}, want: ""},
+		{name: "kind", providerID: "kind://docker/kind/kind-control-plane", want: datadoghqv1alpha1.PlatformKind},
+		{name: "aks", labels: map[string]string{aksNodeLabelKey: "MC_rg_cluster_westeurope"}, want: datadoghqv1alpha1.PlatformAKS},
+		{name: "openshift", labels: map[string]string{openShiftNodeLabelKey: "rhcos"}, want: datadoghqv1alpha1.PlatformOpenShift},
+		{name: "eks", labels: map[string]string{"eks.amazonaws.com/nodegroup": "default"}, want: datadoghqv1alpha1.PlatformEKS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: tt.labels},
+				Spec:       corev1.NodeSpec{ProviderID: tt.providerID},
+			}
+			assert.Equal(t, tt.want, getNodePlatform(node))
+		})
+	}
+}
+
+func TestReconciler_getPlatform(t *testing.T) {
+	eksNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"eks.amazonaws.com/nodegroup": "default"}}}
+	r := newCertificatesTestReconciler(fake.NewFakeClient(eksNode), ReconcilerOptions{})
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+
+	platform, err := r.getPlatform(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, datadoghqv1alpha1.PlatformName(""), platform)
+
+	// The platform set in the spec isn't detected
+	dda.Spec.Platform = datadoghqv1alpha1.PlatformKind
+	platform, err = r.getPlatform(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, datadoghqv1alpha1.PlatformKind, platform)
+
+	dda.Spec.Platform = datadoghqv1alpha1.PlatformAuto
+	platform, err = r.getPlatform(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, datadoghqv1alpha1.PlatformEKS, platform)
+
+	// The platform detected from the APIs takes precedence over the labels of the nodes
+	r.options.Platform = datadoghqv1alpha1.PlatformGKEAutopilot
+	platform, err = r.getPlatform(dda)
+	assert.NoError(t, err)
+	assert.Equal(t, datadoghqv1alpha1.PlatformGKEAutopilot, platform)
+}
+
+func TestApplyPlatformProfile(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	dda.Spec.Agent.Config.Env = []corev1.EnvVar{{Name: datadoghqv1alpha1.DDKubeletClientCA, Value: "/etc/custom/ca.crt"}}
+
+	// Without platform, the DatadogAgent is unchanged
+	assert.Equal(t, dda, datadoghqv1alpha1.ApplyPlatformProfile(dda, ""))
+
+	// The env vars of the spec take precedence over the profile
+	profiled := datadoghqv1alpha1.ApplyPlatformProfile(dda, datadoghqv1alpha1.PlatformAKS)
+	assert.Len(t, dda.Spec.Agent.Config.Env, 1)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: datadoghqv1alpha1.DDKubeletClientCA, Value: "/etc/custom/ca.crt"},
+		{
+			Name: datadoghqv1alpha1.DDKubeletHost,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: FieldPathSpecNodeName},
+			},
+		},
+	}, profiled.Spec.Agent.Config.Env)
+	assert.Len(t, profiled.Spec.Agent.Config.Volumes, 1)
+	assert.Len(t, profiled.Spec.Agent.Config.VolumeMounts, 1)
+
+	// The profile env vars override the env vars set by the operator
+	ds, _, err := newDaemonSetFromInstance(profiled, nil)
+	assert.NoError(t, err)
+	agent := getTestContainer(t, ds.Spec.Template.Spec.Containers, "agent")
+	var kubeletHosts []corev1.EnvVar
+	for _, envVar := range agent.Env {
+		if envVar.Name == datadoghqv1alpha1.DDKubeletHost {
+			kubeletHosts = append(kubeletHosts, envVar)
+		}
+	}
+	assert.Equal(t, FieldPathSpecNodeName, kubeletHosts[len(kubeletHosts)-1].ValueFrom.FieldRef.FieldPath)
+
+	// The security context of the spec takes precedence over the profile
+	profiled = datadoghqv1alpha1.ApplyPlatformProfile(dda, datadoghqv1alpha1.PlatformOpenShift)
+	assert.Equal(t, "spc_t", profiled.Spec.Agent.Config.SecurityContext.SELinuxOptions.Type)
+	dda.Spec.Agent.Config.SecurityContext = &corev1.PodSecurityContext{SELinuxOptions: &corev1.SELinuxOptions{Type: "container_t"}}
+	profiled = datadoghqv1alpha1.ApplyPlatformProfile(dda, datadoghqv1alpha1.PlatformOpenShift)
+	assert.Equal(t, "container_t", profiled.Spec.Agent.Config.SecurityContext.SELinuxOptions.Type)
+}
+
+func TestIsValidPlatformProfile(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{SystemProbeEnabled: true})
+	assert.NoError(t, datadoghqv1alpha1.IsValidPlatformProfile(&dda.Spec, datadoghqv1alpha1.PlatformEKS))
+
+	dda.Spec.Agent.Apm.HostPort = datadoghqv1alpha1.NewInt32Pointer(8126)
+	err := datadoghqv1alpha1.IsValidPlatformProfile(&dda.Spec, datadoghqv1alpha1.PlatformGKEAutopilot)
+	assert.EqualError(t, err, `[invalid spec.agent.apm.hostPort, err: not allowed on the "gke-autopilot" platform, `+
+		`invalid spec.agent.systemProbe.enabled, err: not allowed on the "gke-autopilot" platform]`)
+}
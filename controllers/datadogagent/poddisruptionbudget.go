@@ -31,12 +31,12 @@ type (
 )
 
 func (r *Reconciler) manageClusterAgentPDB(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	cleanUpCondition := dda.Spec.ClusterAgent == nil
+	cleanUpCondition := dda.Spec.ClusterAgent == nil || !isPDBEnabled(dda.Spec.ClusterAgent.PodDisruptionBudget)
 	return r.managePDB(logger, dda, getClusterAgentPDBName(dda), buildClusterAgentPDB, cleanUpCondition)
 }
 
 func (r *Reconciler) manageClusterChecksRunnerPDB(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	cleanUpCondition := !needClusterChecksRunner(dda)
+	cleanUpCondition := !needClusterChecksRunner(dda) || !isPDBEnabled(dda.Spec.ClusterChecksRunner.PodDisruptionBudget)
 	return r.managePDB(logger, dda, getClusterChecksRunnerPDBName(dda), buildClusterChecksRunnerPDB, cleanUpCondition)
 }
 
@@ -129,7 +129,12 @@ func buildClusterAgentPDB(dda *datadoghqv1alpha1.DatadogAgent) *policyv1.PodDisr
 		datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultClusterAgentResourceSuffix,
 	}
 
-	return buildPDB(metadata, matchLabels, pdbMinAvailableInstances)
+	var config *datadoghqv1alpha1.PodDisruptionBudgetConfig
+	if dda.Spec.ClusterAgent != nil {
+		config = dda.Spec.ClusterAgent.PodDisruptionBudget
+	}
+
+	return buildPDB(metadata, matchLabels, config)
 }
 
 func buildClusterChecksRunnerPDB(dda *datadoghqv1alpha1.DatadogAgent) *policyv1.PodDisruptionBudget {
@@ -146,21 +151,37 @@ func buildClusterChecksRunnerPDB(dda *datadoghqv1alpha1.DatadogAgent) *policyv1.
 		datadoghqv1alpha1.AgentDeploymentComponentLabelKey: datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix,
 	}
 
-	return buildPDB(metadata, matchLabels, pdbMinAvailableInstances)
-}
+	var config *datadoghqv1alpha1.PodDisruptionBudgetConfig
+	if dda.Spec.ClusterChecksRunner != nil {
+		config = dda.Spec.ClusterChecksRunner.PodDisruptionBudget
+	}
 
-func buildPDB(metadata metav1.ObjectMeta, matchLabels map[string]string, minAvailable int) *policyv1.PodDisruptionBudget {
-	minAvailableStr := intstr.FromInt(minAvailable)
+	return buildPDB(metadata, matchLabels, config)
+}
 
+// buildPDB returns a PodDisruptionBudget following the configuration, minAvailable defaults to pdbMinAvailableInstances.
+// The budget applies to the replicas of all the zones, it doesn't depend on the anti-affinity preset.
+func buildPDB(metadata metav1.ObjectMeta, matchLabels map[string]string, config *datadoghqv1alpha1.PodDisruptionBudgetConfig) *policyv1.PodDisruptionBudget {
 	pdb := &policyv1.PodDisruptionBudget{
 		ObjectMeta: metadata,
 		Spec: policyv1.PodDisruptionBudgetSpec{
-			MinAvailable: &minAvailableStr,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: matchLabels,
 			},
 		},
 	}
 
+	switch {
+	case config != nil && config.MaxUnavailable != nil:
+		maxUnavailable := *config.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	case config != nil && config.MinAvailable != nil:
+		minAvailable := *config.MinAvailable
+		pdb.Spec.MinAvailable = &minAvailable
+	default:
+		minAvailable := intstr.FromInt(pdbMinAvailableInstances)
+		pdb.Spec.MinAvailable = &minAvailable
+	}
+
 	return pdb
 }
@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	"github.com/DataDog/datadog-operator/pkg/controller/utils/condition"
+)
+
+const (
+	hostTopologyKey = "kubernetes.io/hostname"
+	zoneTopologyKey = "topology.kubernetes.io/zone"
+
+	softAntiAffinityWeight = 100
+)
+
+// schedulingSpec gathers the scheduling configuration shared by the Cluster Agent and the Cluster Checks Runner
+type schedulingSpec struct {
+	component                 string
+	replicas                  *int32
+	nodeSelector              map[string]string
+	antiAffinityPreset        *datadoghqv1alpha1.PodAntiAffinityPreset
+	topologySpreadConstraints []corev1.TopologySpreadConstraint
+	podDisruptionBudget       *datadoghqv1alpha1.PodDisruptionBudgetConfig
+}
+
+func getSchedulingSpecs(dda *datadoghqv1alpha1.DatadogAgent) []schedulingSpec {
+	var specs []schedulingSpec
+	if spec := dda.Spec.ClusterAgent; spec != nil {
+		specs = append(specs, schedulingSpec{
+			component:                 datadoghqv1alpha1.DefaultClusterAgentResourceSuffix,
+			replicas:                  spec.Replicas,
+			nodeSelector:              spec.NodeSelector,
+			antiAffinityPreset:        spec.AntiAffinityPreset,
+			topologySpreadConstraints: spec.TopologySpreadConstraints,
+			podDisruptionBudget:       spec.PodDisruptionBudget,
+		})
+	}
+	if needClusterChecksRunner(dda) {
+		spec := dda.Spec.ClusterChecksRunner
+		specs = append(specs, schedulingSpec{
+			component:                 datadoghqv1alpha1.DefaultClusterChecksRunnerResourceSuffix,
+			replicas:                  spec.Replicas,
+			nodeSelector:              spec.NodeSelector,
+			antiAffinityPreset:        spec.AntiAffinityPreset,
+			topologySpreadConstraints: spec.TopologySpreadConstraints,
+			podDisruptionBudget:       spec.PodDisruptionBudget,
+		})
+	}
+	return specs
+}
+
+// getAffinity returns the affinity of the pods with the pod anti-affinity term of the preset, if any.
+// The term is added to the pod anti-affinity terms of the affinity.
+func getAffinity(affinity *corev1.Affinity, preset *datadoghqv1alpha1.PodAntiAffinityPreset, component string) *corev1.Affinity {
+	if preset == nil {
+		return affinity
+	}
+
+	newAffinity := &corev1.Affinity{}
+	if affinity != nil {
+		newAffinity = affinity.DeepCopy()
+	}
+	if newAffinity.PodAntiAffinity == nil {
+		newAffinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				datadoghqv1alpha1.AgentDeploymentComponentLabelKey: component,
+			},
+		},
+		TopologyKey: getAntiAffinityTopologyKey(preset),
+	}
+	antiAffinity := newAffinity.PodAntiAffinity
+	if preset.Type == datadoghqv1alpha1.PodAntiAffinityTypeHard {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	} else {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          softAntiAffinityWeight,
+			PodAffinityTerm: term,
+		})
+	}
+	return newAffinity
+}
+
+func getAntiAffinityTopologyKey(preset *datadoghqv1alpha1.PodAntiAffinityPreset) string {
+	if preset.Topology == datadoghqv1alpha1.PodAntiAffinityTopologyZone {
+		return zoneTopologyKey
+	}
+	return hostTopologyKey
+}
+
+func isPDBEnabled(config *datadoghqv1alpha1.PodDisruptionBudgetConfig) bool {
+	return config == nil || config.Enabled == nil || *config.Enabled
+}
+
+// updateSchedulingCondition sets the SchedulingWarning condition when the replica count of the Cluster Agent
+// or the Cluster Checks Runner can't satisfy their explicit anti-affinity preset, spread or PodDisruptionBudget
+func (r *Reconciler) updateSchedulingCondition(dda *datadoghqv1alpha1.DatadogAgent, newStatus *datadoghqv1alpha1.DatadogAgentStatus) error {
+	var warnings []string
+	for _, spec := range getSchedulingSpecs(dda) {
+		specWarnings, err := r.getSchedulingWarnings(spec)
+		if err != nil {
+			return err
+		}
+		warnings = append(warnings, specWarnings...)
+	}
+
+	now := metav1.NewTime(time.Now())
+	if len(warnings) == 0 {
+		condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeSchedulingWarning, corev1.ConditionFalse, "The replica counts satisfy the scheduling constraints", false)
+		return nil
+	}
+	condition.UpdateDatadogAgentStatusConditions(newStatus, now, datadoghqv1alpha1.ConditionTypeSchedulingWarning, corev1.ConditionTrue, strings.Join(warnings, "; "), false)
+	return nil
+}
+
+func (r *Reconciler) getSchedulingWarnings(spec schedulingSpec) ([]string, error) {
+	var warnings []string
+	replicas := 1
+	if spec.replicas != nil {
+		replicas = int(*spec.replicas)
+	}
+
+	if preset := spec.antiAffinityPreset; preset != nil {
+		topologyKey := getAntiAffinityTopologyKey(preset)
+		if replicas < 2 {
+			warnings = append(warnings, fmt.Sprintf("%s: a single replica can't be spread by the anti-affinity preset", spec.component))
+		} else if preset.Type == datadoghqv1alpha1.PodAntiAffinityTypeHard {
+			domains, err := r.countTopologyDomains(topologyKey, spec.nodeSelector)
+			if err != nil {
+				return nil, err
+			}
+			if domains < replicas {
+				warnings = append(warnings, fmt.Sprintf("%s: %d replicas require distinct %s domains but only %d are available, the extra replicas stay pending", spec.component, replicas, topologyKey, domains))
+			}
+		}
+	}
+
+	if len(spec.topologySpreadConstraints) > 0 && replicas < 2 {
+		warnings = append(warnings, fmt.Sprintf("%s: a single replica can't be spread by the topology spread constraints", spec.component))
+	}
+
+	if pdb := spec.podDisruptionBudget; pdb != nil && isPDBEnabled(pdb) && !isPDBDisruptionAllowed(pdb, replicas) {
+		warnings = append(warnings, fmt.Sprintf("%s: the PodDisruptionBudget doesn't allow any voluntary disruption with %d replicas", spec.component, replicas))
+	}
+
+	return warnings, nil
+}
+
+// isPDBDisruptionAllowed returns true if the PodDisruptionBudget allows evicting a pod when all the replicas are available
+func isPDBDisruptionAllowed(config *datadoghqv1alpha1.PodDisruptionBudgetConfig, replicas int) bool {
+	if config.MaxUnavailable != nil {
+		maxUnavailable, err := intstr.GetValueFromIntOrPercent(config.MaxUnavailable, replicas, true)
+		return err == nil && maxUnavailable > 0
+	}
+	minAvailable := intstr.FromInt(pdbMinAvailableInstances)
+	if config.MinAvailable != nil {
+		minAvailable = *config.MinAvailable
+	}
+	value, err := intstr.GetValueFromIntOrPercent(&minAvailable, replicas, true)
+	return err == nil && value < replicas
+}
+
+// countTopologyDomains returns the number of distinct values of the topology label among the nodes matching the node selector
+func (r *Reconciler) countTopologyDomains(topologyKey string, nodeSelector map[string]string) (int, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.client.List(context.TODO(), nodes, client.MatchingLabels(nodeSelector)); err != nil {
+		return 0, err
+	}
+	domains := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		if value, found := node.Labels[topologyKey]; found {
+			domains[value] = struct{}{}
+		}
+	}
+	return len(domains), nil
+}
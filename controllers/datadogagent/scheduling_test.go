@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetAffinity(t *testing.T) {
+	nodeAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchFields: []corev1.NodeSelectorRequirement{{Key: "foo", Operator: corev1.NodeSelectorOpExists}}}},
+			},
+		},
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{TopologyKey: "baz"}},
+		},
+	}
+	wantTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"agent.datadoghq.com/component": "cluster-agent"},
+		},
+		TopologyKey: "topology.kubernetes.io/zone",
+	}
+
+	// Without preset, the affinity is kept as is
+	assert.Nil(t, getAffinity(nil, nil, "cluster-agent"))
+	assert.Equal(t, nodeAffinity, getAffinity(nodeAffinity, nil, "cluster-agent"))
+
+	// The preset term is added to the pod anti-affinity, the node affinity is kept
+	got := getAffinity(nodeAffinity, &datadoghqv1alpha1.PodAntiAffinityPreset{
+		Type:     datadoghqv1alpha1.PodAntiAffinityTypeSoft,
+		Topology: datadoghqv1alpha1.PodAntiAffinityTopologyZone,
+	}, "cluster-agent")
+	assert.Equal(t, nodeAffinity.NodeAffinity, got.NodeAffinity)
+	assert.Equal(t, &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution:  []corev1.PodAffinityTerm{{TopologyKey: "baz"}},
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{Weight: 100, PodAffinityTerm: wantTerm}},
+	}, got.PodAntiAffinity)
+
+	got = getAffinity(nodeAffinity, &datadoghqv1alpha1.PodAntiAffinityPreset{
+		Type:     datadoghqv1alpha1.PodAntiAffinityTypeHard,
+		Topology: datadoghqv1alpha1.PodAntiAffinityTopologyZone,
+	}, "cluster-agent")
+	assert.Equal(t, []corev1.PodAffinityTerm{{TopologyKey: "baz"}, wantTerm}, got.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	// The affinity of the spec isn't modified
+	assert.Len(t, nodeAffinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+
+	got = getAffinity(nil, &datadoghqv1alpha1.PodAntiAffinityPreset{
+		Type:     datadoghqv1alpha1.PodAntiAffinityTypeHard,
+		Topology: datadoghqv1alpha1.PodAntiAffinityTopologyZone,
+	}, "cluster-agent")
+	assert.Equal(t, &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{wantTerm},
+		},
+	}, got)
+}
+
+func TestBuildPDB(t *testing.T) {
+	maxUnavailable := intstr.FromString("50%")
+	minAvailable := intstr.FromInt(2)
+	tests := []struct {
+		name               string
+		config             *datadoghqv1alpha1.PodDisruptionBudgetConfig
+		wantMinAvailable   *intstr.IntOrString
+		wantMaxUnavailable *intstr.IntOrString
+	}{
+		{
+			name:             "default",
+			wantMinAvailable: intstrPointer(intstr.FromInt(1)),
+		},
+		{
+			name:             "minAvailable",
+			config:           &datadoghqv1alpha1.PodDisruptionBudgetConfig{MinAvailable: &minAvailable},
+			wantMinAvailable: &minAvailable,
+		},
+		{
+			name:               "maxUnavailable",
+			config:             &datadoghqv1alpha1.PodDisruptionBudgetConfig{MaxUnavailable: &maxUnavailable},
+			wantMaxUnavailable: &maxUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb := buildPDB(metav1.ObjectMeta{Name: "foo"}, map[string]string{"foo": "bar"}, tt.config)
+			assert.Equal(t, tt.wantMinAvailable, pdb.Spec.MinAvailable)
+			assert.Equal(t, tt.wantMaxUnavailable, pdb.Spec.MaxUnavailable)
+		})
+	}
+}
+
+func TestReconcileDatadogAgent_updateSchedulingCondition(t *testing.T) {
+	newNode := func(name, zone string) runtime.Object {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{hostTopologyKey: name, zoneTopologyKey: zone},
+			},
+		}
+	}
+	c := fake.NewFakeClient(newNode("node1", "zone-a"), newNode("node2", "zone-a"), newNode("node3", "zone-b"))
+	r := newCertificatesTestReconciler(c, ReconcilerOptions{})
+
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{ClusterAgentEnabled: true})
+	dda.Spec.ClusterAgent.Replicas = datadoghqv1alpha1.NewInt32Pointer(3)
+
+	// The default configuration doesn't raise any warning
+	status := &datadoghqv1alpha1.DatadogAgentStatus{}
+	assert.NoError(t, r.updateSchedulingCondition(dda, status))
+	assert.Nil(t, getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeSchedulingWarning))
+
+	// 3 replicas fit on 3 hosts
+	dda.Spec.ClusterAgent.AntiAffinityPreset = &datadoghqv1alpha1.PodAntiAffinityPreset{
+		Type:     datadoghqv1alpha1.PodAntiAffinityTypeHard,
+		Topology: datadoghqv1alpha1.PodAntiAffinityTopologyHost,
+	}
+	assert.NoError(t, r.updateSchedulingCondition(dda, status))
+	assert.Nil(t, getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeSchedulingWarning))
+
+	// but not in 2 zones
+	dda.Spec.ClusterAgent.AntiAffinityPreset.Topology = datadoghqv1alpha1.PodAntiAffinityTopologyZone
+	assert.NoError(t, r.updateSchedulingCondition(dda, status))
+	cond := getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeSchedulingWarning)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "cluster-agent: 3 replicas require distinct topology.kubernetes.io/zone domains but only 2 are available, the extra replicas stay pending", cond.Message)
+
+	// A PodDisruptionBudget that blocks every eviction is reported
+	dda.Spec.ClusterAgent.AntiAffinityPreset.Type = datadoghqv1alpha1.PodAntiAffinityTypeSoft
+	minAvailable := intstr.FromString("100%")
+	dda.Spec.ClusterAgent.PodDisruptionBudget = &datadoghqv1alpha1.PodDisruptionBudgetConfig{MinAvailable: &minAvailable}
+	assert.NoError(t, r.updateSchedulingCondition(dda, status))
+	cond = getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeSchedulingWarning)
+	assert.Equal(t, "cluster-agent: the PodDisruptionBudget doesn't allow any voluntary disruption with 3 replicas", cond.Message)
+
+	// unless it is disabled
+	dda.Spec.ClusterAgent.PodDisruptionBudget.Enabled = datadoghqv1alpha1.NewBoolPointer(false)
+	assert.NoError(t, r.updateSchedulingCondition(dda, status))
+	cond = getTestStatusCondition(status, datadoghqv1alpha1.ConditionTypeSchedulingWarning)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
+
+func TestIsPDBDisruptionAllowed(t *testing.T) {
+	minAvailable := intstr.FromInt(2)
+	maxUnavailable := intstr.FromString("10%")
+	zero := intstr.FromInt(0)
+
+	assert.False(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{}, 1))
+	assert.True(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{}, 2))
+	assert.False(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{MinAvailable: &minAvailable}, 2))
+	assert.True(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{MinAvailable: &minAvailable}, 3))
+	// Percentages of maxUnavailable are rounded up
+	assert.True(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{MaxUnavailable: &maxUnavailable}, 2))
+	assert.False(t, isPDBDisruptionAllowed(&datadoghqv1alpha1.PodDisruptionBudgetConfig{MaxUnavailable: &zero}, 2))
+}
+
+func intstrPointer(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
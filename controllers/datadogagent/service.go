@@ -111,20 +111,24 @@ func (r *Reconciler) manageMetricsServerService(logger logr.Logger, dda *datadog
 
 func (r *Reconciler) manageMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
 	if !isMetricsProviderEnabled(dda.Spec.ClusterAgent) {
-		return r.cleanupMetricsServerAPIService(logger)
+		return r.cleanupMetricsServerAPIService(logger, dda)
 	}
 
 	apiServiceName := getMetricsServerAPIServiceName()
+	caBundle, err := r.getClusterAgentCABundle(dda)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 	apiService := &apiregistrationv1.APIService{}
-	err := r.client.Get(context.TODO(), types.NamespacedName{Name: apiServiceName}, apiService)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: apiServiceName}, apiService)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return r.createMetricsServerAPIService(logger, dda)
+			return r.createMetricsServerAPIService(logger, dda, caBundle)
 		}
 		return reconcile.Result{}, err
 	}
 
-	return r.updateIfNeededMetricsServerAPIService(logger, dda, apiService)
+	return r.updateIfNeededMetricsServerAPIService(logger, dda, apiService, caBundle)
 }
 
 func (r *Reconciler) manageAdmissionControllerService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
@@ -150,8 +154,8 @@ func (r *Reconciler) createMetricsServerService(logger logr.Logger, dda *datadog
 	return r.createService(logger, dda, newService)
 }
 
-func (r *Reconciler) createMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
-	newAPIService, _ := newMetricsServerAPIService(dda)
+func (r *Reconciler) createMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, caBundle []byte) (reconcile.Result, error) {
+	newAPIService, _ := newMetricsServerAPIService(dda, caBundle)
 	return r.createAPIService(logger, dda, newAPIService)
 }
 
@@ -165,9 +169,9 @@ func (r *Reconciler) cleanupMetricsServerService(dda *datadoghqv1alpha1.DatadogA
 	return cleanupService(r.client, serviceName, dda.Namespace)
 }
 
-func (r *Reconciler) cleanupMetricsServerAPIService(logger logr.Logger) (reconcile.Result, error) {
+func (r *Reconciler) cleanupMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
 	apiServiceName := getMetricsServerAPIServiceName()
-	return r.cleanupAPIService(logger, apiServiceName)
+	return r.cleanupAPIService(logger, dda, apiServiceName)
 }
 
 func (r *Reconciler) cleanupAdmissionControllerService(dda *datadoghqv1alpha1.DatadogAgent) (reconcile.Result, error) {
@@ -180,8 +184,8 @@ func (r *Reconciler) updateIfNeededMetricsServerService(logger logr.Logger, dda
 	return r.updateIfNeededService(logger, dda, currentService, newService)
 }
 
-func (r *Reconciler) updateIfNeededMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, currentAPIService *apiregistrationv1.APIService) (reconcile.Result, error) {
-	newAPIService, _ := newMetricsServerAPIService(dda)
+func (r *Reconciler) updateIfNeededMetricsServerAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, currentAPIService *apiregistrationv1.APIService, caBundle []byte) (reconcile.Result, error) {
+	newAPIService, _ := newMetricsServerAPIService(dda, caBundle)
 	return r.updateIfNeededAPIService(logger, dda, currentAPIService, newAPIService)
 }
 
@@ -232,7 +236,7 @@ func cleanupService(client client.Client, name, namespace string) (reconcile.Res
 	return reconcile.Result{}, err
 }
 
-func (r *Reconciler) cleanupAPIService(logger logr.Logger, name string) (reconcile.Result, error) {
+func (r *Reconciler) cleanupAPIService(logger logr.Logger, dda *datadoghqv1alpha1.DatadogAgent, name string) (reconcile.Result, error) {
 	apiService := &apiregistrationv1.APIService{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name}, apiService)
 	if err != nil {
@@ -241,6 +245,9 @@ func (r *Reconciler) cleanupAPIService(logger logr.Logger, name string) (reconci
 		}
 		return reconcile.Result{}, err
 	}
+	if ownedByOtherDatadogAgent(apiService.OwnerReferences, dda) {
+		return reconcile.Result{}, nil
+	}
 	err = r.client.Delete(context.TODO(), apiService)
 	if err != nil {
 		logger.Error(err, "failed to delete APIService", "name", name)
@@ -278,6 +285,13 @@ func (r *Reconciler) updateIfNeededAPIService(logger logr.Logger, dda *datadoghq
 	result := reconcile.Result{}
 	hash := newAPIService.Annotations[datadoghqv1alpha1.MD5AgentDeploymentAnnotationKey]
 	if !comparison.IsSameSpecMD5Hash(hash, currentAPIService.GetAnnotations()) {
+		if ownedByOtherDatadogAgent(currentAPIService.OwnerReferences, dda) {
+			// Take over the APIService of a DatadogAgent that isn't its owner anymore
+			currentAPIService.OwnerReferences = nil
+			if err := SetOwnerReference(dda, currentAPIService, r.scheme); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
 
 		updatedAPIService := currentAPIService.DeepCopy()
 		updatedAPIService.Labels = newAPIService.Labels
@@ -329,7 +343,9 @@ func newMetricsServerService(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.Servi
 	return service, hash
 }
 
-func newMetricsServerAPIService(dda *datadoghqv1alpha1.DatadogAgent) (*apiregistrationv1.APIService, string) {
+// newMetricsServerAPIService returns the external metrics APIService.
+// The TLS verification of the Cluster Agent is skipped only when the CA bundle isn't available.
+func newMetricsServerAPIService(dda *datadoghqv1alpha1.DatadogAgent, caBundle []byte) (*apiregistrationv1.APIService, string) {
 	labels := getDefaultLabels(dda, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix, getClusterAgentVersion(dda))
 	annotations := getDefaultAnnotations(dda)
 
@@ -347,7 +363,8 @@ func newMetricsServerAPIService(dda *datadoghqv1alpha1.DatadogAgent) (*apiregist
 				Port:      &port,
 			},
 			Version:               "v1beta1",
-			InsecureSkipTLSVerify: true,
+			InsecureSkipTLSVerify: len(caBundle) == 0,
+			CABundle:              caBundle,
 			Group:                 "external.metrics.k8s.io",
 			GroupPriorityMinimum:  100,
 			VersionPriority:       100,
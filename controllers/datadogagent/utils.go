@@ -67,6 +67,10 @@ func newAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent, select
 		annotations[datadoghqv1alpha1.SysteProbeSeccompAnnotationKey] = getSeccompProfileName(&agentdeployment.Spec.Agent.SystemProbe)
 	}
 
+	if hash := getChecksHash(getChecks(agentdeployment, false)); hash != "" {
+		annotations[datadoghqv1alpha1.ChecksHashAnnotationKey] = hash
+	}
+
 	for key, val := range agentdeployment.Spec.Agent.AdditionalAnnotations {
 		annotations[key] = val
 	}
@@ -121,7 +125,7 @@ func newAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent, select
 		return nil, err
 	}
 
-	return &corev1.PodTemplateSpec{
+	template := &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: agentdeployment.Name,
 			Namespace:    agentdeployment.Namespace,
@@ -129,19 +133,28 @@ func newAgentPodTemplate(agentdeployment *datadoghqv1alpha1.DatadogAgent, select
 			Annotations:  annotations,
 		},
 		Spec: corev1.PodSpec{
-			SecurityContext:    agentdeployment.Spec.Agent.Config.SecurityContext,
-			ServiceAccountName: getAgentServiceAccount(agentdeployment),
-			InitContainers:     initContainers,
-			Containers:         containers,
-			Volumes:            getVolumesForAgent(agentdeployment),
-			Tolerations:        agentdeployment.Spec.Agent.Config.Tolerations,
-			PriorityClassName:  agentdeployment.Spec.Agent.PriorityClassName,
-			HostNetwork:        agentdeployment.Spec.Agent.HostNetwork,
-			HostPID:            agentdeployment.Spec.Agent.HostPID || isComplianceEnabled(agentdeployment),
-			DNSPolicy:          agentdeployment.Spec.Agent.DNSPolicy,
-			DNSConfig:          agentdeployment.Spec.Agent.DNSConfig,
+			NodeSelector:                  map[string]string{corev1.LabelOSStable: linuxOS},
+			SecurityContext:               agentdeployment.Spec.Agent.Config.SecurityContext,
+			ServiceAccountName:            getAgentServiceAccount(agentdeployment),
+			InitContainers:                initContainers,
+			Containers:                    containers,
+			Volumes:                       getVolumesForAgent(agentdeployment),
+			Tolerations:                   agentdeployment.Spec.Agent.Config.Tolerations,
+			PriorityClassName:             agentdeployment.Spec.Agent.PriorityClassName,
+			TerminationGracePeriodSeconds: agentdeployment.Spec.Agent.TerminationGracePeriodSeconds,
+			HostNetwork:                   agentdeployment.Spec.Agent.HostNetwork,
+			HostPID:                       agentdeployment.Spec.Agent.HostPID || isComplianceEnabled(agentdeployment),
+			DNSPolicy:                     agentdeployment.Spec.Agent.DNSPolicy,
+			DNSConfig:                     agentdeployment.Spec.Agent.DNSConfig,
 		},
-	}, nil
+	}
+
+	if isWindowsAgent(agentdeployment) {
+		if err = setWindowsAgentPodTemplate(agentdeployment, template); err != nil {
+			return nil, err
+		}
+	}
+	return template, nil
 }
 
 func isAPMEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
@@ -151,6 +164,47 @@ func isAPMEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
 	return datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Apm.Enabled)
 }
 
+func isAPMSocketEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	if !isAPMEnabled(dda) || dda.Spec.Agent.Apm.UnixDomainSocket == nil {
+		return false
+	}
+	return datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Apm.UnixDomainSocket.Enabled)
+}
+
+func getAPMSocketPath(dda *datadoghqv1alpha1.DatadogAgent) string {
+	if uds := dda.Spec.Agent.Apm.UnixDomainSocket; uds != nil && uds.HostFilepath != nil && *uds.HostFilepath != "" {
+		return *uds.HostFilepath
+	}
+	return datadoghqv1alpha1.DefaultAPMSocketPath
+}
+
+func isDogstatsdSocketEnabled(spec *datadoghqv1alpha1.DatadogAgentSpec) bool {
+	if spec.Agent == nil || spec.Agent.Config.Dogstatsd == nil {
+		return false
+	}
+	dsd := spec.Agent.Config.Dogstatsd
+	if datadoghqv1alpha1.BoolValue(dsd.UseDogStatsDSocketVolume) {
+		return true
+	}
+	return dsd.UnixDomainSocket != nil && datadoghqv1alpha1.BoolValue(dsd.UnixDomainSocket.Enabled)
+}
+
+// isDogstatsdUDPDisabled returns true if DogStatsD is only exposed over Unix Domain Socket.
+// The legacy useDogStatsDSocketVolume option keeps the UDP hostPort along with the socket.
+func isDogstatsdUDPDisabled(spec *datadoghqv1alpha1.DatadogAgentSpec) bool {
+	if spec.Agent == nil || spec.Agent.Config.Dogstatsd == nil || spec.Agent.Config.Dogstatsd.UnixDomainSocket == nil {
+		return false
+	}
+	return datadoghqv1alpha1.BoolValue(spec.Agent.Config.Dogstatsd.UnixDomainSocket.Enabled)
+}
+
+func getDogstatsdSocketPath(spec *datadoghqv1alpha1.DatadogAgentSpec) string {
+	if uds := spec.Agent.Config.Dogstatsd.UnixDomainSocket; uds != nil && uds.HostFilepath != nil && *uds.HostFilepath != "" {
+		return *uds.HostFilepath
+	}
+	return datadoghqv1alpha1.DefaultDogstatsdSocketPath
+}
+
 func isProcessEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
 	if dda.Spec.Agent == nil {
 		return false
@@ -207,7 +261,7 @@ func getAgentContainer(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.Container,
 		Protocol:      corev1.ProtocolUDP,
 	}
 
-	if agentSpec.Config.HostPort != nil {
+	if agentSpec.Config.HostPort != nil && !isDogstatsdUDPDisabled(&dda.Spec) {
 		// Create the host port configuration
 		udpPort.HostPort = *agentSpec.Config.HostPort
 		// If HostNetwork is enabled, set the container port
@@ -240,6 +294,13 @@ func getAgentContainer(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.Container,
 		ReadinessProbe: getDefaultReadinessProbe(),
 	}
 
+	applyContainerOverrides(agentContainer, containerOverrides{
+		livenessProbe:  agentSpec.Config.LivenessProbe,
+		readinessProbe: agentSpec.Config.ReadinessProbe,
+		startupProbe:   agentSpec.Config.StartupProbe,
+		lifecycle:      agentSpec.Config.Lifecycle,
+	})
+
 	return agentContainer, nil
 }
 
@@ -254,9 +315,21 @@ func getAPMAgentContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Contai
 		Name:          "traceport",
 		Protocol:      corev1.ProtocolTCP,
 	}
-	if agentSpec.Apm.HostPort != nil {
+	if agentSpec.Apm.HostPort != nil && !isAPMSocketEnabled(dda) {
 		tcpPort.HostPort = *agentSpec.Apm.HostPort
 	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      datadoghqv1alpha1.ConfigVolumeName,
+			MountPath: datadoghqv1alpha1.ConfigVolumePath,
+		},
+	}
+	if isAPMSocketEnabled(dda) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      datadoghqv1alpha1.APMSocketVolumeName,
+			MountPath: filepath.Dir(getAPMSocketPath(dda)),
+		})
+	}
 
 	apmContainer := corev1.Container{
 		Name:            "trace-agent",
@@ -272,17 +345,19 @@ func getAPMAgentContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Contai
 		},
 		Env:           envVars,
 		LivenessProbe: getDefaultAPMAgentLivenessProbe(),
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:      datadoghqv1alpha1.ConfigVolumeName,
-				MountPath: datadoghqv1alpha1.ConfigVolumePath,
-			},
-		},
+		VolumeMounts:  volumeMounts,
 	}
 	if agentSpec.Apm.Resources != nil {
 		apmContainer.Resources = *agentSpec.Apm.Resources
 	}
 
+	applyContainerOverrides(&apmContainer, containerOverrides{
+		livenessProbe:  agentSpec.Apm.LivenessProbe,
+		readinessProbe: agentSpec.Apm.ReadinessProbe,
+		startupProbe:   agentSpec.Apm.StartupProbe,
+		lifecycle:      agentSpec.Apm.Lifecycle,
+	})
+
 	return []corev1.Container{apmContainer}, nil
 }
 
@@ -309,6 +384,13 @@ func getProcessContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Contain
 		process.Resources = *agentSpec.Process.Resources
 	}
 
+	applyContainerOverrides(&process, containerOverrides{
+		livenessProbe:  agentSpec.Process.LivenessProbe,
+		readinessProbe: agentSpec.Process.ReadinessProbe,
+		startupProbe:   agentSpec.Process.StartupProbe,
+		lifecycle:      agentSpec.Process.Lifecycle,
+	})
+
 	return []corev1.Container{process}, nil
 }
 
@@ -341,6 +423,13 @@ func getSystemProbeContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Con
 		systemProbe.Resources = *agentSpec.SystemProbe.Resources
 	}
 
+	applyContainerOverrides(&systemProbe, containerOverrides{
+		livenessProbe:  agentSpec.SystemProbe.LivenessProbe,
+		readinessProbe: agentSpec.SystemProbe.ReadinessProbe,
+		startupProbe:   agentSpec.SystemProbe.StartupProbe,
+		lifecycle:      agentSpec.SystemProbe.Lifecycle,
+	})
+
 	return []corev1.Container{systemProbe}, nil
 }
 
@@ -370,6 +459,13 @@ func getSecurityAgentContainer(dda *datadoghqv1alpha1.DatadogAgent) (*corev1.Con
 		VolumeMounts: getVolumeMountsForSecurityAgent(dda),
 	}
 
+	applyContainerOverrides(securityAgentContainer, containerOverrides{
+		livenessProbe:  agentSpec.Security.LivenessProbe,
+		readinessProbe: agentSpec.Security.ReadinessProbe,
+		startupProbe:   agentSpec.Security.StartupProbe,
+		lifecycle:      agentSpec.Security.Lifecycle,
+	})
+
 	return securityAgentContainer, nil
 }
 
@@ -382,6 +478,9 @@ func getInitContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Container,
 	}
 
 	containers := getConfigInitContainers(spec, volumeMounts, envVars)
+	if socketsInit := getSocketsInitContainer(dda); socketsInit != nil {
+		containers = append(containers, *socketsInit)
+	}
 
 	if isSystemProbeEnabled(dda) {
 		if getSeccompProfileName(&dda.Spec.Agent.SystemProbe) == datadoghqv1alpha1.DefaultSeccompProfileName || dda.Spec.Agent.SystemProbe.SecCompCustomProfileConfigMap != "" {
@@ -413,6 +512,41 @@ func getInitContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Container,
 	return containers, nil
 }
 
+// getSocketsInitContainer returns the init container opening the directories of the Unix Domain Sockets to the
+// application pods, which can run as any user. The kubelet only sets the mode of the directories it creates,
+// and the Agent makes the sockets writable by everyone.
+func getSocketsInitContainer(dda *datadoghqv1alpha1.DatadogAgent) *corev1.Container {
+	var volumeNames []string
+	if isDogstatsdSocketEnabled(&dda.Spec) {
+		volumeNames = append(volumeNames, datadoghqv1alpha1.DogstatsdSockerVolumeName)
+	}
+	if isAPMSocketEnabled(dda) {
+		volumeNames = append(volumeNames, datadoghqv1alpha1.APMSocketVolumeName)
+	}
+	if len(volumeNames) == 0 {
+		return nil
+	}
+
+	command := []string{"chmod", socketDirectoryMode}
+	volumeMounts := make([]corev1.VolumeMount, 0, len(volumeNames))
+	for _, name := range volumeNames {
+		// The sockets can share a directory, each volume is mounted at its own path
+		path := filepath.Join(socketsInitMountPath, name)
+		command = append(command, path)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: name, MountPath: path})
+	}
+
+	spec := &dda.Spec
+	return &corev1.Container{
+		Name:            "init-sockets",
+		Image:           spec.Agent.Image.Name,
+		ImagePullPolicy: *spec.Agent.Image.PullPolicy,
+		Resources:       *spec.Agent.Config.Resources,
+		Command:         command,
+		VolumeMounts:    volumeMounts,
+	}
+}
+
 // getConfigInitContainers returns the init containers necessary to set up the
 // agent's configuration volume.
 func getConfigInitContainers(spec *datadoghqv1alpha1.DatadogAgentSpec, volumeMounts []corev1.VolumeMount, envVars []corev1.EnvVar) []corev1.Container {
@@ -457,6 +591,13 @@ func getEnvVarsForAPMAgent(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.EnvVar
 		return nil, err
 	}
 	envVars = append(envVars, commonEnvVars...)
+	if isAPMSocketEnabled(dda) {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDAPMReceiverSocket,
+			Value: getAPMSocketPath(dda),
+		})
+	}
+	envVars = append(envVars, getAdditionalEndpointsEnvVars(dda, datadoghqv1alpha1.DDAPMAdditionalEndpoints)...)
 	envVars = append(envVars, dda.Spec.Agent.Apm.Env...)
 	return envVars, nil
 }
@@ -478,6 +619,7 @@ func getEnvVarsForProcessAgent(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.En
 		return nil, err
 	}
 	envVars = append(envVars, commonEnvVars...)
+	envVars = append(envVars, getAdditionalEndpointsEnvVars(dda, datadoghqv1alpha1.DDProcessAdditionalEndpoints)...)
 	envVars = append(envVars, dda.Spec.Agent.Process.Env...)
 	return envVars, nil
 }
@@ -558,6 +700,7 @@ func getEnvVarsCommon(dda *datadoghqv1alpha1.DatadogAgent, needAPIKey bool) ([]c
 		}
 	}
 
+	envVars = append(envVars, getProxyEnvVars(dda)...)
 	envVars = append(envVars, dda.Spec.Agent.Env...)
 
 	return envVars, nil
@@ -622,11 +765,21 @@ func getEnvVarsForAgent(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.EnvVar, e
 			Value: strconv.FormatBool(*spec.Agent.Config.Dogstatsd.DogstatsdOriginDetection),
 		},
 	}
+	if isDogstatsdSocketEnabled(&spec) {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDDogstatsdSocket,
+			Value: getDogstatsdSocketPath(&spec),
+		})
+	}
 	commonEnvVars, err := getEnvVarsCommon(dda, true)
 	if err != nil {
 		return nil, err
 	}
 	envVars = append(envVars, commonEnvVars...)
+	envVars = append(envVars, getAdditionalEndpointsEnvVars(dda, datadoghqv1alpha1.DDAdditionalEndpoints, datadoghqv1alpha1.DDLogsConfigAdditionalEndpoints)...)
+	if *spec.Agent.Log.Enabled {
+		envVars = append(envVars, getLogsConfigEnvVars(dda)...)
+	}
 
 	if spec.ClusterAgent != nil {
 		clusterEnv := []corev1.EnvVar{
@@ -748,6 +901,20 @@ func getEnvVarsForSecurityAgent(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.E
 	return append(envVars, spec.Agent.Config.Env...), nil
 }
 
+// getSocketVolume returns a hostPath volume sharing the directory of a Unix Domain Socket with the application pods
+func getSocketVolume(name, socketPath string) corev1.Volume {
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: filepath.Dir(socketPath),
+				Type: &hostPathType,
+			},
+		},
+	}
+}
+
 // getVolumesForAgent defines volumes for the Agent
 func getVolumesForAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.Volume {
 	volumes := []corev1.Volume{
@@ -806,6 +973,12 @@ func getVolumesForAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.Volume {
 			volumes = append(volumes, criVolume)
 		}
 	}
+	if isDogstatsdSocketEnabled(&dda.Spec) {
+		volumes = append(volumes, getSocketVolume(datadoghqv1alpha1.DogstatsdSockerVolumeName, getDogstatsdSocketPath(&dda.Spec)))
+	}
+	if isAPMSocketEnabled(dda) {
+		volumes = append(volumes, getSocketVolume(datadoghqv1alpha1.APMSocketVolumeName, getAPMSocketPath(dda)))
+	}
 	if datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Process.Enabled) || isComplianceEnabled(dda) {
 		passwdVolume := corev1.Volume{
 			Name: datadoghqv1alpha1.PasswdVolumeName,
@@ -977,22 +1150,9 @@ func getVolumesForAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.Volume {
 }
 
 func getVolumeForConfd(dda *datadoghqv1alpha1.DatadogAgent) corev1.Volume {
-	source := corev1.VolumeSource{
-		EmptyDir: &corev1.EmptyDirVolumeSource{},
-	}
-	if dda.Spec.Agent.Config.Confd != nil {
-		source = corev1.VolumeSource{
-			ConfigMap: &corev1.ConfigMapVolumeSource{
-				LocalObjectReference: corev1.LocalObjectReference{
-					Name: dda.Spec.Agent.Config.Confd.ConfigMapName,
-				},
-			},
-		}
-	}
-
 	return corev1.Volume{
 		Name:         datadoghqv1alpha1.ConfdVolumeName,
-		VolumeSource: source,
+		VolumeSource: getConfdVolumeSource(dda, dda.Spec.Agent.Config.Confd, getChecks(dda, false)),
 	}
 }
 
@@ -1127,10 +1287,10 @@ func getVolumeMountsForAgent(spec *datadoghqv1alpha1.DatadogAgentSpec) []corev1.
 	}
 
 	// Dogstatsd volume
-	if *spec.Agent.Config.Dogstatsd.UseDogStatsDSocketVolume {
+	if isDogstatsdSocketEnabled(spec) {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      datadoghqv1alpha1.DogstatsdSockerVolumeName,
-			MountPath: datadoghqv1alpha1.DogstatsdSockerVolumePath,
+			MountPath: filepath.Dir(getDogstatsdSocketPath(spec)),
 		})
 	}
 
@@ -1452,6 +1612,73 @@ func getAppKeyFromSecret(dda *datadoghqv1alpha1.DatadogAgent) *corev1.EnvVarSour
 	return authTokenValue
 }
 
+// getProxyEnvVars returns the env vars configuring the proxy of the Datadog components.
+// The credentials are read from their Secret and expanded by Kubernetes in the proxy URLs.
+func getProxyEnvVars(dda *datadoghqv1alpha1.DatadogAgent) []corev1.EnvVar {
+	config := dda.Spec.Proxy
+	if config == nil {
+		return nil
+	}
+
+	var envVars []corev1.EnvVar
+	if config.CredentialsSecret != nil {
+		secretName, usernameKey, passwordKey := utils.GetProxyCredentialsSecret(config)
+		envVars = append(envVars,
+			getSecretEnvVar(datadoghqv1alpha1.ProxyUsername, secretName, usernameKey),
+			getSecretEnvVar(datadoghqv1alpha1.ProxyPassword, secretName, passwordKey),
+		)
+	}
+
+	if config.HTTP != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDProxyHTTP,
+			Value: getProxyURLWithCredentials(*config.HTTP, config.CredentialsSecret != nil),
+		})
+	}
+
+	if config.HTTPS != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDProxyHTTPS,
+			Value: getProxyURLWithCredentials(*config.HTTPS, config.CredentialsSecret != nil),
+		})
+	}
+
+	if len(config.NoProxy) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDProxyNoProxy,
+			Value: strings.Join(config.NoProxy, " "),
+		})
+	}
+
+	return envVars
+}
+
+func getSecretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+}
+
+// getProxyURLWithCredentials adds references to the credentials env vars in the proxy URL
+func getProxyURLWithCredentials(proxyURL string, withCredentials bool) string {
+	if !withCredentials {
+		return proxyURL
+	}
+	scheme, rest := "", proxyURL
+	if i := strings.Index(proxyURL, "://"); i >= 0 {
+		scheme, rest = proxyURL[:i+len("://")], proxyURL[i+len("://"):]
+	}
+	return fmt.Sprintf("%s$(%s):$(%s)@%s", scheme, datadoghqv1alpha1.ProxyUsername, datadoghqv1alpha1.ProxyPassword, rest)
+}
+
 func getClusterAgentServiceName(dda *datadoghqv1alpha1.DatadogAgent) string {
 	return fmt.Sprintf("%s-%s", dda.Name, datadoghqv1alpha1.DefaultClusterAgentResourceSuffix)
 }
@@ -1832,3 +2059,200 @@ func (nsn namespacedName) GetName() string {
 func getMonitoredObj(req reconcile.Request) namespacedName {
 	return namespacedName{req}
 }
+
+const (
+	additionalLogsIntakePort = 443
+)
+
+// logsAdditionalEndpoint is an entry of the logs additional endpoints, see DD_LOGS_CONFIG_ADDITIONAL_ENDPOINTS
+type logsAdditionalEndpoint struct {
+	APIKey     string `json:"api_key"`
+	Host       string `json:"Host"`
+	Port       int    `json:"Port"`
+	IsReliable bool   `json:"is_reliable"`
+}
+
+// getAdditionalEndpointsEnvVars returns the env vars of the additional endpoints named in endpointsEnvVarNames.
+// The API keys are read from their Secret and expanded by Kubernetes in the endpoints, they are never inlined.
+func getAdditionalEndpointsEnvVars(dda *datadoghqv1alpha1.DatadogAgent, endpointsEnvVarNames ...string) []corev1.EnvVar {
+	endpoints := dda.Spec.AdditionalEndpoints
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	var envVars []corev1.EnvVar
+	for i, endpoint := range endpoints {
+		secretKeyName := endpoint.APISecret.KeyName
+		if secretKeyName == "" {
+			secretKeyName = datadoghqv1alpha1.DefaultAPIKeyKey
+		}
+		envVars = append(envVars, getSecretEnvVar(getAdditionalEndpointAPIKeyEnvVarName(i), endpoint.APISecret.SecretName, secretKeyName))
+	}
+
+	for _, name := range endpointsEnvVarNames {
+		var value []byte
+		switch name {
+		case datadoghqv1alpha1.DDLogsConfigAdditionalEndpoints:
+			value = getLogsAdditionalEndpoints(endpoints)
+		case datadoghqv1alpha1.DDAPMAdditionalEndpoints:
+			value = getAdditionalEndpointsValue(endpoints, "https://trace.agent.%s", false)
+		case datadoghqv1alpha1.DDProcessAdditionalEndpoints:
+			value = getAdditionalEndpointsValue(endpoints, "https://process.%s", false)
+		default:
+			value = getAdditionalEndpointsValue(endpoints, "https://app.%s", true)
+		}
+		if value == nil {
+			continue
+		}
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  name,
+			Value: string(value),
+		})
+	}
+
+	return envVars
+}
+
+func getAdditionalEndpointAPIKeyEnvVarName(index int) string {
+	return fmt.Sprintf("%s%d", datadoghqv1alpha1.AdditionalEndpointAPIKeyPrefix, index)
+}
+
+// getAdditionalEndpointsValue returns the JSON mapping the intake URLs to their API keys,
+// the intake URL of an endpoint is built from its site and intakeFormat unless useURL is set and the endpoint has an url
+func getAdditionalEndpointsValue(endpoints []datadoghqv1alpha1.AdditionalEndpoint, intakeFormat string, useURL bool) []byte {
+	apiKeys := map[string][]string{}
+	for i, endpoint := range endpoints {
+		var intake string
+		switch {
+		case useURL && endpoint.URL != nil:
+			intake = *endpoint.URL
+		case endpoint.Site != "":
+			intake = fmt.Sprintf(intakeFormat, endpoint.Site)
+		default:
+			continue
+		}
+		apiKeys[intake] = append(apiKeys[intake], fmt.Sprintf("$(%s)", getAdditionalEndpointAPIKeyEnvVarName(i)))
+	}
+	if len(apiKeys) == 0 {
+		return nil
+	}
+	// Marshalling a map of strings can't fail, and the keys are sorted
+	value, _ := json.Marshal(apiKeys)
+	return value
+}
+
+// getLogsAdditionalEndpoints returns the JSON of the logs additional endpoints, sent to the HTTPS intake of the sites
+func getLogsAdditionalEndpoints(endpoints []datadoghqv1alpha1.AdditionalEndpoint) []byte {
+	var logsEndpoints []logsAdditionalEndpoint
+	for i, endpoint := range endpoints {
+		if endpoint.Site == "" {
+			continue
+		}
+		logsEndpoints = append(logsEndpoints, logsAdditionalEndpoint{
+			APIKey:     fmt.Sprintf("$(%s)", getAdditionalEndpointAPIKeyEnvVarName(i)),
+			Host:       fmt.Sprintf("agent-http-intake.logs.%s", endpoint.Site),
+			Port:       additionalLogsIntakePort,
+			IsReliable: true,
+		})
+	}
+	if len(logsEndpoints) == 0 {
+		return nil
+	}
+	value, _ := json.Marshal(logsEndpoints)
+	return value
+}
+
+// logsProcessingRule is an entry of the logs processing rules, see DD_LOGS_CONFIG_PROCESSING_RULES
+type logsProcessingRule struct {
+	Type               string  `json:"type"`
+	Name               string  `json:"name"`
+	Pattern            string  `json:"pattern"`
+	ReplacePlaceholder *string `json:"replace_placeholder,omitempty"`
+}
+
+// getLogsConfigEnvVars returns the env vars of the processing rules, the container filters and the transport of the logs
+func getLogsConfigEnvVars(dda *datadoghqv1alpha1.DatadogAgent) []corev1.EnvVar {
+	log := dda.Spec.Agent.Log
+	var envVars []corev1.EnvVar
+	if len(log.ProcessingRules) > 0 {
+		rules := make([]logsProcessingRule, 0, len(log.ProcessingRules))
+		for _, rule := range log.ProcessingRules {
+			rules = append(rules, logsProcessingRule{
+				Type:               string(rule.Type),
+				Name:               rule.Name,
+				Pattern:            rule.Pattern,
+				ReplacePlaceholder: rule.ReplacePlaceholder,
+			})
+		}
+		value, _ := json.Marshal(rules)
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigProcessingRules,
+			Value: string(value),
+		})
+	}
+	if len(log.ContainerInclude) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDContainerIncludeLogs,
+			Value: strings.Join(log.ContainerInclude, " "),
+		})
+	}
+	if len(log.ContainerExclude) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDContainerExcludeLogs,
+			Value: strings.Join(log.ContainerExclude, " "),
+		})
+	}
+
+	transport := log.Transport
+	if transport == nil {
+		transport = &datadoghqv1alpha1.LogTransportConfig{}
+	}
+	// The dual shipped logs require the HTTPS transport
+	if transport.Protocol == datadoghqv1alpha1.LogTransportProtocolHTTP || hasLogsAdditionalEndpoints(dda) {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigUseHTTP,
+			Value: "true",
+		})
+	} else if transport.Protocol == datadoghqv1alpha1.LogTransportProtocolTCP {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigUseTCP,
+			Value: "true",
+		})
+	}
+	if transport.UseCompression != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigUseCompression,
+			Value: strconv.FormatBool(*transport.UseCompression),
+		})
+	}
+	if transport.CompressionLevel != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigCompressionLevel,
+			Value: strconv.Itoa(int(*transport.CompressionLevel)),
+		})
+	}
+	if transport.BatchWait != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigBatchWait,
+			Value: strconv.Itoa(int(*transport.BatchWait)),
+		})
+	}
+
+	if log.DDUrl != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigLogsDDURL,
+			Value: *log.DDUrl,
+		})
+	}
+	return envVars
+}
+
+// hasLogsAdditionalEndpoints returns true if the logs are dual shipped, which requires the HTTPS transport
+func hasLogsAdditionalEndpoints(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	for _, endpoint := range dda.Spec.AdditionalEndpoints {
+		if endpoint.Site != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+const (
+	linuxOS   = "linux"
+	windowsOS = "windows"
+)
+
+func isWindowsAgentEnabled(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	if dda.Spec.Agent == nil || dda.Spec.Agent.Windows == nil {
+		return false
+	}
+	return datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Windows.Enabled)
+}
+
+func getWindowsAgentDaemonsetName(dda *datadoghqv1alpha1.DatadogAgent) string {
+	if dda.Spec.Agent != nil && dda.Spec.Agent.Windows != nil && dda.Spec.Agent.Windows.DaemonsetName != "" {
+		return dda.Spec.Agent.Windows.DaemonsetName
+	}
+	return fmt.Sprintf("%s-%s-%s", dda.Name, datadoghqv1alpha1.DefaultAgentResourceSuffix, windowsOS)
+}
+
+// isWindowsAgent returns true if the DatadogAgent is the Windows variant built by newWindowsAgentVariant
+func isWindowsAgent(dda *datadoghqv1alpha1.DatadogAgent) bool {
+	return isWindowsAgentEnabled(dda) && daemonsetName(dda) == getWindowsAgentDaemonsetName(dda)
+}
+
+// newWindowsAgentVariant returns the variant deploying the Agent on the Windows nodes:
+// the features that only run on Linux are disabled, and the Windows image, container runtime and pod template patches are used
+func newWindowsAgentVariant(dda *datadoghqv1alpha1.DatadogAgent) agentVariant {
+	variant := dda.DeepCopy()
+	agent := variant.Spec.Agent
+	windows := agent.Windows
+	agent.DaemonsetName = getWindowsAgentDaemonsetName(dda)
+
+	if windows.Image != nil {
+		image := windows.Image.DeepCopy()
+		if image.PullPolicy == nil {
+			image.PullPolicy = agent.Image.PullPolicy
+		}
+		agent.Image = *image
+	}
+	agent.Config.CriSocket = windows.CriSocket
+	if getCRISocketPath(agent.Config.CriSocket) == "" {
+		agent.Config.CriSocket = &datadoghqv1alpha1.CRISocketConfig{DockerSocketPath: datadoghqv1alpha1.NewStringPointer(datadoghqv1alpha1.DefaultWindowsDockerPipePath)}
+	}
+
+	agent.SystemProbe.Enabled = datadoghqv1alpha1.NewBoolPointer(false)
+	agent.Security.Compliance.Enabled = datadoghqv1alpha1.NewBoolPointer(false)
+	agent.Security.Runtime.Enabled = datadoghqv1alpha1.NewBoolPointer(false)
+	if dsd := agent.Config.Dogstatsd; dsd != nil {
+		dsd.UseDogStatsDSocketVolume = datadoghqv1alpha1.NewBoolPointer(false)
+		dsd.UnixDomainSocket = nil
+	}
+	agent.Apm.UnixDomainSocket = nil
+	agent.HostPID = false
+	agent.HostNetwork = false
+	agent.PodTemplatePatches = windows.PodTemplatePatches
+
+	return agentVariant{dda: variant}
+}
+
+// setWindowsAgentPodTemplate adapts the pod template of the Windows Agent: the named pipe of the container runtime and
+// the Windows paths replace the Linux sockets and host paths, and the init containers run PowerShell
+func setWindowsAgentPodTemplate(dda *datadoghqv1alpha1.DatadogAgent, template *corev1.PodTemplateSpec) error {
+	initContainers, err := getWindowsInitContainers(dda)
+	if err != nil {
+		return err
+	}
+	spec := &template.Spec
+	spec.NodeSelector = map[string]string{corev1.LabelOSStable: windowsOS}
+	spec.Volumes = getVolumesForWindowsAgent(dda)
+	spec.InitContainers = initContainers
+
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		switch container.Name {
+		case "agent":
+			container.VolumeMounts = getVolumeMountsForWindowsAgent(dda)
+			if dda.Spec.Agent.CustomConfig != nil {
+				container.Command = append(container.Command, "-c", datadoghqv1alpha1.WindowsAgentCustomConfigVolumePath)
+			}
+		case "process-agent":
+			container.VolumeMounts = []corev1.VolumeMount{getVolumeMountForWindowsConfig(), getVolumeMountForWindowsCRIPipe(dda)}
+		default:
+			container.VolumeMounts = []corev1.VolumeMount{getVolumeMountForWindowsConfig()}
+		}
+		for j, arg := range container.Command {
+			container.Command[j] = strings.Replace(arg, datadoghqv1alpha1.ConfigVolumePath, datadoghqv1alpha1.WindowsConfigVolumePath, 1)
+		}
+		container.Env = getEnvVarsForWindows(dda, container.Env)
+	}
+	return nil
+}
+
+func getWindowsInitContainers(dda *datadoghqv1alpha1.DatadogAgent) ([]corev1.Container, error) {
+	agentSpec := dda.Spec.Agent
+	envVars, err := getEnvVarsForAgent(dda)
+	if err != nil {
+		return nil, err
+	}
+	return []corev1.Container{
+		{
+			Name:            "init-volume",
+			Image:           agentSpec.Image.Name,
+			ImagePullPolicy: *agentSpec.Image.PullPolicy,
+			Resources:       *agentSpec.Config.Resources,
+			Command:         []string{"pwsh", "-Command"},
+			Args:            []string{fmt.Sprintf("Copy-Item -Recurse -Force %s C:/Temp", datadoghqv1alpha1.WindowsConfigVolumePath)},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      datadoghqv1alpha1.ConfigVolumeName,
+					MountPath: "C:/Temp/Datadog",
+				},
+			},
+		},
+		{
+			Name:            "init-config",
+			Image:           agentSpec.Image.Name,
+			ImagePullPolicy: *agentSpec.Image.PullPolicy,
+			Resources:       *agentSpec.Config.Resources,
+			Command:         []string{"pwsh", "-Command"},
+			Args:            []string{"Get-ChildItem 'entrypoint-ps1' | ForEach-Object { & $_.FullName; if (-Not $?) { exit 1 } }"},
+			Env:             getEnvVarsForWindows(dda, envVars),
+			VolumeMounts:    getVolumeMountsForWindowsAgent(dda),
+		},
+	}, nil
+}
+
+// getEnvVarsForWindows replaces the Linux container runtime socket by the named pipe of the Windows nodes
+func getEnvVarsForWindows(dda *datadoghqv1alpha1.DatadogAgent, envVars []corev1.EnvVar) []corev1.EnvVar {
+	windowsEnvVars := make([]corev1.EnvVar, 0, len(envVars)+1)
+	for _, envVar := range envVars {
+		if envVar.Name != datadoghqv1alpha1.DDCriSocketPath && envVar.Name != datadoghqv1alpha1.DockerHost {
+			windowsEnvVars = append(windowsEnvVars, envVar)
+		}
+	}
+
+	criSocket := dda.Spec.Agent.Config.CriSocket
+	if criSocket.CriSocketPath != nil {
+		windowsEnvVars = append(windowsEnvVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDCriSocketPath,
+			Value: *criSocket.CriSocketPath,
+		})
+	} else {
+		windowsEnvVars = append(windowsEnvVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DockerHost,
+			Value: "npipe://" + strings.ReplaceAll(*criSocket.DockerSocketPath, `\`, "/"),
+		})
+	}
+	if datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Log.Enabled) {
+		windowsEnvVars = append(windowsEnvVars, corev1.EnvVar{
+			Name:  datadoghqv1alpha1.DDLogsConfigRunPath,
+			Value: datadoghqv1alpha1.WindowsPointerVolumePath,
+		})
+	}
+	return windowsEnvVars
+}
+
+func getVolumesForWindowsAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.Volume {
+	volumes := []corev1.Volume{
+		getVolumeForConfd(dda),
+		getVolumeForChecksd(dda),
+		getVolumeForConfig(),
+		getHostPathVolume(datadoghqv1alpha1.CriSocketVolumeName, getCRISocketPath(dda.Spec.Agent.Config.CriSocket)),
+	}
+
+	if dda.Spec.Agent.CustomConfig != nil {
+		volume := getVolumeFromCustomConfigSpec(dda.Spec.Agent.CustomConfig, getAgentCustomConfigConfigMapName(dda), datadoghqv1alpha1.AgentCustomConfigVolumeName)
+		volumes = append(volumes, volume)
+	}
+
+	if datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Log.Enabled) {
+		volumes = append(volumes,
+			getHostPathVolume(datadoghqv1alpha1.PointerVolumeName, datadoghqv1alpha1.WindowsPointerVolumePath),
+			getHostPathVolume(datadoghqv1alpha1.LogPodVolumeName, datadoghqv1alpha1.WindowsLogPodVolumePath),
+			getHostPathVolume(datadoghqv1alpha1.LogContainerVolumeName, datadoghqv1alpha1.WindowsLogContainerVolumePath),
+		)
+	}
+
+	return append(volumes, dda.Spec.Agent.Config.Volumes...)
+}
+
+func getVolumeMountsForWindowsAgent(dda *datadoghqv1alpha1.DatadogAgent) []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      datadoghqv1alpha1.ConfdVolumeName,
+			MountPath: datadoghqv1alpha1.WindowsConfdVolumePath,
+			ReadOnly:  true,
+		},
+		{
+			Name:      datadoghqv1alpha1.ChecksdVolumeName,
+			MountPath: datadoghqv1alpha1.WindowsChecksdVolumePath,
+			ReadOnly:  true,
+		},
+		getVolumeMountForWindowsConfig(),
+		getVolumeMountForWindowsCRIPipe(dda),
+	}
+
+	// Windows only mounts directories: the whole custom config volume is mounted and passed to the Agent
+	if dda.Spec.Agent.CustomConfig != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      datadoghqv1alpha1.AgentCustomConfigVolumeName,
+			MountPath: datadoghqv1alpha1.WindowsAgentCustomConfigVolumePath,
+			ReadOnly:  true,
+		})
+	}
+
+	if datadoghqv1alpha1.BoolValue(dda.Spec.Agent.Log.Enabled) {
+		volumeMounts = append(volumeMounts, []corev1.VolumeMount{
+			{
+				Name:      datadoghqv1alpha1.PointerVolumeName,
+				MountPath: datadoghqv1alpha1.WindowsPointerVolumePath,
+			},
+			{
+				Name:      datadoghqv1alpha1.LogPodVolumeName,
+				MountPath: datadoghqv1alpha1.WindowsLogPodVolumePath,
+				ReadOnly:  datadoghqv1alpha1.LogPodVolumeReadOnly,
+			},
+			{
+				Name:      datadoghqv1alpha1.LogContainerVolumeName,
+				MountPath: datadoghqv1alpha1.WindowsLogContainerVolumePath,
+				ReadOnly:  datadoghqv1alpha1.LogContainerVolumeReadOnly,
+			},
+		}...)
+	}
+
+	return append(volumeMounts, dda.Spec.Agent.Config.VolumeMounts...)
+}
+
+func getVolumeMountForWindowsConfig() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      datadoghqv1alpha1.ConfigVolumeName,
+		MountPath: datadoghqv1alpha1.WindowsConfigVolumePath,
+	}
+}
+
+// getVolumeMountForWindowsCRIPipe mounts the named pipe of the container runtime at the same path
+func getVolumeMountForWindowsCRIPipe(dda *datadoghqv1alpha1.DatadogAgent) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      datadoghqv1alpha1.CriSocketVolumeName,
+		MountPath: getCRISocketPath(dda.Spec.Agent.Config.CriSocket),
+	}
+}
+
+func getHostPathVolume(name, path string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: path,
+			},
+		},
+	}
+}
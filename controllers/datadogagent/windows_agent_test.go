@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package datadogagent
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+	test "github.com/DataDog/datadog-operator/api/v1alpha1/test"
+)
+
+func getTestContainer(t *testing.T, containers []corev1.Container, name string) corev1.Container {
+	for _, container := range containers {
+		if container.Name == name {
+			return container
+		}
+	}
+	t.Fatalf("container %q not found", name)
+	return corev1.Container{}
+}
+
+func getTestEnvVar(envVars []corev1.EnvVar, name string) *corev1.EnvVar {
+	for i := range envVars {
+		if envVars[i].Name == name {
+			return &envVars[i]
+		}
+	}
+	return nil
+}
+
+func TestNewWindowsAgentVariant(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{
+		SystemProbeEnabled: true,
+		ProcessEnabled:     true,
+	})
+	assert.False(t, isWindowsAgentEnabled(dda))
+	dda.Spec.Agent.Windows = &datadoghqv1alpha1.WindowsAgentSpec{
+		Enabled: datadoghqv1alpha1.NewBoolPointer(true),
+		Image:   &datadoghqv1alpha1.ImageConfig{Name: "datadog/agent:7-ltsc2019"},
+	}
+	assert.True(t, isWindowsAgentEnabled(dda))
+	assert.False(t, isWindowsAgent(dda))
+
+	variant := newWindowsAgentVariant(dda)
+	assert.Nil(t, variant.placement)
+	assert.True(t, isWindowsAgent(variant.dda))
+	assert.Equal(t, "foo-agent-windows", daemonsetName(variant.dda))
+	assert.Equal(t, "datadog/agent:7-ltsc2019", variant.dda.Spec.Agent.Image.Name)
+	assert.Equal(t, dda.Spec.Agent.Image.PullPolicy, variant.dda.Spec.Agent.Image.PullPolicy)
+	assert.Equal(t, datadoghqv1alpha1.DefaultWindowsDockerPipePath, getCRISocketPath(variant.dda.Spec.Agent.Config.CriSocket))
+	// The DatadogAgent isn't modified
+	assert.True(t, datadoghqv1alpha1.BoolValue(dda.Spec.Agent.SystemProbe.Enabled))
+
+	ds, _, err := newDaemonSetFromInstance(variant.dda, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-agent-windows", ds.Name)
+	podSpec := ds.Spec.Template.Spec
+	assert.Equal(t, map[string]string{corev1.LabelOSStable: windowsOS}, podSpec.NodeSelector)
+	assert.False(t, podSpec.HostPID)
+
+	// The containers that only run on Linux aren't deployed
+	var names []string
+	for _, container := range podSpec.Containers {
+		names = append(names, container.Name)
+	}
+	assert.ElementsMatch(t, []string{"agent", "process-agent"}, names)
+	assert.Len(t, podSpec.InitContainers, 2)
+	assert.Equal(t, []string{"pwsh", "-Command"}, podSpec.InitContainers[0].Command)
+
+	agent := getTestContainer(t, podSpec.Containers, "agent")
+	assert.Equal(t, "datadog/agent:7-ltsc2019", agent.Image)
+	assert.Equal(t, &corev1.EnvVar{Name: datadoghqv1alpha1.DockerHost, Value: "npipe:////./pipe/docker_engine"}, getTestEnvVar(agent.Env, datadoghqv1alpha1.DockerHost))
+	assert.Contains(t, agent.VolumeMounts, corev1.VolumeMount{Name: datadoghqv1alpha1.CriSocketVolumeName, MountPath: datadoghqv1alpha1.DefaultWindowsDockerPipePath})
+	processAgent := getTestContainer(t, podSpec.Containers, "process-agent")
+	assert.Equal(t, []string{"process-agent", "-config=C:/ProgramData/Datadog/datadog.yaml"}, processAgent.Command)
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			assert.NotContains(t, volume.HostPath.Path, "/proc", volume.Name)
+			assert.NotContains(t, volume.HostPath.Path, "/sys", volume.Name)
+		}
+	}
+
+	// The Linux DaemonSet only runs on the Linux nodes
+	ds, _, err = newDaemonSetFromInstance(dda, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{corev1.LabelOSStable: linuxOS}, ds.Spec.Template.Spec.NodeSelector)
+}
+
+func TestNewWindowsAgentVariantPatches(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{SystemProbeEnabled: true})
+	dda.Spec.Agent.PodTemplatePatches = []datadoghqv1alpha1.PodTemplatePatch{
+		{Name: "system-probe", Patch: "spec: {containers: [{name: system-probe, securityContext: {privileged: true}}]}"},
+		{Name: "node-pool", Patch: "spec: {nodeSelector: {pool: linux-pool}}"},
+	}
+	dda.Spec.Agent.Windows = &datadoghqv1alpha1.WindowsAgentSpec{
+		Enabled:            datadoghqv1alpha1.NewBoolPointer(true),
+		PodTemplatePatches: []datadoghqv1alpha1.PodTemplatePatch{{Name: "node-pool", Patch: "spec: {nodeSelector: {pool: windows-pool}}"}},
+	}
+
+	ds, _, err := newDaemonSetFromInstance(dda, nil)
+	assert.NoError(t, err)
+	systemProbe := getTestContainer(t, ds.Spec.Template.Spec.Containers, "system-probe")
+	assert.True(t, *systemProbe.SecurityContext.Privileged)
+	assert.Equal(t, "linux-pool", ds.Spec.Template.Spec.NodeSelector["pool"])
+
+	// The patches of the Agent target a container that the Windows Agent doesn't run, only its own patches are applied
+	variant := newWindowsAgentVariant(dda)
+	ds, _, err = newDaemonSetFromInstance(variant.dda, nil)
+	assert.NoError(t, err)
+	for _, container := range ds.Spec.Template.Spec.Containers {
+		assert.NotEqual(t, "system-probe", container.Name)
+	}
+	assert.Equal(t, map[string]string{corev1.LabelOSStable: windowsOS, "pool": "windows-pool"}, ds.Spec.Template.Spec.NodeSelector)
+}
+
+func TestGetEnvVarsForWindows(t *testing.T) {
+	dda := test.NewDefaultedDatadogAgent("bar", "foo", &test.NewDatadogAgentOptions{})
+	dda.Spec.Agent.Windows = &datadoghqv1alpha1.WindowsAgentSpec{
+		Enabled:   datadoghqv1alpha1.NewBoolPointer(true),
+		CriSocket: &datadoghqv1alpha1.CRISocketConfig{CriSocketPath: datadoghqv1alpha1.NewStringPointer(`\\.\pipe\containerd-containerd`)},
+	}
+	dda.Spec.Agent.Log.Enabled = datadoghqv1alpha1.NewBoolPointer(true)
+	variant := newWindowsAgentVariant(dda)
+
+	envVars := getEnvVarsForWindows(variant.dda, []corev1.EnvVar{
+		{Name: "DD_LOG_LEVEL", Value: "INFO"},
+		{Name: datadoghqv1alpha1.DDCriSocketPath, Value: "/var/run/containerd/containerd.sock"},
+	})
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "DD_LOG_LEVEL", Value: "INFO"},
+		{Name: datadoghqv1alpha1.DDCriSocketPath, Value: `\\.\pipe\containerd-containerd`},
+		{Name: datadoghqv1alpha1.DDLogsConfigRunPath, Value: datadoghqv1alpha1.WindowsPointerVolumePath},
+	}, envVars)
+}
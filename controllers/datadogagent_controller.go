@@ -15,13 +15,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/version"
 
 	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
@@ -33,6 +37,8 @@ import (
 // DatadogAgentReconciler reconciles a DatadogAgent object
 type DatadogAgentReconciler struct {
 	client.Client
+	// APIReader reads from the API server the resources that aren't watched
+	APIReader   client.Reader
 	VersionInfo *version.Info
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
@@ -70,6 +76,17 @@ type DatadogAgentReconciler struct {
 // +kubebuilder:rbac:groups=apiregistration.k8s.io,resources=apiservices,verbs=*
 // +kubebuilder:rbac:groups=datadoghq.com,resources=watermarkpodautoscalers,verbs=get;list;watch
 
+// Configure the Cluster Agent certificates with cert-manager
+// +kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=*
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=*
+
+// Configure the network policies with Cilium
+// +kubebuilder:rbac:groups=cilium.io,resources=ciliumnetworkpolicies,verbs=*
+
+// Recommend the resources of the Agent containers from their usage
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers,verbs=*
+
 // Use ExtendedDaemonSet
 // +kubebuilder:rbac:groups=datadoghq.com,resources=extendeddaemonsets,verbs=*
 
@@ -78,7 +95,7 @@ type DatadogAgentReconciler struct {
 
 // +kubebuilder:rbac:urls=/metrics,verbs=get
 // +kubebuilder:rbac:groups="",resources=componentstatuses,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups="",resources=nodes/metrics,verbs=get
 // +kubebuilder:rbac:groups="",resources=nodes/proxy,verbs=get
 // +kubebuilder:rbac:groups="",resources=nodes/spec,verbs=get
@@ -103,12 +120,24 @@ func (r *DatadogAgentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 // SetupWithManager creates a new DatadogAgent controller
 func (r *DatadogAgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	metricForwarder := datadog.NewForwardersManager(r.Client)
-	internal, err := datadogagent.NewReconciler(r.Options, r.Client, r.VersionInfo, r.Scheme, r.Log, r.Recorder, metricForwarder)
+	internal, err := datadogagent.NewReconciler(r.Options, r.Client, r.APIReader, r.VersionInfo, r.Scheme, r.Log, r.Recorder, metricForwarder)
 	if err != nil {
 		return err
 	}
 	r.internal = internal
 
+	referencesHandler := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return internal.GetReferencingDatadogAgents(obj.Object, obj.Meta)
+		}),
+	}
+
+	nodesHandler := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return internal.GetNodeDatadogAgents(obj.Object, obj.Meta)
+		}),
+	}
+
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&datadoghqv1alpha1.DatadogAgent{}, builder.WithPredicates(predicate.Funcs{
 			// On `DatadogAgent` object creation, we register a metrics forwarder for it
@@ -127,7 +156,27 @@ func (r *DatadogAgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&rbacv1.ClusterRole{}).
 		Owns(&rbacv1.ClusterRoleBinding{}).
 		Owns(&policyv1.PodDisruptionBudget{}).
-		Owns(&networkingv1.NetworkPolicy{})
+		Owns(&networkingv1.NetworkPolicy{}).
+		// The ConfigMaps and Secrets used by the pods, even the ones that aren't owned, roll the pods when they change
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, referencesHandler).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, referencesHandler).
+		// The container runtimes of the nodes select the CRI socket of the Agents, their labels the platform and
+		// the node coverage, and their taints the auto-tolerations
+		Watches(&source.Kind{Type: &corev1.Node{}}, nodesHandler, builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldNode, oldOK := e.ObjectOld.(*corev1.Node)
+				newNode, newOK := e.ObjectNew.(*corev1.Node)
+				if !oldOK || !newOK {
+					return false
+				}
+				return oldNode.Status.NodeInfo.ContainerRuntimeVersion != newNode.Status.NodeInfo.ContainerRuntimeVersion ||
+					!apiequality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) ||
+					!apiequality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints)
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		}))
 
 	if r.Options.SupportExtendedDaemonset {
 		builder = builder.Owns(&edsdatadoghqv1alpha1.ExtendedDaemonSet{})
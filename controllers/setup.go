@@ -11,10 +11,20 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
 	"github.com/DataDog/datadog-operator/controllers/datadogagent"
 	"k8s.io/client-go/discovery"
 )
 
+const (
+	certManagerGroupVersion = "cert-manager.io/v1"
+	ciliumGroupVersion      = "cilium.io/v2"
+	autopilotGroupVersion   = "auto.gke.io/v1"
+	openShiftGroupVersion   = "security.openshift.io/v1"
+	metricsGroupVersion     = "metrics.k8s.io/v1beta1"
+	vpaGroupVersion         = "autoscaling.k8s.io/v1"
+)
+
 // SetupControllers start all controllers (also used by e2e tests)
 func SetupControllers(mgr manager.Manager, supportExtendedDaemonset bool) error {
 	// Get some information about Kubernetes version
@@ -28,14 +38,51 @@ func SetupControllers(mgr manager.Manager, supportExtendedDaemonset bool) error
 		return fmt.Errorf("unable to get APIServer version: %w", err)
 	}
 
+	// cert-manager is used to issue the Cluster Agent certificates when it is installed
+	supportCertManager := false
+	if _, err = discoveryClient.ServerResourcesForGroupVersion(certManagerGroupVersion); err == nil {
+		supportCertManager = true
+	}
+
+	// CiliumNetworkPolicies can only be created when Cilium is installed
+	supportCilium := false
+	if _, err = discoveryClient.ServerResourcesForGroupVersion(ciliumGroupVersion); err == nil {
+		supportCilium = true
+	}
+
+	// The resource recommendations need the usage reported by the metrics server,
+	// and VerticalPodAutoscalers can only be created when the VPA is installed
+	supportMetricsAPI := false
+	if _, err = discoveryClient.ServerResourcesForGroupVersion(metricsGroupVersion); err == nil {
+		supportMetricsAPI = true
+	}
+	supportVPA := false
+	if _, err = discoveryClient.ServerResourcesForGroupVersion(vpaGroupVersion); err == nil {
+		supportVPA = true
+	}
+
+	// The platforms whose API groups are known are detected at startup, the others from the labels of the nodes
+	var platform datadoghqv1alpha1.PlatformName
+	if _, err = discoveryClient.ServerResourcesForGroupVersion(autopilotGroupVersion); err == nil {
+		platform = datadoghqv1alpha1.PlatformGKEAutopilot
+	} else if _, err = discoveryClient.ServerResourcesForGroupVersion(openShiftGroupVersion); err == nil {
+		platform = datadoghqv1alpha1.PlatformOpenShift
+	}
+
 	if err = (&DatadogAgentReconciler{
 		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
 		VersionInfo: versionInfo,
 		Log:         ctrl.Log.WithName("controllers").WithName("DatadogAgent"),
 		Scheme:      mgr.GetScheme(),
 		Recorder:    mgr.GetEventRecorderFor("DatadogAgent"),
 		Options: datadogagent.ReconcilerOptions{
 			SupportExtendedDaemonset: supportExtendedDaemonset,
+			SupportCertManager:       supportCertManager,
+			SupportCilium:            supportCilium,
+			SupportMetricsAPI:        supportMetricsAPI,
+			SupportVPA:               supportVPA,
+			Platform:                 platform,
 		},
 	}).SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("unable to create controller DatadogAgent: %w", err)
@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package certificate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	certificateBlockType = "CERTIFICATE"
+	privateKeyBlockType  = "EC PRIVATE KEY"
+
+	// clockSkew is subtracted from the NotBefore field to tolerate clock differences between nodes
+	clockSkew = 5 * time.Minute
+)
+
+// KeyPair contains a PEM encoded certificate and its PEM encoded private key
+type KeyPair struct {
+	Certificate []byte
+	PrivateKey  []byte
+}
+
+// NewCA generates a self-signed CA valid for the given duration
+func NewCA(commonName string, validity time.Duration, now time.Time) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate CA key: %w", err)
+	}
+
+	template, err := newTemplate(commonName, validity, now)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CA certificate: %w", err)
+	}
+
+	return encode(der, key)
+}
+
+// NewServingCertificate generates a serving certificate for the given DNS names signed by the CA
+func NewServingCertificate(ca *KeyPair, dnsNames []string, validity time.Duration, now time.Time) (*KeyPair, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("at least one DNS name is required")
+	}
+
+	caCert, err := ParseCertificate(ca.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CA certificate: %w", err)
+	}
+	caKey, err := parsePrivateKey(ca.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CA key: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	template, err := newTemplate(dnsNames[0], validity, now)
+	if err != nil {
+		return nil, err
+	}
+	// The serving certificate can't outlive its CA
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create serving certificate: %w", err)
+	}
+
+	return encode(der, key)
+}
+
+// ParseCertificate parses the first certificate of a PEM bundle
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != certificateBlockType {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// NeedsRenewal returns true if the certificate expires in less than renewBefore
+func NeedsRenewal(cert *x509.Certificate, renewBefore time.Duration, now time.Time) bool {
+	return now.Add(renewBefore).After(cert.NotAfter)
+}
+
+// HasDNSNames returns true if the certificate is valid for exactly the given DNS names
+func HasDNSNames(cert *x509.Certificate, dnsNames []string) bool {
+	if len(cert.DNSNames) != len(dnsNames) {
+		return false
+	}
+	current := append([]string{}, cert.DNSNames...)
+	wanted := append([]string{}, dnsNames...)
+	sort.Strings(current)
+	sort.Strings(wanted)
+	for i := range current {
+		if current[i] != wanted[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSignedBy returns true if the certificate has been signed by the CA
+func IsSignedBy(cert, ca *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(ca) == nil
+}
+
+// Bundle concatenates the valid certificates of PEM bundles, skipping duplicates and expired ones
+func Bundle(now time.Time, bundles ...[]byte) []byte {
+	out := &bytes.Buffer{}
+	seen := map[string]bool{}
+	for _, data := range bundles {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != certificateBlockType || seen[string(block.Bytes)] {
+				continue
+			}
+			if cert, err := x509.ParseCertificate(block.Bytes); err != nil || now.After(cert.NotAfter) {
+				continue
+			}
+			seen[string(block.Bytes)] = true
+			_ = pem.Encode(out, block)
+		}
+	}
+	return out.Bytes()
+}
+
+func newTemplate(commonName string, validity time.Duration, now time.Time) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number: %w", err)
+	}
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-clockSkew).UTC(),
+		NotAfter:     now.Add(validity).UTC(),
+	}, nil
+}
+
+func encode(der []byte, key *ecdsa.PrivateKey) (*KeyPair, error) {
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode key: %w", err)
+	}
+
+	return &KeyPair{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: certificateBlockType, Bytes: der}),
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: privateKeyBlockType, Bytes: keyDer}),
+	}, nil
+}
+
+func parsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != privateKeyBlockType {
+		return nil, errors.New("no PEM encoded key found")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package certificate
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServingCertificate(t *testing.T) {
+	now := time.Now()
+	dnsNames := []string{"foo-cluster-agent-metrics-api.bar.svc", "datadog-admission-controller.bar.svc"}
+
+	ca, err := NewCA("foo-ca", 24*time.Hour, now)
+	require.NoError(t, err)
+	serving, err := NewServingCertificate(ca, dnsNames, 48*time.Hour, now)
+	require.NoError(t, err)
+
+	caCert, err := ParseCertificate(ca.Certificate)
+	require.NoError(t, err)
+	assert.True(t, caCert.IsCA)
+
+	cert, err := ParseCertificate(serving.Certificate)
+	require.NoError(t, err)
+	assert.True(t, IsSignedBy(cert, caCert))
+	assert.True(t, HasDNSNames(cert, []string{dnsNames[1], dnsNames[0]}))
+	assert.False(t, HasDNSNames(cert, dnsNames[:1]))
+	// The serving certificate can't outlive its CA
+	assert.Equal(t, caCert.NotAfter, cert.NotAfter)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: dnsNames[0], Roots: roots})
+	assert.NoError(t, err)
+
+	assert.False(t, NeedsRenewal(cert, time.Hour, now))
+	assert.True(t, NeedsRenewal(cert, 25*time.Hour, now))
+}
+
+func TestBundle(t *testing.T) {
+	now := time.Now()
+	ca1, err := NewCA("ca1", time.Hour, now)
+	require.NoError(t, err)
+	ca2, err := NewCA("ca2", time.Hour, now)
+	require.NoError(t, err)
+	expired, err := NewCA("expired", time.Minute, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	bundle := Bundle(now, ca1.Certificate, append(append([]byte{}, ca2.Certificate...), ca1.Certificate...), expired.Certificate)
+	assert.Equal(t, append(append([]byte{}, ca1.Certificate...), ca2.Certificate...), bundle)
+	assert.False(t, bytes.Contains(bundle, expired.Certificate))
+}
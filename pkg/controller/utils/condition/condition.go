@@ -55,6 +55,15 @@ func SetDatadogAgentStatusCondition(status *datadoghqv1alpha1.DatadogAgentStatus
 	}
 }
 
+// GetDatadogAgentStatusCondition returns the condition of the given type, or nil if it isn't set
+func GetDatadogAgentStatusCondition(status *datadoghqv1alpha1.DatadogAgentStatus, t datadoghqv1alpha1.DatadogAgentConditionType) *datadoghqv1alpha1.DatadogAgentCondition {
+	idCondition := getIndexForConditionType(status, t)
+	if idCondition < 0 {
+		return nil
+	}
+	return &status.Conditions[idCondition]
+}
+
 // NewDatadogAgentStatusCondition returns new DatadogAgentCondition instance
 func NewDatadogAgentStatusCondition(conditionType datadoghqv1alpha1.DatadogAgentConditionType, conditionStatus corev1.ConditionStatus, now metav1.Time, reason, message string) datadoghqv1alpha1.DatadogAgentCondition {
 	return datadoghqv1alpha1.DatadogAgentCondition{
@@ -10,7 +10,10 @@ import "fmt"
 // Event contains the rquired information to send Datadog events
 type Event struct {
 	Title string
+	Text  string
 	Type  EventType
+	// AggregationKey groups the events of the same object in the event stream
+	AggregationKey string
 }
 
 // EventType enumerates the possible event types to be sent
@@ -25,20 +28,93 @@ const (
 	UpdateEvent EventType = "Update"
 	// DeletionEvent should be used for resource deletion events
 	DeletionEvent EventType = "Delete"
+	// RolloutStartedEvent should be used when the pods of a workload start being replaced
+	RolloutStartedEvent EventType = "RolloutStarted"
+	// RolloutCompletedEvent should be used when all the pods of a workload run the new version
+	RolloutCompletedEvent EventType = "RolloutCompleted"
+	// RolloutFailedEvent should be used when a rollout doesn't complete
+	RolloutFailedEvent EventType = "RolloutFailed"
+	// ImageChangeEvent should be used when the image of a container changes
+	ImageChangeEvent EventType = "ImageChange"
+	// CanaryPromotedEvent should be used when the canary of an ExtendedDaemonSet is promoted
+	CanaryPromotedEvent EventType = "CanaryPromoted"
+	// CanaryPausedEvent should be used when the canary of an ExtendedDaemonSet is paused
+	CanaryPausedEvent EventType = "CanaryPaused"
+	// InvalidCredentialsEvent should be used when the Datadog credentials are missing or rejected
+	InvalidCredentialsEvent EventType = "InvalidCredentials"
+	// DriftDetectionEvent should be used when a resource managed by the operator is modified by another client
+	DriftDetectionEvent EventType = "DriftDetected"
+	// ReconcileErrorEvent should be used when the reconciliation keeps failing
+	ReconcileErrorEvent EventType = "ReconcileError"
 )
 
+// EventPriority enumerates the priorities of the Datadog events
+type EventPriority string
+
+const (
+	// NormalPriority is the priority of the events displayed by default in the event stream
+	NormalPriority EventPriority = "normal"
+	// LowPriority is the priority of the events hidden by default in the event stream
+	LowPriority EventPriority = "low"
+)
+
+// EventAlertType enumerates the alert types of the Datadog events
+type EventAlertType string
+
+const (
+	// InfoAlertType is the alert type of the informational events
+	InfoAlertType EventAlertType = "info"
+	// SuccessAlertType is the alert type of the events reporting a success
+	SuccessAlertType EventAlertType = "success"
+	// WarningAlertType is the alert type of the events that may need an action
+	WarningAlertType EventAlertType = "warning"
+	// ErrorAlertType is the alert type of the events reporting a failure
+	ErrorAlertType EventAlertType = "error"
+)
+
+var eventTypeAlertTypes = map[EventType]EventAlertType{
+	RolloutCompletedEvent:   SuccessAlertType,
+	RolloutFailedEvent:      ErrorAlertType,
+	CanaryPromotedEvent:     SuccessAlertType,
+	CanaryPausedEvent:       WarningAlertType,
+	InvalidCredentialsEvent: ErrorAlertType,
+	DriftDetectionEvent:     WarningAlertType,
+	ReconcileErrorEvent:     ErrorAlertType,
+}
+
+// Priority returns the priority of the events of this type:
+// the changes of the resources managed by the operator have a low priority
+func (t EventType) Priority() EventPriority {
+	switch t {
+	case CreationEvent, UpdateEvent, DeletionEvent:
+		return LowPriority
+	default:
+		return NormalPriority
+	}
+}
+
+// AlertType returns the alert type of the events of this type
+func (t EventType) AlertType() EventAlertType {
+	if alertType, found := eventTypeAlertTypes[t]; found {
+		return alertType
+	}
+	return InfoAlertType
+}
+
 // crDetected returns the detection event of a CR
 func crDetected(id string) Event {
 	return Event{
-		Title: fmt.Sprintf("Detect Custom Resource %s", id),
-		Type:  DetectionEvent,
+		Title:          fmt.Sprintf("Detect Custom Resource %s", id),
+		Type:           DetectionEvent,
+		AggregationKey: id,
 	}
 }
 
 // crDeleted returns the delete event of a CR
 func crDeleted(id string) Event {
 	return Event{
-		Title: fmt.Sprintf("Delete Custom Resource %s", id),
-		Type:  DeletionEvent,
+		Title:          fmt.Sprintf("Delete Custom Resource %s", id),
+		Type:           DeletionEvent,
+		AggregationKey: id,
 	}
 }
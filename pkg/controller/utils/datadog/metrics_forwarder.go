@@ -10,7 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -47,16 +49,22 @@ const (
 	reconcileSuccessValue       = 1.0
 	reconcileFailureValue       = 0.0
 	reconcileMetricFormat       = "%s.reconcile.success"
+	uncoveredNodesMetricFormat  = "%s.agent.nodes.uncovered"
 	reconcileErrTagFormat       = "reconcile_err:%s"
 	datadogOperatorSourceType   = "datadog_operator"
 	defaultbaseURL              = "https://api.datadoghq.com"
 )
 
+// InvalidCredentialsReason is the reason of the metrics forwarding condition when the credentials are missing or invalid
+const InvalidCredentialsReason = "InvalidCredentials"
+
 var (
 	// ErrEmptyAPIKey empty APIKey error
 	ErrEmptyAPIKey = errors.New("empty api key")
 	// ErrEmptyAPPKey empty APPKey error
 	ErrEmptyAPPKey = errors.New("empty app key")
+	// ErrInvalidCredentials credentials rejected by the Datadog API error
+	ErrInvalidCredentials = errors.New("invalid datadog credentials")
 	// errInitValue used to initialize lastReconcileErr
 	errInitValue = errors.New("last error init value")
 )
@@ -83,6 +91,8 @@ type metricsForwarder struct {
 	decryptor           secrets.Decryptor
 	creds               sync.Map
 	baseURL             string
+	proxy               utils.ProxyFunc
+	proxyHash           uint64
 	sync.Mutex
 	status *datadoghqv1alpha1.DatadogAgentCondition
 }
@@ -91,7 +101,8 @@ type metricsForwarder struct {
 type delegatedAPI interface {
 	delegatedSendDeploymentMetric(float64, string, []string) error
 	delegatedSendReconcileMetric(float64, []string) error
-	delegatedSendEvent(string, EventType) error
+	delegatedSendUncoveredNodesMetric(float64, []string) error
+	delegatedSendEvent(Event) error
 	delegatedValidateCreds(string, string) (*api.Client, error)
 }
 
@@ -205,11 +216,15 @@ func (mf *metricsForwarder) connectToDatadogAPI() (bool, error) {
 	apiKey, appKey, err := mf.getCredentials(dda)
 	mf.baseURL = getbaseURL(dda)
 	mf.logger.Info("Got Datadog Site", "site", mf.baseURL)
-	defer mf.updateStatusIfNeeded(err)
+	defer func() { mf.updateStatusIfNeeded(err) }()
 	if err != nil {
 		mf.logger.Error(err, "cannot get Datadog credentials,  will retry later...")
 		return false, nil
 	}
+	if _, err = mf.updateProxyIfNeeded(dda); err != nil {
+		mf.logger.Error(err, "cannot get the proxy configuration,  will retry later...")
+		return false, nil
+	}
 	mf.logger.Info("Initializing Datadog metrics forwarder")
 	if err = mf.initAPIClient(apiKey, appKey); err != nil {
 		mf.logger.Error(err, "cannot get Datadog metrics forwarder to send deployment metrics, will retry later...")
@@ -229,12 +244,22 @@ func (mf *metricsForwarder) forwardMetrics() error {
 		return err
 	}
 	apiKey, appKey, err := mf.getCredentials(dda)
-	defer mf.updateStatusIfNeeded(err)
+	defer func() { mf.updateStatusIfNeeded(err) }()
 	if err != nil {
 		mf.logger.Error(err, "cannot get Datadog credentials")
 		return err
 	}
-	if err = mf.updateCredsIfNeeded(apiKey, appKey); err != nil {
+	proxyChanged, err := mf.updateProxyIfNeeded(dda)
+	if err != nil {
+		mf.logger.Error(err, "cannot get the proxy configuration")
+		return err
+	}
+	if proxyChanged {
+		err = mf.initAPIClient(apiKey, appKey)
+	} else {
+		err = mf.updateCredsIfNeeded(apiKey, appKey)
+	}
+	if err != nil {
 		mf.logger.Error(err, "cannot update Datadog credentials")
 		return err
 	}
@@ -356,18 +381,23 @@ func (mf *metricsForwarder) validateCreds(apiKey, appKey string) (*api.Client, e
 func (mf *metricsForwarder) delegatedValidateCreds(apiKey, appKey string) (*api.Client, error) {
 	datadogClient := api.NewClient(apiKey, appKey)
 	datadogClient.SetBaseUrl(mf.baseURL)
+	if mf.proxy != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = mf.proxy
+		datadogClient.HttpClient = &http.Client{Transport: transport}
+	}
 	valid, err := datadogClient.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("cannot validate datadog credentials: %v", err)
 	}
 	if !valid {
-		return nil, fmt.Errorf("invalid datadog credentials on %s", mf.baseURL)
+		return nil, fmt.Errorf("%w on %s", ErrInvalidCredentials, mf.baseURL)
 	}
 	return datadogClient, nil
 }
 
 // sendStatusMetrics forwards metrics for each component deployment (agent, clusteragent, clustercheck runner)
-// based on the status of DatadogAgent
+// and the number of nodes that can't run an Agent pod, based on the status of DatadogAgent
 func (mf *metricsForwarder) sendStatusMetrics(status *datadoghqv1alpha1.DatadogAgentStatus) error {
 	if status == nil {
 		return errors.New("nil status")
@@ -387,6 +417,14 @@ func (mf *metricsForwarder) sendStatusMetrics(status *datadoghqv1alpha1.DatadogA
 		}
 	}
 
+	// Number of nodes that can't run an Agent pod
+	if status.AgentCoverage != nil {
+		tags := append(append([]string{}, mf.globalTags...), mf.tags...)
+		if err := mf.sendUncoveredNodesMetric(float64(status.AgentCoverage.UncoveredNodes), tags); err != nil {
+			return err
+		}
+	}
+
 	// Cluster Agent deployment metrics
 	if status.ClusterAgent != nil {
 		if status.ClusterAgent.AvailableReplicas == status.ClusterAgent.Replicas {
@@ -479,6 +517,44 @@ func hashKeys(apiKey, appKey string) uint64 {
 	return h.Sum64()
 }
 
+// updateProxyIfNeeded reads the proxy configuration of the DatadogAgent, it returns true if the proxy changed
+func (mf *metricsForwarder) updateProxyIfNeeded(dda *datadoghqv1alpha1.DatadogAgent) (bool, error) {
+	username, password, err := utils.GetProxyCredentials(mf.k8sClient, dda.Namespace, dda.Spec.Proxy)
+	if err != nil {
+		return false, err
+	}
+	hash := hashProxy(dda.Spec.Proxy, username, password)
+	if hash == mf.proxyHash {
+		return false, nil
+	}
+	proxy, err := utils.NewProxyFunc(dda.Spec.Proxy, username, password)
+	if err != nil {
+		return false, err
+	}
+	mf.proxy = proxy
+	mf.proxyHash = hash
+	return true, nil
+}
+
+// hashProxy is used to detect if the proxy configuration has changed
+// hashProxy is NOT a security function
+func hashProxy(config *datadoghqv1alpha1.ProxyConfig, username, password string) uint64 {
+	if config == nil {
+		return 0
+	}
+	h := fnv.New64()
+	for _, value := range []*string{config.HTTP, config.HTTPS} {
+		if value != nil {
+			_, _ = h.Write([]byte(*value))
+		}
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write([]byte(strings.Join(config.NoProxy, ",")))
+	_, _ = h.Write([]byte(username))
+	_, _ = h.Write([]byte(password))
+	return h.Sum64()
+}
+
 // getDatadogAgent retrieves the DatadogAgent using Get client method
 func (mf *metricsForwarder) getDatadogAgent() (*datadoghqv1alpha1.DatadogAgent, error) {
 	dda := &datadoghqv1alpha1.DatadogAgent{}
@@ -592,20 +668,32 @@ func (mf *metricsForwarder) updateStatusIfNeeded(err error) {
 	now := metav1.NewTime(time.Now())
 	conditionStatus := corev1.ConditionTrue
 	description := "Datadog metrics forwarding ok"
+	reason := ""
 	if err != nil {
 		conditionStatus = corev1.ConditionFalse
 		description = "Datadog metrics forwarding error"
+		if isCredentialsError(err) {
+			reason = InvalidCredentialsReason
+			description = fmt.Sprintf("Datadog metrics forwarding error: %v", err)
+		}
 	}
 
 	oldStatus := mf.getStatus()
 	if oldStatus == nil {
-		newStatus := condition.NewDatadogAgentStatusCondition(datadoghqv1alpha1.ConditionTypeActiveDatadogMetrics, conditionStatus, now, "", description)
+		newStatus := condition.NewDatadogAgentStatusCondition(datadoghqv1alpha1.ConditionTypeActiveDatadogMetrics, conditionStatus, now, reason, description)
 		mf.setStatus(&newStatus)
 	} else {
-		mf.setStatus(condition.UpdateDatadogAgentStatusCondition(oldStatus, now, datadoghqv1alpha1.ConditionTypeActiveDatadogMetrics, conditionStatus, description))
+		newStatus := condition.UpdateDatadogAgentStatusCondition(oldStatus, now, datadoghqv1alpha1.ConditionTypeActiveDatadogMetrics, conditionStatus, description)
+		newStatus.Reason = reason
+		mf.setStatus(newStatus)
 	}
 }
 
+// isCredentialsError returns true if the error is caused by missing or invalid credentials
+func isCredentialsError(err error) bool {
+	return errors.Is(err, ErrEmptyAPIKey) || errors.Is(err, ErrEmptyAPPKey) || errors.Is(err, ErrInvalidCredentials)
+}
+
 // sendReconcileMetric is used to forward reconcile metrics to Datadog
 func (mf *metricsForwarder) sendReconcileMetric(metricValue float64, tags []string) error {
 	return mf.delegator.delegatedSendReconcileMetric(metricValue, tags)
@@ -631,20 +719,53 @@ func (mf *metricsForwarder) delegatedSendReconcileMetric(metricValue float64, ta
 	return mf.datadogClient.PostMetrics(serie)
 }
 
+// sendUncoveredNodesMetric is used to forward the number of nodes that can't run an Agent pod to Datadog
+func (mf *metricsForwarder) sendUncoveredNodesMetric(metricValue float64, tags []string) error {
+	return mf.delegator.delegatedSendUncoveredNodesMetric(metricValue, tags)
+}
+
+// delegatedSendUncoveredNodesMetric is separated from sendUncoveredNodesMetric to facilitate mocking the Datadog API
+func (mf *metricsForwarder) delegatedSendUncoveredNodesMetric(metricValue float64, tags []string) error {
+	ts := float64(time.Now().Unix())
+	metricName := fmt.Sprintf(uncoveredNodesMetricFormat, mf.metricsPrefix)
+	serie := []api.Metric{
+		{
+			Metric: api.String(metricName),
+			Points: []api.DataPoint{
+				{
+					api.Float64(ts),
+					api.Float64(metricValue),
+				},
+			},
+			Type: api.String(gaugeType),
+			Tags: tags,
+		},
+	}
+	return mf.datadogClient.PostMetrics(serie)
+}
+
 // forwardEvent sends events to Datadog
 func (mf *metricsForwarder) forwardEvent(event Event) error {
-	return mf.delegator.delegatedSendEvent(event.Title, event.Type)
+	return mf.delegator.delegatedSendEvent(event)
 }
 
 // delegatedSendEvent is separated from forwardEvent to facilitate mocking the Datadog API
-func (mf *metricsForwarder) delegatedSendEvent(eventTitle string, eventType EventType) error {
+func (mf *metricsForwarder) delegatedSendEvent(ddEvent Event) error {
 	event := &api.Event{
 		Time:       api.Int(int(time.Now().Unix())),
-		Title:      api.String(eventTitle),
-		EventType:  api.String(string(eventType)),
+		Title:      api.String(ddEvent.Title),
+		EventType:  api.String(string(ddEvent.Type)),
+		Priority:   api.String(string(ddEvent.Type.Priority())),
+		AlertType:  api.String(string(ddEvent.Type.AlertType())),
 		SourceType: api.String(datadogOperatorSourceType),
 		Tags:       append(mf.globalTags, mf.tags...),
 	}
+	if ddEvent.Text != "" {
+		event.Text = api.String(ddEvent.Text)
+	}
+	if ddEvent.AggregationKey != "" {
+		event.Aggregation = api.String(ddEvent.AggregationKey)
+	}
 	if _, err := mf.datadogClient.PostEvent(event); err != nil {
 		return err
 	}
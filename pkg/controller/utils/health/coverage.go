@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// daemonSetTolerations are added by the DaemonSet controller to every DaemonSet pod
+var daemonSetTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// hostNetworkTolerations are added by the DaemonSet controller to the DaemonSet pods using the host network
+var hostNetworkTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNetworkUnavailable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// GetUncoveredReason returns why the pods of a DaemonSet with the pod spec can't run on the node, empty if they can
+func GetUncoveredReason(node *corev1.Node, podSpec *corev1.PodSpec) string {
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return "node selector"
+	}
+	if affinity := podSpec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !matchesNodeSelectorTerms(node, affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) {
+			return "node affinity"
+		}
+	}
+	tolerations := append(append([]corev1.Toleration{}, podSpec.Tolerations...), daemonSetTolerations...)
+	if podSpec.HostNetwork {
+		tolerations = append(tolerations, hostNetworkTolerations...)
+	}
+	if taint := GetUntoleratedTaint(tolerations, node.Spec.Taints); taint != nil {
+		return fmt.Sprintf("taint %s", taint.ToString())
+	}
+	return ""
+}
+
+// GetUntoleratedTaint returns the first taint preventing the pods with the tolerations to be scheduled or to run, nil if there is none
+func GetUntoleratedTaint(tolerations []corev1.Toleration, taints []corev1.Taint) *corev1.Taint {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return taint
+		}
+	}
+	return nil
+}
+
+// matchesNodeSelectorTerms returns true if the node matches one of the terms
+func matchesNodeSelectorTerms(node *corev1.Node, terms []corev1.NodeSelectorTerm) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		if matchesRequirements(term.MatchExpressions, labels.Set(node.Labels)) &&
+			matchesRequirements(term.MatchFields, labels.Set{nodeNameField: node.Name}) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRequirements(requirements []corev1.NodeSelectorRequirement, set labels.Set) bool {
+	for _, requirement := range requirements {
+		operator, found := nodeSelectorOperators[requirement.Operator]
+		if !found {
+			return false
+		}
+		selectorRequirement, err := labels.NewRequirement(requirement.Key, operator, requirement.Values)
+		if err != nil || !selectorRequirement.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetUncoveredReason(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		NodeSelector: map[string]string{corev1.LabelOSStable: "linux"},
+		Affinity: &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"system"}}}},
+						{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"system-0"}}}},
+					},
+				},
+			},
+		},
+		Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "monitoring"}},
+	}
+
+	tests := []struct {
+		name string
+		node corev1.Node
+		want string
+	}{
+		{name: "covered", node: newNode("node")},
+		{name: "windows", node: func() corev1.Node {
+			node := newNode("windows")
+			node.Labels[corev1.LabelOSStable] = "windows"
+			return node
+		}(), want: "node selector"},
+		{name: "system pool", node: func() corev1.Node {
+			node := newNode("system-1")
+			node.Labels["pool"] = "system"
+			return node
+		}(), want: "node affinity"},
+		{name: "system node matched by name", node: func() corev1.Node {
+			node := newNode("system-0")
+			node.Labels["pool"] = "system"
+			return node
+		}()},
+		{name: "tolerated taint", node: newNode("monitoring", corev1.Taint{Key: "dedicated", Value: "monitoring", Effect: corev1.TaintEffectNoSchedule})},
+		{name: "taint tolerated by the DaemonSet controller", node: newNode("not-ready", corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute})},
+		{name: "prefer no schedule", node: newNode("preferred", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectPreferNoSchedule})},
+		{name: "untolerated taint", node: newNode("gpu", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}), want: "taint dedicated=gpu:NoSchedule"},
+		{name: "network unavailable", node: newNode("network", corev1.Taint{Key: corev1.TaintNodeNetworkUnavailable, Effect: corev1.TaintEffectNoSchedule}), want: "taint node.kubernetes.io/network-unavailable:NoSchedule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetUncoveredReason(&tt.node, podSpec))
+		})
+	}
+
+	podSpec.HostNetwork = true
+	node := newNode("network", corev1.Taint{Key: corev1.TaintNodeNetworkUnavailable, Effect: corev1.TaintEffectNoSchedule})
+	assert.Equal(t, "", GetUncoveredReason(&node, podSpec))
+}
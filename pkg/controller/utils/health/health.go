@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// MaxStatusNodes is the maximum number of unhealthy nodes reported in the DatadogAgent status
+	MaxStatusNodes = 10
+
+	crashLoopBackOffReason = "CrashLoopBackOff"
+	nodeNameField          = "metadata.name"
+)
+
+var imagePullFailureReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// GetNodesHealth returns the health of the Agent on every node, sorted by node name.
+// A node without Agent pod is reported only if the Agent pods can be scheduled on it,
+// according to the node selector and the tolerations of the existing Agent pods.
+func GetNodesHealth(nodes []corev1.Node, pods []corev1.Pod) []datadoghqv1alpha1.NodeAgentHealth {
+	podsByNode := map[string]*corev1.Pod{}
+	for i := range pods {
+		pod := &pods[i]
+		nodeName := getPodNodeName(pod)
+		if nodeName == "" {
+			continue
+		}
+		if current, found := podsByNode[nodeName]; !found || isPreferredPod(pod, current) {
+			podsByNode[nodeName] = pod
+		}
+	}
+
+	nodesHealth := []datadoghqv1alpha1.NodeAgentHealth{}
+	for i := range nodes {
+		node := &nodes[i]
+		pod, found := podsByNode[node.Name]
+		if !found {
+			if canRunAgent(node, pods) {
+				nodesHealth = append(nodesHealth, datadoghqv1alpha1.NodeAgentHealth{
+					Node:   node.Name,
+					Reason: datadoghqv1alpha1.AgentHealthReasonNoAgent,
+				})
+			}
+			continue
+		}
+		reason, message := getPodHealth(pod)
+		nodesHealth = append(nodesHealth, datadoghqv1alpha1.NodeAgentHealth{
+			Node:     node.Name,
+			Pod:      pod.Name,
+			Reason:   reason,
+			Message:  message,
+			Restarts: getPodRestarts(pod),
+		})
+	}
+	sort.SliceStable(nodesHealth, func(i, j int) bool {
+		return nodesHealth[i].Node < nodesHealth[j].Node
+	})
+	return nodesHealth
+}
+
+// NewAgentHealthStatus summarizes the health of the Agent on the nodes, keeping the maxNodes
+// unhealthy nodes whose Agent restarted the most
+func NewAgentHealthStatus(nodesHealth []datadoghqv1alpha1.NodeAgentHealth, maxNodes int) *datadoghqv1alpha1.AgentHealthStatus {
+	status := &datadoghqv1alpha1.AgentHealthStatus{}
+	counts := map[datadoghqv1alpha1.AgentHealthReason]int32{}
+	unhealthy := []datadoghqv1alpha1.NodeAgentHealth{}
+	for _, nodeHealth := range nodesHealth {
+		if nodeHealth.Reason == datadoghqv1alpha1.AgentHealthReasonHealthy {
+			status.HealthyNodes++
+			continue
+		}
+		status.UnhealthyNodes++
+		counts[nodeHealth.Reason]++
+		unhealthy = append(unhealthy, nodeHealth)
+	}
+
+	for reason, count := range counts {
+		status.Reasons = append(status.Reasons, datadoghqv1alpha1.AgentHealthReasonCount{Reason: reason, Nodes: count})
+	}
+	sort.Slice(status.Reasons, func(i, j int) bool {
+		if status.Reasons[i].Nodes != status.Reasons[j].Nodes {
+			return status.Reasons[i].Nodes > status.Reasons[j].Nodes
+		}
+		return status.Reasons[i].Reason < status.Reasons[j].Reason
+	})
+
+	SortByRestarts(unhealthy)
+	if len(unhealthy) > maxNodes {
+		unhealthy = unhealthy[:maxNodes]
+	}
+	if len(unhealthy) > 0 {
+		status.Nodes = unhealthy
+	}
+	return status
+}
+
+// SortByRestarts sorts the nodes by decreasing number of Agent restarts, then by node name
+func SortByRestarts(nodesHealth []datadoghqv1alpha1.NodeAgentHealth) {
+	sort.SliceStable(nodesHealth, func(i, j int) bool {
+		if nodesHealth[i].Restarts != nodesHealth[j].Restarts {
+			return nodesHealth[i].Restarts > nodesHealth[j].Restarts
+		}
+		return nodesHealth[i].Node < nodesHealth[j].Node
+	})
+}
+
+// getPodHealth returns the health of an Agent pod and the details of it
+func getPodHealth(pod *corev1.Pod) (datadoghqv1alpha1.AgentHealthReason, string) {
+	if pod.Status.Phase == corev1.PodPending {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse {
+				continue
+			}
+			switch {
+			case strings.Contains(condition.Message, "Insufficient"):
+				return datadoghqv1alpha1.AgentHealthReasonPendingResources, condition.Message
+			case strings.Contains(condition.Message, "taint"):
+				return datadoghqv1alpha1.AgentHealthReasonPendingTaints, condition.Message
+			}
+			return datadoghqv1alpha1.AgentHealthReasonPending, condition.Message
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		if status.State.Waiting.Reason == crashLoopBackOffReason {
+			message := fmt.Sprintf("container %s is crashing", status.Name)
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				message = fmt.Sprintf("container %s terminated with reason %s and exit code %d", status.Name, terminated.Reason, terminated.ExitCode)
+			}
+			return datadoghqv1alpha1.AgentHealthReasonCrashLoopBackOff, message
+		}
+		if imagePullFailureReasons[status.State.Waiting.Reason] {
+			return datadoghqv1alpha1.AgentHealthReasonImagePullFailure, fmt.Sprintf("container %s: %s", status.Name, status.State.Waiting.Message)
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		return datadoghqv1alpha1.AgentHealthReasonPending, ""
+	}
+	if !isPodReady(pod) {
+		return datadoghqv1alpha1.AgentHealthReasonNotReady, pod.Status.Reason
+	}
+	return datadoghqv1alpha1.AgentHealthReasonHealthy, ""
+}
+
+// getPodNodeName returns the node of a pod, or the node the DaemonSet controller targets if the pod isn't scheduled yet
+func getPodNodeName(pod *corev1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return pod.Spec.NodeName
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, field := range term.MatchFields {
+			if field.Key == nodeNameField && field.Operator == corev1.NodeSelectorOpIn && len(field.Values) == 1 {
+				return field.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// isPreferredPod returns true if pod better represents the Agent of a node than current:
+// a ready pod is preferred, then the newest one
+func isPreferredPod(pod, current *corev1.Pod) bool {
+	if isPodReady(pod) != isPodReady(current) {
+		return isPodReady(pod)
+	}
+	return current.CreationTimestamp.Before(&pod.CreationTimestamp)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func getPodRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.InitContainerStatuses {
+		restarts += status.RestartCount
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// canRunAgent returns true if one of the Agent pods could run on the node, or if there is no Agent pod to compare with
+func canRunAgent(node *corev1.Node, pods []corev1.Pod) bool {
+	if len(pods) == 0 {
+		return true
+	}
+	for i := range pods {
+		if labels.SelectorFromSet(pods[i].Spec.NodeSelector).Matches(labels.Set(node.Labels)) && GetUntoleratedTaint(pods[i].Spec.Tolerations, node.Spec.Taints) == nil {
+			return true
+		}
+	}
+	return false
+}
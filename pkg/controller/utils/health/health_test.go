@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+func newNode(name string, taints ...corev1.Taint) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelOSStable: "linux"}},
+		Spec:       corev1.NodeSpec{Taints: taints},
+	}
+}
+
+func newPod(name, nodeName string, status corev1.PodStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(time.Unix(0, 0))},
+		Spec: corev1.PodSpec{
+			NodeName:     nodeName,
+			NodeSelector: map[string]string{corev1.LabelOSStable: "linux"},
+		},
+		Status: status,
+	}
+}
+
+func readyStatus(restarts int32) corev1.PodStatus {
+	return corev1.PodStatus{
+		Phase:             corev1.PodRunning,
+		Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "agent", Ready: true, RestartCount: restarts}},
+	}
+}
+
+func waitingStatus(reason string, restarts int32) corev1.PodStatus {
+	return corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "agent", Ready: true},
+			{
+				Name:                 "process-agent",
+				RestartCount:         restarts,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "back-off"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			},
+		},
+	}
+}
+
+func unschedulableStatus(message string) corev1.PodStatus {
+	return corev1.PodStatus{
+		Phase:      corev1.PodPending,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: message}},
+	}
+}
+
+func TestGetNodesHealth(t *testing.T) {
+	pendingPod := newPod("agent-pending", "", unschedulableStatus("0/3 nodes are available: 1 Insufficient memory."))
+	pendingPod.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchFields: []corev1.NodeSelectorRequirement{
+							{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-pending"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	newerCrashingPod := newPod("agent-healthy-new", "node-healthy", waitingStatus("CrashLoopBackOff", 1))
+	newerCrashingPod.CreationTimestamp = metav1.NewTime(time.Unix(60, 0))
+
+	nodes := []corev1.Node{
+		newNode("node-healthy"),
+		newNode("node-crash"),
+		newNode("node-pull"),
+		newNode("node-pending"),
+		newNode("node-taint"),
+		newNode("node-none"),
+		newNode("node-tainted", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}),
+		newNode("node-windows"),
+	}
+	nodes[7].Labels[corev1.LabelOSStable] = "windows"
+	pods := []corev1.Pod{
+		newPod("agent-healthy", "node-healthy", readyStatus(2)),
+		newerCrashingPod,
+		newPod("agent-crash", "node-crash", waitingStatus("CrashLoopBackOff", 5)),
+		newPod("agent-pull", "node-pull", waitingStatus("ImagePullBackOff", 0)),
+		pendingPod,
+		newPod("agent-taint", "node-taint", unschedulableStatus("0/3 nodes are available: 1 node(s) had taint {foo: bar}, that the pod didn't tolerate.")),
+	}
+
+	assert.Equal(t, []datadoghqv1alpha1.NodeAgentHealth{
+		{Node: "node-crash", Pod: "agent-crash", Reason: datadoghqv1alpha1.AgentHealthReasonCrashLoopBackOff, Message: "container process-agent terminated with reason OOMKilled and exit code 137", Restarts: 5},
+		{Node: "node-healthy", Pod: "agent-healthy", Reason: datadoghqv1alpha1.AgentHealthReasonHealthy, Restarts: 2},
+		{Node: "node-none", Reason: datadoghqv1alpha1.AgentHealthReasonNoAgent},
+		{Node: "node-pending", Pod: "agent-pending", Reason: datadoghqv1alpha1.AgentHealthReasonPendingResources, Message: "0/3 nodes are available: 1 Insufficient memory."},
+		{Node: "node-pull", Pod: "agent-pull", Reason: datadoghqv1alpha1.AgentHealthReasonImagePullFailure, Message: "container process-agent: back-off"},
+		{Node: "node-taint", Pod: "agent-taint", Reason: datadoghqv1alpha1.AgentHealthReasonPendingTaints, Message: "0/3 nodes are available: 1 node(s) had taint {foo: bar}, that the pod didn't tolerate."},
+	}, GetNodesHealth(nodes, pods))
+
+	// Without Agent pod, every node is reported
+	assert.Len(t, GetNodesHealth(nodes, nil), len(nodes))
+}
+
+func TestNewAgentHealthStatus(t *testing.T) {
+	nodesHealth := []datadoghqv1alpha1.NodeAgentHealth{
+		{Node: "node-0", Reason: datadoghqv1alpha1.AgentHealthReasonHealthy, Restarts: 100},
+	}
+	for i := 1; i <= 12; i++ {
+		reason := datadoghqv1alpha1.AgentHealthReasonCrashLoopBackOff
+		if i%3 == 0 {
+			reason = datadoghqv1alpha1.AgentHealthReasonNoAgent
+		}
+		nodesHealth = append(nodesHealth, datadoghqv1alpha1.NodeAgentHealth{Node: fmt.Sprintf("node-%02d", i), Reason: reason, Restarts: int32(i % 4)})
+	}
+
+	status := NewAgentHealthStatus(nodesHealth, MaxStatusNodes)
+	assert.Equal(t, int32(1), status.HealthyNodes)
+	assert.Equal(t, int32(12), status.UnhealthyNodes)
+	assert.Equal(t, []datadoghqv1alpha1.AgentHealthReasonCount{
+		{Reason: datadoghqv1alpha1.AgentHealthReasonCrashLoopBackOff, Nodes: 8},
+		{Reason: datadoghqv1alpha1.AgentHealthReasonNoAgent, Nodes: 4},
+	}, status.Reasons)
+	assert.Len(t, status.Nodes, MaxStatusNodes)
+	assert.Equal(t, "node-03", status.Nodes[0].Node)
+	assert.Equal(t, "node-07", status.Nodes[1].Node)
+	assert.Equal(t, "node-11", status.Nodes[2].Node)
+
+	status = NewAgentHealthStatus(nodesHealth[:1], MaxStatusNodes)
+	assert.Equal(t, &datadoghqv1alpha1.AgentHealthStatus{HealthyNodes: 1}, status)
+}
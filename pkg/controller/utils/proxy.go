@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	datadoghqv1alpha1 "github.com/DataDog/datadog-operator/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProxyFunc selects the proxy of a request, see http.Transport.Proxy
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// GetProxyCredentialsSecret returns the name of the Secret containing the proxy credentials and the keys of the username and the password
+func GetProxyCredentialsSecret(config *datadoghqv1alpha1.ProxyConfig) (string, string, string) {
+	secret := config.CredentialsSecret
+	usernameKey, passwordKey := datadoghqv1alpha1.DefaultProxyUsernameKey, datadoghqv1alpha1.DefaultProxyPasswordKey
+	if secret.UsernameKey != "" {
+		usernameKey = secret.UsernameKey
+	}
+	if secret.PasswordKey != "" {
+		passwordKey = secret.PasswordKey
+	}
+	return secret.SecretName, usernameKey, passwordKey
+}
+
+// GetProxyCredentials reads the proxy username and password from their Secret, they are empty without credentials Secret
+func GetProxyCredentials(c client.Client, namespace string, config *datadoghqv1alpha1.ProxyConfig) (string, string, error) {
+	if config == nil || config.CredentialsSecret == nil {
+		return "", "", nil
+	}
+	secretName, usernameKey, passwordKey := GetProxyCredentialsSecret(config)
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: secretName}, secret); err != nil {
+		return "", "", err
+	}
+	return string(secret.Data[usernameKey]), string(secret.Data[passwordKey]), nil
+}
+
+// NewProxyFunc returns the ProxyFunc of the proxy configuration, nil without configuration.
+// The username and password are URL-encoded, like in the credentials Secret used by the Agents.
+func NewProxyFunc(config *datadoghqv1alpha1.ProxyConfig, username, password string) (ProxyFunc, error) {
+	if config == nil || (config.HTTP == nil && config.HTTPS == nil) {
+		return nil, nil
+	}
+
+	var user *url.Userinfo
+	if username != "" {
+		decodedUsername, err := url.PathUnescape(username)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy username: %v", err)
+		}
+		decodedPassword, err := url.PathUnescape(password)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy password: %v", err)
+		}
+		user = url.UserPassword(decodedUsername, decodedPassword)
+	}
+
+	proxies := map[string]*url.URL{}
+	for scheme, proxyURL := range map[string]*string{"http": config.HTTP, "https": config.HTTPS} {
+		if proxyURL == nil {
+			continue
+		}
+		u, err := url.Parse(*proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			u.User = user
+		}
+		proxies[scheme] = u
+	}
+
+	noProxy := config.NoProxy
+	return func(req *http.Request) (*url.URL, error) {
+		if !UseProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxies[req.URL.Scheme], nil
+	}, nil
+}
+
+// UseProxy returns false if the host matches one of the no_proxy entries:
+// "*", a host name, a domain (".example.com" or "example.com" including its subdomains), an IP or a CIDR
+func UseProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return false
+		}
+		if ip != nil {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return false
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return false
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+	return true
+}
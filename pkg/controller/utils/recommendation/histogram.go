@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package recommendation
+
+import (
+	"math"
+)
+
+const (
+	// bucketGrowthRatio is the ratio between the sizes of two consecutive buckets,
+	// the percentiles are overestimated by 5% at most
+	bucketGrowthRatio = 1.05
+	// maxBuckets bounds the memory of a histogram, the values above the last bucket are counted in it
+	maxBuckets = 400
+)
+
+// Histogram counts values in exponentially growing buckets, so that its memory doesn't depend on the number of values
+type Histogram struct {
+	firstBucketSize float64
+	counts          map[int]int
+	total           int
+	max             float64
+}
+
+// NewHistogram returns an empty histogram whose first bucket counts the values up to firstBucketSize
+func NewHistogram(firstBucketSize float64) *Histogram {
+	return &Histogram{
+		firstBucketSize: firstBucketSize,
+		counts:          map[int]int{},
+	}
+}
+
+// Add counts a value
+func (h *Histogram) Add(value float64) {
+	h.counts[h.bucket(value)]++
+	h.total++
+	if value > h.max {
+		h.max = value
+	}
+}
+
+// Merge counts the values of another histogram with the same first bucket size
+func (h *Histogram) Merge(other *Histogram) {
+	for bucket, count := range other.counts {
+		h.counts[bucket] += count
+	}
+	h.total += other.total
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of values
+func (h *Histogram) Count() int {
+	return h.total
+}
+
+// Max returns the highest value
+func (h *Histogram) Max() float64 {
+	return h.max
+}
+
+// Percentile returns the upper bound of the bucket containing the percentile, between 0 and 100, of the values
+func (h *Histogram) Percentile(percentile float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	threshold := int(math.Ceil(float64(h.total) * percentile / 100))
+	count := 0
+	for bucket := 0; bucket < maxBuckets; bucket++ {
+		count += h.counts[bucket]
+		if count >= threshold {
+			return math.Min(h.upperBound(bucket), h.max)
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) bucket(value float64) int {
+	if value <= h.firstBucketSize {
+		return 0
+	}
+	bucket := int(math.Ceil(math.Log(value/h.firstBucketSize) / math.Log(bucketGrowthRatio)))
+	if bucket >= maxBuckets {
+		return maxBuckets - 1
+	}
+	return bucket
+}
+
+func (h *Histogram) upperBound(bucket int) float64 {
+	return h.firstBucketSize * math.Pow(bucketGrowthRatio, float64(bucket))
+}
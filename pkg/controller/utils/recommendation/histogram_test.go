@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package recommendation
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram(1)
+	assert.Equal(t, 0.0, h.Percentile(95))
+
+	for i := 1; i <= 100; i++ {
+		h.Add(float64(i))
+	}
+	assert.Equal(t, 100, h.Count())
+	assert.Equal(t, 100.0, h.Max())
+	// The percentiles are overestimated by the size of the buckets at most
+	assert.InDelta(t, 50, h.Percentile(50), 50*(bucketGrowthRatio-1))
+	assert.InDelta(t, 95, h.Percentile(95), 95*(bucketGrowthRatio-1))
+	assert.GreaterOrEqual(t, h.Percentile(95), 95.0)
+	assert.Equal(t, 100.0, h.Percentile(100))
+
+	other := NewHistogram(1)
+	for i := 0; i < 100; i++ {
+		other.Add(1000)
+	}
+	h.Merge(other)
+	assert.Equal(t, 200, h.Count())
+	assert.Equal(t, 1000.0, h.Max())
+	assert.InDelta(t, 100, h.Percentile(50), 100*(bucketGrowthRatio-1))
+	assert.Equal(t, 1000.0, h.Percentile(95))
+}
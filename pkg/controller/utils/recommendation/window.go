@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package recommendation
+
+import (
+	"time"
+)
+
+const (
+	// slotsPerWindow is the number of slots of a window, the oldest samples expire a slot at a time
+	slotsPerWindow = 24
+	// cpuFirstBucketSize is 1 millicore
+	cpuFirstBucketSize = 0.001
+	// memoryFirstBucketSize is 1 MiB
+	memoryFirstBucketSize = 1024 * 1024
+)
+
+// Window keeps the CPU and memory usage samples of a container during a duration
+type Window struct {
+	duration time.Duration
+	slots    []windowSlot
+}
+
+// windowSlot keeps the samples of a fraction of the window
+type windowSlot struct {
+	start  time.Time
+	cpu    *Histogram
+	memory *Histogram
+}
+
+// Usage summarizes the usage samples of a window
+type Usage struct {
+	// CPU is the percentile of the CPU usage, in cores
+	CPU float64
+	// Memory is the percentile of the memory usage, in bytes
+	Memory float64
+	// MaxMemory is the highest memory usage, in bytes
+	MaxMemory float64
+	// Samples is the number of samples
+	Samples int
+}
+
+// NewWindow returns an empty window keeping the samples during the duration
+func NewWindow(duration time.Duration) *Window {
+	return &Window{duration: duration}
+}
+
+// Duration returns the duration during which the samples are kept
+func (w *Window) Duration() time.Duration {
+	return w.duration
+}
+
+// Add records a sample of the CPU usage, in cores, and of the memory usage, in bytes
+func (w *Window) Add(now time.Time, cpu, memory float64) {
+	w.expire(now)
+	slotDuration := w.duration / slotsPerWindow
+	if len(w.slots) == 0 || now.Sub(w.slots[len(w.slots)-1].start) >= slotDuration {
+		w.slots = append(w.slots, windowSlot{
+			start:  now,
+			cpu:    NewHistogram(cpuFirstBucketSize),
+			memory: NewHistogram(memoryFirstBucketSize),
+		})
+	}
+	slot := w.slots[len(w.slots)-1]
+	slot.cpu.Add(cpu)
+	slot.memory.Add(memory)
+}
+
+// Usage returns the percentile, between 0 and 100, of the CPU and memory samples of the window
+func (w *Window) Usage(now time.Time, percentile float64) Usage {
+	w.expire(now)
+	cpu := NewHistogram(cpuFirstBucketSize)
+	memory := NewHistogram(memoryFirstBucketSize)
+	for _, slot := range w.slots {
+		cpu.Merge(slot.cpu)
+		memory.Merge(slot.memory)
+	}
+	return Usage{
+		CPU:       cpu.Percentile(percentile),
+		Memory:    memory.Percentile(percentile),
+		MaxMemory: memory.Max(),
+		Samples:   cpu.Count(),
+	}
+}
+
+// expire drops the slots older than the window
+func (w *Window) expire(now time.Time) {
+	expired := 0
+	for expired < len(w.slots) && now.Sub(w.slots[expired].start) >= w.duration {
+		expired++
+	}
+	w.slots = w.slots[expired:]
+}
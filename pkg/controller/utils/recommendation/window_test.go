@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package recommendation
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := NewWindow(24 * time.Hour)
+
+	// A peak during the first two hours, then a steady usage
+	for i := 0; i < 120; i++ {
+		w.Add(start.Add(time.Duration(i)*time.Minute), 2, 512*memoryFirstBucketSize)
+	}
+	for i := 120; i < 24*60; i++ {
+		w.Add(start.Add(time.Duration(i)*time.Minute), 0.1, 128*memoryFirstBucketSize)
+	}
+	now := start.Add(24*time.Hour - time.Minute)
+	usage := w.Usage(now, 95)
+	assert.Equal(t, 24*60, usage.Samples)
+	assert.InDelta(t, 2, usage.CPU, 2*(bucketGrowthRatio-1))
+	assert.InDelta(t, 512*memoryFirstBucketSize, usage.Memory, 512*memoryFirstBucketSize*(bucketGrowthRatio-1))
+	assert.Equal(t, float64(512*memoryFirstBucketSize), usage.MaxMemory)
+
+	// The peak expires with its slot
+	usage = w.Usage(start.Add(26*time.Hour), 95)
+	assert.Less(t, usage.Samples, 24*60)
+	assert.InDelta(t, 0.1, usage.CPU, 0.1*(bucketGrowthRatio-1))
+	assert.Equal(t, float64(128*memoryFirstBucketSize), usage.MaxMemory)
+
+	// Every sample expires after the window
+	assert.Equal(t, Usage{}, w.Usage(start.Add(72*time.Hour), 95))
+}
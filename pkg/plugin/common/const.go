@@ -14,6 +14,8 @@ const (
 	AgentLabelValue = "agent"
 	// ComponentLabelKey label key used to define the datadog agent component
 	ComponentLabelKey = "agent.datadoghq.com/component"
+	// ClusterAgentLabelValue label value to define the Cluster Agent
+	ClusterAgentLabelValue = "cluster-agent"
 	// ClcRunnerLabelValue label value to define the Cluster Checks Runner
 	ClcRunnerLabelValue = "cluster-checks-runner"
 )
@@ -21,6 +23,8 @@ const (
 var (
 	// AgentLabel can be used as a LabelSelector for the Agent
 	AgentLabel = fmt.Sprintf("%s=%s", ComponentLabelKey, AgentLabelValue)
+	// ClusterAgentLabel can be used as a LabelSelector for the Cluster Agent
+	ClusterAgentLabel = fmt.Sprintf("%s=%s", ComponentLabelKey, ClusterAgentLabelValue)
 	// ClcRunnerLabel can be used as a LabelSelector for the Cluster Checks Runner
 	ClcRunnerLabel = fmt.Sprintf("%s=%s", ComponentLabelKey, ClcRunnerLabelValue)
 )
@@ -0,0 +1,381 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultRegistry     = "registry-1.docker.io"
+	registryHTTPTimeout = 10 * time.Second
+)
+
+// ImageReference represents the parts of a container image name
+type ImageReference struct {
+	Registry   string
+	Repository string
+	// Reference is either the tag or the digest of the image
+	Reference string
+}
+
+// ParseImageReference splits an image name into its registry, repository and tag or digest
+func ParseImageReference(image string) ImageReference {
+	ref := ImageReference{Registry: defaultRegistry, Reference: "latest"}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		name = parts[1]
+	} else if len(parts) == 1 {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	return ref
+}
+
+// ImageVersion represents the semantic version of an image tag
+type ImageVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var imageVersionRegex = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseImageVersion returns the version of an image from its tag.
+// It returns false if the tag isn't a version, "latest" for instance.
+func ParseImageVersion(image string) (ImageVersion, bool) {
+	match := imageVersionRegex.FindStringSubmatch(ParseImageReference(image).Reference)
+	if match == nil {
+		return ImageVersion{}, false
+	}
+
+	version := ImageVersion{}
+	version.Major, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		version.Minor, _ = strconv.Atoi(match[2])
+	}
+	if match[3] != "" {
+		version.Patch, _ = strconv.Atoi(match[3])
+	}
+
+	return version, true
+}
+
+// LessThan returns true if the version is lower than major.minor.patch
+func (v ImageVersion) LessThan(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major < major
+	}
+	if v.Minor != minor {
+		return v.Minor < minor
+	}
+	return v.Patch < patch
+}
+
+// String returns the version as major.minor.patch
+func (v ImageVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// ImageNotFoundError is returned when the registry doesn't know the image tag or digest
+type ImageNotFoundError struct {
+	Image    string
+	Registry string
+}
+
+func (e *ImageNotFoundError) Error() string {
+	return fmt.Sprintf("image %s not found in registry %s", e.Image, e.Registry)
+}
+
+// IsImageNotFound returns true if the error reports that the image doesn't exist,
+// as opposed to a failure to reach or authenticate to the registry
+func IsImageNotFound(err error) bool {
+	_, ok := err.(*ImageNotFoundError)
+	return ok
+}
+
+// CheckImageExists verifies that the image tag or digest exists in its registry.
+// The registry is authenticated with the credentials of the pull secrets, if any,
+// and reached through the proxy configured in the environment.
+func CheckImageExists(c client.Client, image, namespace string, pullSecrets []corev1.LocalObjectReference) error {
+	ref := ParseImageReference(image)
+	credentials, err := getRegistryCredentials(c, namespace, pullSecrets, ref.Registry)
+	if err != nil {
+		return err
+	}
+
+	rc := &registryClient{
+		httpClient: &http.Client{
+			Timeout:   registryHTTPTimeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		},
+		scheme:      "https",
+		credentials: credentials,
+	}
+	return rc.checkImageExists(image)
+}
+
+// registryCredentials authenticates the requests to a docker registry
+type registryCredentials struct {
+	username string
+	password string
+}
+
+// dockerConfigEntry is an entry of the auths of a docker config
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// getRegistryCredentials returns the credentials of the registry found in the docker config pull secrets
+func getRegistryCredentials(c client.Client, namespace string, pullSecrets []corev1.LocalObjectReference, registry string) (*registryCredentials, error) {
+	for _, ref := range pullSecrets {
+		secret := &corev1.Secret{}
+		if err := c.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+			return nil, fmt.Errorf("unable to get pull secret %s/%s: %v", namespace, ref.Name, err)
+		}
+
+		auths := map[string]dockerConfigEntry{}
+		switch {
+		case len(secret.Data[corev1.DockerConfigJsonKey]) > 0:
+			config := struct {
+				Auths map[string]dockerConfigEntry `json:"auths"`
+			}{}
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+				return nil, fmt.Errorf("invalid pull secret %s/%s: %v", namespace, ref.Name, err)
+			}
+			auths = config.Auths
+		case len(secret.Data[corev1.DockerConfigKey]) > 0:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+				return nil, fmt.Errorf("invalid pull secret %s/%s: %v", namespace, ref.Name, err)
+			}
+		}
+
+		for server, entry := range auths {
+			if normalizeRegistry(server) != registry {
+				continue
+			}
+			credentials := &registryCredentials{username: entry.Username, password: entry.Password}
+			if entry.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+				if err != nil {
+					return nil, fmt.Errorf("invalid auth of registry %s in pull secret %s/%s: %v", server, namespace, ref.Name, err)
+				}
+				parts := strings.SplitN(string(decoded), ":", 2)
+				if len(parts) == 2 {
+					credentials.username, credentials.password = parts[0], parts[1]
+				}
+			}
+			return credentials, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// normalizeRegistry returns the registry host of a docker config server, https://index.docker.io/v1/ for instance
+func normalizeRegistry(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+len("://"):]
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+	switch host {
+	case "docker.io", "index.docker.io":
+		return defaultRegistry
+	}
+	return host
+}
+
+// registryClient queries the manifests of a docker registry v2 API
+type registryClient struct {
+	httpClient  *http.Client
+	scheme      string
+	credentials *registryCredentials
+}
+
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+func (c *registryClient) checkImageExists(image string) error {
+	ref := ParseImageReference(image)
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, ref.Registry, ref.Repository, ref.Reference)
+
+	resp, err := c.headManifest(manifestURL, "")
+	if err != nil {
+		return err
+	}
+
+	// The registries ask for basic credentials or a bearer token first
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.getAuthorization(resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return fmt.Errorf("unable to authenticate to registry %s: %v", ref.Registry, err)
+		}
+		if resp, err = c.headManifest(manifestURL, authorization); err != nil {
+			return err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return &ImageNotFoundError{Image: image, Registry: ref.Registry}
+	default:
+		return fmt.Errorf("unable to check image %s, registry %s returned %s", image, ref.Registry, resp.Status)
+	}
+}
+
+func (c *registryClient) headManifest(manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ","))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+var challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// getAuthorization returns the Authorization header answering the challenge of the registry
+func (c *registryClient) getAuthorization(challenge string) (string, error) {
+	switch {
+	case strings.HasPrefix(challenge, "Basic "):
+		if c.credentials == nil {
+			return "", fmt.Errorf("registry requires credentials, set them in the image pull secrets")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.credentials.username+":"+c.credentials.password)), nil
+	case strings.HasPrefix(challenge, "Bearer "):
+		token, err := c.getToken(challenge)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+// getToken requests a token from the realm of a bearer challenge, authenticated with the registry credentials if any
+func (c *registryClient) getToken(challenge string) (string, error) {
+	params := map[string]string{}
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in challenge %q", challenge)
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.credentials != nil {
+		req.SetBasicAuth(c.credentials.username, c.credentials.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned %s", resp.Status)
+	}
+
+	payload := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+	if payload.Token != "" {
+		return payload.Token, nil
+	}
+	return payload.AccessToken, nil
+}
+
+// CheckVersionCompatibility returns an error if the versions of the Agent and the Cluster Agent
+// are known to be incompatible. Images without a version tag, like "latest", aren't checked.
+func CheckVersionCompatibility(agentImage, clusterAgentImage string, clusterChecksEnabled bool) error {
+	if agentImage == "" || clusterAgentImage == "" {
+		return nil
+	}
+
+	agentVersion, agentOk := ParseImageVersion(agentImage)
+	clusterAgentVersion, clusterAgentOk := ParseImageVersion(clusterAgentImage)
+	if !agentOk || !clusterAgentOk {
+		return nil
+	}
+
+	if agentVersion.LessThan(6, 0, 0) {
+		return fmt.Errorf("agent %s doesn't support the Cluster Agent, use at least 6.0.0", agentVersion)
+	}
+	if agentVersion.Major >= 7 && clusterAgentVersion.LessThan(1, 0, 0) {
+		return fmt.Errorf("agent %s requires at least Cluster Agent 1.0.0, got %s", agentVersion, clusterAgentVersion)
+	}
+	if clusterChecksEnabled {
+		if agentVersion.LessThan(6, 9, 0) {
+			return fmt.Errorf("cluster checks require at least agent 6.9.0, got %s", agentVersion)
+		}
+		if clusterAgentVersion.LessThan(1, 2, 0) {
+			return fmt.Errorf("cluster checks require at least Cluster Agent 1.2.0, got %s", clusterAgentVersion)
+		}
+	}
+
+	return nil
+}
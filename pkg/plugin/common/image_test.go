@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  ImageReference
+	}{
+		{
+			image: "agent",
+			want:  ImageReference{Registry: "registry-1.docker.io", Repository: "library/agent", Reference: "latest"},
+		},
+		{
+			image: "datadog/agent:7.22.0",
+			want:  ImageReference{Registry: "registry-1.docker.io", Repository: "datadog/agent", Reference: "7.22.0"},
+		},
+		{
+			image: "gcr.io/datadoghq/cluster-agent:1.9.0",
+			want:  ImageReference{Registry: "gcr.io", Repository: "datadoghq/cluster-agent", Reference: "1.9.0"},
+		},
+		{
+			image: "localhost:5000/agent@sha256:abcd",
+			want:  ImageReference{Registry: "localhost:5000", Repository: "agent", Reference: "sha256:abcd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseImageReference(tt.image))
+		})
+	}
+}
+
+func TestCheckVersionCompatibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		agent         string
+		clusterAgent  string
+		clusterChecks bool
+		wantErr       bool
+	}{
+		{
+			name:         "compatible",
+			agent:        "datadog/agent:7.22.0",
+			clusterAgent: "datadog/cluster-agent:1.9.0",
+		},
+		{
+			name:         "latest isn't checked",
+			agent:        "datadog/agent:latest",
+			clusterAgent: "datadog/cluster-agent:0.10.0",
+		},
+		{
+			name:         "agent 5",
+			agent:        "datadog/agent:5.32.0",
+			clusterAgent: "datadog/cluster-agent:1.9.0",
+			wantErr:      true,
+		},
+		{
+			name:         "agent 7 with cluster agent 0.x",
+			agent:        "datadog/agent:7.22.0-jmx",
+			clusterAgent: "datadog/cluster-agent:0.10.0",
+			wantErr:      true,
+		},
+		{
+			name:          "cluster checks with old agent",
+			agent:         "datadog/agent:6.8.3",
+			clusterAgent:  "datadog/cluster-agent:1.9.0",
+			clusterChecks: true,
+			wantErr:       true,
+		},
+		{
+			name:          "cluster checks with old cluster agent",
+			agent:         "datadog/agent:6.20.0",
+			clusterAgent:  "datadog/cluster-agent:1.1.0",
+			clusterChecks: true,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckVersionCompatibility(tt.agent, tt.clusterAgent, tt.clusterChecks)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestRegistryClientCheckImageExists(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:datadog/agent:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token": "secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:datadog/agent:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/datadog/agent/manifests/7.22.0":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := &registryClient{httpClient: server.Client(), scheme: "http"}
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	assert.NoError(t, c.checkImageExists(registry+"/datadog/agent:7.22.0"))
+	err := c.checkImageExists(registry + "/datadog/agent:0.0.1")
+	assert.Error(t, err)
+	assert.True(t, IsImageNotFound(err))
+}
+
+func TestRegistryClientCheckImageExistsWithCredentials(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		switch {
+		case !ok || username != "user" || password != "p@ss":
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/datadog/agent/manifests/7.22.0":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	// Without credentials the check fails, but the image isn't reported as missing
+	c := &registryClient{httpClient: server.Client(), scheme: "http"}
+	err := c.checkImageExists(registry + "/datadog/agent:7.22.0")
+	assert.Error(t, err)
+	assert.False(t, IsImageNotFound(err))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "registry"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			// auth is the base64 encoding of user:p@ss
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths": {"https://%s/v1/": {"auth": "dXNlcjpwQHNz"}}}`, registry)),
+		},
+	}
+	credentials, err := getRegistryCredentials(fake.NewFakeClient(secret), "bar", []corev1.LocalObjectReference{{Name: "registry"}}, registry)
+	assert.NoError(t, err)
+	assert.Equal(t, &registryCredentials{username: "user", password: "p@ss"}, credentials)
+
+	c.credentials = credentials
+	assert.NoError(t, c.checkImageExists(registry+"/datadog/agent:7.22.0"))
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	assert.Equal(t, "registry-1.docker.io", normalizeRegistry("https://index.docker.io/v1/"))
+	assert.Equal(t, "registry-1.docker.io", normalizeRegistry("docker.io"))
+	assert.Equal(t, "gcr.io", normalizeRegistry("gcr.io"))
+	assert.Equal(t, "localhost:5000", normalizeRegistry("http://localhost:5000"))
+}
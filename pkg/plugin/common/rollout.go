@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RolloutPollInterval is the interval between two checks of the rollout status
+	RolloutPollInterval = 5 * time.Second
+	// DefaultRolloutTimeout is the default time to wait for a rollout
+	DefaultRolloutTimeout = 10 * time.Minute
+)
+
+// RolloutStatus represents the progress of the rollout of a DatadogAgent component
+type RolloutStatus struct {
+	Desired  int32
+	UpToDate int32
+	Ready    int32
+	Hash     string
+	State    string
+}
+
+// RolloutStatusFunc extracts the rollout status of a component from the DatadogAgent status
+type RolloutStatusFunc func(dd *v1alpha1.DatadogAgent) *RolloutStatus
+
+// AgentRolloutStatus returns the rollout status of the Agent
+func AgentRolloutStatus(dd *v1alpha1.DatadogAgent) *RolloutStatus {
+	if dd.Status.Agent == nil {
+		return nil
+	}
+	s := dd.Status.Agent
+	return &RolloutStatus{Desired: s.Desired, UpToDate: s.UpToDate, Ready: s.Ready, Hash: s.CurrentHash, State: s.State}
+}
+
+// ClusterAgentRolloutStatus returns the rollout status of the Cluster Agent
+func ClusterAgentRolloutStatus(dd *v1alpha1.DatadogAgent) *RolloutStatus {
+	return deploymentRolloutStatus(dd.Status.ClusterAgent)
+}
+
+// ClusterChecksRunnerRolloutStatus returns the rollout status of the Cluster Checks Runner
+func ClusterChecksRunnerRolloutStatus(dd *v1alpha1.DatadogAgent) *RolloutStatus {
+	return deploymentRolloutStatus(dd.Status.ClusterChecksRunner)
+}
+
+func deploymentRolloutStatus(s *v1alpha1.DeploymentStatus) *RolloutStatus {
+	if s == nil {
+		return nil
+	}
+	return &RolloutStatus{Desired: s.Replicas, UpToDate: s.UpdatedReplicas, Ready: s.ReadyReplicas, Hash: s.CurrentHash, State: s.State}
+}
+
+// IsComplete returns true if the component has been updated since previousHash and all its pods are up-to-date and ready
+func (s *RolloutStatus) IsComplete(previousHash string) bool {
+	return s.Hash != "" && s.Hash != previousHash && s.UpToDate >= s.Desired && s.Ready >= s.Desired
+}
+
+// IsFailed returns true if the operator reported the component as failed
+func (s *RolloutStatus) IsFailed() bool {
+	return s.State == string(v1alpha1.DatadogAgentStateFailed)
+}
+
+// String returns a human readable progress
+func (s *RolloutStatus) String() string {
+	state := s.State
+	if state == "" {
+		state = "Unknown"
+	}
+	return fmt.Sprintf("%d/%d up-to-date, %d/%d ready (%s)", s.UpToDate, s.Desired, s.Ready, s.Desired, state)
+}
+
+// WaitForRollout waits until the component of the DatadogAgent is rolled out.
+// previous is the DatadogAgent before the update, the progress is printed in out each time it changes.
+func WaitForRollout(c client.Client, previous *v1alpha1.DatadogAgent, name string, getStatus RolloutStatusFunc, interval, timeout time.Duration, out io.Writer) error {
+	key := client.ObjectKey{Namespace: previous.GetNamespace(), Name: previous.GetName()}
+	var previousHash, lastProgress string
+	if status := getStatus(previous); status != nil {
+		previousHash = status.Hash
+	}
+
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		dd := &v1alpha1.DatadogAgent{}
+		if err := c.Get(context.TODO(), key, dd); err != nil {
+			return false, fmt.Errorf("unable to get DatadogAgent: %v", err)
+		}
+
+		status := getStatus(dd)
+		if status == nil {
+			return false, nil
+		}
+
+		if progress := status.String(); progress != lastProgress {
+			fmt.Fprintf(out, "%s rollout in %s/%s: %s\n", name, key.Namespace, key.Name, progress)
+			lastProgress = progress
+		}
+
+		if status.Hash != previousHash && status.IsFailed() {
+			return false, fmt.Errorf("%s rollout failed", name)
+		}
+
+		return status.IsComplete(previousHash), nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("%s rollout not completed after %v", name, timeout)
+	}
+
+	return err
+}
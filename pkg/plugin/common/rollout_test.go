@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWaitForRollout(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.Nil(t, v1alpha1.AddToScheme(s))
+
+	previous := &v1alpha1.DatadogAgent{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo"},
+		Status: v1alpha1.DatadogAgentStatus{
+			Agent: &v1alpha1.DaemonSetStatus{Desired: 2, UpToDate: 2, Ready: 2, CurrentHash: "old"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		status  v1alpha1.DaemonSetStatus
+		wantErr bool
+	}{
+		{
+			name:   "completed",
+			status: v1alpha1.DaemonSetStatus{Desired: 2, UpToDate: 2, Ready: 2, CurrentHash: "new", State: "Running"},
+		},
+		{
+			name:    "not updated yet",
+			status:  v1alpha1.DaemonSetStatus{Desired: 2, UpToDate: 2, Ready: 2, CurrentHash: "old", State: "Running"},
+			wantErr: true,
+		},
+		{
+			name:    "in progress",
+			status:  v1alpha1.DaemonSetStatus{Desired: 2, UpToDate: 1, Ready: 1, CurrentHash: "new", State: "Updating"},
+			wantErr: true,
+		},
+		{
+			name:    "failed",
+			status:  v1alpha1.DaemonSetStatus{Desired: 2, UpToDate: 2, Ready: 0, CurrentHash: "new", State: "Failed"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := previous.DeepCopy()
+			current.Status.Agent = tt.status.DeepCopy()
+			c := fake.NewFakeClientWithScheme(s, current)
+
+			out := &bytes.Buffer{}
+			err := WaitForRollout(c, previous, "Agent", AgentRolloutStatus, 10*time.Millisecond, 50*time.Millisecond, out)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Contains(t, out.String(), "Agent rollout in bar/foo")
+		})
+	}
+}
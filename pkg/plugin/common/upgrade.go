@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+// CheckDatadogAgentCompatibility verifies that the Agent and Cluster Agent images configured in a DatadogAgent are compatible
+func CheckDatadogAgentCompatibility(dd *v1alpha1.DatadogAgent) error {
+	if dd.Spec.Agent == nil || dd.Spec.ClusterAgent == nil {
+		return nil
+	}
+
+	clusterChecksEnabled := dd.Spec.ClusterAgent.Config.ClusterChecksEnabled != nil && *dd.Spec.ClusterAgent.Config.ClusterChecksEnabled
+	if err := CheckVersionCompatibility(dd.Spec.Agent.Image.Name, dd.Spec.ClusterAgent.Image.Name, clusterChecksEnabled); err != nil {
+		return fmt.Errorf("incompatible versions: %v", err)
+	}
+
+	return nil
+}
+
+// UpgradeSummary returns the message describing an image change of a DatadogAgent component
+func UpgradeSummary(component string, dd *v1alpha1.DatadogAgent, from, to string) string {
+	return fmt.Sprintf("%s/%s: %s image %s -> %s", dd.GetNamespace(), dd.GetName(), component, from, to)
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/hako/durafmt"
+	"github.com/olekukonko/tablewriter"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -151,3 +152,19 @@ func ValidateAnnotationsMatching(annotations map[string]string, validIDs map[str
 	}
 	return errors
 }
+
+// NewTable returns a borderless table, left aligned, used by the commands printing lists
+func NewTable(out io.Writer, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(false)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAutoWrapText(false)
+	return table
+}